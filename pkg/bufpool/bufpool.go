@@ -0,0 +1,102 @@
+// Package bufpool provides opt-in sync.Pool-backed buffers for the build paths
+// and for the read buffers of the reader-based parsers
+//
+// Pooling is disabled by default. Enable it once at startup in processes that
+// build many messages per second (proxies, scanners) to reduce GC pressure:
+//
+//	bufpool.Enable(true)
+//
+// Ownership rules:
+//   - Buffers from Get are owned by the caller until passed to Put or Release
+//   - Release copies the contents into a new slice owned by the caller, then
+//     returns the buffer to the pool, so pooled memory never escapes to callers
+//   - Never use a buffer after passing it to Put or Release
+//   - Slices from GetBytes are owned by the caller until passed to PutBytes
+package bufpool
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// MaxPooledSize is the largest buffer capacity returned to the pool
+// Larger buffers are dropped so one huge message does not pin memory forever
+const MaxPooledSize = 1024 * 1024 // 1MB
+
+var (
+	enabled atomic.Bool
+	pool    = sync.Pool{
+		New: func() any {
+			return new(bytes.Buffer)
+		},
+	}
+)
+
+// Enable turns buffer pooling on or off for all packages using bufpool
+func Enable(on bool) {
+	enabled.Store(on)
+}
+
+// Enabled reports whether buffer pooling is on
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Get returns an empty buffer, from the pool when pooling is enabled
+func Get() *bytes.Buffer {
+	if !enabled.Load() {
+		return new(bytes.Buffer)
+	}
+	buf := pool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// Put returns a buffer to the pool
+// No-op when pooling is disabled or the buffer grew beyond MaxPooledSize
+func Put(buf *bytes.Buffer) {
+	if buf == nil || !enabled.Load() || buf.Cap() > MaxPooledSize {
+		return
+	}
+	buf.Reset()
+	pool.Put(buf)
+}
+
+// Release returns the buffer contents as a slice owned by the caller and
+// puts the buffer back in the pool
+// When pooling is disabled, the buffer's own slice is returned without copying
+func Release(buf *bytes.Buffer) []byte {
+	if !enabled.Load() {
+		return buf.Bytes()
+	}
+	out := make([]byte, buf.Len())
+	copy(out, buf.Bytes())
+	Put(buf)
+	return out
+}
+
+// GetBytes returns an empty slice with pooled capacity, for code that builds
+// with append; nil when pooling is disabled
+func GetBytes() []byte {
+	if !enabled.Load() {
+		return nil
+	}
+	return Get().AvailableBuffer()
+}
+
+// PutBytes returns a slice from GetBytes, possibly grown by append, to the pool
+// No-op when pooling is disabled or the slice grew beyond MaxPooledSize
+func PutBytes(b []byte) {
+	if !enabled.Load() || cap(b) > MaxPooledSize {
+		return
+	}
+	pool.Put(bytes.NewBuffer(b[:0]))
+}
+
+// ReleaseString returns the buffer contents as a string and puts the buffer back in the pool
+func ReleaseString(buf *bytes.Buffer) string {
+	s := buf.String()
+	Put(buf)
+	return s
+}
@@ -0,0 +1,95 @@
+package bufpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGetPut_Disabled(t *testing.T) {
+	Enable(false)
+
+	buf := Get()
+	buf.WriteString("data")
+	out := Release(buf)
+
+	if string(out) != "data" {
+		t.Errorf("Expected 'data', got %q", out)
+	}
+}
+
+func TestGetBytes(t *testing.T) {
+	Enable(false)
+	if b := GetBytes(); b != nil {
+		t.Errorf("Expected nil slice when disabled, got cap %d", cap(b))
+	}
+
+	Enable(true)
+	defer Enable(false)
+
+	b := append(GetBytes(), "grown by append"...)
+	PutBytes(b)
+
+	// The pool hands out empty slices, whatever they held before
+	if b := GetBytes(); len(b) != 0 {
+		t.Errorf("Expected empty slice, got %q", b)
+	}
+	PutBytes(make([]byte, 0, MaxPooledSize+1))
+}
+
+func TestRelease_CopiesOut(t *testing.T) {
+	Enable(true)
+	defer Enable(false)
+
+	buf := Get()
+	buf.WriteString("first")
+	first := Release(buf)
+
+	// Reuse whatever the pool hands back and overwrite it
+	buf = Get()
+	if buf.Len() != 0 {
+		t.Errorf("Pooled buffer should be empty, got %d bytes", buf.Len())
+	}
+	buf.WriteString("XXXXX")
+	Release(buf)
+
+	if string(first) != "first" {
+		t.Errorf("Released slice must not alias pooled memory, got %q", first)
+	}
+}
+
+func TestPut_DropsLargeBuffers(t *testing.T) {
+	Enable(true)
+	defer Enable(false)
+
+	buf := bytes.NewBuffer(make([]byte, 0, MaxPooledSize+1))
+	Put(buf)
+
+	if got := Get(); got.Cap() > MaxPooledSize {
+		t.Errorf("Oversized buffer should not be pooled, got cap %d", got.Cap())
+	}
+}
+
+func BenchmarkRelease_Pooled(b *testing.B) {
+	Enable(true)
+	defer Enable(false)
+	payload := bytes.Repeat([]byte("header: value\r\n"), 64)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := Get()
+		buf.Write(payload)
+		Release(buf)
+	}
+}
+
+func BenchmarkRelease_Unpooled(b *testing.B) {
+	Enable(false)
+	payload := bytes.Repeat([]byte("header: value\r\n"), 64)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := Get()
+		buf.Write(payload)
+		Release(buf)
+	}
+}
@@ -0,0 +1,215 @@
+// Package bulk parses large numbers of raw HTTP messages concurrently
+//
+// It is meant for tools that post-process stored captures (proxy histories,
+// scanner logs) where millions of requests/responses have to be parsed:
+//
+//	results := bulk.ParseRequests(raws, bulk.Options{})
+//	for _, r := range results {
+//		if r.Err != nil {
+//			log.Printf("message %d: %v", r.Index, r.Err)
+//			continue
+//		}
+//		process(r.Request)
+//	}
+//
+// For inputs that do not fit in memory, use the channel-based variants,
+// which stream results as they are produced
+package bulk
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+// Options configures bulk parsing
+type Options struct {
+	// Workers is the number of parsing goroutines (0 = runtime.NumCPU())
+	Workers int
+
+	// Ordered emits channel results in input order
+	// Unordered emission is faster when message sizes vary a lot
+	// Ordered streams keep at most 4 messages per worker in flight, so a slow
+	// message pauses reading from the input instead of buffering later results
+	// Slice-based functions always return results in input order
+	Ordered bool
+
+	// ResponseOptions are passed to response.ParseWithOptions
+	ResponseOptions response.ParseOptions
+}
+
+// RequestResult is the outcome of parsing one raw request
+type RequestResult struct {
+	Index   int // Position of the message in the input
+	Request *request.Request
+	Err     error
+}
+
+// ResponseResult is the outcome of parsing one raw response
+type ResponseResult struct {
+	Index    int // Position of the message in the input
+	Response *response.Response
+	Err      error
+}
+
+// ParseRequests parses all raw requests concurrently
+// Results are returned in input order; a failed message does not stop the others
+func ParseRequests(raws [][]byte, opts Options) []RequestResult {
+	results := make([]RequestResult, len(raws))
+	forEach(len(raws), opts.workers(), func(i int) {
+		req, err := request.Parse(raws[i])
+		results[i] = RequestResult{Index: i, Request: req, Err: err}
+	})
+	return results
+}
+
+// ParseResponses parses all raw responses concurrently
+// Results are returned in input order; a failed message does not stop the others
+func ParseResponses(raws [][]byte, opts Options) []ResponseResult {
+	results := make([]ResponseResult, len(raws))
+	forEach(len(raws), opts.workers(), func(i int) {
+		resp, err := response.ParseWithOptions(raws[i], opts.ResponseOptions)
+		results[i] = ResponseResult{Index: i, Response: resp, Err: err}
+	})
+	return results
+}
+
+// ParseRequestsChan parses raw requests received from in until it is closed
+// The returned channel is closed after the last result has been sent
+// Index is the position of the message in the input stream
+func ParseRequestsChan(in <-chan []byte, opts Options) <-chan RequestResult {
+	out := make(chan RequestResult, opts.workers())
+	go func() {
+		defer close(out)
+		stream(in, opts, func(i int, raw []byte) RequestResult {
+			req, err := request.Parse(raw)
+			return RequestResult{Index: i, Request: req, Err: err}
+		}, func(r RequestResult) int { return r.Index }, out)
+	}()
+	return out
+}
+
+// ParseResponsesChan parses raw responses received from in until it is closed
+// The returned channel is closed after the last result has been sent
+// Index is the position of the message in the input stream
+func ParseResponsesChan(in <-chan []byte, opts Options) <-chan ResponseResult {
+	out := make(chan ResponseResult, opts.workers())
+	go func() {
+		defer close(out)
+		stream(in, opts, func(i int, raw []byte) ResponseResult {
+			resp, err := response.ParseWithOptions(raw, opts.ResponseOptions)
+			return ResponseResult{Index: i, Response: resp, Err: err}
+		}, func(r ResponseResult) int { return r.Index }, out)
+	}()
+	return out
+}
+
+// workers returns the effective worker count
+func (o Options) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// forEach calls fn for every index in [0, n) using the given number of goroutines
+func forEach(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+
+	indexes := make(chan int, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// reorderWindow is the number of in-flight messages per worker in ordered streams
+// It bounds the reorder buffer when one message takes much longer than the rest
+const reorderWindow = 4
+
+// indexedInput pairs a raw message with its position in the input stream
+type indexedInput struct {
+	index int
+	raw   []byte
+}
+
+// stream parses messages from in with a worker pool and sends results to out
+// When opts.Ordered is set, results are buffered until all earlier ones were sent,
+// and no new message is dispatched while workers*reorderWindow are still unsent
+func stream[T any](in <-chan []byte, opts Options, parse func(int, []byte) T, index func(T) int, out chan<- T) {
+	workers := opts.workers()
+	jobs := make(chan indexedInput, workers)
+	parsed := make(chan T, workers)
+
+	var window chan struct{}
+	if opts.Ordered {
+		window = make(chan struct{}, workers*reorderWindow)
+	}
+
+	go func() {
+		i := 0
+		for raw := range in {
+			if window != nil {
+				window <- struct{}{}
+			}
+			jobs <- indexedInput{index: i, raw: raw}
+			i++
+		}
+		close(jobs)
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				parsed <- parse(job.index, job.raw)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(parsed)
+	}()
+
+	if !opts.Ordered {
+		for r := range parsed {
+			out <- r
+		}
+		return
+	}
+
+	// Reorder buffer: holds results that arrived ahead of the next expected index
+	pending := make(map[int]T)
+	next := 0
+	for r := range parsed {
+		pending[index(r)] = r
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			out <- ready
+			<-window
+			next++
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package cache is a private HTTP response cache (RFC 9111) in front of a
+// caller-supplied transport
+//
+// Responses to GET and HEAD are stored per method and normalized URL, and per
+// Vary header values (see Key and VaryKey); fresh entries are served without sending, and stale
+// entries with a validator are revalidated with a conditional request:
+//
+//	c := cache.New(nil)
+//	send = c.Wrap(send)
+//	resp, err := send(ctx, req) // second call for the same URL is served from the cache
+package cache
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WhileEndless/go-httptools/pkg/clock"
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+	"github.com/WhileEndless/go-httptools/pkg/schedule"
+)
+
+// Entry is a stored response with the request that produced it
+type Entry struct {
+	Request  *request.Request
+	Response *response.Response
+	Stored   time.Time // When the response was received or last revalidated
+}
+
+// Store holds cache entries by key
+// Implementations must be safe for concurrent use
+type Store interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, e *Entry)
+	Delete(key string)
+}
+
+// MemoryStore is an unbounded in-memory Store
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]*Entry
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*Entry)}
+}
+
+// Get returns the entry stored under key
+func (s *MemoryStore) Get(key string) (*Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[key]
+	return e, ok
+}
+
+// Set stores e under key
+func (s *MemoryStore) Set(key string, e *Entry) {
+	s.mu.Lock()
+	s.entries[key] = e
+	s.mu.Unlock()
+}
+
+// Delete removes the entry stored under key
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+}
+
+// Cache serves and stores responses
+type Cache struct {
+	Store Store
+	Clock clock.Clock // nil = clock.Real
+}
+
+// New creates a Cache backed by store (nil = a new MemoryStore)
+func New(store Store) *Cache {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Cache{Store: store}
+}
+
+// Key returns the primary cache key of req: method and normalized URL
+func Key(req *request.Request) string {
+	return req.Canonicalize(request.CanonicalOptions{}).String()
+}
+
+// VaryKey returns the full cache key of req for a response carrying the given
+// Vary header: Key(req) followed by the normalized values of the selected
+// request headers, in sorted name order
+// ok is false for "Vary: *", which matches no other request
+func VaryKey(req *request.Request, vary string) (key string, ok bool) {
+	names, ok := varyNames(vary)
+	if !ok {
+		return "", false
+	}
+	return req.Canonicalize(request.CanonicalOptions{Headers: names}).String(), true
+}
+
+// NormalizeURL returns the URL of req in a canonical form (see
+// request.Request.Canonicalize): scheme, lowercase host without the scheme's
+// default port, then path and query with normalized percent-encoding
+func NormalizeURL(req *request.Request) string {
+	c := req.Canonicalize(request.CanonicalOptions{})
+	return c.Scheme + "://" + c.Host + c.Target()
+}
+
+// Lookup returns a copy of the stored response for req if it is fresh
+func (c *Cache) Lookup(req *request.Request) (*response.Response, bool) {
+	e, _, ok := c.entry(req)
+	if !ok || !c.fresh(e) {
+		return nil, false
+	}
+	return e.Response.Clone(), true
+}
+
+// Wrap returns a SendFunc that serves fresh responses from the cache,
+// revalidates stale ones and stores cacheable responses from send
+func (c *Cache) Wrap(send schedule.SendFunc) schedule.SendFunc {
+	return func(ctx context.Context, req *request.Request) (*response.Response, error) {
+		if !cacheableMethod(req.Method) || hasDirective(req.Headers, "no-store") {
+			return send(ctx, req)
+		}
+
+		e, key, ok := c.entry(req)
+		if ok && c.fresh(e) && !hasDirective(req.Headers, "no-cache") {
+			return e.Response.Clone(), nil
+		}
+
+		outgoing := req
+		if ok {
+			outgoing = conditional(req, e.Response)
+		}
+		resp, err := send(ctx, outgoing)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok && resp.StatusCode == 304 && outgoing != req {
+			e = c.revalidated(e, resp)
+			c.set(req, e)
+			return e.Response.Clone(), nil
+		}
+
+		if storable(resp, c.now()) {
+			c.set(req, &Entry{Request: req.Clone(), Response: resp.Clone(), Stored: c.now()})
+		} else if ok {
+			c.Store.Delete(key)
+		}
+		return resp, nil
+	}
+}
+
+// entry returns the stored entry for req if its Vary headers match, and the
+// key it is stored under
+// The entry under Key is the latest response for the URL; when it has a Vary
+// header, the entry for req is the one under its VaryKey
+func (c *Cache) entry(req *request.Request) (*Entry, string, bool) {
+	key := Key(req)
+	e, ok := c.Store.Get(key)
+	if !ok {
+		return nil, "", false
+	}
+	if vary := e.Response.Headers.Get("Vary"); strings.TrimSpace(vary) != "" {
+		if key, ok = VaryKey(req, vary); !ok {
+			return nil, "", false
+		}
+		if e, ok = c.Store.Get(key); !ok {
+			return nil, "", false
+		}
+	}
+	if !varyMatches(e, req) {
+		return nil, "", false
+	}
+	return e, key, true
+}
+
+// set stores e for req under its VaryKey, so each Vary variant has its own
+// entry, and under Key, where entry looks up the Vary header names
+// Responses with "Vary: *" match no request and are not stored
+func (c *Cache) set(req *request.Request, e *Entry) {
+	key, ok := VaryKey(req, e.Response.Headers.Get("Vary"))
+	if !ok {
+		return
+	}
+	c.Store.Set(key, e)
+	if primary := Key(req); primary != key {
+		c.Store.Set(primary, e)
+	}
+}
+
+// fresh reports whether e can be served without revalidation
+func (c *Cache) fresh(e *Entry) bool {
+	if hasDirective(e.Response.Headers, "no-cache") {
+		return false
+	}
+	age := e.Response.GetAge() + c.now().Sub(e.Stored)
+	return age < e.Response.FreshnessLifetime(e.Stored)
+}
+
+// revalidated returns e updated with the headers of a 304 response
+// (RFC 9111 section 4.3.4)
+func (c *Cache) revalidated(e *Entry, notModified *response.Response) *Entry {
+	updated := e.Response.Clone()
+	for _, h := range notModified.Headers.All() {
+		switch strings.ToLower(h.Name) {
+		case "content-length", "transfer-encoding", "content-encoding":
+			continue
+		}
+		updated.Headers.Set(h.Name, h.Value)
+	}
+	return &Entry{Request: e.Request, Response: updated, Stored: c.now()}
+}
+
+func (c *Cache) now() time.Time {
+	return clock.Or(c.Clock).Now()
+}
+
+// conditional returns a copy of req with validators from stored,
+// or req itself if stored has none
+func conditional(req *request.Request, stored *response.Response) *request.Request {
+	etag := stored.Headers.Get("ETag")
+	lastModified := stored.Headers.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return req
+	}
+	clone := req.Clone()
+	if etag != "" {
+		clone.Headers.Set("If-None-Match", strings.TrimSpace(etag))
+	}
+	if lastModified != "" {
+		clone.Headers.Set("If-Modified-Since", strings.TrimSpace(lastModified))
+	}
+	return clone
+}
+
+// storable reports whether resp may be stored by a private cache
+// Responses without freshness information are kept only if they can be revalidated
+func storable(resp *response.Response, now time.Time) bool {
+	if resp.StatusCode < 200 || resp.StatusCode == 206 || resp.StatusCode == 304 {
+		return false
+	}
+	if hasDirective(resp.Headers, "no-store") || strings.TrimSpace(resp.Headers.Get("Vary")) == "*" {
+		return false
+	}
+	if resp.FreshnessLifetime(now) > 0 {
+		return true
+	}
+	return resp.Headers.Has("ETag") || resp.Headers.Has("Last-Modified")
+}
+
+// varyMatches reports whether req selects the stored response of e
+// (RFC 9111 section 4.1)
+func varyMatches(e *Entry, req *request.Request) bool {
+	vary := e.Response.Headers.Get("Vary")
+	stored, ok := VaryKey(e.Request, vary)
+	if !ok {
+		return false
+	}
+	key, _ := VaryKey(req, vary)
+	return key == stored
+}
+
+// varyNames returns the sorted, lowercased, deduplicated header names of a
+// Vary value; false for "*"
+func varyNames(vary string) ([]string, bool) {
+	var names []string
+	for _, name := range strings.Split(vary, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "*" {
+			return nil, false
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names), true
+}
+
+func cacheableMethod(method string) bool {
+	return strings.EqualFold(method, "GET") || strings.EqualFold(method, "HEAD")
+}
+
+// hasDirective reports whether the Cache-Control header in h has the named directive
+func hasDirective(h *headers.OrderedHeaders, name string) bool {
+	_, ok := headers.ParseCacheControl(h.Get("Cache-Control"))[name]
+	return ok
+}
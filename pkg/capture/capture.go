@@ -0,0 +1,169 @@
+// Package capture iterates over files of concatenated raw HTTP messages
+//
+// Files are memory-mapped (on Unix) so multi-gigabyte captures can be
+// processed without loading them into heap memory:
+//
+//	f, err := capture.Open("responses.bin")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//
+//	it := f.Responses()
+//	for it.Next() {
+//		raw := it.Raw() // zero-copy view into the mapped file
+//		resp, err := it.Response()
+//		...
+//	}
+//
+// Message boundaries are found from the head and body framing:
+// Content-Length, chunked, or (responses only) up to the next status line
+package capture
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/WhileEndless/go-httptools/pkg/framing"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+// Kind selects whether messages are requests or responses
+type Kind = framing.Kind
+
+const (
+	KindRequest  = framing.KindRequest
+	KindResponse = framing.KindResponse
+)
+
+// File is a memory-mapped capture file
+// IMPORTANT: Always call Close() when done; slices returned by Raw() are
+// invalid after Close
+type File struct {
+	file  *os.File
+	data  []byte
+	unmap func() error
+}
+
+// Open memory-maps the capture file at path
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	data, unmap, err := mapFile(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &File{file: f, data: data, unmap: unmap}, nil
+}
+
+// Close unmaps and closes the file
+func (c *File) Close() error {
+	err := c.unmap()
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+	c.data = nil
+	return err
+}
+
+// Size returns the size of the mapped data in bytes
+func (c *File) Size() int64 {
+	return int64(len(c.data))
+}
+
+// Requests returns an iterator over the requests in the file
+func (c *File) Requests() *Iterator {
+	return NewIterator(c.data, KindRequest)
+}
+
+// Responses returns an iterator over the responses in the file
+func (c *File) Responses() *Iterator {
+	return NewIterator(c.data, KindResponse)
+}
+
+// Iterator walks concatenated raw messages in a byte slice
+type Iterator struct {
+	data  []byte
+	kind  Kind
+	pos   int
+	start int
+	raw   []byte
+}
+
+// NewIterator creates an iterator over concatenated raw messages in data
+func NewIterator(data []byte, kind Kind) *Iterator {
+	return &Iterator{data: data, kind: kind}
+}
+
+// Next advances to the next message, returning false when data is exhausted
+func (it *Iterator) Next() bool {
+	// Skip blank lines between messages
+	for it.pos < len(it.data) && (it.data[it.pos] == '\r' || it.data[it.pos] == '\n') {
+		it.pos++
+	}
+	if it.pos >= len(it.data) {
+		it.raw = nil
+		return false
+	}
+
+	n := messageLength(it.data[it.pos:], it.kind)
+	it.start = it.pos
+	it.raw = it.data[it.pos : it.pos+n]
+	it.pos += n
+	return true
+}
+
+// Raw returns the current message bytes without copying
+// The slice points into the mapped file and must not be modified
+func (it *Iterator) Raw() []byte {
+	return it.raw
+}
+
+// Offset returns the position of the current message in the data
+func (it *Iterator) Offset() int64 {
+	return int64(it.start)
+}
+
+// Request parses the current message as a request
+// The message is copied first, so the result stays valid after the file is closed
+func (it *Iterator) Request() (*request.Request, error) {
+	return request.Parse(bytes.Clone(it.raw))
+}
+
+// Response parses the current message as a response
+// The message is copied first, so the result stays valid after the file is closed
+func (it *Iterator) Response() (*response.Response, error) {
+	return response.Parse(bytes.Clone(it.raw))
+}
+
+// ResponseWithOptions parses the current message as a response with custom options
+// Like Response, the result stays valid after the file is closed
+func (it *Iterator) ResponseWithOptions(opts response.ParseOptions) (*response.Response, error) {
+	return response.ParseWithOptions(bytes.Clone(it.raw), opts)
+}
+
+// messageLength returns the length of the message at the start of data
+// Truncated messages extend to the end of data
+func messageLength(data []byte, kind Kind) int {
+	n, state := framing.Scan(data, kind)
+	if state == framing.StateUntilClose {
+		// No framing: in a capture file the body runs until the next status line
+		body := data[framing.HeadEnd(data):]
+		if idx := bytes.Index(body, []byte("\nHTTP/")); idx != -1 {
+			return len(data) - len(body) + idx + 1
+		}
+	}
+	return n
+}
@@ -7,16 +7,29 @@ import (
 	"io"
 	"strconv"
 	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/errors"
+	"github.com/WhileEndless/go-httptools/pkg/headers"
 )
 
 // Decode decodes chunked transfer encoding to plain body
 // Always succeeds - if malformed, returns what it can parse
 // Returns decoded body and any trailers found after final chunk
 func Decode(chunkedBody []byte) (body []byte, trailers map[string]string) {
-	trailers = make(map[string]string)
+	body, trailerStart := decode(chunkedBody)
+	if trailerStart == -1 {
+		return body, make(map[string]string)
+	}
+	return body, parseTrailers(chunkedBody[trailerStart:])
+}
 
+// decode implements Decode
+// Returns the decoded body and the offset of the trailer section following
+// the final chunk, or -1 when there is no final chunk
+func decode(chunkedBody []byte) (body []byte, trailerStart int) {
+	trailerStart = -1
 	if len(chunkedBody) == 0 {
-		return []byte{}, trailers
+		return []byte{}, trailerStart
 	}
 
 	var result bytes.Buffer
@@ -63,8 +76,8 @@ func Decode(chunkedBody []byte) (body []byte, trailers map[string]string) {
 
 		// If chunk size is 0, this is the last chunk
 		if chunkSize == 0 {
-			// Parse trailers (if any)
-			trailers = parseTrailers(data[pos:])
+			// Trailers (if any) follow
+			trailerStart = pos
 			break
 		}
 
@@ -89,7 +102,7 @@ func Decode(chunkedBody []byte) (body []byte, trailers map[string]string) {
 		}
 	}
 
-	return result.Bytes(), trailers
+	return result.Bytes(), trailerStart
 }
 
 // parseTrailers parses HTTP trailers after final chunk
@@ -244,12 +257,15 @@ type DecodeReader struct {
 	reader       *bufio.Reader
 	remaining    int64  // remaining bytes in current chunk
 	eof          bool   // reached final 0-length chunk
+	err          error  // set after a malformed chunk size line
 	trailers     map[string]string
 	trailersRead bool
 }
 
 // NewDecodeReader creates a new streaming chunked decoder reader
 // The returned reader decodes chunked transfer encoding on-the-fly as it's read
+// An invalid chunk size line fails every further Read with an error matching
+// errors.ErrMalformedChunk
 func NewDecodeReader(r io.Reader) *DecodeReader {
 	var br *bufio.Reader
 	if b, ok := r.(*bufio.Reader); ok {
@@ -270,6 +286,9 @@ func (d *DecodeReader) Read(p []byte) (int, error) {
 	if d.eof {
 		return 0, io.EOF
 	}
+	if d.err != nil {
+		return 0, d.err
+	}
 
 	// If we have remaining data in the current chunk, read it
 	if d.remaining > 0 {
@@ -308,10 +327,9 @@ func (d *DecodeReader) Read(p []byte) (int, error) {
 	sizeLine = strings.TrimSpace(sizeLine)
 
 	chunkSize, err := strconv.ParseInt(sizeLine, 16, 64)
-	if err != nil {
-		// Invalid chunk size - might be end or corrupted
-		d.eof = true
-		return 0, io.EOF
+	if err != nil || chunkSize < 0 {
+		d.err = malformedSize(sizeLine)
+		return 0, d.err
 	}
 
 	// Zero-length chunk signals end of body
@@ -368,6 +386,239 @@ func (d *DecodeReader) Trailers() map[string]string {
 	return d.trailers
 }
 
+// ReadRaw reads one complete chunked body from br without decoding it
+// Returns the exact wire bytes: size lines, chunk data, the final 0-size chunk
+// and any trailers up to and including the terminating empty line
+// Nothing beyond the end of the chunked body is consumed, so pipelined messages
+// remain in br
+// If the stream ends early, the bytes read so far are returned with io.ErrUnexpectedEOF;
+// after an invalid chunk size line they are returned with an error matching
+// errors.ErrMalformedChunk, since the end of the body cannot be found
+func ReadRaw(br *bufio.Reader) ([]byte, error) {
+	return ReadRawLimit(br, 0)
+}
+
+// ReadRawLimit is ReadRaw keeping at most limit bytes (0 = no limit)
+// The rest of the body is still read and discarded, so br stays positioned
+// at the next pipelined message; an oversized body is reported by the
+// returned length alone
+func ReadRawLimit(br *bufio.Reader, limit int64) ([]byte, error) {
+	raw := &limitedBuffer{limit: limit}
+
+	for {
+		sizeLine, err := br.ReadBytes('\n')
+		raw.Write(sizeLine)
+		if err != nil {
+			return raw.buf, errors.UnexpectedEOF(err)
+		}
+
+		// Parse chunk size (strip CRLF and any extensions)
+		size := strings.TrimRight(string(sizeLine), "\r\n")
+		if idx := strings.Index(size, ";"); idx != -1 {
+			size = size[:idx]
+		}
+		chunkSize, err := strconv.ParseInt(strings.TrimSpace(size), 16, 64)
+		if err != nil || chunkSize < 0 {
+			// The bytes read so far can still be decoded fault-tolerantly
+			return raw.buf, malformedSize(size)
+		}
+
+		if chunkSize == 0 {
+			break
+		}
+
+		// Chunk data plus its line terminator
+		// Copied through limitedBuffer so a bogus size cannot force a huge allocation
+		if n, err := io.CopyN(raw, br, chunkSize); n < chunkSize {
+			return raw.buf, errors.UnexpectedEOF(err)
+		}
+		terminator, err := br.ReadBytes('\n')
+		raw.Write(terminator)
+		if err != nil {
+			return raw.buf, errors.UnexpectedEOF(err)
+		}
+	}
+
+	// Trailers until empty line
+	for {
+		line, err := br.ReadBytes('\n')
+		raw.Write(line)
+		if err != nil {
+			return raw.buf, errors.UnexpectedEOF(err)
+		}
+		if len(bytes.TrimRight(line, "\r\n")) == 0 {
+			return raw.buf, nil
+		}
+	}
+}
+
+// limitedBuffer keeps the first limit bytes written to it (0 = no limit)
+// and silently drops the rest
+type limitedBuffer struct {
+	buf   []byte
+	limit int64
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	keep := p
+	if b.limit > 0 {
+		left := b.limit - int64(len(b.buf))
+		if left <= 0 {
+			keep = nil
+		} else if int64(len(keep)) > left {
+			keep = keep[:left]
+		}
+	}
+	b.buf = append(b.buf, keep...)
+	return len(p), nil
+}
+
+// ScanRaw returns the length of the complete chunked body at the start of data,
+// including the final 0-size chunk and trailers, or -1 if data ends before the body does
+// It is the in-memory counterpart of ReadRaw and does not copy or decode anything
+func ScanRaw(data []byte) int {
+	pos := 0
+
+	for {
+		lineEnd := bytes.IndexByte(data[pos:], '\n')
+		if lineEnd == -1 {
+			return -1
+		}
+		sizeLine := data[pos : pos+lineEnd]
+		pos += lineEnd + 1
+
+		// Parse chunk size (strip CR and any extensions)
+		if idx := bytes.IndexByte(sizeLine, ';'); idx != -1 {
+			sizeLine = sizeLine[:idx]
+		}
+		chunkSize, err := strconv.ParseInt(string(bytes.TrimSpace(sizeLine)), 16, 64)
+		if err != nil || chunkSize < 0 {
+			// Malformed size line - the body ends here (ReadRaw reports it)
+			return pos
+		}
+
+		if chunkSize == 0 {
+			break
+		}
+
+		// Chunk data plus its line terminator
+		if chunkSize > int64(len(data)-pos) {
+			return -1
+		}
+		pos += int(chunkSize)
+		lineEnd = bytes.IndexByte(data[pos:], '\n')
+		if lineEnd == -1 {
+			return -1
+		}
+		pos += lineEnd + 1
+	}
+
+	// Trailers until empty line
+	for {
+		lineEnd := bytes.IndexByte(data[pos:], '\n')
+		if lineEnd == -1 {
+			return -1
+		}
+		line := data[pos : pos+lineEnd]
+		pos += lineEnd + 1
+		if len(bytes.TrimRight(line, "\r")) == 0 {
+			return pos
+		}
+	}
+}
+
+// Validate strictly checks a complete chunked body (RFC 9112 section 7.1)
+// Decode accepts malformed bodies; Validate reports the first violation as an
+// error matching errors.ErrMalformedChunk, carrying its byte offset in data
+// Every line must end with CRLF and chunk sizes must be at most 16 hex digits
+func Validate(data []byte) error {
+	malformed := func(message string, offset int) error {
+		return errors.NewErrorAt(errors.ErrorTypeMalformedChunk, message, "Validate", data, offset)
+	}
+
+	// line returns the end of the line starting at pos (index of its CR)
+	line := func(pos int) (int, error) {
+		lf := bytes.IndexByte(data[pos:], '\n')
+		if lf == -1 {
+			return 0, malformed("unterminated line", len(data))
+		}
+		if lf == 0 || data[pos+lf-1] != '\r' {
+			return 0, malformed("line not terminated by CRLF", pos+lf)
+		}
+		return pos + lf - 1, nil
+	}
+
+	pos := 0
+	for {
+		end, err := line(pos)
+		if err != nil {
+			return err
+		}
+
+		size := data[pos:end]
+		if idx := bytes.IndexByte(size, ';'); idx != -1 {
+			size = size[:idx]
+		}
+		if len(size) == 0 {
+			return malformed("missing chunk size", pos)
+		}
+		if len(size) > 16 {
+			return malformed("chunk size too large", pos)
+		}
+		for i, c := range size {
+			if !isHexDigit(c) {
+				return malformed("invalid chunk size", pos+i)
+			}
+		}
+		chunkSize, _ := strconv.ParseUint(string(size), 16, 64)
+		pos = end + 2
+
+		if chunkSize == 0 {
+			break
+		}
+		if chunkSize > uint64(len(data)-pos) {
+			return malformed("chunk data truncated", len(data))
+		}
+		pos += int(chunkSize)
+		if !bytes.HasPrefix(data[pos:], []byte("\r\n")) {
+			return malformed("chunk data not followed by CRLF", pos)
+		}
+		pos += 2
+	}
+
+	// Trailers until empty line
+	for {
+		end, err := line(pos)
+		if err != nil {
+			return err
+		}
+		if end == pos {
+			pos += 2
+			break
+		}
+		if bytes.IndexByte(data[pos:end], ':') <= 0 {
+			return malformed("invalid trailer field", pos)
+		}
+		pos = end + 2
+	}
+
+	if pos != len(data) {
+		return malformed("data after end of chunked body", pos)
+	}
+	return nil
+}
+
+// isHexDigit reports whether c is a hexadecimal digit
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// malformedSize returns the error for an invalid chunk size line
+func malformedSize(size string) error {
+	return errors.NewError(errors.ErrorTypeMalformedChunk,
+		"invalid chunk size: "+strconv.Quote(strings.TrimSpace(size)), "chunked", nil)
+}
+
 // ============================================================================
 // Streaming Chunked Encoder
 // ============================================================================
@@ -379,6 +630,7 @@ type EncodeWriter struct {
 	chunkSize int
 	closed    bool
 	trailers  map[string]string
+	ordered   *headers.OrderedHeaders
 }
 
 // NewEncodeWriter creates a new streaming chunked encoder writer
@@ -402,6 +654,12 @@ func (e *EncodeWriter) SetTrailer(name, value string) {
 	e.trailers[name] = value
 }
 
+// SetTrailers sets ordered trailer fields written after those set with SetTrailer
+// Must be called before Close()
+func (e *EncodeWriter) SetTrailers(trailers *headers.OrderedHeaders) {
+	e.ordered = trailers
+}
+
 // Write implements io.Writer interface
 // Writes data as one or more chunks
 func (e *EncodeWriter) Write(p []byte) (int, error) {
@@ -466,6 +724,11 @@ func (e *EncodeWriter) Close() error {
 			return err
 		}
 	}
+	if e.ordered != nil {
+		if _, err := e.writer.Write(e.ordered.Build()); err != nil {
+			return err
+		}
+	}
 
 	// Write final CRLF
 	_, err := e.writer.Write([]byte("\r\n"))
@@ -1,8 +1,14 @@
 package chunked
 
 import (
+	"bufio"
 	"bytes"
+	stderrors "errors"
+	"io"
+	"strings"
 	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/errors"
 )
 
 func TestDecode_Simple(t *testing.T) {
@@ -298,3 +304,120 @@ func BenchmarkIsChunked(b *testing.B) {
 		IsChunked(input)
 	}
 }
+
+func TestReadRaw_StopsAtEndOfBody(t *testing.T) {
+	body := "3\r\nfoo\r\n0\r\nX-Trailer: yes\r\n\r\n"
+	br := bufio.NewReader(strings.NewReader(body + "NEXT MESSAGE"))
+
+	raw, err := ReadRaw(br)
+	if err != nil {
+		t.Fatalf("ReadRaw failed: %v", err)
+	}
+	if string(raw) != body {
+		t.Errorf("Expected %q, got %q", body, raw)
+	}
+
+	rest, _ := io.ReadAll(br)
+	if string(rest) != "NEXT MESSAGE" {
+		t.Errorf("Expected remaining data to be untouched, got %q", rest)
+	}
+}
+
+func TestReadRaw_Truncated(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("a\r\nonly5"))
+
+	raw, err := ReadRaw(br)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if string(raw) != "a\r\nonly5" {
+		t.Errorf("Expected partial data, got %q", raw)
+	}
+}
+
+func TestReadRaw_HugeSizeDoesNotAllocate(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("7fffffffffff\r\nshort"))
+
+	raw, err := ReadRaw(br)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if !bytes.HasSuffix(raw, []byte("short")) {
+		t.Errorf("Expected partial data, got %q", raw)
+	}
+}
+
+func TestReadRaw_MalformedSize(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("3\r\nfoo\r\nzz\r\nbar"))
+
+	raw, err := ReadRaw(br)
+	if !stderrors.Is(err, errors.ErrMalformedChunk) {
+		t.Errorf("Expected ErrMalformedChunk, got %v", err)
+	}
+	if string(raw) != "3\r\nfoo\r\nzz\r\n" {
+		t.Errorf("Expected data up to the bad size line, got %q", raw)
+	}
+
+	dr := NewDecodeReader(strings.NewReader("3\r\nfoo\r\nzz\r\nbar"))
+	data, err := io.ReadAll(dr)
+	if string(data) != "foo" || !stderrors.Is(err, errors.ErrMalformedChunk) {
+		t.Errorf("DecodeReader = %q, %v", data, err)
+	}
+	if _, err := dr.Read(make([]byte, 1)); !stderrors.Is(err, errors.ErrMalformedChunk) {
+		t.Errorf("Expected sticky error, got %v", err)
+	}
+}
+
+func TestScanRaw(t *testing.T) {
+	body := "3\r\nfoo\r\n0\r\nX-Trailer: yes\r\n\r\n"
+
+	if n := ScanRaw([]byte(body + "NEXT")); n != len(body) {
+		t.Errorf("Expected length %d, got %d", len(body), n)
+	}
+
+	for i := 0; i < len(body); i++ {
+		if n := ScanRaw([]byte(body[:i])); n != -1 {
+			t.Errorf("Prefix of length %d: expected -1, got %d", i, n)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		"5\r\nhello\r\n0\r\n\r\n",
+		"5;ext=1\r\nhello\r\n6\r\n world\r\n0\r\nX-Trailer: v\r\n\r\n",
+		"0\r\n\r\n",
+	}
+	for _, body := range valid {
+		if err := Validate([]byte(body)); err != nil {
+			t.Errorf("Validate(%q) = %v", body, err)
+		}
+	}
+
+	tests := []struct {
+		body   string
+		offset int
+		line   int
+	}{
+		{"5\nhello\r\n0\r\n\r\n", 1, 1},                // bare LF
+		{"5\r\nhello\r\nzz\r\n\r\n", 10, 3},            // invalid hex
+		{"5\r\nhelloXX\r\n0\r\n\r\n", 8, 2},            // chunk longer than its size
+		{"5\r\nhello\r\n", 10, 3},                      // missing last chunk
+		{"ffffffffffffffffff\r\nx\r\n0\r\n\r\n", 0, 1}, // size overflow
+		{"a\r\nhello\r\n0\r\n\r\n", 15, 5},             // truncated data
+		{"0\r\n\r\nextra", 5, 3},                       // trailing data
+	}
+	for _, tt := range tests {
+		err := Validate([]byte(tt.body))
+		if !stderrors.Is(err, errors.ErrMalformedChunk) {
+			t.Errorf("Validate(%q) = %v, want ErrMalformedChunk", tt.body, err)
+			continue
+		}
+		var e *errors.Error
+		stderrors.As(err, &e)
+		if e.Offset != tt.offset || e.Line != tt.line || e.Snippet == "" {
+			t.Errorf("Validate(%q): offset %d line %d snippet %q, want offset %d line %d",
+				tt.body, e.Offset, e.Line, e.Snippet, tt.offset, tt.line)
+		}
+	}
+}
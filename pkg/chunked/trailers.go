@@ -0,0 +1,51 @@
+package chunked
+
+import (
+	"bytes"
+
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+)
+
+// Trailers returns the trailer fields after the final chunk of chunkedBody
+// Order and original formatting are preserved; returns nil when there are no
+// trailers or no final chunk
+func Trailers(chunkedBody []byte) *headers.OrderedHeaders {
+	_, start := decode(chunkedBody)
+	if start == -1 {
+		return nil
+	}
+	trailers, err := headers.ParseHeaders(chunkedBody[start:])
+	if err != nil || trailers.Len() == 0 {
+		return nil
+	}
+	return trailers
+}
+
+// SetTrailers returns a copy of chunkedBody with its trailer section replaced
+// by trailers (nil or empty removes it)
+// The terminating empty line uses the line ending of the final chunk;
+// bodies without a final chunk are returned unchanged
+// When trailers equal the fields Trailers parses from chunkedBody, the original
+// trailer bytes are kept, so repeated fields (which Trailers collapses) survive
+func SetTrailers(chunkedBody []byte, trailers *headers.OrderedHeaders) []byte {
+	_, start := decode(chunkedBody)
+	if start == -1 {
+		return chunkedBody
+	}
+	if trailers != nil {
+		existing, err := headers.ParseHeaders(chunkedBody[start:])
+		if err == nil && bytes.Equal(existing.AppendBuild(nil), trailers.AppendBuild(nil)) {
+			return chunkedBody
+		}
+	}
+
+	lineEnd := "\n"
+	if start >= 2 && chunkedBody[start-2] == '\r' {
+		lineEnd = "\r\n"
+	}
+	out := append([]byte(nil), chunkedBody[:start]...)
+	if trailers != nil {
+		out = trailers.AppendBuild(out)
+	}
+	return append(out, lineEnd...)
+}
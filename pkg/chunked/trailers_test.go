@@ -0,0 +1,54 @@
+package chunked
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+)
+
+func TestTrailers(t *testing.T) {
+	body := []byte("5\r\nhello\r\n0\r\nX-Checksum:  abc\r\nx-b: 2\r\n\r\n")
+
+	trailers := Trailers(body)
+	if trailers == nil || trailers.Len() != 2 || trailers.Get("x-checksum") != "  abc" {
+		t.Fatalf("Trailers = %v", trailers)
+	}
+	if Trailers([]byte("5\r\nhello\r\n0\r\n\r\n")) != nil || Trailers([]byte("5\r\nhel")) != nil {
+		t.Error("expected nil trailers")
+	}
+
+	// Unchanged trailers rebuild the original bytes
+	if got := SetTrailers(body, trailers); string(got) != string(body) {
+		t.Errorf("SetTrailers round trip = %q", got)
+	}
+
+	replaced := headers.NewOrderedHeaders()
+	replaced.Set("Server-Timing", "db;dur=53")
+	if got := string(SetTrailers(body, replaced)); got != "5\r\nhello\r\n0\r\nServer-Timing: db;dur=53\r\n\r\n" {
+		t.Errorf("SetTrailers = %q", got)
+	}
+	if got := string(SetTrailers([]byte("0\n\n"), nil)); got != "0\n\n" {
+		t.Errorf("SetTrailers(nil) = %q", got)
+	}
+
+	// Repeated fields are kept while the trailers are unchanged
+	repeated := []byte("0\r\nX-A: 1\r\nX-A: 2\r\n\r\n")
+	if got := SetTrailers(repeated, Trailers(repeated)); string(got) != string(repeated) {
+		t.Errorf("SetTrailers with repeated fields = %q", got)
+	}
+}
+
+func TestEncodeWriterSetTrailers(t *testing.T) {
+	var out strings.Builder
+	w := NewEncodeWriter(&out, 0)
+	trailers := headers.NewOrderedHeaders()
+	trailers.Set("X-A", "1")
+	trailers.Set("X-B", "2")
+	w.SetTrailers(trailers)
+	w.Write([]byte("hi"))
+	w.Close()
+	if got := out.String(); got != "2\r\nhi\r\n0\r\nX-A: 1\r\nX-B: 2\r\n\r\n" {
+		t.Errorf("encoded = %q", got)
+	}
+}
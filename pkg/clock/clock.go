@@ -0,0 +1,75 @@
+// Package clock abstracts the current time
+//
+// Code that measures or compares against the current time takes a Clock, so
+// tests and replays can substitute a Fake and get deterministic results:
+//
+//	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	sb, _ := resp.WrapBodyReaderWithOptions(r, response.BodyReaderOptions{Clock: fake})
+//	fake.Advance(2 * time.Second)
+//	sb.Elapsed() // 2s
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the system clock
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Or returns c, or Real if c is nil
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// Since returns the time elapsed on c since t
+func Since(c Clock, t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Fake is a Clock that only moves when told to
+// It is safe for concurrent use
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake creates a Fake set to now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the current fake time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set sets the fake time
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	f.now = now
+	f.mu.Unlock()
+}
+
+// Advance moves the fake time forward by d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
@@ -0,0 +1,36 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+
+	if !fake.Now().Equal(start) {
+		t.Errorf("Now() = %v, want %v", fake.Now(), start)
+	}
+	fake.Advance(90 * time.Second)
+	if got := Since(fake, start); got != 90*time.Second {
+		t.Errorf("Since() = %v, want 90s", got)
+	}
+	fake.Set(start)
+	if got := Since(fake, start); got != 0 {
+		t.Errorf("Since() after Set = %v, want 0", got)
+	}
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != Real {
+		t.Error("Or(nil) should return Real")
+	}
+	fake := NewFake(time.Time{})
+	if Or(fake) != fake {
+		t.Error("Or should return a non-nil clock unchanged")
+	}
+	if before := time.Now(); Real.Now().Before(before) {
+		t.Error("Real clock is behind time.Now")
+	}
+}
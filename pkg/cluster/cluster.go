@@ -0,0 +1,129 @@
+// Package cluster groups similar responses for triage
+//
+// Responses are compared by signature: status code, body length bucket, page
+// title and SimHash of the body. Large scan outputs typically collapse into a
+// few big clusters (error pages, soft 404s) and a handful of small ones worth
+// a closer look:
+//
+//	for _, g := range cluster.Responses(resps, cluster.Options{}) {
+//		fmt.Printf("%4d x %d %q\n", g.Size(), g.Signature.StatusCode, g.Signature.Title)
+//		inspect(resps[g.Exemplar()])
+//	}
+package cluster
+
+import (
+	"math"
+	"slices"
+
+	"github.com/WhileEndless/go-httptools/pkg/extract"
+	"github.com/WhileEndless/go-httptools/pkg/fingerprint"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+// DefaultBucketRatio is the relative width of a length bucket
+const DefaultBucketRatio = 0.1
+
+// Options configures clustering
+type Options struct {
+	// Threshold is the maximum SimHash distance within a cluster
+	// (0 = fingerprint.DefaultThreshold; use a negative value for exact hashes)
+	Threshold int
+
+	// BucketRatio is the relative width of length buckets (0 = DefaultBucketRatio)
+	// Lengths in the same or adjacent buckets are considered similar
+	BucketRatio float64
+}
+
+// Signature summarizes a response for clustering
+type Signature struct {
+	StatusCode   int
+	LengthBucket int // Logarithmic bucket of the decoded body length
+	Title        string
+	Hash         uint64 // SimHash of the decoded body
+}
+
+// Group is a cluster of similar responses
+type Group struct {
+	Signature Signature // Signature of the exemplar
+	Members   []int     // Indexes into the clustered responses, in input order
+}
+
+// Size returns the number of responses in the group
+func (g Group) Size() int {
+	return len(g.Members)
+}
+
+// Exemplar returns the index of the first response of the group
+func (g Group) Exemplar() int {
+	return g.Members[0]
+}
+
+// Sign computes the signature of resp
+func Sign(resp *response.Response, opts Options) Signature {
+	return Signature{
+		StatusCode:   resp.StatusCode,
+		LengthBucket: lengthBucket(len(resp.Body), opts.bucketRatio()),
+		Title:        extract.HTML(resp.Body).Title,
+		Hash:         resp.BodySimHash(),
+	}
+}
+
+// Responses clusters resps, largest groups first (ties in order of first appearance)
+// A response joins the first group whose exemplar has the same status and title,
+// a length in the same or an adjacent bucket, and a SimHash within Threshold
+func Responses(resps []*response.Response, opts Options) []Group {
+	threshold := opts.Threshold
+	if threshold == 0 {
+		threshold = fingerprint.DefaultThreshold
+	} else if threshold < 0 {
+		threshold = 0
+	}
+
+	var groups []Group
+	for i, resp := range resps {
+		sig := Sign(resp, opts)
+		placed := false
+		for g := range groups {
+			if similar(groups[g].Signature, sig, threshold) {
+				groups[g].Members = append(groups[g].Members, i)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			groups = append(groups, Group{Signature: sig, Members: []int{i}})
+		}
+	}
+
+	slices.SortStableFunc(groups, func(a, b Group) int {
+		return b.Size() - a.Size()
+	})
+	return groups
+}
+
+// similar reports whether two signatures belong to the same cluster
+func similar(a, b Signature, threshold int) bool {
+	if a.StatusCode != b.StatusCode || a.Title != b.Title {
+		return false
+	}
+	if d := a.LengthBucket - b.LengthBucket; d < -1 || d > 1 {
+		return false
+	}
+	return fingerprint.Distance(a.Hash, b.Hash) <= threshold
+}
+
+// lengthBucket returns the logarithmic bucket of n: lengths within a factor
+// of 1+ratio of each other fall into the same or adjacent buckets
+func lengthBucket(n int, ratio float64) int {
+	if n == 0 {
+		return 0
+	}
+	return 1 + int(math.Log(float64(n))/math.Log1p(ratio))
+}
+
+func (o Options) bucketRatio() float64 {
+	if o.BucketRatio > 0 {
+		return o.BucketRatio
+	}
+	return DefaultBucketRatio
+}
@@ -24,7 +24,7 @@ const (
 )
 
 // DetectCompression detects compression type from Content-Encoding header
-// Supports: gzip, x-gzip, deflate, br, brotli, zstd, identity
+// Supports: gzip, x-gzip, deflate, br, brotli, zstd, identity and registered codecs
 func DetectCompression(contentEncoding string) CompressionType {
 	encoding := strings.ToLower(strings.TrimSpace(contentEncoding))
 
@@ -40,7 +40,8 @@ func DetectCompression(contentEncoding string) CompressionType {
 	case "identity", "":
 		return CompressionNone
 	default:
-		return CompressionNone
+		ct, _ := registeredType(encoding)
+		return ct
 	}
 }
 
@@ -56,7 +57,8 @@ func CompressionTypeToString(ct CompressionType) string {
 	case CompressionZstd:
 		return "zstd"
 	default:
-		return ""
+		_, name, _ := registeredCodec(ct)
+		return name
 	}
 }
 
@@ -67,13 +69,15 @@ func IsSupported(contentEncoding string) bool {
 	case "gzip", "x-gzip", "deflate", "x-deflate", "br", "brotli", "zstd", "zstandard", "identity", "":
 		return true
 	default:
-		return false
+		_, ok := registeredType(encoding)
+		return ok
 	}
 }
 
-// GetSupportedEncodings returns a list of supported Content-Encoding values
+// GetSupportedEncodings returns a list of supported Content-Encoding values,
+// registered codecs included
 func GetSupportedEncodings() []string {
-	return []string{"gzip", "deflate", "br", "zstd", "identity"}
+	return append([]string{"gzip", "deflate", "br", "zstd", "identity"}, registeredNames()...)
 }
 
 // Decompress decompresses data based on the compression type
@@ -94,8 +98,11 @@ func Decompress(data []byte, compressionType CompressionType) ([]byte, error) {
 	case CompressionNone:
 		return data, nil
 	default:
-		return nil, errors.NewError(errors.ErrorTypeCompressionError,
-			"unsupported compression type", "decompress", data)
+		if codec, _, ok := registeredCodec(compressionType); ok {
+			return codec.Decompress(data)
+		}
+		return nil, errors.WrapError(errors.ErrorTypeCompressionError,
+			"unsupported compression type", "decompress", data, errors.ErrUnsupportedEncoding)
 	}
 }
 
@@ -238,8 +245,11 @@ func Compress(data []byte, compressionType CompressionType) ([]byte, error) {
 	case CompressionNone:
 		return data, nil
 	default:
-		return nil, errors.NewError(errors.ErrorTypeCompressionError,
-			"unsupported compression type", "compress", data)
+		if codec, _, ok := registeredCodec(compressionType); ok {
+			return codec.Compress(data)
+		}
+		return nil, errors.WrapError(errors.ErrorTypeCompressionError,
+			"unsupported compression type", "compress", data, errors.ErrUnsupportedEncoding)
 	}
 }
 
@@ -248,6 +258,7 @@ func Compress(data []byte, compressionType CompressionType) ([]byte, error) {
 // - gzip/deflate: 1-9 (1=fastest, 9=best)
 // - brotli: 0-11 (0=fastest, 11=best)
 // - zstd: 1-22 (1=fastest, 22=best), 0=default
+// - registered codecs: ignored
 func CompressWithLevel(data []byte, compressionType CompressionType, level int) ([]byte, error) {
 	if len(data) == 0 {
 		return data, nil
@@ -265,8 +276,11 @@ func CompressWithLevel(data []byte, compressionType CompressionType, level int)
 	case CompressionNone:
 		return data, nil
 	default:
-		return nil, errors.NewError(errors.ErrorTypeCompressionError,
-			"unsupported compression type", "compressWithLevel", data)
+		if codec, _, ok := registeredCodec(compressionType); ok {
+			return codec.Compress(data)
+		}
+		return nil, errors.WrapError(errors.ErrorTypeCompressionError,
+			"unsupported compression type", "compressWithLevel", data, errors.ErrUnsupportedEncoding)
 	}
 }
 
@@ -400,7 +414,8 @@ type DecompressReader struct {
 
 // NewDecompressReader creates a new streaming decompression reader
 // The returned reader decompresses data on-the-fly as it's read
-// Supports gzip, deflate, brotli, and zstd compression
+// Supports gzip, deflate, brotli, and zstd compression, and the codecs added with
+// Register, which see the whole body: it is read and decompressed on the first Read
 // Returns the original reader unchanged if compressionType is CompressionNone
 func NewDecompressReader(r io.Reader, compressionType CompressionType) (io.ReadCloser, error) {
 	if compressionType == CompressionNone {
@@ -439,8 +454,11 @@ func NewDecompressReader(r io.Reader, compressionType CompressionType) (io.ReadC
 		closer = &zstdCloser{zr}
 
 	default:
-		return nil, errors.NewError(errors.ErrorTypeCompressionError,
-			"unsupported compression type for streaming", "NewDecompressReader", nil)
+		if _, _, ok := registeredCodec(compressionType); !ok {
+			return nil, errors.WrapError(errors.ErrorTypeCompressionError,
+				"unsupported compression type for streaming", "NewDecompressReader", nil, errors.ErrUnsupportedEncoding)
+		}
+		reader = &codecReader{src: r, compType: compressionType}
 	}
 
 	return &DecompressReader{
@@ -474,6 +492,30 @@ func (d *DecompressReader) CompressionType() CompressionType {
 	return d.compType
 }
 
+// codecReader decompresses a registered codec's body
+// Codecs only work on whole bodies, so the source is read to EOF on the first Read
+type codecReader struct {
+	src      io.Reader
+	compType CompressionType
+	out      *bytes.Reader
+	err      error
+}
+
+func (c *codecReader) Read(p []byte) (int, error) {
+	if c.out == nil && c.err == nil {
+		var data []byte
+		data, c.err = io.ReadAll(c.src)
+		if c.err == nil {
+			data, c.err = Decompress(data, c.compType)
+		}
+		c.out = bytes.NewReader(data)
+	}
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.out.Read(p)
+}
+
 // nopCloserReader wraps an io.Reader to provide io.ReadCloser with no-op Close
 type nopCloserReader struct {
 	io.Reader
@@ -540,8 +582,8 @@ func NewCompressWriter(w io.Writer, compressionType CompressionType) (io.WriteCl
 		compWriter = encoder
 
 	default:
-		return nil, errors.NewError(errors.ErrorTypeCompressionError,
-			"unsupported compression type for streaming", "NewCompressWriter", nil)
+		return nil, errors.WrapError(errors.ErrorTypeCompressionError,
+			"unsupported compression type for streaming", "NewCompressWriter", nil, errors.ErrUnsupportedEncoding)
 	}
 
 	return &CompressWriter{
@@ -606,8 +648,8 @@ func NewCompressWriterLevel(w io.Writer, compressionType CompressionType, level
 		compWriter = encoder
 
 	default:
-		return nil, errors.NewError(errors.ErrorTypeCompressionError,
-			"unsupported compression type for streaming", "NewCompressWriterLevel", nil)
+		return nil, errors.WrapError(errors.ErrorTypeCompressionError,
+			"unsupported compression type for streaming", "NewCompressWriterLevel", nil, errors.ErrUnsupportedEncoding)
 	}
 
 	return &CompressWriter{
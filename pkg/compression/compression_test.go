@@ -599,3 +599,39 @@ func TestCompressionTypeToString(t *testing.T) {
 		})
 	}
 }
+
+// TestTransferCodings verifies decoding of stacked transfer codings
+func TestTransferCodings(t *testing.T) {
+	codings := TransferCodings(" GZIP, deflate;q=1 ,, chunked")
+	if len(codings) != 3 || codings[0] != "gzip" || codings[1] != "deflate" || codings[2] != "chunked" {
+		t.Fatalf("TransferCodings = %q", codings)
+	}
+
+	original := []byte("transfer coded body")
+	gz, _ := Compress(original, CompressionGzip)
+	encoded, _ := Compress(gz, CompressionDeflate)
+
+	decoded, err := DecodeTransferCodings(encoded, codings)
+	if err != nil || !bytes.Equal(decoded, original) {
+		t.Errorf("DecodeTransferCodings = %q, %v", decoded, err)
+	}
+
+	r, err := NewTransferDecodeReader(bytes.NewReader(encoded), codings)
+	if err != nil {
+		t.Fatalf("NewTransferDecodeReader failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil || !bytes.Equal(buf.Bytes(), original) {
+		t.Errorf("streamed = %q, %v", buf.Bytes(), err)
+	}
+	r.Close()
+
+	if _, err := DecodeTransferCodings(original, []string{"compress", "chunked"}); err == nil {
+		t.Error("expected error for unsupported transfer coding")
+	}
+
+	undecodable, decodable := SplitTransferCodings([]string{"gzip", "cow", "deflate", "chunked"})
+	if len(undecodable) != 2 || undecodable[1] != "cow" || len(decodable) != 2 || decodable[0] != "deflate" {
+		t.Errorf("SplitTransferCodings = %q, %q", undecodable, decodable)
+	}
+}
@@ -0,0 +1,88 @@
+package compression
+
+import (
+	"strings"
+	"sync"
+)
+
+// Codec compresses and decompresses one custom content coding
+type Codec interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// registry holds the codecs added with Register
+var registry = struct {
+	sync.RWMutex
+	types  map[string]CompressionType // Lowercase encoding -> type
+	names  []string                   // Encoding of each custom type, by type - firstCustom
+	codecs []Codec
+}{types: make(map[string]CompressionType)}
+
+// firstCustom is the CompressionType of the first registered codec
+const firstCustom = CompressionZstd + 1
+
+// Register adds a codec for a Content-Encoding not supported natively
+// (e.g. "lz4" or a target's proprietary coding) and returns its CompressionType
+// Once registered, the encoding is detected, compressed and decompressed like
+// the built-in ones, so parsing, BuildOptions and NewDecompressReader use it
+// too; NewCompressWriter does not
+// Registering an encoding again replaces its codec and keeps its type
+// Panics if encoding is empty or natively supported
+func Register(encoding string, codec Codec) CompressionType {
+	name := strings.ToLower(strings.TrimSpace(encoding))
+	if name == "" || isNative(name) {
+		panic("compression: cannot register encoding " + encoding)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if ct, ok := registry.types[name]; ok {
+		registry.codecs[ct-firstCustom] = codec
+		return ct
+	}
+	ct := firstCustom + CompressionType(len(registry.codecs))
+	registry.types[name] = ct
+	registry.names = append(registry.names, name)
+	registry.codecs = append(registry.codecs, codec)
+	return ct
+}
+
+// isNative reports whether the lowercase encoding is handled without a codec
+func isNative(encoding string) bool {
+	switch encoding {
+	case "gzip", "x-gzip", "deflate", "x-deflate", "br", "brotli", "zstd", "zstandard", "identity":
+		return true
+	}
+	return false
+}
+
+// registeredType returns the type registered for the lowercase encoding
+func registeredType(encoding string) (CompressionType, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	ct, ok := registry.types[encoding]
+	return ct, ok
+}
+
+// registeredCodec returns the codec and encoding of a registered type
+func registeredCodec(ct CompressionType) (Codec, string, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	i := int(ct - firstCustom)
+	if i < 0 || i >= len(registry.codecs) {
+		return nil, "", false
+	}
+	return registry.codecs[i], registry.names[i], true
+}
+
+// registeredNames returns the registered encodings in registration order
+func registeredNames() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return append([]string(nil), registry.names...)
+}
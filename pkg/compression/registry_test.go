@@ -0,0 +1,64 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// upperCodec is a toy codec: "compression" uppercases ASCII letters
+type upperCodec struct{}
+
+func (upperCodec) Compress(data []byte) ([]byte, error)   { return bytes.ToUpper(data), nil }
+func (upperCodec) Decompress(data []byte) ([]byte, error) { return bytes.ToLower(data), nil }
+
+func TestRegister(t *testing.T) {
+	ct := Register("X-Upper", upperCodec{})
+	if ct <= CompressionZstd {
+		t.Fatalf("Expected a custom type, got %d", ct)
+	}
+	if Register("x-upper", upperCodec{}) != ct {
+		t.Error("Registering again should keep the type")
+	}
+	if DetectCompression(" x-UPPER ") != ct || CompressionTypeToString(ct) != "x-upper" || !IsSupported("x-upper") {
+		t.Error("Registered encoding not recognized")
+	}
+
+	compressed, err := Compress([]byte("hello"), ct)
+	if err != nil || string(compressed) != "HELLO" {
+		t.Fatalf("Compress = %q, %v", compressed, err)
+	}
+	decompressed, err := Decompress(compressed, ct)
+	if err != nil || string(decompressed) != "hello" {
+		t.Errorf("Decompress = %q, %v", decompressed, err)
+	}
+
+	if _, err := Compress([]byte("x"), ct+1); err == nil {
+		t.Error("Expected error for an unregistered type")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic when registering a native encoding")
+		}
+	}()
+	Register("gzip", upperCodec{})
+}
+
+func TestRegister_Streaming(t *testing.T) {
+	Register("x-upper", upperCodec{})
+
+	r, err := NewDecompressReaderFromEncoding(strings.NewReader("HELLO"), "x-upper")
+	if err != nil {
+		t.Fatalf("NewDecompressReaderFromEncoding failed: %v", err)
+	}
+	defer r.Close()
+	if out, err := io.ReadAll(r); err != nil || string(out) != "hello" {
+		t.Errorf("ReadAll = %q, %v", out, err)
+	}
+
+	if _, err := NewDecompressReader(strings.NewReader("x"), firstCustom+100); err == nil {
+		t.Error("Expected error for an unregistered type")
+	}
+}
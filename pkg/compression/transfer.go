@@ -0,0 +1,107 @@
+package compression
+
+import (
+	"io"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/errors"
+)
+
+// TransferCodings splits a Transfer-Encoding value into lowercase coding names
+// in the order they were applied, with parameters removed
+// "gzip, chunked" yields [gzip chunked]: gzip was applied first, chunked last
+func TransferCodings(transferEncoding string) []string {
+	var codings []string
+	for _, part := range strings.Split(transferEncoding, ",") {
+		name, _, _ := strings.Cut(part, ";")
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			codings = append(codings, name)
+		}
+	}
+	return codings
+}
+
+// transferCoding maps a transfer coding to its compression type
+// chunked and identity map to CompressionNone; they are not decoded here
+func transferCoding(coding string) (CompressionType, error) {
+	switch coding {
+	case "chunked", "identity":
+		return CompressionNone, nil
+	}
+	ct := DetectCompression(coding)
+	if ct == CompressionNone {
+		return CompressionNone, errors.WrapError(errors.ErrorTypeCompressionError,
+			"unsupported transfer coding: "+coding, "transferCoding", nil, errors.ErrUnsupportedEncoding)
+	}
+	return ct, nil
+}
+
+// SplitTransferCodings splits codings at the last one that cannot be decoded
+// decodable holds the codings after it, which DecodeTransferCodings and
+// NewTransferDecodeReader can remove; undecodable holds that coding and every
+// coding applied before it, which stay on the data
+func SplitTransferCodings(codings []string) (undecodable, decodable []string) {
+	for i := len(codings) - 1; i >= 0; i-- {
+		if _, err := transferCoding(codings[i]); err != nil {
+			return codings[:i+1], codings[i+1:]
+		}
+	}
+	return nil, codings
+}
+
+// DecodeTransferCodings removes the compression codings from data, last applied first
+// chunked is skipped, so callers dechunk before calling it
+// Unknown codings (e.g. compress) return an error matching errors.ErrUnsupportedEncoding
+func DecodeTransferCodings(data []byte, codings []string) ([]byte, error) {
+	for i := len(codings) - 1; i >= 0; i-- {
+		ct, err := transferCoding(codings[i])
+		if err != nil {
+			return nil, err
+		}
+		if ct == CompressionNone {
+			continue
+		}
+		if data, err = Decompress(data, ct); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// NewTransferDecodeReader is the streaming form of DecodeTransferCodings
+// Closing the returned reader closes every decompressor layer
+func NewTransferDecodeReader(r io.Reader, codings []string) (io.ReadCloser, error) {
+	layers := &transferReader{Reader: r}
+	for i := len(codings) - 1; i >= 0; i-- {
+		ct, err := transferCoding(codings[i])
+		if err == nil && ct != CompressionNone {
+			var dr io.ReadCloser
+			if dr, err = NewDecompressReader(layers.Reader, ct); err == nil {
+				layers.Reader = dr
+				layers.closers = append(layers.closers, dr)
+			}
+		}
+		if err != nil {
+			layers.Close()
+			return nil, err
+		}
+	}
+	return layers, nil
+}
+
+// transferReader is a stack of decompressors
+type transferReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes the layers from the outermost reader inwards
+func (t *transferReader) Close() error {
+	var lastErr error
+	for i := len(t.closers) - 1; i >= 0; i-- {
+		if err := t.closers[i].Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,145 @@
+package cookies
+
+import (
+	"strings"
+	"time"
+
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+)
+
+// SameSite attribute values
+const (
+	SameSiteStrict = "Strict"
+	SameSiteLax    = "Lax"
+	SameSiteNone   = "None"
+)
+
+// Issue is an insecure or invalid attribute combination reported by Validate
+type Issue string
+
+const (
+	IssueSameSiteNoneWithoutSecure Issue = "samesite-none-without-secure" // Rejected by browsers
+	IssuePartitionedWithoutSecure  Issue = "partitioned-without-secure"   // Rejected by browsers
+	IssueInvalidSameSite           Issue = "invalid-samesite"             // Not Strict, Lax or None
+	IssueBroadDomain               Issue = "broad-domain"                 // Domain is a top-level or public second-level domain
+	IssueSecurePrefix              Issue = "secure-prefix"                // __Secure- cookie without Secure
+	IssueHostPrefix                Issue = "host-prefix"                  // __Host- cookie without Secure, with Domain or Path other than /
+)
+
+// publicSecondLevel lists labels that, under a two-letter country code, form
+// a registry suffix ("co.uk", "com.au") rather than a registrable domain
+var publicSecondLevel = map[string]bool{
+	"ac": true, "co": true, "com": true, "edu": true, "gov": true,
+	"net": true, "org": true, "ne": true, "or": true, "go": true,
+}
+
+// expiredDate is the Expires value used to delete a cookie
+const expiredDate = "Thu, 01 Jan 1970 00:00:00 GMT"
+
+// SetSameSite sets the SameSite attribute ("" removes it)
+// SameSite=None is only accepted by browsers on Secure cookies, so it also sets Secure
+func (c *ResponseCookie) SetSameSite(mode string) {
+	c.SameSite = mode
+	if strings.EqualFold(mode, SameSiteNone) {
+		c.Secure = true
+	}
+}
+
+// SetPartitioned sets the Partitioned attribute
+// Partitioned cookies must be Secure, so enabling it also sets Secure
+func (c *ResponseCookie) SetPartitioned(partitioned bool) {
+	c.Partitioned = partitioned
+	if partitioned {
+		c.Secure = true
+	}
+}
+
+// SetMaxAge sets the lifetime in seconds
+// seconds <= 0 deletes the cookie: Max-Age=0, with an Expires date in the past
+// for clients that ignore Max-Age
+func (c *ResponseCookie) SetMaxAge(seconds int) {
+	c.MaxAge = max(seconds, 0)
+	c.HasMaxAge = true
+	if seconds <= 0 {
+		c.Expires = expiredDate
+	}
+}
+
+// SetExpires sets the Expires attribute to t
+// Max-Age takes precedence over Expires, so it is removed
+func (c *ResponseCookie) SetExpires(t time.Time) {
+	c.Expires = headers.FormatHTTPDate(t)
+	c.MaxAge = -1
+	c.HasMaxAge = false
+}
+
+// hasMaxAge reports whether the cookie carries a Max-Age attribute
+// A positive MaxAge counts even without HasMaxAge, as set by older code
+func (c *ResponseCookie) hasMaxAge() bool {
+	return c.HasMaxAge || c.MaxAge > 0
+}
+
+// Expiry returns when the cookie expires, relative to now for Max-Age
+// Max-Age takes precedence over Expires (RFC 6265 section 5.3); a MaxAge of 0
+// (parsed from Max-Age=0 or a negative value) means expired at now
+// Returns false for session cookies and unparseable Expires dates
+func (c *ResponseCookie) Expiry(now time.Time) (time.Time, bool) {
+	if c.hasMaxAge() {
+		return now.Add(time.Duration(max(c.MaxAge, 0)) * time.Second), true
+	}
+	if c.Expires == "" {
+		return time.Time{}, false
+	}
+	t, err := headers.ParseHTTPDate(c.Expires)
+	return t, err == nil
+}
+
+// Validate reports insecure or invalid attribute combinations
+// Returns nil when none are found
+func (c *ResponseCookie) Validate() []Issue {
+	var issues []Issue
+
+	switch {
+	case c.SameSite == "":
+	case strings.EqualFold(c.SameSite, SameSiteNone):
+		if !c.Secure {
+			issues = append(issues, IssueSameSiteNoneWithoutSecure)
+		}
+	case !strings.EqualFold(c.SameSite, SameSiteStrict) && !strings.EqualFold(c.SameSite, SameSiteLax):
+		issues = append(issues, IssueInvalidSameSite)
+	}
+
+	if c.Partitioned && !c.Secure {
+		issues = append(issues, IssuePartitionedWithoutSecure)
+	}
+	if c.Domain != "" && isBroadDomain(c.Domain) {
+		issues = append(issues, IssueBroadDomain)
+	}
+
+	// Cookie prefixes are case-sensitive
+	switch {
+	case strings.HasPrefix(c.Name, "__Secure-"):
+		if !c.Secure {
+			issues = append(issues, IssueSecurePrefix)
+		}
+	case strings.HasPrefix(c.Name, "__Host-"):
+		if !c.Secure || c.Domain != "" || c.Path != "/" {
+			issues = append(issues, IssueHostPrefix)
+		}
+	}
+
+	return issues
+}
+
+// isBroadDomain reports whether domain covers a whole top-level domain or a
+// public second-level domain such as co.uk
+func isBroadDomain(domain string) bool {
+	labels := strings.Split(strings.Trim(strings.ToLower(domain), "."), ".")
+	switch len(labels) {
+	case 1:
+		return true
+	case 2:
+		return len(labels[1]) == 2 && publicSecondLevel[labels[0]]
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package cookies
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestResponseCookie_Partitioned(t *testing.T) {
+	cookie := ParseSetCookie("id=1; Secure; SameSite=None; Partitioned")
+	if !cookie.Partitioned {
+		t.Fatal("Expected Partitioned")
+	}
+	if got := cookie.Build(); got != "id=1; Secure; SameSite=None; Partitioned" {
+		t.Errorf("Unexpected build %q", got)
+	}
+}
+
+func TestResponseCookie_Setters(t *testing.T) {
+	cookie := ParseSetCookie("id=1; Max-Age=60")
+
+	cookie.SetSameSite(SameSiteNone)
+	cookie.SetPartitioned(true)
+	if !cookie.Secure || cookie.SameSite != "None" {
+		t.Errorf("Expected Secure with SameSite=None, got %+v", cookie)
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if exp, ok := cookie.Expiry(now); !ok || !exp.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expiry = %v, %v", exp, ok)
+	}
+
+	// Expires replaces Max-Age, which would otherwise take precedence
+	cookie.SetExpires(now.Add(time.Hour))
+	if cookie.MaxAge != -1 || cookie.Expires != "Mon, 01 Jan 2024 01:00:00 GMT" {
+		t.Errorf("Unexpected Expires %q (Max-Age %d)", cookie.Expires, cookie.MaxAge)
+	}
+	if exp, ok := cookie.Expiry(now); !ok || !exp.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expiry = %v, %v", exp, ok)
+	}
+
+	cookie.SetMaxAge(0)
+	if exp, ok := cookie.Expiry(now); !ok || exp.After(now) {
+		t.Errorf("Expected expired cookie, got %v, %v", exp, ok)
+	}
+	if got := cookie.Build(); got != "id=1; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0; Secure; SameSite=None; Partitioned" {
+		t.Errorf("Unexpected deletion build %q", got)
+	}
+
+	// Max-Age=0 and negative values win over Expires
+	for _, header := range []string{"id=1; Max-Age=0; Expires=Wed, 01 Jan 2025 00:00:00 GMT", "id=1; Max-Age=-5; Expires=Wed, 01 Jan 2025 00:00:00 GMT"} {
+		expired := ParseSetCookie(header)
+		if exp, ok := expired.Expiry(now); !ok || !exp.Equal(now) {
+			t.Errorf("For %q, expected expiry at now, got %v, %v", header, exp, ok)
+		}
+	}
+
+	session := ParseSetCookie("id=1")
+	if _, ok := session.Expiry(now); ok {
+		t.Error("Expected session cookie")
+	}
+	if _, ok := (&ResponseCookie{Name: "id"}).Expiry(now); ok {
+		t.Error("Expected a zero-value cookie to be a session cookie")
+	}
+}
+
+func TestResponseCookie_MaxAgeRoundTrip(t *testing.T) {
+	for _, header := range []string{"id=; Path=/; Max-Age=0", "id=1; Max-Age=3600; HttpOnly", "id=1; Path=/"} {
+		cookie := ParseSetCookie(header)
+		if got := cookie.Build(); got != header {
+			t.Errorf("Build() = %q, want %q", got, header)
+		}
+	}
+
+	// Negative values are sent as Max-Age=0
+	cookie := ParseSetCookie("id=; Max-Age=-1")
+	if got := cookie.Build(); got != "id=; Max-Age=0" {
+		t.Errorf("Build() = %q", got)
+	}
+}
+
+func TestResponseCookie_Validate(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []Issue
+	}{
+		{"id=1; Secure; HttpOnly; SameSite=Lax; Domain=app.example.com", nil},
+		{"id=1; SameSite=None", []Issue{IssueSameSiteNoneWithoutSecure}},
+		{"id=1; SameSite=Loose", []Issue{IssueInvalidSameSite}},
+		{"id=1; Partitioned", []Issue{IssuePartitionedWithoutSecure}},
+		{"id=1; Domain=.com", []Issue{IssueBroadDomain}},
+		{"id=1; Domain=co.uk", []Issue{IssueBroadDomain}},
+		{"__Secure-id=1", []Issue{IssueSecurePrefix}},
+		{"__Host-id=1; Secure; Path=/; Domain=example.com", []Issue{IssueHostPrefix}},
+		{"__Host-id=1; Secure; Path=/", nil},
+	}
+
+	for _, tc := range testCases {
+		cookie := ParseSetCookie(tc.input)
+		if got := cookie.Validate(); !slices.Equal(got, tc.expected) {
+			t.Errorf("For %q, expected %v, got %v", tc.input, tc.expected, got)
+		}
+	}
+}
@@ -78,16 +78,18 @@ func BuildCookieHeader(cookies []Cookie) string {
 
 // ResponseCookie represents Set-Cookie header (from HTTP response)
 type ResponseCookie struct {
-	Name     string
-	Value    string
-	Path     string
-	Domain   string
-	Expires  string
-	MaxAge   int
-	Secure   bool
-	HttpOnly bool
-	SameSite string
-	Raw      string // Original Set-Cookie header (preserved)
+	Name        string
+	Value       string
+	Path        string
+	Domain      string
+	Expires     string
+	MaxAge      int  // Seconds; 0 with HasMaxAge deletes the cookie
+	HasMaxAge   bool // Max-Age is present (a positive MaxAge counts as present too)
+	Secure      bool
+	HttpOnly    bool
+	SameSite    string
+	Partitioned bool   // CHIPS partitioned cookie
+	Raw         string // Original Set-Cookie header (preserved)
 }
 
 // ParseSetCookie parses Set-Cookie header
@@ -145,10 +147,12 @@ func ParseSetCookie(setCookie string) ResponseCookie {
 			case "expires":
 				cookie.Expires = value
 			case "max-age":
-				// Try to parse as int
+				// Try to parse as int; zero or negative means expire now
+				// (RFC 6265 section 5.2.2), kept as 0
 				var maxAge int
 				if _, err := fmt.Sscanf(value, "%d", &maxAge); err == nil {
-					cookie.MaxAge = maxAge
+					cookie.MaxAge = max(maxAge, 0)
+					cookie.HasMaxAge = true
 				}
 			case "samesite":
 				cookie.SameSite = value
@@ -161,6 +165,8 @@ func ParseSetCookie(setCookie string) ResponseCookie {
 				cookie.Secure = true
 			case "httponly":
 				cookie.HttpOnly = true
+			case "partitioned":
+				cookie.Partitioned = true
 			}
 		}
 	}
@@ -193,9 +199,9 @@ func (c *ResponseCookie) Build() string {
 		parts = append(parts, "Expires="+c.Expires)
 	}
 
-	// Max-Age (only if explicitly set to positive value)
-	if c.MaxAge > 0 {
-		parts = append(parts, fmt.Sprintf("Max-Age=%d", c.MaxAge))
+	// Max-Age (Max-Age=0 for deletions)
+	if c.hasMaxAge() {
+		parts = append(parts, fmt.Sprintf("Max-Age=%d", max(c.MaxAge, 0)))
 	}
 
 	// Secure
@@ -213,5 +219,77 @@ func (c *ResponseCookie) Build() string {
 		parts = append(parts, "SameSite="+c.SameSite)
 	}
 
+	// Partitioned
+	if c.Partitioned {
+		parts = append(parts, "Partitioned")
+	}
+
 	return strings.Join(parts, "; ")
 }
+
+// SplitMode controls how a Set-Cookie value containing commas is handled
+type SplitMode int
+
+const (
+	// SplitLenient splits on commas that start a new name=value pair
+	// Handles servers that fold several cookies into one header while keeping
+	// commas inside Expires dates intact
+	SplitLenient SplitMode = iota
+
+	// SplitStrict treats the value as a single cookie (RFC 6265)
+	SplitStrict
+)
+
+// SplitSetCookie splits a Set-Cookie value that may hold several comma-separated cookies
+// In SplitStrict mode the value is returned as is
+func SplitSetCookie(setCookie string, mode SplitMode) []string {
+	if mode == SplitStrict || !strings.Contains(setCookie, ",") {
+		return []string{setCookie}
+	}
+
+	var parts []string
+	start := 0
+	for i := 0; i < len(setCookie); i++ {
+		if setCookie[i] != ',' {
+			continue
+		}
+		if inExpiresDate(setCookie[start:i]) || !startsCookiePair(setCookie[i+1:]) {
+			continue
+		}
+		parts = append(parts, strings.TrimSpace(setCookie[start:i]))
+		start = i + 1
+	}
+	return append(parts, strings.TrimSpace(setCookie[start:]))
+}
+
+// ParseSetCookies parses a Set-Cookie value that may hold several cookies
+func ParseSetCookies(setCookie string, mode SplitMode) []ResponseCookie {
+	var result []ResponseCookie
+	for _, part := range SplitSetCookie(setCookie, mode) {
+		result = append(result, ParseSetCookie(part))
+	}
+	return result
+}
+
+// inExpiresDate reports whether a comma following cookie text would fall inside
+// an Expires date, i.e. the last attribute is "Expires=<weekday>"
+func inExpiresDate(cookie string) bool {
+	attr := cookie
+	if idx := strings.LastIndex(cookie, ";"); idx != -1 {
+		attr = cookie[idx+1:]
+	}
+	key, value, found := strings.Cut(strings.TrimSpace(attr), "=")
+	return found && strings.EqualFold(strings.TrimSpace(key), "expires") &&
+		!strings.ContainsAny(strings.TrimSpace(value), " \t")
+}
+
+// startsCookiePair reports whether s begins with a "name=" cookie pair
+func startsCookiePair(s string) bool {
+	s = strings.TrimLeft(s, " \t")
+	end := strings.IndexAny(s, ";,")
+	if end == -1 {
+		end = len(s)
+	}
+	name, _, found := strings.Cut(s[:end], "=")
+	return found && name != "" && !strings.ContainsAny(name, " \t")
+}
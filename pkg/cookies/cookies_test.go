@@ -409,3 +409,52 @@ func BenchmarkResponseCookieBuild(b *testing.B) {
 		cookie.Build()
 	}
 }
+
+func TestSplitSetCookie(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		mode     SplitMode
+		expected []string
+	}{
+		{"single", "a=1; Path=/", SplitLenient, []string{"a=1; Path=/"}},
+		{"two cookies", "a=1; Path=/, b=2", SplitLenient, []string{"a=1; Path=/", "b=2"}},
+		{
+			"expires comma",
+			"a=1; Expires=Wed, 09 Jun 2021 10:18:14 GMT; Path=/, b=2; expires=Thu, 10-Jun-2021 10:18:14 GMT",
+			SplitLenient,
+			[]string{"a=1; Expires=Wed, 09 Jun 2021 10:18:14 GMT; Path=/", "b=2; expires=Thu, 10-Jun-2021 10:18:14 GMT"},
+		},
+		{"expires last attribute", "a=1; Expires=Wed, 09 Jun 2021 10:18:14 GMT, b=2", SplitLenient,
+			[]string{"a=1; Expires=Wed, 09 Jun 2021 10:18:14 GMT", "b=2"}},
+		{"comma in value", "a=x,y; Path=/", SplitLenient, []string{"a=x,y; Path=/"}},
+		{"strict", "a=1, b=2", SplitStrict, []string{"a=1, b=2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitSetCookie(tt.input, tt.mode)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %q, got %q", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Part %d: expected %q, got %q", i, tt.expected[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSetCookies_Multiple(t *testing.T) {
+	parsed := ParseSetCookies("session=abc; HttpOnly, theme=dark; Expires=Wed, 09 Jun 2021 10:18:14 GMT", SplitLenient)
+	if len(parsed) != 2 {
+		t.Fatalf("Expected 2 cookies, got %d", len(parsed))
+	}
+	if parsed[0].Name != "session" || !parsed[0].HttpOnly {
+		t.Errorf("Unexpected first cookie: %+v", parsed[0])
+	}
+	if parsed[1].Name != "theme" || parsed[1].Expires != "Wed, 09 Jun 2021 10:18:14 GMT" {
+		t.Errorf("Unexpected second cookie: %+v", parsed[1])
+	}
+}
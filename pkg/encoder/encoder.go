@@ -0,0 +1,348 @@
+// Package encoder provides composable payload encoders
+//
+// Encoders can be chained and applied to payloads before substitution, and
+// decoded in reverse order to analyze reflected output:
+//
+//	chain := encoder.Chain{encoder.HTML, encoder.URL}
+//	encoded := chain.Encode([]byte(`<script>`)) // %26lt%3Bscript%26gt%3B
+//	decoded, err := chain.Decode(encoded)
+package encoder
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoder transforms payloads and reverses the transformation
+type Encoder interface {
+	// Name returns the encoder name as used by Parse (e.g. "url")
+	Name() string
+
+	// Encode returns the encoded form of p
+	Encode(p []byte) []byte
+
+	// Decode reverses Encode
+	// Lossy encoders (such as mixed case) return their input unchanged
+	Decode(p []byte) ([]byte, error)
+}
+
+// Built-in encoders
+var (
+	// URL percent-encodes every byte except unreserved characters (RFC 3986)
+	URL Encoder = urlEncoder{all: false}
+
+	// URLAll percent-encodes every byte
+	URLAll Encoder = urlEncoder{all: true}
+
+	// DoubleURL applies URL encoding twice (%3C becomes %253C)
+	DoubleURL Encoder = Chain{URL, URL}
+
+	// HTML escapes <, >, &, ' and " as HTML entities
+	HTML Encoder = htmlEncoder{all: false}
+
+	// HTMLAll encodes every character as a decimal HTML entity (&#60;)
+	HTMLAll Encoder = htmlEncoder{all: true}
+
+	// Base64 applies standard base64 encoding
+	Base64 Encoder = base64Encoder{}
+
+	// Unicode encodes every character as a \uXXXX escape (surrogate pairs above U+FFFF)
+	Unicode Encoder = unicodeEncoder{}
+
+	// MixedCase alternates letter case (SeLeCt) to evade case-sensitive filters
+	MixedCase Encoder = mixedCaseEncoder{}
+)
+
+// byName maps encoder names to encoders for Parse
+var byName = map[string]Encoder{
+	"url":        URL,
+	"url-all":    URLAll,
+	"double-url": DoubleURL,
+	"html":       HTML,
+	"html-all":   HTMLAll,
+	"base64":     Base64,
+	"unicode":    Unicode,
+	"mixed-case": MixedCase,
+}
+
+// Chain applies encoders in order; Decode applies them in reverse order
+type Chain []Encoder
+
+// Parse builds a chain from comma-separated encoder names (e.g. "html,url")
+func Parse(spec string) (Chain, error) {
+	var chain Chain
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		enc, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("encoder: unknown encoder %q", name)
+		}
+		chain = append(chain, enc)
+	}
+	return chain, nil
+}
+
+// Name returns the encoder names joined by commas
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, enc := range c {
+		names[i] = enc.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Encode applies every encoder in order
+func (c Chain) Encode(p []byte) []byte {
+	for _, enc := range c {
+		p = enc.Encode(p)
+	}
+	return p
+}
+
+// Decode reverses the chain, decoding with the last encoder first
+func (c Chain) Decode(p []byte) ([]byte, error) {
+	for i := len(c) - 1; i >= 0; i-- {
+		var err error
+		if p, err = c[i].Decode(p); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// EncodeAll encodes each payload, returning new slices
+func (c Chain) EncodeAll(payloads [][]byte) [][]byte {
+	out := make([][]byte, len(payloads))
+	for i, p := range payloads {
+		out[i] = c.Encode(p)
+	}
+	return out
+}
+
+// urlEncoder implements URL and URLAll
+type urlEncoder struct {
+	all bool
+}
+
+func (e urlEncoder) Name() string {
+	if e.all {
+		return "url-all"
+	}
+	return "url"
+}
+
+func (e urlEncoder) Encode(p []byte) []byte {
+	const hex = "0123456789ABCDEF"
+	out := make([]byte, 0, len(p)*3)
+	for _, c := range p {
+		if !e.all && isUnreserved(c) {
+			out = append(out, c)
+			continue
+		}
+		out = append(out, '%', hex[c>>4], hex[c&0x0F])
+	}
+	return out
+}
+
+// Decode percent-decodes p and turns '+' into a space
+// Malformed escapes are kept as is, so reflected output can always be analyzed
+func (e urlEncoder) Decode(p []byte) ([]byte, error) {
+	out := make([]byte, 0, len(p))
+	for i := 0; i < len(p); i++ {
+		switch {
+		case p[i] == '%' && i+2 < len(p) && isHex(p[i+1]) && isHex(p[i+2]):
+			out = append(out, unhex(p[i+1])<<4|unhex(p[i+2]))
+			i += 2
+		case p[i] == '+':
+			out = append(out, ' ')
+		default:
+			out = append(out, p[i])
+		}
+	}
+	return out, nil
+}
+
+// NormalizePercent applies RFC 3986 section 6.2.2 normalization:
+// escapes of unreserved characters are decoded, other escapes are uppercased
+// Invalid escapes and all other bytes are kept as they are
+func NormalizePercent(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+// htmlEncoder implements HTML and HTMLAll
+type htmlEncoder struct {
+	all bool
+}
+
+func (e htmlEncoder) Name() string {
+	if e.all {
+		return "html-all"
+	}
+	return "html"
+}
+
+func (e htmlEncoder) Encode(p []byte) []byte {
+	if !e.all {
+		return []byte(html.EscapeString(string(p)))
+	}
+	var b strings.Builder
+	for _, r := range string(p) {
+		b.WriteString("&#")
+		b.WriteString(strconv.Itoa(int(r)))
+		b.WriteByte(';')
+	}
+	return []byte(b.String())
+}
+
+func (e htmlEncoder) Decode(p []byte) ([]byte, error) {
+	return []byte(html.UnescapeString(string(p))), nil
+}
+
+// base64Encoder implements Base64
+type base64Encoder struct{}
+
+func (base64Encoder) Name() string { return "base64" }
+
+func (base64Encoder) Encode(p []byte) []byte {
+	out := make([]byte, base64.StdEncoding.EncodedLen(len(p)))
+	base64.StdEncoding.Encode(out, p)
+	return out
+}
+
+// Decode accepts standard and URL-safe alphabets, with or without padding
+func (base64Encoder) Decode(p []byte) ([]byte, error) {
+	s := strings.TrimSpace(string(p))
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if out, err := enc.DecodeString(s); err == nil {
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("encoder: invalid base64 input")
+}
+
+// unicodeEncoder implements Unicode
+type unicodeEncoder struct{}
+
+func (unicodeEncoder) Name() string { return "unicode" }
+
+func (unicodeEncoder) Encode(p []byte) []byte {
+	var b strings.Builder
+	for _, r := range string(p) {
+		if r > 0xFFFF {
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, "\\u%04x\\u%04x", r1, r2)
+			continue
+		}
+		fmt.Fprintf(&b, "\\u%04x", r)
+	}
+	return []byte(b.String())
+}
+
+// Decode resolves \uXXXX escapes (including surrogate pairs); other text is kept
+func (unicodeEncoder) Decode(p []byte) ([]byte, error) {
+	out := make([]byte, 0, len(p))
+	for i := 0; i < len(p); {
+		r, n := parseUnicodeEscape(p[i:])
+		if n == 0 {
+			out = append(out, p[i])
+			i++
+			continue
+		}
+		i += n
+		if r >= 0xD800 && r < 0xDC00 {
+			if low, m := parseUnicodeEscape(p[i:]); m > 0 && utf16.IsSurrogate(low) {
+				r = utf16.DecodeRune(r, low)
+				i += m
+			}
+		}
+		out = utf8.AppendRune(out, r)
+	}
+	return out, nil
+}
+
+// mixedCaseEncoder implements MixedCase
+type mixedCaseEncoder struct{}
+
+func (mixedCaseEncoder) Name() string { return "mixed-case" }
+
+func (mixedCaseEncoder) Encode(p []byte) []byte {
+	var b strings.Builder
+	upper := true
+	for _, r := range string(p) {
+		if unicode.IsLetter(r) {
+			if upper {
+				r = unicode.ToUpper(r)
+			} else {
+				r = unicode.ToLower(r)
+			}
+			upper = !upper
+		}
+		b.WriteRune(r)
+	}
+	return []byte(b.String())
+}
+
+// Decode returns p unchanged: the original case is not recoverable
+func (mixedCaseEncoder) Decode(p []byte) ([]byte, error) {
+	return p, nil
+}
+
+// parseUnicodeEscape parses a \uXXXX escape at the start of p
+// Returns the code unit and the number of bytes consumed (0 if none)
+func parseUnicodeEscape(p []byte) (rune, int) {
+	if len(p) < 6 || p[0] != '\\' || (p[1] != 'u' && p[1] != 'U') {
+		return 0, 0
+	}
+	v, err := strconv.ParseUint(string(p[2:6]), 16, 32)
+	if err != nil {
+		return 0, 0
+	}
+	return rune(v), 6
+}
+
+func isUnreserved(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
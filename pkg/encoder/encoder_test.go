@@ -0,0 +1,84 @@
+package encoder
+
+import "testing"
+
+func TestEncoders(t *testing.T) {
+	tests := []struct {
+		enc     Encoder
+		input   string
+		encoded string
+	}{
+		{URL, "<a b>&x=1~", "%3Ca%20b%3E%26x%3D1~"},
+		{URLAll, "ab", "%61%62"},
+		{DoubleURL, "<", "%253C"},
+		{HTML, `<a href="x">'`, "&lt;a href=&#34;x&#34;&gt;&#39;"},
+		{HTMLAll, "<é", "&#60;&#233;"},
+		{Base64, "hello?", "aGVsbG8/"},
+		{Unicode, "<é😀", `\u003c\u00e9\ud83d\ude00`},
+		{MixedCase, "select * from", "SeLeCt * FrOm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.enc.Name(), func(t *testing.T) {
+			if got := string(tt.enc.Encode([]byte(tt.input))); got != tt.encoded {
+				t.Errorf("Encode: expected %q, got %q", tt.encoded, got)
+			}
+			if tt.enc == MixedCase {
+				return
+			}
+			decoded, err := tt.enc.Decode([]byte(tt.encoded))
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if string(decoded) != tt.input {
+				t.Errorf("Decode: expected %q, got %q", tt.input, decoded)
+			}
+		})
+	}
+}
+
+func TestDecodeLenient(t *testing.T) {
+	if got, _ := URL.Decode([]byte("a+b%zz%41")); string(got) != "a b%zzA" {
+		t.Errorf("Unexpected URL decode %q", got)
+	}
+	if got, _ := Base64.Decode([]byte("aGVsbG8_")); string(got) != "hello?" {
+		t.Errorf("Expected URL-safe base64 to decode, got %q", got)
+	}
+	if _, err := Base64.Decode([]byte("***")); err == nil {
+		t.Error("Expected error for invalid base64")
+	}
+}
+
+func TestNormalizePercent(t *testing.T) {
+	if got := NormalizePercent("/a%7eb%2fc%zz%4"); got != "/a~b%2Fc%zz%4" {
+		t.Errorf("Unexpected normalization %q", got)
+	}
+}
+
+func TestChain(t *testing.T) {
+	chain, err := Parse("html, url")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if chain.Name() != "html,url" {
+		t.Errorf("Unexpected chain name %q", chain.Name())
+	}
+
+	encoded := chain.Encode([]byte("<script>"))
+	if string(encoded) != "%26lt%3Bscript%26gt%3B" {
+		t.Errorf("Unexpected encoding %q", encoded)
+	}
+	decoded, err := chain.Decode(encoded)
+	if err != nil || string(decoded) != "<script>" {
+		t.Errorf("Unexpected decoding %q (%v)", decoded, err)
+	}
+
+	all := chain.EncodeAll([][]byte{[]byte("<"), []byte(">")})
+	if len(all) != 2 || string(all[0]) != "%26lt%3B" {
+		t.Errorf("Unexpected EncodeAll result %q", all)
+	}
+
+	if _, err := Parse("url,rot13"); err == nil {
+		t.Error("Expected error for unknown encoder")
+	}
+}
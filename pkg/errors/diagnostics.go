@@ -0,0 +1,63 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+)
+
+// DiagnosticCode identifies an anomaly that parsing tolerated
+type DiagnosticCode string
+
+const (
+	DiagUnparseable          DiagnosticCode = "unparseable"             // Message could not be parsed at all
+	DiagMissingVersion       DiagnosticCode = "missing-version"         // Start line without HTTP version
+	DiagInvalidVersion       DiagnosticCode = "invalid-version"         // Version not starting with HTTP/, replaced by HTTP/1.1
+	DiagExtraStartLineTokens DiagnosticCode = "extra-start-line-tokens" // More than three start line tokens
+	DiagMissingReason        DiagnosticCode = "missing-reason"          // Status line without reason phrase, default text used
+	DiagBareLineEnding       DiagnosticCode = "bare-line-ending"        // LF or CR instead of CRLF
+	DiagMalformedHeader      DiagnosticCode = "malformed-header"        // Header line without colon, kept as X-Malformed-Header
+	DiagEmptyHeaderName      DiagnosticCode = "empty-header-name"       // Header with empty name, kept as X-Empty-Header-Name
+	DiagObsFold              DiagnosticCode = "obs-fold"                // Folded header line joined to the previous header
+	DiagMissingHeaderEnd     DiagnosticCode = "missing-header-end"      // No empty line after the headers
+	DiagDecompressionFailed  DiagnosticCode = "decompression-failed"    // Body kept compressed
+	DiagInvalidContentLength DiagnosticCode = "invalid-content-length"  // Content-Length not a non-negative integer
+	DiagLengthMismatch       DiagnosticCode = "content-length-mismatch" // Content-Length differs from the body length
+	DiagConflictingFraming   DiagnosticCode = "conflicting-framing"     // Content-Length alongside chunked Transfer-Encoding
+	DiagBodyTruncated        DiagnosticCode = "body-truncated"          // Body cut at ParseOptions.MaxBodySize
+)
+
+// Diagnostic records one anomaly that parsing tolerated instead of failing
+type Diagnostic struct {
+	Code    DiagnosticCode
+	Message string
+	Offset  int // Byte offset in the parsed data
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("offset %d: %s: %s", d.Offset, d.Code, d.Message)
+}
+
+// Diagnostics collects diagnostics during parsing
+// The request and response parsers always collect them (see ParseWarnings)
+// Methods on a nil *Diagnostics do nothing, so helpers such as
+// headers.ParseHeadersWithDiagnostics accept nil when nothing is collected
+type Diagnostics []Diagnostic
+
+// Add records a diagnostic
+func (d *Diagnostics) Add(code DiagnosticCode, offset int, format string, args ...any) {
+	if d == nil {
+		return
+	}
+	*d = append(*d, Diagnostic{Code: code, Message: fmt.Sprintf(format, args...), Offset: offset})
+}
+
+// AddError records a failed parse as a DiagUnparseable diagnostic
+// The offset is taken from err when it is an *Error with a known position
+func (d *Diagnostics) AddError(err error) {
+	offset := 0
+	var e *Error
+	if stderrors.As(err, &e) && e.Line > 0 {
+		offset = e.Offset
+	}
+	d.Add(DiagUnparseable, offset, "%v", err)
+}
@@ -1,6 +1,15 @@
 package errors
 
-import "fmt"
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
 
 // ErrorType represents different types of parsing errors
 type ErrorType int
@@ -13,20 +22,74 @@ const (
 	ErrorTypeInvalidVersion
 	ErrorTypeInvalidStatusCode
 	ErrorTypeCompressionError
+	ErrorTypeBodyTooLarge
+	ErrorTypeMalformedChunk
+	ErrorTypeUnsupportedEncoding
+)
+
+// Sentinel errors for use with errors.Is
+// Every *Error matches the sentinel of its Type, and the sentinel of its cause
+var (
+	ErrInvalidFormat       = stderrors.New("httptools: invalid format")
+	ErrMalformedHeader     = stderrors.New("httptools: malformed header")
+	ErrInvalidMethod       = stderrors.New("httptools: invalid method")
+	ErrInvalidURL          = stderrors.New("httptools: invalid URL")
+	ErrInvalidVersion      = stderrors.New("httptools: invalid version")
+	ErrInvalidStatusCode   = stderrors.New("httptools: invalid status code")
+	ErrCompression         = stderrors.New("httptools: compression error")
+	ErrBodyTooLarge        = stderrors.New("httptools: body too large")
+	ErrMalformedChunk      = stderrors.New("httptools: malformed chunk")
+	ErrUnsupportedEncoding = stderrors.New("httptools: unsupported encoding")
 )
 
+// sentinels maps error types to their sentinel errors
+var sentinels = map[ErrorType]error{
+	ErrorTypeInvalidFormat:       ErrInvalidFormat,
+	ErrorTypeMalformedHeader:     ErrMalformedHeader,
+	ErrorTypeInvalidMethod:       ErrInvalidMethod,
+	ErrorTypeInvalidURL:          ErrInvalidURL,
+	ErrorTypeInvalidVersion:      ErrInvalidVersion,
+	ErrorTypeInvalidStatusCode:   ErrInvalidStatusCode,
+	ErrorTypeCompressionError:    ErrCompression,
+	ErrorTypeBodyTooLarge:        ErrBodyTooLarge,
+	ErrorTypeMalformedChunk:      ErrMalformedChunk,
+	ErrorTypeUnsupportedEncoding: ErrUnsupportedEncoding,
+}
+
 // Error represents a structured HTTP parsing error
 type Error struct {
 	Type    ErrorType
 	Message string
 	Context string
 	Raw     []byte
+
+	// Err is the underlying cause, if any
+	Err error
+
+	// Position of the offending byte in the parsed data, set by NewErrorAt and Locate
+	Offset  int    // Byte offset
+	Line    int    // 1-based line number (0 = position unknown)
+	Snippet string // Hex/ASCII dump of the surrounding bytes
 }
 
 func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("httptools: %s (context: %s) at line %d, offset %d: %s",
+			e.Message, e.Context, e.Line, e.Offset, e.Snippet)
+	}
 	return fmt.Sprintf("httptools: %s (context: %s)", e.Message, e.Context)
 }
 
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel error of e's type
+func (e *Error) Is(target error) bool {
+	return target != nil && sentinels[e.Type] == target
+}
+
 // NewError creates a new Error
 func NewError(errType ErrorType, message, context string, raw []byte) *Error {
 	return &Error{
@@ -37,8 +100,185 @@ func NewError(errType ErrorType, message, context string, raw []byte) *Error {
 	}
 }
 
+// WrapError creates a new Error with an underlying cause
+func WrapError(errType ErrorType, message, context string, raw []byte, err error) *Error {
+	e := NewError(errType, message, context, raw)
+	e.Err = err
+	return e
+}
+
+// NewErrorAt creates a new Error located at offset in data
+// Raw is set to data
+func NewErrorAt(errType ErrorType, message, context string, data []byte, offset int) *Error {
+	e := NewError(errType, message, context, data)
+	e.locate(data, offset)
+	return e
+}
+
+// Locate positions err in the full message data, for an *Error that a helper
+// located in the slice of data starting at start (a single line, or the final
+// response after interim ones); an *Error without a position is placed at start
+// Raw is set to data; other errors are returned unchanged
+func Locate(err error, data []byte, start int) error {
+	var e *Error
+	if stderrors.As(err, &e) {
+		e.Raw = data
+		e.locate(data, start+e.Offset)
+	}
+	return err
+}
+
+// SnippetRadius is the number of bytes shown on each side of an error offset
+const SnippetRadius = 8
+
+// locate sets the position fields for offset in data
+func (e *Error) locate(data []byte, offset int) {
+	offset = max(0, min(offset, len(data)))
+	e.Offset = offset
+	e.Line = bytes.Count(data[:offset], []byte("\n")) + 1
+	e.Snippet = snippet(data, offset)
+}
+
+// snippet returns a hex/ASCII dump of the bytes around offset
+// The offending byte is marked with brackets in the hex part:
+// "48 54 54 50 [0d] 0a |HTTP..|"
+func snippet(data []byte, offset int) string {
+	start := max(0, offset-SnippetRadius)
+	end := min(len(data), offset+SnippetRadius)
+
+	var hex, ascii strings.Builder
+	for i := start; i < end; i++ {
+		if i > start {
+			hex.WriteByte(' ')
+		}
+		if i == offset {
+			fmt.Fprintf(&hex, "[%02x]", data[i])
+		} else {
+			fmt.Fprintf(&hex, "%02x", data[i])
+		}
+		if data[i] >= 0x20 && data[i] < 0x7f {
+			ascii.WriteByte(data[i])
+		} else {
+			ascii.WriteByte('.')
+		}
+	}
+	if offset == len(data) {
+		if hex.Len() > 0 {
+			hex.WriteByte(' ')
+		}
+		hex.WriteString("[EOF]")
+	}
+	return hex.String() + " |" + ascii.String() + "|"
+}
+
 // IsParseError checks if an error is a parsing error
 func IsParseError(err error) bool {
-	_, ok := err.(*Error)
-	return ok
+	var e *Error
+	return stderrors.As(err, &e)
+}
+
+// IsBodyTooLarge checks if an error reports a body exceeding its size limit
+func IsBodyTooLarge(err error) bool {
+	return stderrors.Is(err, ErrBodyTooLarge)
+}
+
+// UnexpectedEOF converts io.EOF into io.ErrUnexpectedEOF, for readers that
+// hit the end of input in the middle of a message
+func UnexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// ============================================================================
+// Network Errors
+// ============================================================================
+
+// DNSError reports a failed host name lookup
+type DNSError struct {
+	Host string
+	Err  error
+}
+
+func (e *DNSError) Error() string {
+	return fmt.Sprintf("httptools: DNS lookup for %s failed: %v", e.Host, e.Err)
+}
+
+// Unwrap returns the underlying cause
+func (e *DNSError) Unwrap() error {
+	return e.Err
+}
+
+// TLSError reports a failed TLS handshake or certificate verification
+type TLSError struct {
+	Host string
+	Err  error
+}
+
+func (e *TLSError) Error() string {
+	return fmt.Sprintf("httptools: TLS handshake with %s failed: %v", e.Host, e.Err)
+}
+
+// Unwrap returns the underlying cause
+func (e *TLSError) Unwrap() error {
+	return e.Err
+}
+
+// ProxyError reports a proxy that could not be reached or refused the tunnel
+type ProxyError struct {
+	Proxy      string
+	StatusCode int // Status of the CONNECT response (0 if none was received)
+	Err        error
+}
+
+func (e *ProxyError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("httptools: proxy %s refused tunnel with status %d", e.Proxy, e.StatusCode)
+	}
+	return fmt.Sprintf("httptools: proxy %s failed: %v", e.Proxy, e.Err)
+}
+
+// Unwrap returns the underlying cause
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err is likely transient, so that sending the
+// same request again may succeed:
+//   - timeouts (net.Error, context.DeadlineExceeded)
+//   - temporary DNS failures
+//   - connections refused, reset or closed mid-exchange
+//   - proxies answering CONNECT with a 5xx status
+//
+// Causes are looked up through wrapping, so an *Error from ParseReader that
+// wraps a reset connection is retryable; parse errors without such a cause,
+// certificate errors, caller cancellation and proxies refusing with a non-5xx
+// status are permanent
+func IsRetryable(err error) bool {
+	if err == nil || stderrors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var proxyErr *ProxyError
+	if stderrors.As(err, &proxyErr) && proxyErr.StatusCode != 0 {
+		return proxyErr.StatusCode >= 500
+	}
+
+	var dnsErr *net.DNSError
+	if stderrors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+
+	var netErr net.Error
+	if stderrors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return stderrors.Is(err, io.EOF) ||
+		stderrors.Is(err, io.ErrUnexpectedEOF) ||
+		stderrors.Is(err, syscall.ECONNREFUSED) ||
+		stderrors.Is(err, syscall.ECONNRESET) ||
+		stderrors.Is(err, syscall.ECONNABORTED) ||
+		stderrors.Is(err, syscall.EPIPE)
 }
@@ -0,0 +1,142 @@
+package errors_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/compression"
+	httperrors "github.com/WhileEndless/go-httptools/pkg/errors"
+)
+
+func TestErrorIsSentinel(t *testing.T) {
+	err := httperrors.NewError(httperrors.ErrorTypeBodyTooLarge, "body exceeds limit", "body", nil)
+	wrapped := fmt.Errorf("reading response: %w", err)
+
+	if !errors.Is(wrapped, httperrors.ErrBodyTooLarge) {
+		t.Error("wrapped error should match ErrBodyTooLarge")
+	}
+	if errors.Is(wrapped, httperrors.ErrInvalidFormat) {
+		t.Error("error should not match the sentinel of another type")
+	}
+	if !httperrors.IsBodyTooLarge(wrapped) || !httperrors.IsParseError(wrapped) {
+		t.Error("helpers should see through wrapping")
+	}
+
+	var e *httperrors.Error
+	if !errors.As(wrapped, &e) || e.Context != "body" {
+		t.Errorf("errors.As = %v", e)
+	}
+}
+
+func TestWrapErrorCause(t *testing.T) {
+	err := httperrors.WrapError(httperrors.ErrorTypeInvalidFormat, "truncated", "head", nil, io.ErrUnexpectedEOF)
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.Is(err, httperrors.ErrInvalidFormat) {
+		t.Error("error should match both its cause and its type sentinel")
+	}
+
+	_, decompressErr := compression.Decompress([]byte("x"), compression.CompressionType(99))
+	if !errors.Is(decompressErr, httperrors.ErrUnsupportedEncoding) || !errors.Is(decompressErr, httperrors.ErrCompression) {
+		t.Errorf("unsupported compression error = %v", decompressErr)
+	}
+}
+
+func TestUnexpectedEOF(t *testing.T) {
+	cause := errors.New("reset")
+	if httperrors.UnexpectedEOF(io.EOF) != io.ErrUnexpectedEOF || httperrors.UnexpectedEOF(cause) != cause || httperrors.UnexpectedEOF(nil) != nil {
+		t.Error("only io.EOF should be converted")
+	}
+}
+
+func TestNetworkErrors(t *testing.T) {
+	cause := errors.New("connection refused")
+	var err error = fmt.Errorf("send: %w", &httperrors.ProxyError{Proxy: "127.0.0.1:8080", StatusCode: 407})
+
+	var proxyErr *httperrors.ProxyError
+	if !errors.As(err, &proxyErr) || proxyErr.StatusCode != 407 {
+		t.Errorf("errors.As ProxyError = %v", proxyErr)
+	}
+
+	err = &httperrors.TLSError{Host: "example.com", Err: cause}
+	var tlsErr *httperrors.TLSError
+	if !errors.As(err, &tlsErr) || !errors.Is(err, cause) {
+		t.Error("TLSError should unwrap to its cause")
+	}
+
+	err = &httperrors.DNSError{Host: "nx.example", Err: cause}
+	var dnsErr *httperrors.DNSError
+	if !errors.As(err, &dnsErr) || dnsErr.Host != "nx.example" {
+		t.Errorf("errors.As DNSError = %v", dnsErr)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"timeout", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, true},
+		{"deadline", context.DeadlineExceeded, true},
+		{"canceled", fmt.Errorf("send: %w", context.Canceled), false},
+		{"reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"refused", &httperrors.DNSError{Host: "a", Err: syscall.ECONNREFUSED}, true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"dns temporary", &net.DNSError{Err: "server misbehaving", IsTemporary: true}, true},
+		{"dns not found", &httperrors.DNSError{Host: "nx", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, false},
+		{"proxy 502", &httperrors.ProxyError{Proxy: "p", StatusCode: 502}, true},
+		{"proxy 407", &httperrors.ProxyError{Proxy: "p", StatusCode: 407, Err: io.EOF}, false},
+		{"proxy unreachable", &httperrors.ProxyError{Proxy: "p", Err: syscall.ECONNREFUSED}, true},
+		{"tls certificate", &httperrors.TLSError{Host: "a", Err: errors.New("x509: certificate has expired")}, false},
+		{"parse", httperrors.NewError(httperrors.ErrorTypeInvalidFormat, "bad", "parse", nil), false},
+		{"parse reset", httperrors.WrapError(httperrors.ErrorTypeInvalidFormat, "read failed", "parseReader", nil, syscall.ECONNRESET), true},
+		{"parse timeout", httperrors.WrapError(httperrors.ErrorTypeInvalidFormat, "read failed", "parseReader", nil,
+			&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}), true},
+		{"parse wrapping parse", httperrors.WrapError(httperrors.ErrorTypeInvalidFormat, "read failed", "parseReader", nil,
+			httperrors.NewError(httperrors.ErrorTypeMalformedChunk, "bad size", "chunked", nil)), false},
+	}
+	for _, tt := range tests {
+		if got := httperrors.IsRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: IsRetryable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestNewErrorAt(t *testing.T) {
+	data := []byte("HTTP/1.1 200 OK\r\nBad\x00Header: x\r\n\r\n")
+	err := httperrors.NewErrorAt(httperrors.ErrorTypeMalformedHeader, "NUL in header name", "test", data, 20)
+
+	if err.Offset != 20 || err.Line != 2 {
+		t.Errorf("Offset = %d, Line = %d", err.Offset, err.Line)
+	}
+	if want := "20 4f 4b 0d 0a 42 61 64 [00] 48 65 61 64 65 72 3a | OK..Bad.Header:|"; err.Snippet != want {
+		t.Errorf("Snippet = %q, want %q", err.Snippet, want)
+	}
+	if msg := err.Error(); msg != "httptools: NUL in header name (context: test) at line 2, offset 20: "+err.Snippet {
+		t.Errorf("Error() = %q", msg)
+	}
+
+	atEnd := httperrors.NewErrorAt(httperrors.ErrorTypeInvalidFormat, "truncated", "test", []byte("ab"), 2)
+	if atEnd.Snippet != "61 62 [EOF] |ab|" {
+		t.Errorf("EOF snippet = %q", atEnd.Snippet)
+	}
+
+	// Locate shifts a position found in a slice to the full data
+	line := httperrors.NewErrorAt(httperrors.ErrorTypeInvalidStatusCode, "bad code", "test", []byte("HTTP/1.1 abc OK"), 9)
+	full := []byte("HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 abc OK")
+	located := httperrors.Locate(line, full, 25).(*httperrors.Error)
+	if located.Offset != 34 || located.Line != 3 || string(located.Raw) != string(full) {
+		t.Errorf("Locate Offset = %d, Line = %d", located.Offset, located.Line)
+	}
+	plain := httperrors.NewError(httperrors.ErrorTypeInvalidFormat, "x", "test", nil)
+	httperrors.Locate(plain, []byte("a\nb"), 2)
+	if plain.Line != 2 {
+		t.Errorf("Locate Line = %d", plain.Line)
+	}
+}
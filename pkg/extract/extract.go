@@ -0,0 +1,425 @@
+// Package extract pulls links and forms out of HTML response bodies
+//
+// It is a lightweight, fault-tolerant tag scanner covering what crawlers need
+// most (anchors, forms, scripts, meta refresh), not a full HTML5 parser:
+//
+//	page := extract.HTML(resp.Body)
+//	page.Resolve(baseURL)
+//	for _, link := range page.Links {
+//		fmt.Println(link.URL, link.Text)
+//	}
+package extract
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// Link is a URL referenced from an HTML element
+type Link struct {
+	Tag  string // Element name ("a", "area", "iframe", "frame", "link", "img")
+	URL  string // Attribute value, entity-decoded
+	Text string // Anchor text with tags stripped and whitespace collapsed ("a" only)
+	Rel  string // rel attribute, if any
+}
+
+// Form is an HTML form with its fields
+type Form struct {
+	Action  string // action attribute (empty means the page URL)
+	Method  string // Uppercased method (defaults to GET)
+	Enctype string // enctype attribute, if any
+	Inputs  []Input
+}
+
+// Input is a form field (input, select, textarea or button)
+type Input struct {
+	Tag   string // Element name
+	Type  string // type attribute (lowercased), if any
+	Name  string
+	Value string // value attribute, or the content of a textarea
+}
+
+// Page holds everything extracted from an HTML document
+type Page struct {
+	Title       string   // Text of the first <title>, entity-decoded with whitespace collapsed
+	Base        string   // href of the <base> element, if any
+	Links       []Link   // Anchors and other URL-bearing elements, in document order
+	Forms       []Form   // Forms in document order
+	Scripts     []string // src of external scripts
+	MetaRefresh []string // Target URLs of <meta http-equiv="refresh">
+}
+
+// linkAttrs maps elements to the attribute holding their URL
+var linkAttrs = map[string]string{
+	"a":      "href",
+	"area":   "href",
+	"link":   "href",
+	"iframe": "src",
+	"frame":  "src",
+	"img":    "src",
+}
+
+// HTML extracts the title, links, forms, scripts and meta refresh targets from body
+// Never fails; malformed markup yields whatever could be recognized
+func HTML(body []byte) *Page {
+	page := &Page{}
+	s := scanner{data: body}
+	var form *Form
+	anchor := -1 // Index in page.Links of the open anchor
+	var anchorText strings.Builder
+	closeAnchor := func() {
+		if anchor != -1 {
+			page.Links[anchor].Text = collapseSpace(html.UnescapeString(anchorText.String()))
+			anchor = -1
+		}
+	}
+
+	for {
+		t, ok := s.next()
+		if !ok {
+			break
+		}
+
+		if t.text != nil {
+			if anchor != -1 {
+				anchorText.Write(t.text)
+			}
+			continue
+		}
+
+		if t.end {
+			switch t.name {
+			case "a":
+				closeAnchor()
+			case "form":
+				if form != nil {
+					page.Forms = append(page.Forms, *form)
+					form = nil
+				}
+			}
+			continue
+		}
+
+		switch t.name {
+		case "title":
+			text := s.rawText("title")
+			if page.Title == "" {
+				page.Title = collapseSpace(html.UnescapeString(string(text)))
+			}
+		case "base":
+			if href, ok := t.attrs["href"]; ok && page.Base == "" {
+				page.Base = href
+			}
+		case "script":
+			if src := t.attrs["src"]; src != "" {
+				page.Scripts = append(page.Scripts, src)
+			}
+		case "meta":
+			if strings.EqualFold(t.attrs["http-equiv"], "refresh") {
+				if target := refreshURL(t.attrs["content"]); target != "" {
+					page.MetaRefresh = append(page.MetaRefresh, target)
+				}
+			}
+		case "form":
+			if form != nil {
+				page.Forms = append(page.Forms, *form)
+			}
+			method := strings.ToUpper(strings.TrimSpace(t.attrs["method"]))
+			if method == "" {
+				method = "GET"
+			}
+			form = &Form{Action: t.attrs["action"], Method: method, Enctype: t.attrs["enctype"]}
+		case "input", "select", "textarea", "button":
+			if form == nil {
+				continue
+			}
+			input := Input{
+				Tag:   t.name,
+				Type:  strings.ToLower(t.attrs["type"]),
+				Name:  t.attrs["name"],
+				Value: t.attrs["value"],
+			}
+			if t.name == "textarea" {
+				input.Value = html.UnescapeString(string(s.rawText("textarea")))
+			}
+			form.Inputs = append(form.Inputs, input)
+		}
+
+		if attr, ok := linkAttrs[t.name]; ok {
+			target, present := t.attrs[attr]
+			if !present {
+				continue
+			}
+			page.Links = append(page.Links, Link{Tag: t.name, URL: target, Rel: t.attrs["rel"]})
+			if t.name == "a" && !t.selfClosing {
+				// An unclosed anchor is implicitly closed by the next one
+				closeAnchor()
+				anchor = len(page.Links) - 1
+				anchorText.Reset()
+			}
+		}
+	}
+
+	closeAnchor()
+	if form != nil {
+		page.Forms = append(page.Forms, *form)
+	}
+	return page
+}
+
+// Resolve makes all extracted URLs absolute against base
+// The document's <base href> is applied first when present
+// URLs that fail to parse are left unchanged
+func (p *Page) Resolve(base *url.URL) {
+	if base == nil {
+		return
+	}
+	if p.Base != "" {
+		if ref, err := url.Parse(strings.TrimSpace(p.Base)); err == nil {
+			base = base.ResolveReference(ref)
+		}
+	}
+
+	resolve := func(s string) string {
+		ref, err := url.Parse(strings.TrimSpace(s))
+		if err != nil {
+			return s
+		}
+		return base.ResolveReference(ref).String()
+	}
+
+	for i := range p.Links {
+		p.Links[i].URL = resolve(p.Links[i].URL)
+	}
+	for i := range p.Forms {
+		p.Forms[i].Action = resolve(p.Forms[i].Action)
+	}
+	for i := range p.Scripts {
+		p.Scripts[i] = resolve(p.Scripts[i])
+	}
+	for i := range p.MetaRefresh {
+		p.MetaRefresh[i] = resolve(p.MetaRefresh[i])
+	}
+}
+
+// URLs returns every extracted URL (links, form actions, scripts, refresh targets)
+func (p *Page) URLs() []string {
+	var urls []string
+	for _, link := range p.Links {
+		urls = append(urls, link.URL)
+	}
+	for _, form := range p.Forms {
+		urls = append(urls, form.Action)
+	}
+	urls = append(urls, p.Scripts...)
+	return append(urls, p.MetaRefresh...)
+}
+
+// refreshURL extracts the target from a meta refresh content value ("5; url=/next")
+func refreshURL(content string) string {
+	_, rest, found := strings.Cut(content, ";")
+	if !found {
+		_, rest, found = strings.Cut(content, ",")
+		if !found {
+			return ""
+		}
+	}
+	rest = strings.TrimSpace(rest)
+	if len(rest) >= 4 && strings.EqualFold(rest[:3], "url") {
+		if _, value, ok := strings.Cut(rest, "="); ok {
+			rest = strings.TrimSpace(value)
+		}
+	}
+	return strings.Trim(rest, `"'`)
+}
+
+// collapseSpace trims s and replaces runs of whitespace with a single space
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// token is a start tag, end tag or text run
+type token struct {
+	name        string            // Lowercased tag name
+	attrs       map[string]string // Lowercased names, entity-decoded values
+	end         bool              // End tag
+	selfClosing bool              // Start tag ending with "/>"
+	text        []byte            // Text content (set for text tokens only)
+}
+
+// scanner splits HTML into tokens
+type scanner struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next token, or false at the end of the data
+func (s *scanner) next() (token, bool) {
+	for s.pos < len(s.data) {
+		lt := bytes.IndexByte(s.data[s.pos:], '<')
+		if lt == -1 {
+			text := s.data[s.pos:]
+			s.pos = len(s.data)
+			return token{text: text}, true
+		}
+		if lt > 0 {
+			text := s.data[s.pos : s.pos+lt]
+			s.pos += lt
+			return token{text: text}, true
+		}
+
+		rest := s.data[s.pos:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			s.skipPast("-->")
+			continue
+		case bytes.HasPrefix(rest, []byte("<!")), bytes.HasPrefix(rest, []byte("<?")):
+			s.skipPast(">")
+			continue
+		}
+
+		t, ok := s.tag()
+		if !ok {
+			// Not a tag: treat "<" as text
+			s.pos++
+			return token{text: rest[:1]}, true
+		}
+
+		// Script and style content is raw text, never markup
+		if !t.end && !t.selfClosing && (t.name == "script" || t.name == "style") {
+			s.rawText(t.name)
+		}
+		return t, true
+	}
+	return token{}, false
+}
+
+// tag parses the tag at s.pos
+func (s *scanner) tag() (token, bool) {
+	i := s.pos + 1
+	t := token{}
+	if i < len(s.data) && s.data[i] == '/' {
+		t.end = true
+		i++
+	}
+
+	start := i
+	for i < len(s.data) && isNameByte(s.data[i]) {
+		i++
+	}
+	if i == start || !isLetter(s.data[start]) {
+		return token{}, false
+	}
+	t.name = strings.ToLower(string(s.data[start:i]))
+	t.attrs = make(map[string]string)
+
+	for i < len(s.data) {
+		// Skip whitespace and stray slashes
+		for i < len(s.data) && (isSpace(s.data[i]) || s.data[i] == '/') {
+			if s.data[i] == '/' && i+1 < len(s.data) && s.data[i+1] == '>' {
+				t.selfClosing = true
+			}
+			i++
+		}
+		if i >= len(s.data) {
+			break
+		}
+		if s.data[i] == '>' {
+			i++
+			break
+		}
+
+		// Attribute name
+		nameStart := i
+		for i < len(s.data) && !isSpace(s.data[i]) && s.data[i] != '=' && s.data[i] != '>' && s.data[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(string(s.data[nameStart:i]))
+
+		for i < len(s.data) && isSpace(s.data[i]) {
+			i++
+		}
+		value := ""
+		if i < len(s.data) && s.data[i] == '=' {
+			i++
+			for i < len(s.data) && isSpace(s.data[i]) {
+				i++
+			}
+			if i < len(s.data) && (s.data[i] == '"' || s.data[i] == '\'') {
+				quote := s.data[i]
+				i++
+				valueStart := i
+				end := bytes.IndexByte(s.data[i:], quote)
+				if end == -1 {
+					end = len(s.data) - i
+				}
+				i += end
+				value = string(s.data[valueStart:i])
+				if i < len(s.data) {
+					i++
+				}
+			} else {
+				valueStart := i
+				for i < len(s.data) && !isSpace(s.data[i]) && s.data[i] != '>' {
+					i++
+				}
+				value = string(s.data[valueStart:i])
+			}
+		}
+
+		// First occurrence wins, like browsers
+		if _, exists := t.attrs[name]; !exists && name != "" {
+			t.attrs[name] = html.UnescapeString(value)
+		}
+	}
+
+	s.pos = i
+	return t, true
+}
+
+// rawText consumes content up to the closing tag of name and returns it
+func (s *scanner) rawText(name string) []byte {
+	closing := []byte("</" + name)
+	rest := s.data[s.pos:]
+	idx := indexFold(rest, closing)
+	if idx == -1 {
+		s.pos = len(s.data)
+		return rest
+	}
+	s.pos += idx
+	return rest[:idx]
+}
+
+// skipPast moves s.pos just past the next occurrence of marker (or to the end)
+func (s *scanner) skipPast(marker string) {
+	idx := bytes.Index(s.data[s.pos:], []byte(marker))
+	if idx == -1 {
+		s.pos = len(s.data)
+		return
+	}
+	s.pos += idx + len(marker)
+}
+
+// indexFold returns the index of the first case-insensitive match of sep in data
+func indexFold(data, sep []byte) int {
+	for i := 0; i+len(sep) <= len(data); i++ {
+		if bytes.EqualFold(data[i:i+len(sep)], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameByte(c byte) bool {
+	return isLetter(c) || (c >= '0' && c <= '9') || c == '-' || c == ':'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
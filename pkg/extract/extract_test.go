@@ -0,0 +1,109 @@
+package extract
+
+import (
+	"net/url"
+	"testing"
+)
+
+const testPage = `<!DOCTYPE html>
+<html><head>
+<title> Sign in &amp;
+  continue </title>
+<base href="/app/">
+<meta http-equiv="Refresh" content="5; URL='/next?a=1&amp;b=2'">
+<script src="/static/app.js"></script>
+<script>var s = "<a href='/not-a-link'>";</script>
+<!-- <a href="/commented">hidden</a> -->
+</head><body>
+<a href="page?id=1&amp;x=2" rel=nofollow>First <b>link</b>
+</a>
+<A HREF='https://other.example/'>Other</A>
+<img src=logo.png>
+<form action="/login" method="post">
+  <input type="hidden" name="csrf" value="tok&lt;en">
+  <input type=text name=user>
+  <textarea name="bio">Hello &amp; bye</textarea>
+  <select name="role"><option value="a">A</option></select>
+  <button type="submit" name="go" value="1">Go</button>
+</form>
+<form action="search"><input name="q"></form>
+</body></html>`
+
+func TestHTML(t *testing.T) {
+	page := HTML([]byte(testPage))
+
+	if page.Title != "Sign in & continue" {
+		t.Errorf("Expected title 'Sign in & continue', got %q", page.Title)
+	}
+	if page.Base != "/app/" {
+		t.Errorf("Expected base '/app/', got %q", page.Base)
+	}
+	if len(page.Scripts) != 1 || page.Scripts[0] != "/static/app.js" {
+		t.Errorf("Unexpected scripts %q", page.Scripts)
+	}
+	if len(page.MetaRefresh) != 1 || page.MetaRefresh[0] != "/next?a=1&b=2" {
+		t.Errorf("Unexpected meta refresh %q", page.MetaRefresh)
+	}
+
+	if len(page.Links) != 3 {
+		t.Fatalf("Expected 3 links, got %+v", page.Links)
+	}
+	first := page.Links[0]
+	if first.URL != "page?id=1&x=2" || first.Text != "First link" || first.Rel != "nofollow" {
+		t.Errorf("Unexpected first link %+v", first)
+	}
+	if page.Links[1].URL != "https://other.example/" || page.Links[1].Text != "Other" {
+		t.Errorf("Unexpected second link %+v", page.Links[1])
+	}
+	if page.Links[2].Tag != "img" || page.Links[2].URL != "logo.png" {
+		t.Errorf("Unexpected third link %+v", page.Links[2])
+	}
+
+	if len(page.Forms) != 2 {
+		t.Fatalf("Expected 2 forms, got %d", len(page.Forms))
+	}
+	login := page.Forms[0]
+	if login.Action != "/login" || login.Method != "POST" || len(login.Inputs) != 5 {
+		t.Fatalf("Unexpected login form %+v", login)
+	}
+	if login.Inputs[0].Type != "hidden" || login.Inputs[0].Value != "tok<en" {
+		t.Errorf("Unexpected hidden input %+v", login.Inputs[0])
+	}
+	if login.Inputs[2].Tag != "textarea" || login.Inputs[2].Value != "Hello & bye" {
+		t.Errorf("Unexpected textarea %+v", login.Inputs[2])
+	}
+	if page.Forms[1].Method != "GET" || page.Forms[1].Inputs[0].Name != "q" {
+		t.Errorf("Unexpected search form %+v", page.Forms[1])
+	}
+}
+
+func TestPage_Resolve(t *testing.T) {
+	page := HTML([]byte(testPage))
+	base, _ := url.Parse("https://example.com/index.html")
+	page.Resolve(base)
+
+	if page.Links[0].URL != "https://example.com/app/page?id=1&x=2" {
+		t.Errorf("Unexpected resolved link %q", page.Links[0].URL)
+	}
+	if page.Forms[1].Action != "https://example.com/app/search" {
+		t.Errorf("Unexpected resolved action %q", page.Forms[1].Action)
+	}
+	if page.Scripts[0] != "https://example.com/static/app.js" {
+		t.Errorf("Unexpected resolved script %q", page.Scripts[0])
+	}
+	if len(page.URLs()) != 7 {
+		t.Errorf("Expected 7 URLs, got %d", len(page.URLs()))
+	}
+}
+
+func TestHTML_Malformed(t *testing.T) {
+	inputs := []string{"", "<", "<a href=", "<a href='x", "<<<>>>", "<form><input name=a", "<!--", "< a>"}
+	for _, input := range inputs {
+		HTML([]byte(input)) // Must not panic
+	}
+
+	page := HTML([]byte("<a href=/one>one<a href=/two>two"))
+	if len(page.Links) != 2 || page.Links[0].Text != "one" || page.Links[1].Text != "two" {
+		t.Errorf("Expected unclosed anchors to be split, got %+v", page.Links)
+	}
+}
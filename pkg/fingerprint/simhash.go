@@ -0,0 +1,122 @@
+// Package fingerprint computes fuzzy fingerprints of response bodies
+//
+// SimHash fingerprints of near-identical bodies (differing in a timestamp, a
+// CSRF token or a reflected value) are only a few bits apart, so responses can
+// be grouped even when their lengths differ slightly:
+//
+//	a := fingerprint.SimHash(resp1.Body)
+//	b := fingerprint.SimHash(resp2.Body)
+//	if fingerprint.Distance(a, b) <= fingerprint.DefaultThreshold {
+//		// same page
+//	}
+package fingerprint
+
+import "math/bits"
+
+// DefaultThreshold is the Hamming distance up to which two SimHashes are
+// considered near-duplicates
+const DefaultThreshold = 3
+
+// SimHash returns a 64-bit SimHash of data
+// Features are word bigrams (runs of letters and digits), so reordering of
+// small parts or single changed tokens only flips a few bits
+// Empty input (or input without words) hashes to 0
+func SimHash(data []byte) uint64 {
+	var weights [64]int
+	var prev []byte
+	features := 0
+
+	add := func(feature []byte) {
+		sum := fnv64a(feature)
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+		features++
+	}
+
+	bigram := make([]byte, 0, 64)
+	for i := 0; i < len(data); {
+		// Skip separators
+		for i < len(data) && !isWordByte(data[i]) {
+			i++
+		}
+		start := i
+		for i < len(data) && isWordByte(data[i]) {
+			i++
+		}
+		if start == i {
+			break
+		}
+		word := data[start:i]
+
+		if prev == nil {
+			add(word)
+		} else {
+			bigram = append(append(append(bigram[:0], prev...), ' '), word...)
+			add(bigram)
+		}
+		prev = word
+	}
+
+	if features == 0 {
+		return 0
+	}
+
+	var hash uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash
+}
+
+// Distance returns the Hamming distance between two SimHashes (0-64)
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Similarity returns the fraction of equal bits between two SimHashes (0.0-1.0)
+func Similarity(a, b uint64) float64 {
+	return 1 - float64(Distance(a, b))/64
+}
+
+// Group clusters hashes whose distance to the first member of a group is at
+// most threshold, returning groups of indexes into hashes in input order
+func Group(hashes []uint64, threshold int) [][]int {
+	var groups [][]int
+	for i, h := range hashes {
+		placed := false
+		for g := range groups {
+			if Distance(hashes[groups[g][0]], h) <= threshold {
+				groups[g] = append(groups[g], i)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			groups = append(groups, []int{i})
+		}
+	}
+	return groups
+}
+
+// fnv64a returns the 64-bit FNV-1a hash of b
+func fnv64a(b []byte) uint64 {
+	hash := uint64(14695981039346656037)
+	for _, c := range b {
+		hash ^= uint64(c)
+		hash *= 1099511628211
+	}
+	return hash
+}
+
+// isWordByte reports whether c is part of a word (ASCII letters, digits,
+// and any non-ASCII byte so UTF-8 text forms words too)
+func isWordByte(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
@@ -0,0 +1,69 @@
+package fingerprint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func samplePage(token string, items int) []byte {
+	var b strings.Builder
+	b.WriteString("<html><head><title>Account overview</title></head><body>")
+	fmt.Fprintf(&b, `<input type="hidden" name="csrf" value="%s">`, token)
+	for i := 0; i < items; i++ {
+		fmt.Fprintf(&b, "<li>Order number %d shipped to the customer address on time</li>", i)
+	}
+	b.WriteString("</body></html>")
+	return []byte(b.String())
+}
+
+func TestSimHash_NearDuplicates(t *testing.T) {
+	a := SimHash(samplePage("a1b2c3d4", 40))
+	b := SimHash(samplePage("zz99yy88", 40))
+	c := SimHash(samplePage("a1b2c3d4", 41))
+
+	if d := Distance(a, b); d > DefaultThreshold {
+		t.Errorf("Expected pages differing in a token to be near-duplicates, distance %d", d)
+	}
+	if d := Distance(a, c); d > DefaultThreshold {
+		t.Errorf("Expected pages differing in one item to be near-duplicates, distance %d", d)
+	}
+
+	other := SimHash([]byte("<html><body><h1>404 Not Found</h1><p>The requested URL was not found on this server.</p></body></html>"))
+	if d := Distance(a, other); d <= DefaultThreshold {
+		t.Errorf("Expected different pages to be far apart, distance %d", d)
+	}
+}
+
+func TestSimHash_Basics(t *testing.T) {
+	if SimHash(nil) != 0 || SimHash([]byte(" <> ")) != 0 {
+		t.Error("Expected 0 for input without words")
+	}
+	body := samplePage("x", 5)
+	if SimHash(body) != SimHash(body) {
+		t.Error("Expected deterministic hash")
+	}
+	if Similarity(0, 0) != 1 || Similarity(0, ^uint64(0)) != 0 {
+		t.Error("Unexpected Similarity bounds")
+	}
+}
+
+func TestGroup(t *testing.T) {
+	hashes := []uint64{0b0000, 0b0001, 0xFFFF0000, 0b0011, 0xFFFF0001}
+	groups := Group(hashes, 2)
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %v", groups)
+	}
+	if fmt.Sprint(groups[0]) != "[0 1 3]" || fmt.Sprint(groups[1]) != "[2 4]" {
+		t.Errorf("Unexpected groups %v", groups)
+	}
+}
+
+func BenchmarkSimHash(b *testing.B) {
+	body := samplePage("token", 500)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		SimHash(body)
+	}
+}
@@ -0,0 +1,120 @@
+// Package framing finds HTTP/1.x message boundaries in raw byte streams
+//
+// It determines where a message ends from its head and body framing
+// (Content-Length, chunked, or connection close) without parsing it fully,
+// and provides a push-style Parser for data arriving in arbitrary segments
+package framing
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+)
+
+// Kind selects whether messages are requests or responses
+type Kind int
+
+const (
+	KindRequest Kind = iota
+	KindResponse
+)
+
+// State describes the result of scanning for a message boundary
+type State int
+
+const (
+	// StateComplete means the message is complete
+	StateComplete State = iota
+
+	// StateNeedMore means the head or framed body is incomplete
+	StateNeedMore
+
+	// StateUntilClose means the body is delimited by connection close
+	// (response without Content-Length or chunked encoding)
+	StateUntilClose
+)
+
+// Scan determines the length of the message at the start of data
+// Returns the message length and StateComplete, or StateNeedMore/StateUntilClose
+// with len(data) when the end of the message is not in data
+func Scan(data []byte, kind Kind) (int, State) {
+	return scan(data, kind, "")
+}
+
+// ScanResponse is like Scan for a response to a request with the given method
+// Responses to HEAD requests end at the head despite Content-Length
+func ScanResponse(data []byte, requestMethod string) (int, State) {
+	return scan(data, KindResponse, requestMethod)
+}
+
+// scan implements Scan and ScanResponse
+func scan(data []byte, kind Kind, requestMethod string) (int, State) {
+	headEnd := HeadEnd(data)
+	if headEnd == -1 {
+		return len(data), StateNeedMore
+	}
+
+	lineEnd := bytes.IndexByte(data, '\n')
+	body := data[headEnd:]
+
+	if kind == KindResponse {
+		statusCode := 0
+		if fields := strings.Fields(string(data[:lineEnd])); len(fields) >= 2 {
+			statusCode, _ = strconv.Atoi(fields[1])
+		}
+		if !headers.ResponseHasBody(statusCode, requestMethod) {
+			return headEnd, StateComplete
+		}
+	}
+
+	parsedHeaders, _ := headers.ParseHeaders(data[lineEnd+1 : headEnd])
+	if parsedHeaders == nil {
+		parsedHeaders = headers.NewOrderedHeaders()
+	}
+	framing := headers.GetFraming(parsedHeaders)
+
+	switch {
+	case framing.Chunked:
+		if n := chunked.ScanRaw(body); n >= 0 {
+			return headEnd + n, StateComplete
+		}
+		return len(data), StateNeedMore
+	case framing.ContentLength >= 0:
+		if framing.ContentLength > int64(len(body)) {
+			return len(data), StateNeedMore
+		}
+		return headEnd + int(framing.ContentLength), StateComplete
+	case kind == KindResponse:
+		return len(data), StateUntilClose
+	default:
+		// Requests without framing have no body
+		return headEnd, StateComplete
+	}
+}
+
+// HeadEnd returns the index just past the first empty line (end of the head), or -1
+func HeadEnd(data []byte) int {
+	crlf := bytes.Index(data, []byte("\r\n\r\n"))
+	lf := bytes.Index(data, []byte("\n\n"))
+
+	switch {
+	case crlf == -1 && lf == -1:
+		return -1
+	case lf == -1 || (crlf != -1 && crlf < lf):
+		return crlf + 4
+	default:
+		return lf + 2
+	}
+}
+
+// skipBlankLines returns the number of leading CR/LF bytes in data
+func skipBlankLines(data []byte) int {
+	n := 0
+	for n < len(data) && (data[n] == '\r' || data[n] == '\n') {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,98 @@
+package framing
+
+import "errors"
+
+// ErrParserClosed is returned by Feed after Close
+var ErrParserClosed = errors.New("framing: parser closed")
+
+// Parser is a push-style message splitter for data arriving in arbitrary segments
+// Feed it bytes as they arrive; onMessage is called as soon as a message's
+// framing completes. Suitable for event-loop proxies and TCP reassembly
+//
+//	p := framing.NewParser(framing.KindResponse, func(raw []byte) error {
+//		resp, err := response.Parse(raw)
+//		...
+//	})
+//	for segment := range segments {
+//		if err := p.Feed(segment); err != nil { ... }
+//	}
+//	p.Close()
+type Parser struct {
+	kind      Kind
+	onMessage func(raw []byte) error
+	buf       []byte
+	closed    bool
+}
+
+// NewParser creates a Parser that calls onMessage for every complete message
+// The raw slice passed to onMessage is only valid during the call; copy it to retain it
+// (request.Parse and response.Parse already copy their input)
+// An error returned by onMessage stops processing and is returned from Feed
+func NewParser(kind Kind, onMessage func(raw []byte) error) *Parser {
+	return &Parser{
+		kind:      kind,
+		onMessage: onMessage,
+	}
+}
+
+// Feed appends data and emits every message completed by it
+func (p *Parser) Feed(data []byte) error {
+	if p.closed {
+		return ErrParserClosed
+	}
+	p.buf = append(p.buf, data...)
+	return p.emit()
+}
+
+// Buffered returns the number of bytes waiting for a message to complete
+func (p *Parser) Buffered() int {
+	return len(p.buf)
+}
+
+// Close signals end of stream
+// A pending response delimited by connection close is emitted, as is a
+// truncated message, so captures cut mid-message are not lost
+func (p *Parser) Close() error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+
+	p.buf = p.buf[skipBlankLines(p.buf):]
+	if len(p.buf) == 0 {
+		return nil
+	}
+	raw := p.buf
+	p.buf = nil
+	return p.onMessage(raw)
+}
+
+// emit calls onMessage for each complete message at the front of the buffer
+func (p *Parser) emit() error {
+	start := 0
+	defer func() {
+		// Compact the buffer, keeping only the incomplete tail
+		if start > 0 {
+			n := copy(p.buf, p.buf[start:])
+			p.buf = p.buf[:n]
+		}
+	}()
+
+	for {
+		start += skipBlankLines(p.buf[start:])
+		if start >= len(p.buf) {
+			return nil
+		}
+
+		n, state := Scan(p.buf[start:], p.kind)
+		if state != StateComplete {
+			return nil
+		}
+
+		if err := p.onMessage(p.buf[start : start+n]); err != nil {
+			start += n
+			return err
+		}
+		start += n
+	}
+}
@@ -0,0 +1,118 @@
+package framing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		kind  Kind
+		n     int
+		state State
+	}{
+		{"content-length", "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nokEXTRA", KindResponse, 40, StateComplete},
+		{"partial body", "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nok", KindResponse, 40, StateNeedMore},
+		{"partial head", "HTTP/1.1 200 OK\r\nContent-Le", KindResponse, 27, StateNeedMore},
+		{"until close", "HTTP/1.1 200 OK\r\n\r\nbody", KindResponse, 23, StateUntilClose},
+		{"no body status", "HTTP/1.1 304 Not Modified\r\nContent-Length: 10\r\n\r\n", KindResponse, 49, StateComplete},
+		{"request without body", "GET / HTTP/1.1\r\nHost: x\r\n\r\nGET", KindRequest, 27, StateComplete},
+		{"chunked", "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n1\r\na\r\n0\r\n\r\nX", KindRequest, 58, StateComplete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, state := Scan([]byte(tt.data), tt.kind)
+			if n != tt.n || state != tt.state {
+				t.Errorf("Expected (%d, %d), got (%d, %d)", tt.n, tt.state, n, state)
+			}
+		})
+	}
+}
+
+func TestScanResponse_HEAD(t *testing.T) {
+	data := []byte("HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\nHTTP/1.1 200 OK\r\n")
+
+	if n, state := ScanResponse(data, "HEAD"); n != 40 || state != StateComplete {
+		t.Errorf("Expected (40, complete) for HEAD response, got (%d, %d)", n, state)
+	}
+	if _, state := ScanResponse(data, "GET"); state != StateNeedMore {
+		t.Errorf("Expected need-more for GET response, got %d", state)
+	}
+}
+
+func TestParser_ByteByByte(t *testing.T) {
+	stream := "GET /a HTTP/1.1\r\nHost: x\r\n\r\n" +
+		"POST /b HTTP/1.1\r\nContent-Length: 3\r\n\r\nabc" +
+		"\r\n" +
+		"POST /c HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nxyz\r\n0\r\n\r\n"
+
+	var messages []string
+	p := NewParser(KindRequest, func(raw []byte) error {
+		messages = append(messages, string(raw))
+		return nil
+	})
+
+	for i := 0; i < len(stream); i++ {
+		if err := p.Feed([]byte{stream[i]}); err != nil {
+			t.Fatalf("Feed failed: %v", err)
+		}
+	}
+
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d: %q", len(messages), messages)
+	}
+	if messages[1] != "POST /b HTTP/1.1\r\nContent-Length: 3\r\n\r\nabc" {
+		t.Errorf("Unexpected second message: %q", messages[1])
+	}
+	if p.Buffered() != 0 {
+		t.Errorf("Expected empty buffer, got %d bytes", p.Buffered())
+	}
+}
+
+func TestParser_CloseFlushesUntilCloseResponse(t *testing.T) {
+	var messages []string
+	p := NewParser(KindResponse, func(raw []byte) error {
+		messages = append(messages, string(raw))
+		return nil
+	})
+
+	p.Feed([]byte("HTTP/1.1 200 OK\r\n\r\nbody until "))
+	p.Feed([]byte("close"))
+	if len(messages) != 0 {
+		t.Fatalf("Response delimited by close should wait for Close, got %q", messages)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if len(messages) != 1 || messages[0] != "HTTP/1.1 200 OK\r\n\r\nbody until close" {
+		t.Errorf("Unexpected messages: %q", messages)
+	}
+
+	if err := p.Feed([]byte("x")); err != ErrParserClosed {
+		t.Errorf("Expected ErrParserClosed, got %v", err)
+	}
+}
+
+func TestParser_CallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	p := NewParser(KindRequest, func(raw []byte) error {
+		calls++
+		return stop
+	})
+
+	err := p.Feed([]byte("GET /a HTTP/1.1\r\n\r\nGET /b HTTP/1.1\r\n\r\n"))
+	if err != stop {
+		t.Errorf("Expected callback error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected processing to stop after first message, got %d calls", calls)
+	}
+	if p.Buffered() != len("GET /b HTTP/1.1\r\n\r\n") {
+		t.Errorf("Remaining message should stay buffered, got %d bytes", p.Buffered())
+	}
+}
@@ -0,0 +1,461 @@
+// Package har converts exchanges to and from HAR 1.2 (HTTP Archive) entries
+//
+// Exported captures open in browser devtools and analysis tools; imported
+// entries become parsed requests and responses that can be edited and replayed:
+//
+//	archive := har.New()
+//	archive.Add(har.ToHAR(req, resp, har.Timing{Started: start, Wait: ttfb}))
+//	data, _ := json.Marshal(archive)
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/clock"
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+	"github.com/WhileEndless/go-httptools/pkg/version"
+)
+
+// HAR is the root object of a HAR file
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log holds the entries of an archive
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator names the application that wrote the archive
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is one exchange
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"` // Total time in milliseconds
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         Timings  `json:"timings"`
+}
+
+// Request is a HAR request
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Response is a HAR response
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// NameValue is a header or query parameter
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Cookie is a request or response cookie
+type Cookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// PostData is a request body
+type PostData struct {
+	MimeType string      `json:"mimeType"`
+	Text     string      `json:"text"`
+	Params   []NameValue `json:"params,omitempty"`
+	Encoding string      `json:"encoding,omitempty"` // "base64" for binary bodies, as in Content
+}
+
+// Content is a decoded response body
+type Content struct {
+	Size        int    `json:"size"`
+	Compression int    `json:"compression,omitempty"`
+	MimeType    string `json:"mimeType"`
+	Text        string `json:"text,omitempty"`
+	Encoding    string `json:"encoding,omitempty"` // "base64" for binary bodies
+}
+
+// Timings are the phases of an exchange in milliseconds
+// The optional phases use -1 when not measured; Send, Wait and Receive are
+// required by HAR 1.2 and use 0
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Timing is the measured timing of an exchange
+// Zero phases are exported as not measured
+type Timing struct {
+	Started time.Time   // Zero = the current time on Clock
+	Clock   clock.Clock // nil = clock.Real
+	DNS     time.Duration
+	Connect time.Duration // Includes TLS, as in HAR
+	TLS     time.Duration
+	Send    time.Duration
+	Wait    time.Duration // Time to first byte
+	Receive time.Duration
+}
+
+// New creates an empty HAR 1.2 archive
+func New() *HAR {
+	return &HAR{Log: Log{
+		Version: "1.2",
+		Creator: Creator{Name: "go-httptools", Version: version.Version},
+		Entries: []Entry{},
+	}}
+}
+
+// Parse decodes a HAR file
+func Parse(data []byte) (*HAR, error) {
+	var h HAR
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("har: %w", err)
+	}
+	return &h, nil
+}
+
+// Add appends entries to the archive
+func (h *HAR) Add(entries ...Entry) {
+	h.Log.Entries = append(h.Log.Entries, entries...)
+}
+
+// ToHAR converts an exchange to a HAR entry
+// Origin-form request targets are made absolute with the Host header, using
+// https when the host has port 443; resp may be nil for a request without response
+func ToHAR(req *request.Request, resp *response.Response, t Timing) Entry {
+	if t.Started.IsZero() {
+		t.Started = clock.Or(t.Clock).Now()
+	}
+	e := Entry{
+		StartedDateTime: t.Started.Format(time.RFC3339Nano),
+		Request:         toRequest(req),
+		Timings: Timings{
+			Blocked: -1,
+			DNS:     millis(t.DNS, -1),
+			Connect: millis(t.Connect, -1),
+			SSL:     millis(t.TLS, -1),
+			Send:    millis(t.Send, 0),
+			Wait:    millis(t.Wait, 0),
+			Receive: millis(t.Receive, 0),
+		},
+	}
+	if resp != nil {
+		e.Response = toResponse(resp)
+	}
+	for _, phase := range []float64{e.Timings.DNS, e.Timings.Connect, e.Timings.Send, e.Timings.Wait, e.Timings.Receive} {
+		if phase > 0 {
+			e.Time += phase
+		}
+	}
+	return e
+}
+
+// millis converts a phase duration, with unmeasured for zero phases
+func millis(d time.Duration, unmeasured float64) float64 {
+	if d <= 0 {
+		return unmeasured
+	}
+	return float64(d) / float64(time.Millisecond)
+}
+
+func toRequest(req *request.Request) Request {
+	r := Request{
+		Method:      req.Method,
+		URL:         absoluteURL(req),
+		HTTPVersion: req.Version,
+		Cookies:     []Cookie{},
+		Headers:     nameValues(req.Headers.All()),
+		QueryString: []NameValue{},
+		HeadersSize: headSize(req.Raw),
+		BodySize:    len(req.Body),
+	}
+	if len(req.RawBody) > 0 {
+		r.BodySize = len(req.RawBody)
+	}
+	for _, c := range req.Cookies {
+		r.Cookies = append(r.Cookies, Cookie{Name: c.Name, Value: c.Value})
+	}
+
+	// Query parameters in their original order
+	if _, query, ok := strings.Cut(req.URL, "?"); ok {
+		query, _, _ = strings.Cut(query, "#")
+		for _, pair := range strings.Split(query, "&") {
+			if pair == "" {
+				continue
+			}
+			name, value, _ := strings.Cut(pair, "=")
+			r.QueryString = append(r.QueryString, NameValue{Name: unescape(name), Value: unescape(value)})
+		}
+	}
+
+	if body := dechunk(req.Body, req.IsBodyChunked); len(body) > 0 {
+		r.PostData = &PostData{MimeType: req.GetContentType()}
+		r.PostData.Text, r.PostData.Encoding = text(body)
+		if req.IsFormURLEncoded() {
+			for _, pair := range strings.Split(string(body), "&") {
+				if pair == "" {
+					continue
+				}
+				name, value, _ := strings.Cut(pair, "=")
+				r.PostData.Params = append(r.PostData.Params, NameValue{Name: unescape(name), Value: unescape(value)})
+			}
+		}
+	}
+	return r
+}
+
+func toResponse(resp *response.Response) Response {
+	body := dechunk(resp.Body, resp.IsBodyChunked)
+	r := Response{
+		Status:      resp.StatusCode,
+		StatusText:  resp.StatusText,
+		HTTPVersion: resp.Version,
+		Cookies:     []Cookie{},
+		Headers:     nameValues(resp.Headers.All()),
+		Content: Content{
+			Size:     len(body),
+			MimeType: strings.TrimSpace(resp.Headers.Get("Content-Type")),
+		},
+		RedirectURL: strings.TrimSpace(resp.Headers.Get("Location")),
+		HeadersSize: headSize(finalRaw(resp)),
+		BodySize:    len(resp.Body),
+	}
+	if len(resp.RawBody) > 0 {
+		r.BodySize = len(resp.RawBody)
+		// Bytes saved by the content coding; RawBody of a compressed response
+		// holds the transfer-decoded content, otherwise it may still be chunked
+		if resp.Compressed {
+			r.Content.Compression = len(resp.Body) - len(resp.RawBody)
+		}
+	}
+	r.Content.Text, r.Content.Encoding = text(body)
+
+	for _, c := range resp.SetCookies {
+		cookie := Cookie{Name: c.Name, Value: c.Value, Path: c.Path, Domain: c.Domain, HTTPOnly: c.HttpOnly, Secure: c.Secure}
+		if expires, err := http.ParseTime(c.Expires); err == nil {
+			cookie.Expires = expires.UTC().Format(time.RFC3339)
+		}
+		r.Cookies = append(r.Cookies, cookie)
+	}
+	return r
+}
+
+// dechunk removes the chunk framing of a body that is still chunked
+// FromHAR drops Transfer-Encoding, so HAR bodies must hold the content only
+func dechunk(body []byte, isChunked bool) []byte {
+	if !isChunked {
+		return body
+	}
+	decoded, _ := chunked.Decode(body)
+	return decoded
+}
+
+// text returns body as HAR text, base64-encoded when it is not valid UTF-8
+func text(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// decodeText reverses text
+func decodeText(text, encoding string) ([]byte, error) {
+	if encoding != "base64" {
+		return []byte(text), nil
+	}
+	body, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("har: invalid base64 content: %w", err)
+	}
+	return body, nil
+}
+
+// absoluteURL returns the request URL with scheme and host
+func absoluteURL(req *request.Request) string {
+	if u, err := url.Parse(req.URL); err == nil && u.Scheme != "" {
+		return req.URL
+	}
+	host := req.GetHost()
+	scheme := "http"
+	if strings.HasSuffix(host, ":443") {
+		scheme = "https"
+		host = strings.TrimSuffix(host, ":443")
+	}
+	return scheme + "://" + host + req.URL
+}
+
+// finalRaw returns the part of Raw after any interim responses
+func finalRaw(resp *response.Response) []byte {
+	raw := resp.Raw
+	for _, interim := range resp.InterimResponses {
+		if len(interim.Raw) > len(raw) {
+			return nil
+		}
+		raw = raw[len(interim.Raw):]
+	}
+	return raw
+}
+
+// headSize returns the length of the message head in raw (-1 if unknown)
+func headSize(raw []byte) int {
+	for i := 0; i+1 < len(raw); i++ {
+		if raw[i] != '\n' {
+			continue
+		}
+		if raw[i+1] == '\n' {
+			return i + 2
+		}
+		if raw[i+1] == '\r' && i+2 < len(raw) && raw[i+2] == '\n' {
+			return i + 3
+		}
+	}
+	return -1
+}
+
+func nameValues(fields []headers.Header) []NameValue {
+	nv := make([]NameValue, 0, len(fields))
+	for _, h := range fields {
+		nv = append(nv, NameValue{Name: h.Name, Value: strings.TrimSpace(h.Value)})
+	}
+	return nv
+}
+
+// unescape decodes a query component, keeping it as is when malformed
+func unescape(s string) string {
+	if u, err := url.QueryUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
+
+// FromHAR converts an entry back into a parsed request and response
+// The messages are rebuilt as HTTP/1.1 from the decoded bodies, so
+// Content-Encoding and Transfer-Encoding are dropped and Content-Length is
+// recomputed; HTTP/2 pseudo-headers are replaced by a Host header
+// resp is nil when the entry has no response (status 0)
+func FromHAR(e Entry) (*request.Request, *response.Response, error) {
+	u, err := url.Parse(e.Request.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("har: invalid request URL: %w", err)
+	}
+
+	target := u.RequestURI()
+	if u.Host == "" {
+		target = e.Request.URL
+	}
+	var body []byte
+	if e.Request.PostData != nil {
+		if body, err = decodeText(e.Request.PostData.Text, e.Request.PostData.Encoding); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(e.Request.Method + " " + target + " " + httpVersion(e.Request.HTTPVersion) + "\r\n")
+	hasHost := false
+	for _, h := range e.Request.Headers {
+		if strings.EqualFold(h.Name, "Host") {
+			hasHost = true
+		}
+	}
+	if !hasHost && u.Host != "" {
+		b.WriteString("Host: " + u.Host + "\r\n")
+	}
+	writeHeaders(&b, e.Request.Headers, len(body))
+	b.Write(body)
+
+	req, err := request.Parse([]byte(b.String()))
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.Response.Status == 0 {
+		return req, nil, nil
+	}
+
+	if body, err = decodeText(e.Response.Content.Text, e.Response.Content.Encoding); err != nil {
+		return nil, nil, err
+	}
+
+	b.Reset()
+	b.WriteString(httpVersion(e.Response.HTTPVersion) + " " + strconv.Itoa(e.Response.Status) + " " + e.Response.StatusText + "\r\n")
+	writeHeaders(&b, e.Response.Headers, len(body))
+	b.Write(body)
+
+	resp, err := response.ParseWithOptions([]byte(b.String()), response.ParseOptions{RequestMethod: e.Request.Method})
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, resp, nil
+}
+
+// writeHeaders writes HAR headers and the empty line ending the head,
+// with framing for a decoded body of bodyLen bytes
+func writeHeaders(b *strings.Builder, fields []NameValue, bodyLen int) {
+	for _, h := range fields {
+		switch strings.ToLower(h.Name) {
+		case "content-length", "content-encoding", "transfer-encoding":
+			continue
+		}
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		b.WriteString(h.Name + ": " + h.Value + "\r\n")
+	}
+	if bodyLen > 0 {
+		b.WriteString("Content-Length: " + strconv.Itoa(bodyLen) + "\r\n")
+	}
+	b.WriteString("\r\n")
+}
+
+// httpVersion maps a HAR version to an HTTP/1.x start line version
+func httpVersion(v string) string {
+	if strings.HasPrefix(strings.ToUpper(v), "HTTP/1.") {
+		return strings.ToUpper(v)
+	}
+	return "HTTP/1.1"
+}
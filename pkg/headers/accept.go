@@ -0,0 +1,155 @@
+package headers
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// AcceptItem is one entry of an Accept, Accept-Language, Accept-Charset or
+// Accept-Encoding header
+type AcceptItem struct {
+	Value  string            // Media range, language range, charset or coding (lowercase)
+	Q      float64           // Quality weight from 0 to 1 (1 if absent)
+	Params map[string]string // Media type parameters other than q (nil if none)
+}
+
+// ParseAccept parses an Accept-style header value
+// Items are sorted by descending q, more specific items first among equal q,
+// otherwise keeping header order
+// Items with an invalid q-value are dropped
+func ParseAccept(value string) []AcceptItem {
+	var items []AcceptItem
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Split(part, ";")
+		item := AcceptItem{Value: strings.ToLower(strings.TrimSpace(fields[0])), Q: 1}
+		if item.Value == "" {
+			continue
+		}
+
+		valid := true
+		for _, param := range fields[1:] {
+			name, val, _ := strings.Cut(param, "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			val = strings.Trim(strings.TrimSpace(val), `"`)
+			if name == "q" {
+				q, err := strconv.ParseFloat(val, 64)
+				if err != nil || q < 0 || q > 1 {
+					valid = false
+					break
+				}
+				item.Q = q
+				// Parameters after q are accept-ext, not media type parameters
+				break
+			}
+			if name != "" {
+				if item.Params == nil {
+					item.Params = make(map[string]string)
+				}
+				item.Params[name] = val
+			}
+		}
+		if valid {
+			items = append(items, item)
+		}
+	}
+
+	slices.SortStableFunc(items, func(a, b AcceptItem) int {
+		if a.Q != b.Q {
+			if a.Q > b.Q {
+				return -1
+			}
+			return 1
+		}
+		return specificity(b) - specificity(a)
+	})
+	return items
+}
+
+// Negotiate returns the entry of available preferred by an Accept-style
+// header value, or false if none is acceptable (406 Not Acceptable)
+// Media ranges match by type and subtype with "*" wildcards, and their
+// parameters must be present on the available type; other values match
+// exactly, by "*", or as a language prefix ("en" matches "en-US")
+// Each available entry takes the q of the most specific item matching it;
+// ties are broken by the order of available
+// An empty header value accepts the first available entry
+func Negotiate(header string, available []string) (string, bool) {
+	if len(available) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(header) == "" {
+		return available[0], true
+	}
+
+	items := ParseAccept(header)
+	best, bestQ := "", 0.0
+	for _, candidate := range available {
+		if q := quality(items, candidate); q > bestQ {
+			best, bestQ = candidate, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// quality returns the q of the most specific item matching candidate (0 if none)
+func quality(items []AcceptItem, candidate string) float64 {
+	value, params := splitParams(candidate)
+	q, spec := 0.0, -1
+	for _, item := range items {
+		if !acceptMatches(item, value, params) {
+			continue
+		}
+		if s := specificity(item); s > spec {
+			q, spec = item.Q, s
+		}
+	}
+	return q
+}
+
+// acceptMatches reports whether item matches the lowercase value with params
+func acceptMatches(item AcceptItem, value string, params map[string]string) bool {
+	if item.Value == "*" || item.Value == "*/*" {
+		return true
+	}
+	if itemType, itemSub, ok := strings.Cut(item.Value, "/"); ok {
+		typ, sub, _ := strings.Cut(value, "/")
+		if itemType != typ || (itemSub != "*" && itemSub != sub) {
+			return false
+		}
+		for name, val := range item.Params {
+			if !strings.EqualFold(params[name], val) {
+				return false
+			}
+		}
+		return true
+	}
+	return item.Value == value || strings.HasPrefix(value, item.Value+"-")
+}
+
+// specificity ranks items: wildcards lowest, then ranges, then exact values,
+// with media type parameters adding to the rank, and language subtags too,
+// so "en-us" outranks "en" (RFC 4647 lookup)
+func specificity(item AcceptItem) int {
+	switch {
+	case item.Value == "*" || item.Value == "*/*":
+		return 0
+	case strings.HasSuffix(item.Value, "/*"):
+		return 1
+	case strings.Contains(item.Value, "/"):
+		return 2 + len(item.Params)
+	default:
+		return 2 + strings.Count(item.Value, "-")
+	}
+}
+
+// splitParams splits "text/html; level=1" into its lowercase value and parameters
+func splitParams(s string) (string, map[string]string) {
+	fields := strings.Split(s, ";")
+	params := make(map[string]string)
+	for _, param := range fields[1:] {
+		name, val, _ := strings.Cut(param, "=")
+		params[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(val), `"`)
+	}
+	return strings.ToLower(strings.TrimSpace(fields[0])), params
+}
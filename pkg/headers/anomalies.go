@@ -0,0 +1,171 @@
+package headers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AnomalyKind identifies a header quirk that parsers are known to disagree on
+type AnomalyKind string
+
+const (
+	AnomalyObsFold                   AnomalyKind = "obs-fold"                     // Line continuing the previous header
+	AnomalyLeadingWhitespace         AnomalyKind = "leading-whitespace"           // First header line starts with whitespace
+	AnomalySpaceBeforeColon          AnomalyKind = "space-before-colon"           // Whitespace between name and colon
+	AnomalyInvalidName               AnomalyKind = "invalid-name"                 // Missing colon, empty name or non-token characters
+	AnomalyDuplicateContentLength    AnomalyKind = "duplicate-content-length"     // Several equal Content-Length values
+	AnomalyConflictingContentLength  AnomalyKind = "conflicting-content-length"   // Several different Content-Length values
+	AnomalyInvalidContentLength      AnomalyKind = "invalid-content-length"       // Content-Length not a non-negative integer
+	AnomalyDuplicateTransferEncoding AnomalyKind = "duplicate-transfer-encoding"  // Transfer-Encoding on several lines
+	AnomalyObfuscatedTransferCoding  AnomalyKind = "obfuscated-transfer-encoding" // Unknown coding or chunked not last
+	AnomalyContentLengthWithTE       AnomalyKind = "content-length-with-te"       // Content-Length alongside Transfer-Encoding
+)
+
+// Anomaly reports one header quirk
+type Anomaly struct {
+	Kind   AnomalyKind
+	Name   string // Header name as it appeared ("" when the line has none)
+	Line   int    // Zero-based index of the line among the header lines
+	Detail string
+}
+
+// knownCodings are the transfer codings registered by RFC 9112
+var knownCodings = map[string]bool{
+	"chunked":    true,
+	"compress":   true,
+	"deflate":    true,
+	"gzip":       true,
+	"x-compress": true,
+	"x-gzip":     true,
+	"identity":   true,
+}
+
+// FindAnomalies analyzes a raw header section for quirks used in request
+// smuggling: folded lines, odd header names and ambiguous framing headers
+// Like FindDuplicateHeaders every line is considered, folded lines included
+func FindAnomalies(headerData []byte) []Anomaly {
+	parsed, _ := ParseHeadersRaw(headerData)
+	return parsed.Anomalies()
+}
+
+// Anomalies returns the header quirks in line order, followed by the framing
+// findings (Content-Length and Transfer-Encoding)
+func (h *OrderedHeadersRaw) Anomalies() []Anomaly {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var anomalies []Anomaly
+	type field struct {
+		name, value string
+		line        int
+	}
+	var fields []field
+
+	for pos, header := range h.headers {
+		line := header.OriginalLine
+		if line[0] == ' ' || line[0] == '\t' {
+			if len(fields) == 0 {
+				anomalies = append(anomalies, Anomaly{AnomalyLeadingWhitespace, "", pos,
+					"header line starts with whitespace"})
+				continue
+			}
+			prev := &fields[len(fields)-1]
+			anomalies = append(anomalies, Anomaly{AnomalyObsFold, prev.name, pos,
+				"line continues " + prev.name})
+			prev.value += " " + strings.TrimSpace(line)
+			continue
+		}
+
+		rawName, value, ok := strings.Cut(line, ":")
+		name := strings.TrimRight(rawName, " \t")
+		switch {
+		case !ok:
+			anomalies = append(anomalies, Anomaly{AnomalyInvalidName, "", pos, "line without colon"})
+			continue
+		case name == "":
+			anomalies = append(anomalies, Anomaly{AnomalyInvalidName, "", pos, "empty header name"})
+			continue
+		case len(name) != len(rawName):
+			anomalies = append(anomalies, Anomaly{AnomalySpaceBeforeColon, name, pos,
+				"whitespace between name and colon"})
+		}
+		if !isToken(name) {
+			anomalies = append(anomalies, Anomaly{AnomalyInvalidName, name, pos,
+				"name contains non-token characters"})
+		}
+		fields = append(fields, field{name, strings.TrimSpace(value), pos})
+	}
+
+	// Framing headers, with folded continuations joined
+	var lengths []string
+	lengthLine := 0
+	firstCL, firstTE := -1, -1
+	for _, f := range fields {
+		switch strings.ToLower(f.name) {
+		case "content-length":
+			if firstCL == -1 {
+				firstCL = f.line
+			}
+			for _, v := range strings.Split(f.value, ",") {
+				v = strings.TrimSpace(v)
+				if n, err := strconv.ParseInt(v, 10, 64); err != nil || n < 0 || v[0] == '+' {
+					anomalies = append(anomalies, Anomaly{AnomalyInvalidContentLength, f.name, f.line,
+						"value " + strconv.Quote(v)})
+				}
+				lengths = append(lengths, v)
+				lengthLine = f.line
+			}
+		case "transfer-encoding":
+			if firstTE == -1 {
+				firstTE = f.line
+			} else {
+				anomalies = append(anomalies, Anomaly{AnomalyDuplicateTransferEncoding, f.name, f.line,
+					"Transfer-Encoding repeated"})
+			}
+			codings := strings.Split(f.value, ",")
+			for i, c := range codings {
+				c = strings.TrimSpace(c)
+				name, _, _ := strings.Cut(c, ";")
+				name = strings.ToLower(strings.TrimSpace(name))
+				switch {
+				case !knownCodings[name]:
+					anomalies = append(anomalies, Anomaly{AnomalyObfuscatedTransferCoding, f.name, f.line,
+						"unknown coding " + strconv.Quote(c)})
+				case name == "chunked" && i != len(codings)-1:
+					anomalies = append(anomalies, Anomaly{AnomalyObfuscatedTransferCoding, f.name, f.line,
+						"chunked is not the last coding"})
+				}
+			}
+		}
+	}
+
+	if len(lengths) > 1 {
+		kind, detail := AnomalyDuplicateContentLength, "Content-Length repeated"
+		for _, v := range lengths[1:] {
+			if v != lengths[0] {
+				kind, detail = AnomalyConflictingContentLength, "values "+strings.Join(lengths, ", ")
+				break
+			}
+		}
+		anomalies = append(anomalies, Anomaly{kind, "Content-Length", lengthLine, detail})
+	}
+	if firstCL != -1 && firstTE != -1 {
+		anomalies = append(anomalies, Anomaly{AnomalyContentLengthWithTE, "Transfer-Encoding", max(firstCL, firstTE),
+			"Content-Length alongside Transfer-Encoding"})
+	}
+	return anomalies
+}
+
+// isToken reports whether s consists of RFC 9110 token characters
+func isToken(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			continue
+		}
+		if !strings.ContainsRune("!#$%&'*+-.^_`|~", rune(c)) {
+			return false
+		}
+	}
+	return true
+}
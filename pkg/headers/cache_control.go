@@ -0,0 +1,41 @@
+package headers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDeltaSeconds caps delta-seconds values (RFC 9111 section 1.2.2), which
+// also keeps them far from overflowing a time.Duration
+const maxDeltaSeconds = 1 << 31
+
+// ParseCacheControl parses a Cache-Control value into lowercase directive
+// names and unquoted values ("" for directives without a value)
+func ParseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		name, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		directives[name] = strings.Trim(strings.TrimSpace(val), `"`)
+	}
+	return directives
+}
+
+// ParseDeltaSeconds parses a delta-seconds value (max-age, Age) as a duration
+// Values above 2^31 seconds are capped; ok is false for invalid or negative values
+func ParseDeltaSeconds(value string) (d time.Duration, ok bool) {
+	value = strings.TrimSpace(value)
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if numErr, isNum := err.(*strconv.NumError); isNum && numErr.Err == strconv.ErrRange && !strings.HasPrefix(value, "-") {
+		// Too many digits for int64, but still a valid value beyond the cap
+		seconds, err = maxDeltaSeconds, nil
+	}
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(min(seconds, maxDeltaSeconds)) * time.Second, true
+}
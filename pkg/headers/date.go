@@ -0,0 +1,47 @@
+package headers
+
+import (
+	"strings"
+	"time"
+)
+
+// TimeFormat is the preferred HTTP-date format (IMF-fixdate, RFC 9110 section 5.6.7)
+const TimeFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+// httpDateLayouts lists accepted HTTP-date layouts, most common first
+// Besides the three RFC formats, common non-conforming variants seen in the
+// wild (numeric zones, cookie-style dashes, missing weekday) are accepted
+var httpDateLayouts = []string{
+	"Mon, _2 Jan 2006 15:04:05 MST",    // IMF-fixdate (RFC 1123)
+	"Monday, _2-Jan-06 15:04:05 MST",   // RFC 850 (obsolete)
+	"Mon Jan _2 15:04:05 2006",         // asctime
+	"Mon, _2 Jan 2006 15:04:05 -0700",  // numeric zone
+	"Mon, _2-Jan-2006 15:04:05 MST",    // cookie-style dashes
+	"Mon, _2-Jan-06 15:04:05 MST",      // cookie-style dashes, 2-digit year
+	"_2 Jan 2006 15:04:05 MST",         // missing weekday
+	"Monday, _2 Jan 2006 15:04:05 MST", // full weekday name
+}
+
+// ParseHTTPDate parses an HTTP-date header value (Date, Expires, Last-Modified, ...)
+// Accepts IMF-fixdate, RFC 850 and asctime formats plus common lenient variants
+// The result is always in UTC
+func ParseHTTPDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	var firstErr error
+	for _, layout := range httpDateLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t.UTC(), nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return time.Time{}, firstErr
+}
+
+// FormatHTTPDate formats t as an IMF-fixdate in GMT
+func FormatHTTPDate(t time.Time) string {
+	return t.UTC().Format(TimeFormat)
+}
@@ -0,0 +1,104 @@
+package headers
+
+import "strings"
+
+// singletonHeaders are headers that must appear at most once (lowercase names)
+// Repeating them is a common source of parser discrepancies (request smuggling,
+// host confusion, cache poisoning)
+var singletonHeaders = map[string]bool{
+	"host":                true,
+	"content-length":      true,
+	"content-type":        true,
+	"content-location":    true,
+	"content-range":       true,
+	"authorization":       true,
+	"proxy-authorization": true,
+	"age":                 true,
+	"date":                true,
+	"etag":                true,
+	"expires":             true,
+	"last-modified":       true,
+	"location":            true,
+	"max-forwards":        true,
+	"referer":             true,
+	"retry-after":         true,
+	"server":              true,
+	"user-agent":          true,
+	"from":                true,
+	"origin":              true,
+	"range":               true,
+	"if-range":            true,
+	"if-modified-since":   true,
+	"if-unmodified-since": true,
+}
+
+// IsSingletonHeader reports whether name is a header that must not be repeated
+func IsSingletonHeader(name string) bool {
+	return singletonHeaders[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// DuplicateHeader reports a singleton header that occurs more than once
+// Content-Length is also reported when a single line lists several values ("5, 5")
+type DuplicateHeader struct {
+	Name        string   // Header name as it first appeared
+	Positions   []int    // Zero-based index of each occurrence among the header lines
+	Values      []string // Values in order (Content-Length lists are split)
+	Conflicting bool     // Whether the values differ (e.g. two different Content-Lengths)
+}
+
+// FindDuplicateHeaders analyzes a raw header section for repeated singleton headers
+// Unlike OrderedHeaders, which keeps one value per name, every line is considered,
+// so the findings reflect what was actually on the wire
+func FindDuplicateHeaders(headerData []byte) []DuplicateHeader {
+	parsed, _ := ParseHeadersRaw(headerData)
+	return parsed.Duplicates()
+}
+
+// Duplicates returns the repeated singleton headers, in order of first occurrence
+func (h *OrderedHeadersRaw) Duplicates() []DuplicateHeader {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var findings []DuplicateHeader
+	index := make(map[string]int)
+
+	for pos, header := range h.headers {
+		lowerName := strings.ToLower(header.Name)
+		if !singletonHeaders[lowerName] {
+			continue
+		}
+
+		values := []string{header.Value}
+		if lowerName == "content-length" && strings.Contains(header.Value, ",") {
+			values = values[:0]
+			for _, v := range strings.Split(header.Value, ",") {
+				values = append(values, strings.TrimSpace(v))
+			}
+		}
+
+		i, seen := index[lowerName]
+		if !seen {
+			i = len(findings)
+			index[lowerName] = i
+			findings = append(findings, DuplicateHeader{Name: header.Name})
+		}
+		findings[i].Positions = append(findings[i].Positions, pos)
+		findings[i].Values = append(findings[i].Values, values...)
+	}
+
+	// Keep only headers with more than one value
+	result := findings[:0]
+	for _, f := range findings {
+		if len(f.Values) < 2 {
+			continue
+		}
+		for _, v := range f.Values[1:] {
+			if v != f.Values[0] && !(strings.EqualFold(f.Name, "host") && strings.EqualFold(v, f.Values[0])) {
+				f.Conflicting = true
+				break
+			}
+		}
+		result = append(result, f)
+	}
+	return result
+}
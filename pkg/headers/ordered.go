@@ -1,6 +1,7 @@
 package headers
 
 import (
+	"maps"
 	"strings"
 	"sync"
 )
@@ -23,12 +24,18 @@ type HeaderEntry struct {
 
 // NewOrderedHeaders creates a new OrderedHeaders instance
 func NewOrderedHeaders() *OrderedHeaders {
+	return newOrderedHeadersSize(0)
+}
+
+// newOrderedHeadersSize creates an OrderedHeaders with room for n headers,
+// avoiding map growth while parsing
+func newOrderedHeadersSize(n int) *OrderedHeaders {
 	return &OrderedHeaders{
-		order:         make([]string, 0),
-		values:        make(map[string]string),
-		raw:           make(map[string]string),
-		originalLines: make(map[string]string),
-		lineEndings:   make(map[string]string),
+		order:         make([]string, 0, n),
+		values:        make(map[string]string, n),
+		raw:           make(map[string]string, n),
+		originalLines: make(map[string]string, n),
+		lineEndings:   make(map[string]string, n),
 	}
 }
 
@@ -264,6 +271,20 @@ func (h *OrderedHeaders) All() []Header {
 	return headers
 }
 
+// Clone returns a deep copy, keeping repeated fields and original formatting
+func (h *OrderedHeaders) Clone() *OrderedHeaders {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return &OrderedHeaders{
+		order:         append([]string(nil), h.order...),
+		values:        maps.Clone(h.values),
+		raw:           maps.Clone(h.raw),
+		originalLines: maps.Clone(h.originalLines),
+		lineEndings:   maps.Clone(h.lineEndings),
+	}
+}
+
 // Len returns the number of headers
 func (h *OrderedHeaders) Len() int {
 	h.mu.RLock()
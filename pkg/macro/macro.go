@@ -0,0 +1,233 @@
+// Package macro runs multi-step request chains
+//
+// Each step renders a request template with the current variables, sends it,
+// and captures values from the response into variables used by later steps:
+//
+//	steps := []macro.Step{
+//		{
+//			Name:     "login",
+//			Template: template.Template{Method: "POST", URL: "/login", Headers: []template.Field{{"Host", "{{host}}"}}, Body: "user={{user}}&pass={{pass}}"},
+//			Extract:  []macro.Rule{{Var: "session", Kind: macro.RuleCookie, Expr: "session", Required: true}},
+//		},
+//		{
+//			Name:     "profile",
+//			Template: template.Template{URL: "/me", Headers: []template.Field{{"Host", "{{host}}"}, {"Cookie", "session={{session}}"}}},
+//			Extract:  []macro.Rule{{Var: "email", Kind: macro.RuleJSON, Expr: "$.user.email"}},
+//		},
+//	}
+//	result, err := macro.Run(ctx, send, steps, template.Env{Vars: vars})
+package macro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+	"github.com/WhileEndless/go-httptools/pkg/schedule"
+	"github.com/WhileEndless/go-httptools/pkg/template"
+)
+
+// ErrNotCaptured is returned when a required rule captures nothing
+var ErrNotCaptured = errors.New("macro: required value not captured")
+
+// RuleKind selects where a rule looks for its value
+type RuleKind int
+
+const (
+	RuleRegex  RuleKind = iota // Body regex: first capture group, or the whole match
+	RuleJSON                   // JSON path into the body ($.a.b[0].c)
+	RuleHeader                 // Response header value
+	RuleCookie                 // Value of a Set-Cookie by cookie name
+)
+
+// Rule captures one value from a response into a variable
+type Rule struct {
+	Var      string
+	Kind     RuleKind
+	Expr     string // Regex, JSON path, header name or cookie name
+	Required bool   // Stop the chain with ErrNotCaptured if nothing matches
+}
+
+// Step is one request of a chain
+type Step struct {
+	Name     string
+	Template template.Template
+	Extract  []Rule
+}
+
+// StepResult is the outcome of one step
+type StepResult struct {
+	Name     string
+	Request  *request.Request
+	Response *response.Response
+	Captured map[string]string
+}
+
+// Result is the outcome of a chain
+type Result struct {
+	Steps []StepResult
+	Vars  map[string]string // Final variables: the initial ones plus everything captured
+}
+
+// Run executes steps in order with send
+// env.Vars is not modified; captured values are added to a copy
+// On error the result holds the steps completed so far
+func Run(ctx context.Context, send schedule.SendFunc, steps []Step, env template.Env) (*Result, error) {
+	vars := make(map[string]string, len(env.Vars))
+	for k, v := range env.Vars {
+		vars[k] = v
+	}
+	env.Vars = vars
+	result := &Result{Vars: vars}
+
+	for _, step := range steps {
+		req, err := step.Template.Render(env)
+		if err != nil {
+			return result, fmt.Errorf("macro: step %q: %w", step.Name, err)
+		}
+		resp, err := send(ctx, req)
+		if err != nil {
+			return result, fmt.Errorf("macro: step %q: %w", step.Name, err)
+		}
+
+		sr := StepResult{Name: step.Name, Request: req, Response: resp, Captured: make(map[string]string)}
+		for _, rule := range step.Extract {
+			value, ok, err := rule.Apply(resp)
+			if err != nil {
+				return result, fmt.Errorf("macro: step %q: rule %q: %w", step.Name, rule.Var, err)
+			}
+			if !ok {
+				if rule.Required {
+					result.Steps = append(result.Steps, sr)
+					return result, fmt.Errorf("%w: step %q, variable %q", ErrNotCaptured, step.Name, rule.Var)
+				}
+				continue
+			}
+			sr.Captured[rule.Var] = value
+			vars[rule.Var] = value
+		}
+		result.Steps = append(result.Steps, sr)
+	}
+	return result, nil
+}
+
+// Apply extracts the rule's value from resp
+// ok is false if nothing matched; err reports an invalid rule or body
+func (r Rule) Apply(resp *response.Response) (value string, ok bool, err error) {
+	switch r.Kind {
+	case RuleRegex:
+		re, err := regexp.Compile(r.Expr)
+		if err != nil {
+			return "", false, err
+		}
+		m := re.FindSubmatch(resp.Body)
+		if m == nil {
+			return "", false, nil
+		}
+		if len(m) > 1 {
+			return string(m[1]), true, nil
+		}
+		return string(m[0]), true, nil
+
+	case RuleJSON:
+		// UseNumber keeps large integers (IDs, tokens) exact instead of float64
+		var doc any
+		dec := json.NewDecoder(bytes.NewReader(resp.Body))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return "", false, nil
+		}
+		return JSONPath(doc, r.Expr)
+
+	case RuleHeader:
+		if !resp.Headers.Has(r.Expr) {
+			return "", false, nil
+		}
+		return strings.TrimSpace(resp.Headers.Get(r.Expr)), true, nil
+
+	case RuleCookie:
+		for i := len(resp.SetCookies) - 1; i >= 0; i-- {
+			if resp.SetCookies[i].Name == r.Expr {
+				return resp.SetCookies[i].Value, true, nil
+			}
+		}
+		return "", false, nil
+	}
+	return "", false, fmt.Errorf("unknown rule kind %d", r.Kind)
+}
+
+// JSONPath evaluates a simple JSON path ($.a.b[0]["c d"]) on a decoded document
+// Strings and json.Number values are returned as is, other values as JSON
+// ok is false if the path does not exist; err reports a malformed path
+func JSONPath(doc any, path string) (value string, ok bool, err error) {
+	keys, err := splitPath(path)
+	if err != nil {
+		return "", false, err
+	}
+	cur := doc
+	for _, key := range keys {
+		switch node := cur.(type) {
+		case map[string]any:
+			if cur, ok = node[key]; !ok {
+				return "", false, nil
+			}
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", false, nil
+			}
+			cur = node[i]
+		default:
+			return "", false, nil
+		}
+	}
+	switch v := cur.(type) {
+	case string:
+		return v, true, nil
+	case json.Number:
+		return v.String(), true, nil
+	}
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return "", false, err
+	}
+	return string(b), true, nil
+}
+
+// splitPath splits "$.a.b[0]['c']" into its keys
+func splitPath(path string) ([]string, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var keys []string
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("macro: empty key in JSON path %q", path)
+			}
+			keys = append(keys, p[:end])
+			p = p[end:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("macro: unclosed [ in JSON path %q", path)
+			}
+			keys = append(keys, strings.Trim(p[1:end], `'"`))
+			p = p[end+1:]
+		default:
+			return nil, fmt.Errorf("macro: invalid JSON path %q", path)
+		}
+	}
+	return keys, nil
+}
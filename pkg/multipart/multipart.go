@@ -0,0 +1,451 @@
+// Package multipart parses and builds multipart bodies (RFC 2046, RFC 7578)
+//
+// Unlike mime/multipart it is fault tolerant and keeps the formatting of the
+// original body (line endings, transport padding, preamble, epilogue, header
+// spelling), so an unmodified Form builds back to the exact input:
+//
+//	form, err := multipart.Parse(body, boundary)
+//	form.Get("avatar").SetFileName("../../shell.php")
+//	body = form.Build()
+package multipart
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// ErrMissingBoundary is returned when no boundary is given or found
+var ErrMissingBoundary = errors.New("multipart: missing boundary")
+
+// ErrNoDelimiter is returned when the body contains no boundary delimiter
+var ErrNoDelimiter = errors.New("multipart: boundary delimiter not found")
+
+// Header is a part header line
+type Header struct {
+	Name  string
+	Value string // Trimmed
+
+	line       string // Original line without line ending; empty when set programmatically
+	lineEnding string // Original line ending; empty = the form's line ending
+}
+
+// Part is one body part
+type Part struct {
+	Headers []Header // In order, duplicates included
+	Body    []byte
+
+	// Original formatting of parsed parts; for new parts the form's line
+	// ending is used instead
+	parsed      bool
+	delimSuffix string // Transport padding and line ending after the delimiter
+	headEnd     string // Empty line ending the headers
+	noHead      bool   // Part had no empty line: everything was treated as body
+	tail        string // Line ending before the next delimiter
+}
+
+// Form is a parsed multipart body
+type Form struct {
+	Boundary   string
+	Preamble   []byte // Data before the first delimiter
+	Parts      []*Part
+	Epilogue   []byte // Data after the close delimiter
+	LineEnding string // Used for new lines (default "\r\n")
+
+	// Unterminated is set when the close delimiter was missing
+	// Build then omits it as well
+	Unterminated bool
+}
+
+// NewForm creates an empty form with the given boundary
+// An empty boundary is replaced by a random one
+func NewForm(boundary string) *Form {
+	if boundary == "" {
+		var b [16]byte
+		rand.Read(b[:])
+		boundary = hex.EncodeToString(b[:])
+	}
+	return &Form{Boundary: boundary, Epilogue: []byte("\r\n"), LineEnding: "\r\n"}
+}
+
+// Boundary extracts the boundary parameter of a multipart Content-Type value
+func Boundary(contentType string) (string, bool) {
+	mediaType, params := parseParams(contentType)
+	if !strings.HasPrefix(strings.ToLower(mediaType), "multipart/") {
+		return "", false
+	}
+	boundary := params["boundary"]
+	return boundary, boundary != ""
+}
+
+// Parse parses a multipart body delimited by boundary
+// Malformed parts are kept as they are; only a missing boundary or delimiter
+// is an error
+func Parse(body []byte, boundary string) (*Form, error) {
+	if boundary == "" {
+		return nil, ErrMissingBoundary
+	}
+	delim := []byte("--" + boundary)
+
+	pos := indexDelimiter(body, delim, 0)
+	if pos == -1 {
+		return nil, ErrNoDelimiter
+	}
+	form := &Form{Boundary: boundary, Preamble: body[:pos], LineEnding: "\r\n"}
+
+	for {
+		pos += len(delim)
+		if bytes.HasPrefix(body[pos:], []byte("--")) {
+			form.Epilogue = body[pos+2:]
+			return form, nil
+		}
+
+		// Transport padding and line ending after the delimiter
+		lineEnd := bytes.IndexByte(body[pos:], '\n')
+		if lineEnd == -1 {
+			lineEnd = len(body)
+		} else {
+			lineEnd += pos + 1
+		}
+		part := &Part{parsed: true, delimSuffix: string(body[pos:lineEnd])}
+		if len(form.Parts) == 0 && strings.HasSuffix(part.delimSuffix, "\n") && !strings.HasSuffix(part.delimSuffix, "\r\n") {
+			form.LineEnding = "\n"
+		}
+		form.Parts = append(form.Parts, part)
+		pos = lineEnd
+
+		next := indexDelimiter(body, delim, pos)
+		end := next
+		if next == -1 {
+			end = len(body)
+		} else if next > pos {
+			end--
+			if end > pos && body[end-1] == '\r' {
+				end--
+			}
+			part.tail = string(body[end:next])
+		}
+		part.parse(body[pos:end])
+
+		if next == -1 {
+			form.Unterminated = true
+			return form, nil
+		}
+		pos = next
+	}
+}
+
+// indexDelimiter finds delim at the start of data or of a line, at or after from
+func indexDelimiter(data, delim []byte, from int) int {
+	if from == 0 && bytes.HasPrefix(data, delim) {
+		return 0
+	}
+	for {
+		i := bytes.Index(data[from:], delim)
+		if i == -1 {
+			return -1
+		}
+		i += from
+		if i > 0 && data[i-1] == '\n' {
+			return i
+		}
+		from = i + 1
+	}
+}
+
+// parse splits the content of a part into headers and body
+func (p *Part) parse(content []byte) {
+	pos := 0
+	for pos < len(content) {
+		end := bytes.IndexByte(content[pos:], '\n')
+		if end == -1 {
+			break
+		}
+		end += pos
+		lineEnding := "\n"
+		lineStart, lineEnd := pos, end
+		if lineEnd > lineStart && content[lineEnd-1] == '\r' {
+			lineEnd--
+			lineEnding = "\r\n"
+		}
+		pos = end + 1
+
+		if lineEnd == lineStart {
+			p.headEnd = lineEnding
+			p.Body = content[pos:]
+			return
+		}
+		line := string(content[lineStart:lineEnd])
+		name, value, _ := strings.Cut(line, ":")
+		p.Headers = append(p.Headers, Header{
+			Name:       strings.TrimSpace(name),
+			Value:      strings.TrimSpace(value),
+			line:       line,
+			lineEnding: lineEnding,
+		})
+	}
+
+	// No empty line: keep everything as body
+	p.Headers = nil
+	p.noHead = true
+	p.Body = content
+}
+
+// Build returns the multipart body
+// An unmodified parsed Form is reproduced byte for byte
+func (f *Form) Build() []byte {
+	lineEnding := f.LineEnding
+	if lineEnding == "" {
+		lineEnding = "\r\n"
+	}
+
+	var b bytes.Buffer
+	b.Write(f.Preamble)
+	for i, p := range f.Parts {
+		or := func(s string) string {
+			if s == "" && !p.parsed {
+				return lineEnding
+			}
+			return s
+		}
+		b.WriteString("--" + f.Boundary)
+		b.WriteString(or(p.delimSuffix))
+		if !p.noHead {
+			for _, h := range p.Headers {
+				if h.line != "" {
+					b.WriteString(h.line)
+				} else {
+					b.WriteString(h.Name + ": " + h.Value)
+				}
+				b.WriteString(or(h.lineEnding))
+			}
+			b.WriteString(or(p.headEnd))
+		}
+		b.Write(p.Body)
+		if i < len(f.Parts)-1 || !f.Unterminated {
+			b.WriteString(or(p.tail))
+		}
+	}
+	if !f.Unterminated {
+		b.WriteString("--" + f.Boundary + "--")
+		b.Write(f.Epilogue)
+	}
+	return b.Bytes()
+}
+
+// ContentType returns the Content-Type value for a multipart/form-data body
+func (f *Form) ContentType() string {
+	return "multipart/form-data; boundary=" + quoteIfNeeded(f.Boundary)
+}
+
+// Get returns the first part with the given form field name
+func (f *Form) Get(name string) *Part {
+	for _, p := range f.Parts {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Value returns the body of the first part with the given field name
+func (f *Form) Value(name string) string {
+	if p := f.Get(name); p != nil {
+		return string(p.Body)
+	}
+	return ""
+}
+
+// Files returns the parts carrying a filename
+func (f *Form) Files() []*Part {
+	var files []*Part
+	for _, p := range f.Parts {
+		if _, ok := p.FileName(); ok {
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// AddField appends a form field part
+func (f *Form) AddField(name, value string) *Part {
+	p := &Part{Body: []byte(value)}
+	p.SetHeader("Content-Disposition", `form-data; name="`+escapeQuotes(name)+`"`)
+	f.Parts = append(f.Parts, p)
+	return p
+}
+
+// AddFile appends a file part
+// An empty contentType defaults to application/octet-stream
+func (f *Form) AddFile(name, fileName, contentType string, data []byte) *Part {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	p := &Part{Body: data}
+	p.SetHeader("Content-Disposition", `form-data; name="`+escapeQuotes(name)+`"; filename="`+escapeQuotes(fileName)+`"`)
+	p.SetHeader("Content-Type", contentType)
+	f.Parts = append(f.Parts, p)
+	return p
+}
+
+// Header returns the value of the first header named name (case-insensitive)
+func (p *Part) Header(name string) string {
+	for _, h := range p.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// SetHeader sets the first header named name, or appends it
+// The original spelling of the header name is kept
+func (p *Part) SetHeader(name, value string) {
+	if p.noHead {
+		p.noHead = false
+		p.headEnd = p.lineEnding()
+	}
+	for i := range p.Headers {
+		if strings.EqualFold(p.Headers[i].Name, name) {
+			p.Headers[i].Value = value
+			p.Headers[i].line = ""
+			return
+		}
+	}
+	p.Headers = append(p.Headers, Header{Name: name, Value: value, lineEnding: p.lineEnding()})
+}
+
+// lineEnding returns the line ending of a parsed part ("" for new parts)
+func (p *Part) lineEnding() string {
+	switch {
+	case !p.parsed:
+		return ""
+	case p.headEnd != "":
+		return p.headEnd
+	case strings.HasSuffix(p.delimSuffix, "\r\n") || p.delimSuffix == "":
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// Name returns the form field name from Content-Disposition
+func (p *Part) Name() string {
+	_, params := parseParams(p.Header("Content-Disposition"))
+	return params["name"]
+}
+
+// FileName returns the filename from Content-Disposition
+// The path is returned as sent, without sanitizing
+func (p *Part) FileName() (string, bool) {
+	_, params := parseParams(p.Header("Content-Disposition"))
+	name, ok := params["filename"]
+	return name, ok
+}
+
+// SetFileName sets the filename parameter of Content-Disposition
+func (p *Part) SetFileName(fileName string) {
+	disposition := p.Header("Content-Disposition")
+	if disposition == "" {
+		disposition = "form-data"
+	}
+	p.SetHeader("Content-Disposition", setParam(disposition, "filename", fileName))
+}
+
+// ContentType returns the part's Content-Type value
+func (p *Part) ContentType() string {
+	return p.Header("Content-Type")
+}
+
+// parseParams splits a header value into its first token and its
+// lowercase-named parameters; quoted values are unquoted
+// Malformed parameters are skipped
+func parseParams(value string) (string, map[string]string) {
+	params := make(map[string]string)
+	first, rest, _ := strings.Cut(value, ";")
+	for rest != "" {
+		var param string
+		param, rest = cutParam(rest)
+		name, val, ok := strings.Cut(param, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			continue
+		}
+		if _, exists := params[name]; !exists {
+			params[name] = unquote(strings.TrimSpace(val))
+		}
+	}
+	return strings.TrimSpace(first), params
+}
+
+// cutParam returns the next ';'-separated parameter, honoring quotes
+func cutParam(s string) (string, string) {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == ';' && !quoted:
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+// unquote removes surrounding quotes and backslash escapes
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	s = s[1 : len(s)-1]
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// setParam replaces or appends a quoted parameter in a header value
+func setParam(value, name, paramValue string) string {
+	first, rest, _ := strings.Cut(value, ";")
+	parts := []string{first}
+	replaced := false
+	for rest != "" {
+		var param string
+		param, rest = cutParam(rest)
+		key, _, _ := strings.Cut(param, "=")
+		if strings.EqualFold(strings.TrimSpace(key), name) {
+			if replaced {
+				continue
+			}
+			param = " " + name + `="` + escapeQuotes(paramValue) + `"`
+			replaced = true
+		}
+		parts = append(parts, param)
+	}
+	if !replaced {
+		parts = append(parts, " "+name+`="`+escapeQuotes(paramValue)+`"`)
+	}
+	return strings.Join(parts, ";")
+}
+
+func escapeQuotes(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// quoteIfNeeded quotes a boundary containing characters outside token
+func quoteIfNeeded(s string) string {
+	if strings.ContainsAny(s, ` ()<>@,;:\"/[]?=`) {
+		return `"` + escapeQuotes(s) + `"`
+	}
+	return s
+}
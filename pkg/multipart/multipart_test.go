@@ -0,0 +1,94 @@
+package multipart
+
+import (
+	"bytes"
+	"mime/multipart"
+	"strings"
+	"testing"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	bodies := []string{
+		"--b\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\n1\r\n--b\r\nContent-Disposition: form-data; name=\"f\"; filename=\"x.txt\"\r\nContent-Type: text/plain\r\n\r\nhello\r\n--b--\r\n",
+		// Preamble, padding, bare LF, odd header spacing, epilogue
+		"junk\n--b  \ncontent-disposition:form-data;name=a\n\nv\n--b--\ntrailing",
+		// Missing close delimiter, part without headers, empty part
+		"--b\r\n\r\nraw\r\n--b\r\n--b\r\nno head",
+	}
+	for _, body := range bodies {
+		form, err := Parse([]byte(body), "b")
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", body, err)
+		}
+		if got := string(form.Build()); got != body {
+			t.Errorf("Build = %q, want %q", got, body)
+		}
+	}
+
+	form, _ := Parse([]byte(bodies[1]), "b")
+	if form.Value("a") != "v" || form.LineEnding != "\n" || string(form.Preamble) != "junk\n" {
+		t.Errorf("form = %+v", form)
+	}
+	form, _ = Parse([]byte(bodies[2]), "b")
+	if !form.Unterminated || len(form.Parts) != 3 || string(form.Parts[2].Body) != "no head" {
+		t.Errorf("form = %+v", form)
+	}
+
+	if _, err := Parse([]byte("no delimiter"), "b"); err != ErrNoDelimiter {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestEditAndBuild(t *testing.T) {
+	body := "--b\nContent-Disposition: form-data; name=\"f\"; filename=\"a.png\"\n\nPNG\n--b--\n"
+	form, err := Parse([]byte(body), "b")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	files := form.Files()
+	if len(files) != 1 {
+		t.Fatalf("Files = %d", len(files))
+	}
+	files[0].SetFileName(`../"x".php`)
+	files[0].SetHeader("Content-Type", "image/png")
+	want := "--b\nContent-Disposition: form-data; name=\"f\"; filename=\"../\\\"x\\\".php\"\nContent-Type: image/png\n\nPNG\n--b--\n"
+	if got := string(form.Build()); got != want {
+		t.Errorf("Build = %q, want %q", got, want)
+	}
+	if name, _ := files[0].FileName(); name != `../"x".php` {
+		t.Errorf("FileName = %q", name)
+	}
+
+	// New forms are readable by mime/multipart
+	form = NewForm("")
+	form.AddField("a", "1")
+	form.AddFile("f", "x.bin", "", []byte{0, 1})
+	boundary, ok := Boundary(form.ContentType())
+	if !ok || boundary != form.Boundary {
+		t.Fatalf("Boundary = %q, %v", boundary, ok)
+	}
+	mr := multipart.NewReader(bytes.NewReader(form.Build()), boundary)
+	parsed, err := mr.ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("ReadForm failed: %v", err)
+	}
+	if parsed.Value["a"][0] != "1" || parsed.File["f"][0].Filename != "x.bin" {
+		t.Errorf("form = %+v", parsed)
+	}
+}
+
+func TestBoundary(t *testing.T) {
+	for ct, want := range map[string]string{
+		`multipart/form-data; boundary=abc`:               "abc",
+		`Multipart/Mixed;BOUNDARY="a b;c"`:                "a b;c",
+		`multipart/form-data; charset=x; boundary="q\"q"`: `q"q`,
+		`text/plain; boundary=abc`:                        "",
+	} {
+		if got, _ := Boundary(ct); got != want {
+			t.Errorf("Boundary(%q) = %q, want %q", ct, got, want)
+		}
+	}
+	if strings.Contains(NewForm("a b").ContentType(), "boundary=a b") {
+		t.Error("boundary with space not quoted")
+	}
+}
@@ -0,0 +1,254 @@
+// Package mutate generates malformed variants of raw HTTP messages
+//
+// Each mutation takes a well-formed seed request or response and breaks one
+// aspect of it (line endings, header syntax, framing, chunk encoding), giving a
+// labeled corpus for testing servers and parsers against malformed input:
+//
+//	for _, c := range mutate.All(req.Build()) {
+//		if _, err := request.Parse(c.Raw); err != nil {
+//			log.Printf("%s: %v", c.Name, err)
+//		}
+//	}
+//
+// Random stacks several mutations for fuzz-style exploration
+package mutate
+
+import (
+	"bytes"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/framing"
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+)
+
+// Case is a mutated message labeled with the mutations applied
+type Case struct {
+	Name string // Mutation name, or names joined with "+" for stacked mutations
+	Raw  []byte
+}
+
+// Mutation breaks one aspect of a message
+type Mutation struct {
+	Name  string
+	Apply func(seed []byte) []byte
+}
+
+// Mutations are the built-in mutations, in the order All applies them
+var Mutations = []Mutation{
+	// Line endings
+	{"lf-only", lines(func(m *message) { m.eol = "\n" })},
+	{"cr-only", lines(func(m *message) { m.eol = "\r" })},
+	{"mixed-line-endings", mixedLineEndings},
+	{"bare-cr-in-header", lines(func(m *message) { m.appendToFirst(" a\rb") })},
+
+	// Header syntax
+	{"null-in-header-name", lines(func(m *message) { m.add("X-Null\x00Name: value") })},
+	{"null-in-header-value", lines(func(m *message) { m.appendToFirst("\x00") })},
+	{"folded-header", lines(func(m *message) { m.add("X-Folded: first", " second") })},
+	{"space-before-colon", lines(func(m *message) { m.add("X-Space : value") })},
+	{"missing-colon", lines(func(m *message) { m.add("X-Missing-Colon value") })},
+	{"empty-header-name", lines(func(m *message) { m.add(": value") })},
+	{"oversized-header", lines(func(m *message) { m.add("X-Oversized: " + strings.Repeat("A", 64*1024)) })},
+	{"start-line-extra-spaces", lines(func(m *message) { m.start = strings.ReplaceAll(m.start, " ", "  ") })},
+	// Drops the last start line token: the version of requests, the reason of responses
+	{"start-line-truncated", lines(func(m *message) { m.start = m.start[:max(strings.LastIndexByte(m.start, ' '), 0)] })},
+
+	// Framing
+	{"truncated-head", func(seed []byte) []byte {
+		end := framing.HeadEnd(seed)
+		if end == -1 {
+			end = len(seed)
+		}
+		return append([]byte(nil), seed[:end/2]...)
+	}},
+	{"truncated-body", lines(func(m *message) { m.body = m.body[:len(m.body)/2] })},
+	{"content-length-too-large", lines(func(m *message) { m.setLength(strconv.Itoa(len(m.body) + 10)) })},
+	{"content-length-negative", lines(func(m *message) { m.setLength("-1") })},
+	{"content-length-non-numeric", lines(func(m *message) { m.setLength("1e3") })},
+	{"content-length-conflicting", lines(func(m *message) {
+		m.setLength(strconv.Itoa(len(m.body)))
+		m.add("Content-Length: " + strconv.Itoa(len(m.body)+1))
+	})},
+	{"content-length-and-chunked", lines(func(m *message) {
+		m.chunk(chunked.Encode(m.body, 0))
+		m.add("Content-Length: " + strconv.Itoa(len(m.body)))
+	})},
+
+	// Chunked encoding
+	{"chunk-size-oversized", lines(func(m *message) {
+		m.chunk([]byte("ffffffffffffffffff\r\n" + string(m.body) + "\r\n0\r\n\r\n"))
+	})},
+	{"chunk-size-invalid-hex", lines(func(m *message) {
+		m.chunk([]byte("zz\r\n" + string(m.body) + "\r\n0\r\n\r\n"))
+	})},
+	{"chunk-size-mismatch", lines(func(m *message) {
+		m.chunk([]byte(strconv.FormatInt(int64(len(m.body)+5), 16) + "\r\n" + string(m.body) + "\r\n0\r\n\r\n"))
+	})},
+	{"chunk-missing-last", lines(func(m *message) {
+		m.chunk([]byte(strconv.FormatInt(int64(len(m.body)), 16) + "\r\n" + string(m.body) + "\r\n"))
+	})},
+	{"chunk-extension", lines(func(m *message) {
+		m.chunk([]byte(strconv.FormatInt(int64(len(m.body)), 16) + ";ext=\"x\"\r\n" + string(m.body) + "\r\n0\r\n\r\n"))
+	})},
+	{"chunk-bare-lf", lines(func(m *message) {
+		m.chunk([]byte(strconv.FormatInt(int64(len(m.body)), 16) + "\n" + string(m.body) + "\n0\n\n"))
+	})},
+	{"transfer-encoding-obfuscated", lines(func(m *message) {
+		m.chunk(chunked.Encode(m.body, 0))
+		m.setHeader("Transfer-Encoding", "Transfer-Encoding : chunked")
+	})},
+}
+
+// All applies every built-in mutation to seed
+func All(seed []byte) []Case {
+	cases := make([]Case, 0, len(Mutations))
+	for _, mut := range Mutations {
+		cases = append(cases, Case{Name: mut.Name, Raw: mut.Apply(seed)})
+	}
+	return cases
+}
+
+// Random returns n cases, each stacking 1 to depth randomly chosen mutations
+// The same rng seed always produces the same cases
+func Random(seed []byte, rng *rand.Rand, n, depth int) []Case {
+	if depth < 1 {
+		depth = 1
+	}
+	cases := make([]Case, 0, n)
+	for i := 0; i < n; i++ {
+		raw := seed
+		var names []string
+		for j := rng.Intn(depth) + 1; j > 0; j-- {
+			mut := Mutations[rng.Intn(len(Mutations))]
+			raw = mut.Apply(raw)
+			names = append(names, mut.Name)
+		}
+		cases = append(cases, Case{Name: strings.Join(names, "+"), Raw: raw})
+	}
+	return cases
+}
+
+// message is a seed split into start line, header lines and body
+type message struct {
+	start string
+	lines []string
+	eol   string
+	body  []byte
+}
+
+// lines returns a mutation that edits the split message
+// Chunked seeds are dechunked first, so edits see the decoded body
+func lines(edit func(m *message)) func(seed []byte) []byte {
+	return func(seed []byte) []byte {
+		m := split(seed)
+		edit(m)
+		return m.build()
+	}
+}
+
+// split parses seed into its parts
+func split(seed []byte) *message {
+	m := &message{eol: "\r\n"}
+
+	head := seed
+	if end := framing.HeadEnd(seed); end != -1 {
+		head = seed[:end]
+		m.body = append([]byte(nil), seed[end:]...)
+	}
+	m.start = string(head[:headers.IndexLineEnd(head)])
+
+	for _, line := range strings.Split(string(headers.HeaderSection(seed)), "\n") {
+		if line = strings.TrimSuffix(line, "\r"); line != "" {
+			m.lines = append(m.lines, line)
+		}
+	}
+
+	if i := m.index("Transfer-Encoding"); i != -1 && strings.Contains(strings.ToLower(m.lines[i]), "chunked") {
+		m.body, _ = chunked.Decode(m.body)
+		m.lines = append(m.lines[:i], m.lines[i+1:]...)
+		m.setLength(strconv.Itoa(len(m.body)))
+	}
+	return m
+}
+
+// index returns the position of the first header line named name, or -1
+func (m *message) index(name string) int {
+	for i, line := range m.lines {
+		if n, _, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(n), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// add appends raw header lines
+func (m *message) add(lines ...string) {
+	m.lines = append(m.lines, lines...)
+}
+
+// appendToFirst appends s to the first header line (or adds a header if there is none)
+func (m *message) appendToFirst(s string) {
+	if len(m.lines) == 0 {
+		m.add("X-Mutated: value")
+	}
+	m.lines[0] += s
+}
+
+// setHeader replaces the header line named name, or appends line if absent
+func (m *message) setHeader(name, line string) {
+	if i := m.index(name); i != -1 {
+		m.lines[i] = line
+		return
+	}
+	m.add(line)
+}
+
+// setLength sets the Content-Length header to value
+func (m *message) setLength(value string) {
+	m.setHeader("Content-Length", "Content-Length: "+value)
+}
+
+// chunk replaces the body with the given chunked data and switches framing to chunked
+func (m *message) chunk(body []byte) {
+	if i := m.index("Content-Length"); i != -1 {
+		m.lines = append(m.lines[:i], m.lines[i+1:]...)
+	}
+	m.setHeader("Transfer-Encoding", "Transfer-Encoding: chunked")
+	m.body = body
+}
+
+// build assembles the message
+func (m *message) build() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(m.start)
+	buf.WriteString(m.eol)
+	for _, line := range m.lines {
+		buf.WriteString(line)
+		buf.WriteString(m.eol)
+	}
+	buf.WriteString(m.eol)
+	buf.Write(m.body)
+	return buf.Bytes()
+}
+
+// mixedLineEndings alternates CRLF and LF between lines
+func mixedLineEndings(seed []byte) []byte {
+	m := split(seed)
+	var buf bytes.Buffer
+	buf.WriteString(m.start)
+	buf.WriteString("\r\n")
+	for i, line := range m.lines {
+		buf.WriteString(line)
+		if i%2 == 0 {
+			buf.WriteString("\n")
+		} else {
+			buf.WriteString("\r\n")
+		}
+	}
+	buf.WriteString("\n")
+	buf.Write(m.body)
+	return buf.Bytes()
+}
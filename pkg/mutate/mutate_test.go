@@ -0,0 +1,80 @@
+package mutate
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const seed = "POST /submit HTTP/1.1\r\nHost: example.com\r\nContent-Length: 11\r\n\r\nhello world"
+
+func caseByName(t *testing.T, cases []Case, name string) string {
+	t.Helper()
+	for _, c := range cases {
+		if c.Name == name {
+			return string(c.Raw)
+		}
+	}
+	t.Fatalf("case %q not generated", name)
+	return ""
+}
+
+func TestAll(t *testing.T) {
+	cases := All([]byte(seed))
+	if len(cases) != len(Mutations) {
+		t.Fatalf("got %d cases, want %d", len(cases), len(Mutations))
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"lf-only", "POST /submit HTTP/1.1\nHost: example.com\nContent-Length: 11\n\nhello world"},
+		{"cr-only", "POST /submit HTTP/1.1\rHost: example.com\r"},
+		{"mixed-line-endings", "POST /submit HTTP/1.1\r\nHost: example.com\nContent-Length: 11\r\n\n"},
+		{"null-in-header-value", "Host: example.com\x00\r\n"},
+		{"folded-header", "X-Folded: first\r\n second\r\n"},
+		{"start-line-truncated", "POST /submit\r\n"},
+		{"truncated-body", "Content-Length: 11\r\n\r\nhello"},
+		{"content-length-too-large", "Content-Length: 21\r\n"},
+		{"content-length-conflicting", "Content-Length: 11\r\nContent-Length: 12\r\n"},
+		{"chunk-size-oversized", "Transfer-Encoding: chunked\r\n\r\nffffffffffffffffff\r\nhello world\r\n0\r\n\r\n"},
+		{"chunk-size-invalid-hex", "\r\n\r\nzz\r\nhello world"},
+		{"chunk-missing-last", "\r\n\r\nb\r\nhello world\r\n"},
+		{"transfer-encoding-obfuscated", "Transfer-Encoding : chunked\r\n"},
+	}
+	for _, tt := range tests {
+		if raw := caseByName(t, cases, tt.name); !strings.Contains(raw, tt.want) {
+			t.Errorf("%s:\n%q\nmissing %q", tt.name, raw, tt.want)
+		}
+	}
+
+	if raw := caseByName(t, cases, "chunk-size-invalid-hex"); strings.Contains(raw, "Content-Length") {
+		t.Errorf("chunked mutation kept Content-Length: %q", raw)
+	}
+}
+
+func TestChunkedSeedIsDecoded(t *testing.T) {
+	chunkedSeed := "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n"
+	raw := caseByName(t, All([]byte(chunkedSeed)), "lf-only")
+	if raw != "HTTP/1.1 200 OK\nContent-Length: 5\n\nhello" {
+		t.Errorf("lf-only of chunked seed = %q", raw)
+	}
+}
+
+func TestRandomDeterministic(t *testing.T) {
+	a := Random([]byte(seed), rand.New(rand.NewSource(1)), 20, 3)
+	b := Random([]byte(seed), rand.New(rand.NewSource(1)), 20, 3)
+	if len(a) != 20 {
+		t.Fatalf("got %d cases", len(a))
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || !bytes.Equal(a[i].Raw, b[i].Raw) {
+			t.Fatalf("case %d differs between runs with the same seed", i)
+		}
+		if n := strings.Count(a[i].Name, "+") + 1; n > 3 {
+			t.Errorf("case %d stacks %d mutations", i, n)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package probe
+
+import "strings"
+
+// CacheBusterParam is the query parameter that gives each cache probe its own cache key
+const CacheBusterParam = "cb"
+
+// UnkeyedInput is a header commonly processed by applications but left out of cache keys
+type UnkeyedInput struct {
+	Header string
+	Value  string // Injected value; "{canary}" is replaced by the probe canary
+}
+
+// UnkeyedInputs are the default candidate headers for cache poisoning probes
+var UnkeyedInputs = []UnkeyedInput{
+	{"X-Forwarded-Host", "{canary}"},
+	{"X-Host", "{canary}"},
+	{"X-Forwarded-Server", "{canary}"},
+	{"X-Original-Host", "{canary}"},
+	{"Forwarded", "host={canary}"},
+	{"X-Forwarded-Scheme", "http"},
+	{"X-Forwarded-Proto", "http"},
+	{"X-Forwarded-Port", "1337"},
+	{"X-Original-URL", "/{canary}"},
+	{"X-Rewrite-URL", "/{canary}"},
+}
+
+// CacheProbe is an unkeyed-input probe paired with its verification request
+// Send Probe, then Verify: if the Verify response still reflects the injected
+// value, the response was cached with the unkeyed input
+type CacheProbe struct {
+	Header string // Candidate header
+	Value  string // Injected value
+	Buster string // Cache-buster value shared by Probe and Verify
+
+	Probe  []byte // Request carrying the candidate header
+	Verify []byte // The same request without the header
+}
+
+// CachePoisoning returns one probe per unkeyed input (nil = UnkeyedInputs)
+// Each probe gets its own cache buster so probes cannot poison each other,
+// or the real cache entry of the page
+func CachePoisoning(raw []byte, canary string, inputs []UnkeyedInput) []CacheProbe {
+	if inputs == nil {
+		inputs = UnkeyedInputs
+	}
+	base := parseMessage(raw)
+
+	probes := make([]CacheProbe, 0, len(inputs))
+	for _, in := range inputs {
+		buster := strings.ToLower(in.Header) + "-" + canary
+		value := strings.ReplaceAll(in.Value, "{canary}", canary)
+
+		verify := base.clone()
+		verify.del(in.Header)
+		verify.addQuery(CacheBusterParam+"="+buster, false)
+
+		probe := verify.clone()
+		probe.add(in.Header, value)
+
+		probes = append(probes, CacheProbe{
+			Header: in.Header,
+			Value:  value,
+			Buster: buster,
+			Probe:  probe.build(),
+			Verify: verify.build(),
+		})
+	}
+	return probes
+}
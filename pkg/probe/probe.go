@@ -0,0 +1,193 @@
+// Package probe generates labeled attack variants of raw requests
+//
+// Generators work on raw bytes so that variants the structured Request type
+// cannot represent (duplicate Host headers, folded lines, conflicting
+// request targets) are preserved exactly:
+//
+//	for _, v := range probe.HostHeader(raw, "attacker.example") {
+//		resp := send(v.Raw)
+//		report(v.Name, resp)
+//	}
+package probe
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/framing"
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+)
+
+// Variant is a generated request labeled for reporting
+type Variant struct {
+	Name string // Short label, e.g. "duplicate-host"
+	Raw  []byte
+}
+
+// message is a raw request split into editable parts
+// Header lines are kept verbatim so unusual formatting survives a round trip
+type message struct {
+	method  string
+	target  string
+	version string
+	lines   []string // Header lines without line endings
+	eol     string
+	body    []byte
+}
+
+// parseMessage splits a raw request into its parts
+// Data without an empty line is treated as a head without body
+func parseMessage(raw []byte) *message {
+	m := &message{eol: "\r\n"}
+
+	headEnd := framing.HeadEnd(raw)
+	head := raw
+	if headEnd != -1 {
+		head = raw[:headEnd]
+		m.body = append([]byte(nil), raw[headEnd:]...)
+	}
+	if idx := bytes.IndexByte(head, '\n'); idx != -1 && (idx == 0 || head[idx-1] != '\r') {
+		m.eol = "\n"
+	}
+
+	end := headers.IndexLineEnd(head)
+	parts := strings.SplitN(string(head[:end]), " ", 3)
+	m.method = parts[0]
+	if len(parts) > 1 {
+		m.target = parts[1]
+	}
+	if len(parts) > 2 {
+		m.version = parts[2]
+	}
+
+	for _, line := range strings.Split(string(headers.HeaderSection(raw)), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line != "" {
+			m.lines = append(m.lines, line)
+		}
+	}
+	return m
+}
+
+// clone returns a deep copy of the message
+func (m *message) clone() *message {
+	c := *m
+	c.lines = append([]string(nil), m.lines...)
+	c.body = append([]byte(nil), m.body...)
+	return &c
+}
+
+// index returns the position of the first header line named name, or -1
+func (m *message) index(name string) int {
+	for i, line := range m.lines {
+		if n, _, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(n), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// get returns the trimmed value of the first header named name
+func (m *message) get(name string) string {
+	if i := m.index(name); i != -1 {
+		_, v, _ := strings.Cut(m.lines[i], ":")
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+// set replaces the first header named name, or appends it if absent
+func (m *message) set(name, value string) {
+	if i := m.index(name); i != -1 {
+		m.lines[i] = name + ": " + value
+		return
+	}
+	m.add(name, value)
+}
+
+// add appends a header line
+func (m *message) add(name, value string) {
+	m.lines = append(m.lines, name+": "+value)
+}
+
+// insert inserts a raw header line at position i
+func (m *message) insert(i int, line string) {
+	m.lines = append(m.lines[:i], append([]string{line}, m.lines[i:]...)...)
+}
+
+// del removes every header named name
+func (m *message) del(name string) {
+	kept := m.lines[:0]
+	for _, line := range m.lines {
+		if n, _, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(n), name) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	m.lines = kept
+}
+
+// path returns the origin-form of the target (path and query)
+// Absolute-form targets are stripped of scheme and authority
+func (m *message) path() string {
+	target := m.target
+	if i := strings.Index(target, "://"); i != -1 {
+		target = target[i+3:]
+		if slash := strings.IndexByte(target, '/'); slash != -1 {
+			return target[slash:]
+		}
+		return "/"
+	}
+	return target
+}
+
+// scheme returns the scheme of an absolute-form target, or "http"
+func (m *message) scheme() string {
+	if i := strings.Index(m.target, "://"); i != -1 {
+		return m.target[:i]
+	}
+	return "http"
+}
+
+// editBody replaces the body with edit(body)
+// A chunked body is dechunked before the edit and re-chunked afterwards,
+// keeping its trailers
+func (m *message) editBody(edit func(body []byte) []byte) {
+	if !strings.Contains(strings.ToLower(m.get("Transfer-Encoding")), "chunked") {
+		m.body = edit(m.body)
+		return
+	}
+	body, _ := chunked.Decode(m.body)
+	encoded := chunked.Encode(edit(body), 0)
+	if trailers := chunked.Trailers(m.body); trailers != nil {
+		encoded = chunked.SetTrailers(encoded, trailers)
+	}
+	m.body = encoded
+}
+
+// build assembles the raw request
+// Content-Length is rewritten to match the body when present
+func (m *message) build() []byte {
+	if m.index("Content-Length") != -1 && m.index("Transfer-Encoding") == -1 {
+		m.set("Content-Length", strconv.Itoa(len(m.body)))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(m.method)
+	buf.WriteByte(' ')
+	buf.WriteString(m.target)
+	if m.version != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(m.version)
+	}
+	buf.WriteString(m.eol)
+	for _, line := range m.lines {
+		buf.WriteString(line)
+		buf.WriteString(m.eol)
+	}
+	buf.WriteString(m.eol)
+	buf.Write(m.body)
+	return buf.Bytes()
+}
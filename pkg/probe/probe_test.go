@@ -0,0 +1,259 @@
+package probe
+
+import (
+	"strings"
+	"testing"
+)
+
+const baseRequest = "POST /login?next=/ HTTP/1.1\r\nHost: target.example:8443\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 7\r\n\r\nuser=me"
+
+func variantByName(t *testing.T, variants []Variant, name string) string {
+	t.Helper()
+	for _, v := range variants {
+		if v.Name == name {
+			return string(v.Raw)
+		}
+	}
+	t.Fatalf("variant %q not generated", name)
+	return ""
+}
+
+func TestParseMessageRoundTrip(t *testing.T) {
+	m := parseMessage([]byte(baseRequest))
+	if got := string(m.build()); got != baseRequest {
+		t.Errorf("round trip = %q", got)
+	}
+
+	lf := "GET / HTTP/1.1\nHost: a\n\n"
+	if got := string(parseMessage([]byte(lf)).build()); got != lf {
+		t.Errorf("LF round trip = %q", got)
+	}
+}
+
+func TestHostHeader(t *testing.T) {
+	variants := HostHeader([]byte(baseRequest), "evil.example")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"host-replace", "\r\nHost: evil.example\r\n"},
+		{"absolute-uri", "POST http://target.example:8443/login?next=/ HTTP/1.1\r\nHost: evil.example\r\n"},
+		{"absolute-uri-attacker", "POST http://evil.example/login?next=/ HTTP/1.1\r\nHost: target.example:8443\r\n"},
+		{"duplicate-host", "Host: target.example:8443\r\nHost: evil.example\r\n"},
+		{"duplicate-host-first", "Host: evil.example\r\nHost: target.example:8443\r\n"},
+		{"x-forwarded-host", "\r\nX-Forwarded-Host: evil.example\r\n"},
+		{"forwarded", "\r\nForwarded: host=evil.example\r\n"},
+		{"port-injection", "\r\nHost: target.example:evil.example\r\n"},
+		{"port-juggling", "\r\nHost: target.example:1337\r\n"},
+		{"line-wrapped", "HTTP/1.1\r\n Host: evil.example\r\nHost: target.example:8443\r\n"},
+		{"obs-fold", "Host: target.example:8443\r\n evil.example\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := variantByName(t, variants, tt.name)
+			if !strings.Contains(raw, tt.want) {
+				t.Errorf("%s:\n%s\nmissing %q", tt.name, raw, tt.want)
+			}
+			if !strings.HasSuffix(raw, "\r\n\r\nuser=me") {
+				t.Errorf("%s: body not preserved: %q", tt.name, raw)
+			}
+		})
+	}
+
+	if len(variants) != 10+len(HostOverrideHeaders) {
+		t.Errorf("got %d variants", len(variants))
+	}
+}
+
+func TestMethodOverride(t *testing.T) {
+	variants := MethodOverride([]byte("GET /admin?x=1 HTTP/1.1\r\nHost: target.example\r\n\r\n"), "DELETE")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"x-http-method-override", "GET /admin?x=1 HTTP/1.1\r\nHost: target.example\r\nX-HTTP-Method-Override: DELETE\r\n\r\n"},
+		{"post-x-method-override", "POST /admin?x=1 HTTP/1.1\r\nHost: target.example\r\nX-Method-Override: DELETE\r\nContent-Length: 0\r\n\r\n"},
+		{"query-method", "GET /admin?x=1&_method=DELETE HTTP/1.1\r\n"},
+		{"body-method", "POST /admin?x=1 HTTP/1.1\r\nHost: target.example\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 14\r\n\r\n_method=DELETE"},
+		{"method-lowercase", "delete /admin?x=1 HTTP/1.1\r\n"},
+		{"method-padded", "DELETE\t /admin?x=1 HTTP/1.1\r\n"},
+		{"method-direct", "DELETE /admin?x=1 HTTP/1.1\r\n"},
+	}
+	for _, tt := range tests {
+		if raw := variantByName(t, variants, tt.name); !strings.Contains(raw, tt.want) {
+			t.Errorf("%s:\n%q\nmissing %q", tt.name, raw, tt.want)
+		}
+	}
+
+	// Existing form bodies keep their parameters
+	raw := variantByName(t, MethodOverride([]byte(baseRequest), "PUT"), "body-method")
+	if !strings.HasSuffix(raw, "Content-Length: 19\r\n\r\n_method=PUT&user=me") {
+		t.Errorf("body-method with form body = %q", raw)
+	}
+
+	// Chunked bodies are re-chunked
+	chunkedForm := "POST /a HTTP/1.1\r\nContent-Type: application/x-www-form-urlencoded\r\nTransfer-Encoding: chunked\r\n\r\n7\r\nuser=me\r\n0\r\n\r\n"
+	raw = variantByName(t, MethodOverride([]byte(chunkedForm), "PUT"), "body-method")
+	if !strings.HasSuffix(raw, "\r\n\r\n13\r\n_method=PUT&user=me\r\n0\r\n\r\n") {
+		t.Errorf("body-method with chunked body = %q", raw)
+	}
+}
+
+func TestParameterPollution(t *testing.T) {
+	variants := ParameterPollution([]byte(baseRequest), "user", "admin")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"query-first", "POST /login?user=admin&next=/ HTTP/1.1\r\n"},
+		{"query-last", "POST /login?next=/&user=admin HTTP/1.1\r\n"},
+		{"query-semicolon", "POST /login?next=/;user=admin HTTP/1.1\r\n"},
+		{"query-array", "POST /login?next=/&user[]=admin HTTP/1.1\r\n"},
+		{"query-encoded-name", "POST /login?next=/&%75%73%65%72=admin HTTP/1.1\r\n"},
+		{"query-uppercase-name", "POST /login?next=/&USER=admin HTTP/1.1\r\n"},
+		{"body-first", "Content-Length: 18\r\n\r\nuser=admin&user=me"},
+		{"body-last", "Content-Length: 18\r\n\r\nuser=me&user=admin"},
+		{"cookie", "\r\nCookie: user=admin\r\n"},
+	}
+	for _, tt := range tests {
+		if raw := variantByName(t, variants, tt.name); !strings.Contains(raw, tt.want) {
+			t.Errorf("%s:\n%q\nmissing %q", tt.name, raw, tt.want)
+		}
+	}
+
+	json := "PUT /api HTTP/1.1\r\nHost: a\r\nCookie: sid=1\r\nContent-Type: application/json\r\nContent-Length: 13\r\n\r\n{\"user\":\"me\"}"
+	variants = ParameterPollution([]byte(json), "user", "admin")
+	jsonTests := []struct {
+		name string
+		want string
+	}{
+		{"json-first", "Content-Length: 28\r\n\r\n{\"user\":\"admin\",\"user\":\"me\"}"},
+		{"json-last", "{\"user\":\"me\",\"user\":\"admin\"}"},
+		{"cookie-first", "Cookie: user=admin; sid=1\r\n"},
+		{"cookie-last", "Cookie: sid=1; user=admin\r\n"},
+	}
+	for _, tt := range jsonTests {
+		if raw := variantByName(t, variants, tt.name); !strings.Contains(raw, tt.want) {
+			t.Errorf("%s:\n%q\nmissing %q", tt.name, raw, tt.want)
+		}
+	}
+	for _, v := range variants {
+		if strings.HasPrefix(v.Name, "body-") {
+			t.Errorf("JSON request should not get form body variant %s", v.Name)
+		}
+	}
+
+	// Chunked form bodies are dechunked, edited and re-chunked
+	chunkedForm := "POST /a HTTP/1.1\r\nContent-Type: application/x-www-form-urlencoded\r\nTransfer-Encoding: chunked\r\n\r\n7\r\nuser=me\r\n0\r\nX-T: 1\r\n\r\n"
+	raw := variantByName(t, ParameterPollution([]byte(chunkedForm), "user", "admin"), "body-last")
+	if !strings.HasSuffix(raw, "\r\n\r\n12\r\nuser=me&user=admin\r\n0\r\nX-T: 1\r\n\r\n") {
+		t.Errorf("body-last with chunked body = %q", raw)
+	}
+}
+
+func TestCachePoisoning(t *testing.T) {
+	raw := []byte("GET /home?lang=en HTTP/1.1\r\nHost: target.example\r\nX-Forwarded-Host: old\r\n\r\n")
+	probes := CachePoisoning(raw, "c4n4ry", nil)
+	if len(probes) != len(UnkeyedInputs) {
+		t.Fatalf("got %d probes", len(probes))
+	}
+
+	p := probes[0]
+	if p.Header != "X-Forwarded-Host" || p.Value != "c4n4ry" || p.Buster != "x-forwarded-host-c4n4ry" {
+		t.Errorf("probe = %+v", p)
+	}
+	wantVerify := "GET /home?lang=en&cb=x-forwarded-host-c4n4ry HTTP/1.1\r\nHost: target.example\r\n\r\n"
+	if string(p.Verify) != wantVerify {
+		t.Errorf("Verify = %q", p.Verify)
+	}
+	wantProbe := "GET /home?lang=en&cb=x-forwarded-host-c4n4ry HTTP/1.1\r\nHost: target.example\r\nX-Forwarded-Host: c4n4ry\r\n\r\n"
+	if string(p.Probe) != wantProbe {
+		t.Errorf("Probe = %q", p.Probe)
+	}
+
+	busters := make(map[string]bool)
+	for _, p := range probes {
+		if busters[p.Buster] {
+			t.Errorf("duplicate cache buster %q", p.Buster)
+		}
+		busters[p.Buster] = true
+	}
+
+	custom := CachePoisoning(raw, "x", []UnkeyedInput{{"X-Original-URL", "/{canary}"}})
+	if len(custom) != 1 || !strings.Contains(string(custom[0].Probe), "\r\nX-Original-URL: /x\r\n") {
+		t.Errorf("custom probes = %+v", custom)
+	}
+}
+
+func TestClassifyRedirect(t *testing.T) {
+	tests := []struct {
+		location string
+		want     RedirectKind
+	}{
+		{"", RedirectNone},
+		{"/account", RedirectSameOrigin},
+		{"login?next=/", RedirectSameOrigin},
+		{"https://www.example.com/home", RedirectSameOrigin},
+		{"https://WWW.EXAMPLE.COM:8443/", RedirectSameOrigin},
+		{"https://api.www.example.com/", RedirectSubdomain},
+		{"https://example.com/", RedirectSubdomain},
+		{"https://static.example.com/", RedirectSubdomain},
+		{"https://example.com.evil.net/", RedirectExternal},
+		{"http://evil.net", RedirectExternal},
+		{"//evil.net/", RedirectProtocolRelative},
+		{`/\evil.net`, RedirectProtocolRelative},
+		{"javascript:alert(1)", RedirectDangerousScheme},
+		{"/%0d%0aSet-Cookie:x=1", RedirectHeaderInjected},
+		{"/\r\nX: y", RedirectHeaderInjected},
+	}
+	for _, tt := range tests {
+		if got := ClassifyRedirect("www.example.com:8443", tt.location); got != tt.want {
+			t.Errorf("ClassifyRedirect(%q) = %s, want %s", tt.location, got, tt.want)
+		}
+	}
+}
+
+func TestOpenRedirect(t *testing.T) {
+	raw := []byte("GET /login?next=/home&x=1 HTTP/1.1\r\nHost: target.example\r\n\r\n")
+	variants := OpenRedirect(raw, "next", "evil.net")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"absolute", "GET /login?next=https://evil.net/&x=1 HTTP/1.1\r\n"},
+		{"protocol-relative", "?next=//evil.net/&x=1 "},
+		{"userinfo", "?next=https://target.example@evil.net/&x=1 "},
+		{"crlf", "?next=/%0d%0aLocation:%20https://evil.net/&x=1 "},
+		{"fragment", "?next=https://evil.net/%23target.example&x=1 "},
+	}
+	for _, tt := range tests {
+		if raw := variantByName(t, variants, tt.name); !strings.Contains(raw, tt.want) {
+			t.Errorf("%s:\n%q\nmissing %q", tt.name, raw, tt.want)
+		}
+	}
+
+	// Form body parameters are replaced in place
+	form := "POST /go HTTP/1.1\r\nHost: a\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 13\r\n\r\nurl=/home&a=b"
+	got := variantByName(t, OpenRedirect([]byte(form), "url", "evil.net"), "protocol-relative")
+	if !strings.HasSuffix(got, "Content-Length: 19\r\n\r\nurl=//evil.net/&a=b") {
+		t.Errorf("form body variant = %q", got)
+	}
+
+	// Chunked form bodies are decoded, edited and chunked again
+	chunkedForm := "POST /go HTTP/1.1\r\nHost: a\r\nContent-Type: application/x-www-form-urlencoded\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"3\r\nu=1\r\na\r\n&next=/home\r\n0\r\n\r\n"
+	got = variantByName(t, OpenRedirect([]byte(chunkedForm), "next", "evil.net"), "protocol-relative")
+	if !strings.HasSuffix(got, "\r\n\r\n14\r\nu=1&next=//evil.net/\r\n0\r\n\r\n") {
+		t.Errorf("chunked form body variant = %q", got)
+	}
+
+	// Missing parameters are added to the query
+	got = variantByName(t, OpenRedirect([]byte("GET / HTTP/1.1\r\nHost: a\r\n\r\n"), "r", "evil.net"), "absolute")
+	if !strings.HasPrefix(got, "GET /?r=https://evil.net/ HTTP/1.1") {
+		t.Errorf("added parameter variant = %q", got)
+	}
+}
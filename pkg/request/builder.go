@@ -2,48 +2,63 @@ package request
 
 import (
 	"bytes"
-	"fmt"
+	"strconv"
+
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
 )
 
 // Build reconstructs the HTTP request from parsed components
 // Preserves original line endings when available
+// In strict mode (see StrictBuild) an unmodified request returns a copy of Raw
 func (r *Request) Build() []byte {
-	var buf bytes.Buffer
+	out := r.build()
+	if r.pristine != nil && bytes.Equal(out, r.pristine) {
+		return append([]byte(nil), r.Raw...)
+	}
+	return out
+}
 
+// build implements Build without strict mode
+func (r *Request) build() []byte {
 	// Use original line separator or default to CRLF
 	lineSep := r.LineSeparator
 	if lineSep == "" {
 		lineSep = "\r\n"
 	}
 
+	// Empty line between headers and body (use same separator as headers)
+	// Use the same line ending as the last header for the blank line, otherwise lineSep
+	headerEnd := r.Headers.LastLineEnding()
+	if headerEnd == "" {
+		headerEnd = lineSep
+	}
+
+	body := r.Body
+	if r.IsBodyChunked && r.Trailers != nil {
+		body = chunked.SetTrailers(body, r.Trailers)
+	}
+
+	// Pre-size the output so the whole message is built with a single allocation
+	size := len(r.Method) + 1 + len(r.URL) + 1 + len(r.Version) + len(lineSep) +
+		r.Headers.BuildSize() + len(headerEnd) + len(body)
+	buf := make([]byte, 0, size)
+
 	// Request line
-	buf.WriteString(r.Method)
-	buf.WriteString(" ")
-	buf.WriteString(r.URL)
-	buf.WriteString(" ")
-	buf.WriteString(r.Version)
-	buf.WriteString(lineSep)
+	buf = append(buf, r.Method...)
+	buf = append(buf, ' ')
+	buf = append(buf, r.URL...)
+	buf = append(buf, ' ')
+	buf = append(buf, r.Version...)
+	buf = append(buf, lineSep...)
 
 	// Headers (in preserved order with original formatting)
-	headerBytes := r.Headers.Build()
-	buf.Write(headerBytes)
-
-	// Empty line between headers and body (use same separator as headers)
-	// Check if last header has a specific line ending, otherwise use lineSep
-	allHeaders := r.Headers.All()
-	if len(allHeaders) > 0 && allHeaders[len(allHeaders)-1].LineEnding != "" {
-		// Use the same line ending as the last header for the blank line
-		buf.WriteString(allHeaders[len(allHeaders)-1].LineEnding)
-	} else {
-		buf.WriteString(lineSep)
-	}
+	buf = r.Headers.AppendBuild(buf)
+	buf = append(buf, headerEnd...)
 
 	// Body
-	if len(r.Body) > 0 {
-		buf.Write(r.Body)
-	}
+	buf = append(buf, body...)
 
-	return buf.Bytes()
+	return buf
 }
 
 // BuildString reconstructs the HTTP request as a string
@@ -54,7 +69,7 @@ func (r *Request) BuildString() string {
 // UpdateContentLength updates the Content-Length header based on body size
 func (r *Request) UpdateContentLength() {
 	if len(r.Body) > 0 {
-		r.Headers.Set("Content-Length", fmt.Sprintf("%d", len(r.Body)))
+		r.Headers.Set("Content-Length", strconv.Itoa(len(r.Body)))
 	} else {
 		r.Headers.Del("Content-Length")
 	}
@@ -0,0 +1,142 @@
+package request
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/encoder"
+)
+
+// CanonicalOptions selects what Canonicalize includes
+type CanonicalOptions struct {
+	Headers   []string // Header names to include (case-insensitive); missing headers get an empty value
+	SortQuery bool     // Sort query parameters by name, then value
+}
+
+// CanonicalHeader is a header in canonical form
+type CanonicalHeader struct {
+	Name  string // Lowercase
+	Value string // Trimmed, inner whitespace collapsed to single spaces
+}
+
+// Canonical is the normalized form of a request
+// Equivalent requests (host case, default port, percent-encoding case,
+// header whitespace) have the same Canonical form
+type Canonical struct {
+	Method  string            // Uppercase
+	Scheme  string            // "http" or "https"
+	Host    string            // Lowercase, without the scheme's default port
+	Path    string            // Percent-encoding normalized; "/" when empty
+	Query   string            // Percent-encoding normalized, without "?"
+	Headers []CanonicalHeader // Sorted by name
+}
+
+// Canonicalize returns the normalized form of the request
+// Absolute-form targets are reduced to their scheme, host, path and query;
+// origin-form targets take the host from the Host header, with https when it
+// has port 443 and http otherwise
+// The fragment is dropped
+func (r *Request) Canonicalize(opts CanonicalOptions) *Canonical {
+	scheme, host, target := "http", r.GetHost(), r.URL
+	if u, err := url.Parse(r.URL); err == nil && u.Scheme != "" && u.Host != "" {
+		scheme, host, target = strings.ToLower(u.Scheme), u.Host, u.RequestURI()
+	} else if _, port, err := net.SplitHostPort(host); err == nil && port == "443" {
+		scheme = "https"
+	}
+	target, _, _ = strings.Cut(target, "#")
+	path, query, _ := strings.Cut(target, "?")
+
+	c := &Canonical{
+		Method: strings.ToUpper(r.Method),
+		Scheme: scheme,
+		Host:   canonicalHost(scheme, host),
+		Path:   encoder.NormalizePercent(path),
+		Query:  canonicalQuery(query, opts.SortQuery),
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+
+	names := make([]string, 0, len(opts.Headers))
+	for _, name := range opts.Headers {
+		names = append(names, strings.ToLower(strings.TrimSpace(name)))
+	}
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		value := strings.Join(strings.Fields(r.Headers.Get(name)), " ")
+		c.Headers = append(c.Headers, CanonicalHeader{Name: name, Value: value})
+	}
+	return c
+}
+
+// Target returns the canonical path and query
+func (c *Canonical) Target() string {
+	if c.Query == "" {
+		return c.Path
+	}
+	return c.Path + "?" + c.Query
+}
+
+// String returns the canonical representation:
+// "METHOD scheme://host/target", then one "name: value" line per selected header
+func (c *Canonical) String() string {
+	var b strings.Builder
+	b.WriteString(c.Method)
+	b.WriteString(" ")
+	b.WriteString(c.Scheme)
+	b.WriteString("://")
+	b.WriteString(c.Host)
+	b.WriteString(c.Target())
+	for _, h := range c.Headers {
+		b.WriteString("\n")
+		b.WriteString(h.Name)
+		b.WriteString(": ")
+		b.WriteString(h.Value)
+	}
+	return b.String()
+}
+
+// Bytes returns String as bytes, for hashing and signing
+func (c *Canonical) Bytes() []byte {
+	return []byte(c.String())
+}
+
+// canonicalHost lowercases host and strips the default port of scheme
+func canonicalHost(scheme, host string) string {
+	host = strings.ToLower(host)
+	if h, port, err := net.SplitHostPort(host); err == nil && port == defaultPorts[scheme] {
+		host = h
+		if strings.Contains(h, ":") {
+			host = "[" + h + "]"
+		}
+	}
+	return host
+}
+
+// defaultPorts are the ports canonicalHost strips, by scheme
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// canonicalQuery normalizes the percent-encoding of each parameter and
+// optionally sorts them by name, then value
+func canonicalQuery(query string, sorted bool) string {
+	if query == "" {
+		return ""
+	}
+	params := strings.Split(query, "&")
+	for i, p := range params {
+		params[i] = encoder.NormalizePercent(p)
+	}
+	if sorted {
+		slices.SortStableFunc(params, func(a, b string) int {
+			an, av, _ := strings.Cut(a, "=")
+			bn, bv, _ := strings.Cut(b, "=")
+			if c := strings.Compare(an, bn); c != 0 {
+				return c
+			}
+			return strings.Compare(av, bv)
+		})
+	}
+	return strings.Join(params, "&")
+}
@@ -0,0 +1,73 @@
+package request
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
+)
+
+// ============================================================================
+// Form Parameters (application/x-www-form-urlencoded bodies)
+// ============================================================================
+
+// IsFormURLEncoded reports whether Content-Type is application/x-www-form-urlencoded
+func (r *Request) IsFormURLEncoded() bool {
+	mediaType, _, _ := strings.Cut(r.GetContentType(), ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), "application/x-www-form-urlencoded")
+}
+
+// ParseFormParams extracts form parameters from a urlencoded body
+// Updates FormParams; other content types leave it empty
+func (r *Request) ParseFormParams() {
+	r.FormParams = url.Values{}
+	if !r.IsFormURLEncoded() || len(r.Body) == 0 {
+		return
+	}
+
+	body := r.Body
+	if r.IsBodyChunked {
+		body, _ = chunked.Decode(body)
+	}
+
+	// Best effort: ParseQuery keeps every pair it could decode
+	params, _ := url.ParseQuery(string(body))
+	r.FormParams = params
+}
+
+// GetFormParam returns first value for form parameter key
+func (r *Request) GetFormParam(key string) string {
+	return r.FormParams.Get(key)
+}
+
+// GetFormParams returns all values for form parameter key
+func (r *Request) GetFormParams(key string) []string {
+	return r.FormParams[key]
+}
+
+// SetFormParam sets form parameter (replaces existing)
+func (r *Request) SetFormParam(key, value string) {
+	r.FormParams.Set(key, value)
+}
+
+// AddFormParam adds form parameter (allows duplicates)
+func (r *Request) AddFormParam(key, value string) {
+	r.FormParams.Add(key, value)
+}
+
+// DeleteFormParam removes form parameter
+func (r *Request) DeleteFormParam(key string) {
+	r.FormParams.Del(key)
+}
+
+// RebuildForm rebuilds the body from FormParams and updates Content-Length
+// (or re-chunks a chunked body)
+// This must be called after modifying form parameters
+func (r *Request) RebuildForm() {
+	body := []byte(r.FormParams.Encode())
+	if r.IsBodyChunked {
+		r.Body = chunked.Encode(body, 0)
+		return
+	}
+	r.SetBody(body)
+}
@@ -0,0 +1,37 @@
+package request
+
+import (
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/multipart"
+)
+
+// MultipartForm parses the body using the boundary from Content-Type
+// A chunked body is dechunked first; the request itself is not modified
+// Returns multipart.ErrMissingBoundary if Content-Type is not multipart
+func (r *Request) MultipartForm() (*multipart.Form, error) {
+	boundary, ok := multipart.Boundary(r.GetContentType())
+	if !ok {
+		return nil, multipart.ErrMissingBoundary
+	}
+	body := r.Body
+	if r.IsBodyChunked {
+		body, _ = chunked.Decode(body)
+	}
+	return multipart.Parse(body, boundary)
+}
+
+// SetMultipartForm sets the body to the built form and updates Content-Length
+// (or re-chunks a chunked body, as RebuildForm does)
+// Content-Type is only rewritten when its boundary differs from the form's,
+// so an unmodified form keeps the original header
+func (r *Request) SetMultipartForm(form *multipart.Form) {
+	if boundary, _ := multipart.Boundary(r.GetContentType()); boundary != form.Boundary {
+		r.Headers.Set("Content-Type", form.ContentType())
+	}
+	body := form.Build()
+	if r.IsBodyChunked {
+		r.Body = chunked.Encode(body, 0)
+		return
+	}
+	r.SetBody(body)
+}
@@ -2,31 +2,166 @@ package request
 
 import (
 	"bufio"
+	"bytes"
 	"io"
+	"strconv"
 	"strings"
 
+	"github.com/WhileEndless/go-httptools/pkg/bufpool"
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
 	"github.com/WhileEndless/go-httptools/pkg/compression"
 	"github.com/WhileEndless/go-httptools/pkg/cookies"
 	"github.com/WhileEndless/go-httptools/pkg/errors"
 	"github.com/WhileEndless/go-httptools/pkg/headers"
 )
 
+// ParseOptions configures request parsing
+type ParseOptions struct {
+	// AutoDecodeChunked automatically decodes chunked transfer encoding
+	// When true, the Body field will contain the decoded content
+	// When false (default), chunked bodies remain encoded and IsBodyChunked=true
+	AutoDecodeChunked bool
+
+	// PreserveChunkedTrailers stores trailers from chunked encoding as headers
+	// Only effective when AutoDecodeChunked is true
+	PreserveChunkedTrailers bool
+
+	// MaxHeaderCount limits the number of header lines (0 = no limit)
+	// Requests with more lines fail with an ErrorTypeMalformedHeader error
+	MaxHeaderCount int
+
+	// StrictMode turns every anomaly the parser would tolerate (see
+	// ParseWithDiagnostics) into an error located at the anomaly
+	// DiagBodyTruncated is not an anomaly: the caller asked for it with MaxBodySize
+	StrictMode bool
+
+	// ReadBufferSize is the bufio.Reader size used by the reader-based functions
+	// (0 = headers.DefaultReadBufferSize)
+	// Ignored when the reader passed in is already a *bufio.Reader
+	ReadBufferSize int
+
+	// MaxBodySize limits the body to this many bytes (0 = no limit)
+	// Longer bodies are cut and the request is marked Truncated, with the head
+	// fully usable; the reader-based functions read and discard the rest of a
+	// framed body, so the reader stays positioned at the next message
+	// The limit applies to the body as received (chunked or compressed)
+	MaxBodySize int64
+}
+
 // Parse parses raw HTTP request data with fault tolerance
 // Preserves original header formatting and line endings
+// Tolerated anomalies are listed in ParseWarnings
 func Parse(data []byte) (*Request, error) {
-	return parse(data)
+	return parse(data, ParseOptions{})
+}
+
+// ParseWithOptions parses raw HTTP request data with custom options
+func ParseWithOptions(data []byte, opts ParseOptions) (*Request, error) {
+	return parse(data, opts)
+}
+
+// ParseWithDiagnostics parses raw HTTP request data and never fails
+// Every anomaly tolerated by the fault-tolerant parser is reported as a diagnostic
+// If the data cannot be parsed at all, an empty request holding Raw is returned
+// with a single errors.DiagUnparseable diagnostic
+func ParseWithDiagnostics(data []byte, opts ParseOptions) (*Request, []errors.Diagnostic) {
+	req, err := parse(data, opts)
+	if err != nil {
+		var diag errors.Diagnostics
+		diag.AddError(err)
+		req = NewRequest()
+		req.Raw = append([]byte(nil), data...)
+		req.ParseWarnings = diag
+	}
+	return req, req.ParseWarnings
 }
 
 // ParseReader parses an HTTP request from an io.Reader
-// Reads all data from the reader and parses it
-// Suitable for integration with streaming sources like buffers or file readers
+// Reads the head incrementally, then exactly the framed body (Content-Length or
+// chunked; a request with neither has no body, RFC 9112 section 6.3) and parses it
+// Pass a *bufio.Reader to parse several pipelined requests from the same stream
+// Memory use is bounded by the header size plus the framed body
 func ParseReader(r io.Reader) (*Request, error) {
-	data, err := io.ReadAll(r)
+	return ParseReaderWithOptions(r, ParseOptions{})
+}
+
+// ParseReaderWithOptions parses an HTTP request from an io.Reader with custom options
+func ParseReaderWithOptions(r io.Reader, opts ParseOptions) (*Request, error) {
+	br := headers.NewReader(r, opts.ReadBufferSize)
+
+	// The message is read into a pooled buffer; parse copies it into Raw
+	data, err := readMessage(bufpool.GetBytes(), br, opts.MaxBodySize)
+	defer bufpool.PutBytes(data)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, errors.WrapError(errors.ErrorTypeInvalidFormat,
+			"failed to read from reader: "+err.Error(), "parseReader", nil, err)
+	}
+	return parse(data, opts)
+}
+
+// readMessage reads one request (head plus framed body) from br
+// Truncated messages are returned with io.ErrUnexpectedEOF so they can still be parsed
+// With maxBody > 0, at most maxBody+1 body bytes are kept, so parse can tell
+// that the body exceeded the limit; the rest is read and discarded
+// The message is appended to dst
+func readMessage(dst []byte, br *bufio.Reader, maxBody int64) ([]byte, error) {
+	data, err := headers.AppendHead(dst, br)
 	if err != nil {
-		return nil, errors.NewError(errors.ErrorTypeInvalidFormat,
-			"failed to read from reader: "+err.Error(), "parseReader", nil)
+		return data, err
+	}
+
+	head := data[len(dst):]
+	lineEnd := bytes.IndexByte(head, '\n')
+	parsedHeaders, _ := headers.ParseHeaders(head[lineEnd+1:])
+	if parsedHeaders == nil {
+		parsedHeaders = headers.NewOrderedHeaders()
+	}
+	framing := headers.GetFraming(parsedHeaders)
+
+	limit := int64(0)
+	if maxBody > 0 {
+		limit = maxBody + 1
+	}
+
+	switch {
+	case framing.Chunked:
+		body, err := chunked.ReadRawLimit(br, limit)
+		return append(data, body...), err
+	case framing.ContentLength >= 0:
+		return headers.ReadBodyLimit(data, br, framing.ContentLength, limit)
+	default:
+		// No framing: a request without Content-Length or Transfer-Encoding
+		// has no body, so the next bytes belong to the next request
+		return data, nil
 	}
-	return parse(data)
+}
+
+// limitBody applies ParseOptions.MaxBodySize to the received body
+// Returns the body cut to maxBody bytes and whether the message body was longer:
+// cut here, or declared longer by Content-Length (ParseReader discards the rest)
+func limitBody(body []byte, h *headers.OrderedHeaders, maxBody int64) ([]byte, bool) {
+	if maxBody <= 0 {
+		return body, false
+	}
+	if int64(len(body)) > maxBody {
+		return body[:maxBody], true
+	}
+	f := headers.GetFraming(h)
+	return body, !f.Chunked && f.ContentLength > maxBody
+}
+
+// capBody cuts data to the head plus maxBody+1 body bytes, so that parse
+// copies no more of an oversized body than limitBody needs to detect it
+func capBody(data []byte, maxBody int64) []byte {
+	headerEnd := findHeaderEndIndex(data)
+	if maxBody <= 0 || headerEnd < 0 {
+		return data
+	}
+	bodyStart := headerEnd + getHeaderSeparatorLength(data, headerEnd)
+	if int64(len(data)-bodyStart) > maxBody {
+		return data[:bodyStart+int(maxBody)+1]
+	}
+	return data
 }
 
 // ParseHeadersFromReader parses only the HTTP request headers from an io.Reader
@@ -44,7 +179,13 @@ func ParseReader(r io.Reader) (*Request, error) {
 //	// Body can be streamed separately
 //	io.Copy(outputFile, bodyReader)
 func ParseHeadersFromReader(r io.Reader) (*Request, io.Reader, error) {
-	br := bufio.NewReader(r)
+	return ParseHeadersFromReaderWithOptions(r, ParseOptions{})
+}
+
+// ParseHeadersFromReaderWithOptions parses only the HTTP request headers from an io.Reader with options
+// Returns the parsed Request (without body) and an io.Reader for the remaining body data
+func ParseHeadersFromReaderWithOptions(r io.Reader, opts ParseOptions) (*Request, io.Reader, error) {
+	br := headers.NewReader(r, opts.ReadBufferSize)
 
 	req := NewRequest()
 
@@ -65,7 +206,7 @@ func ParseHeadersFromReader(r io.Reader) (*Request, io.Reader, error) {
 	}
 
 	// Parse request line
-	if err := req.parseRequestLine(requestLine); err != nil {
+	if err := req.parseRequestLine(requestLine, nil); err != nil {
 		return nil, nil, err
 	}
 
@@ -85,6 +226,10 @@ func ParseHeadersFromReader(r io.Reader) (*Request, io.Reader, error) {
 		}
 
 		headerLines = append(headerLines, line)
+		if opts.MaxHeaderCount > 0 && len(headerLines) > opts.MaxHeaderCount {
+			return nil, nil, errors.NewError(errors.ErrorTypeMalformedHeader,
+				"more than "+strconv.Itoa(opts.MaxHeaderCount)+" header lines", "parseHeadersFromReader", nil)
+		}
 
 		if err == io.EOF {
 			break
@@ -119,25 +264,28 @@ func ParseHeadersFromReader(r io.Reader) (*Request, io.Reader, error) {
 }
 
 // parse is the internal implementation for parsing HTTP request data
-func parse(data []byte) (*Request, error) {
+// Tolerated anomalies are collected in ParseWarnings
+func parse(data []byte, opts ParseOptions) (*Request, error) {
 	if len(data) == 0 {
 		return nil, errors.NewError(errors.ErrorTypeInvalidFormat,
 			"empty request data", "parse", data)
 	}
+	diag := new(errors.Diagnostics)
 
 	req := NewRequest()
+	data = capBody(data, opts.MaxBodySize)
 	req.Raw = make([]byte, len(data))
 	copy(req.Raw, data)
 
+	// Everything below slices Raw, so nothing refers to the caller's data
+	data = req.Raw
+
 	// Find first line ending to extract request line and detect line separator
-	requestLineEnd := 0
-	for requestLineEnd < len(data) && data[requestLineEnd] != '\n' && data[requestLineEnd] != '\r' {
-		requestLineEnd++
-	}
+	requestLineEnd := headers.IndexLineEnd(data)
 
 	if requestLineEnd == 0 {
-		return nil, errors.NewError(errors.ErrorTypeInvalidFormat,
-			"no request line found", "parse", data)
+		return nil, errors.NewErrorAt(errors.ErrorTypeInvalidFormat,
+			"no request line found", "parse", data, 0)
 	}
 
 	// Detect line separator from first line
@@ -149,12 +297,15 @@ func parse(data []byte) (*Request, error) {
 		} else if data[requestLineEnd] == '\r' {
 			req.LineSeparator = "\r"
 		}
+		if req.LineSeparator != "\r\n" {
+			diag.Add(errors.DiagBareLineEnding, requestLineEnd, "line separator %q instead of CRLF", req.LineSeparator)
+		}
 	}
 
 	// Parse request line (Method URL Version)
 	requestLine := string(data[:requestLineEnd])
-	if err := req.parseRequestLine(requestLine); err != nil {
-		return nil, err
+	if err := req.parseRequestLine(requestLine, diag); err != nil {
+		return nil, errors.Locate(err, data, 0)
 	}
 
 	// Skip past request line and its line ending
@@ -169,6 +320,7 @@ func parse(data []byte) (*Request, error) {
 	// Find header section end
 	headerEnd := findHeaderEndIndex(data)
 	if headerEnd < 0 {
+		diag.Add(errors.DiagMissingHeaderEnd, len(data), "no empty line after headers, treating all data as head")
 		headerEnd = len(data)
 	}
 
@@ -191,7 +343,11 @@ func parse(data []byte) (*Request, error) {
 	// Extract header section with original line endings preserved
 	if headerStart < headerDataEnd {
 		headerData := data[headerStart:headerDataEnd]
-		parsedHeaders, err := headers.ParseHeaders(headerData)
+		if offset := excessHeaderLine(headerData, opts.MaxHeaderCount); offset != -1 {
+			return nil, errors.NewErrorAt(errors.ErrorTypeMalformedHeader,
+				"more than "+strconv.Itoa(opts.MaxHeaderCount)+" header lines", "parse", data, headerStart+offset)
+		}
+		parsedHeaders, err := headers.ParseHeadersWithDiagnostics(headerData, headerStart, diag)
 		if err != nil {
 			req.Headers = headers.NewOrderedHeaders()
 		} else {
@@ -201,9 +357,10 @@ func parse(data []byte) (*Request, error) {
 
 	// Read body (everything after headers)
 	var bodyBytes []byte
+	bodyStart := len(data)
 	if headerEnd >= 0 && headerEnd < len(data) {
 		separatorLen := getHeaderSeparatorLength(data, headerEnd)
-		bodyStart := headerEnd + separatorLen
+		bodyStart = headerEnd + separatorLen
 		if bodyStart < len(data) {
 			bodyBytes = data[bodyStart:]
 		} else {
@@ -213,9 +370,44 @@ func parse(data []byte) (*Request, error) {
 		bodyBytes = []byte{}
 	}
 
+	bodyBytes, req.Truncated = limitBody(bodyBytes, req.Headers, opts.MaxBodySize)
+	if req.Truncated {
+		diag.Add(errors.DiagBodyTruncated, bodyStart+len(bodyBytes), "body truncated at %d bytes", len(bodyBytes))
+		req.Raw = req.Raw[:bodyStart+len(bodyBytes)]
+	} else {
+		headers.CheckFraming(req.Headers, len(bodyBytes), bodyStart, diag)
+	}
+
 	// Store raw body
 	req.RawBody = bodyBytes
 
+	// Auto-parse Transfer-Encoding header
+	req.parseTransferEncoding()
+	if req.IsBodyChunked {
+		req.Trailers = chunked.Trailers(bodyBytes)
+	}
+
+	// Auto-decode transfer codings if requested, as response.ParseWithOptions does
+	var trailers map[string]string
+	var keptCodings []string // Codings still applied to the body
+	transferDecoded := req.IsBodyChunked && opts.AutoDecodeChunked
+	if transferDecoded {
+		bodyBytes, trailers = chunked.Decode(bodyBytes)
+		codings := compression.TransferCodings(req.Headers.Get("Transfer-Encoding"))
+		decoded, err := compression.DecodeTransferCodings(bodyBytes, codings)
+		if err != nil {
+			diag.Add(errors.DiagDecompressionFailed, bodyStart,
+				"transfer coding %q not decoded, body only dechunked", req.Headers.Get("Transfer-Encoding"))
+			for _, c := range codings {
+				if c != "chunked" {
+					keptCodings = append(keptCodings, c)
+				}
+			}
+		} else {
+			bodyBytes = decoded
+		}
+	}
+
 	// Detect compression - first try header, then magic bytes
 	contentEncoding := req.GetContentEncoding()
 	compressionType := compression.CompressionNone
@@ -238,6 +430,8 @@ func parse(data []byte) (*Request, error) {
 		decompressed, err := compression.Decompress(bodyBytes, compressionType)
 		if err != nil {
 			// On decompression error, keep raw body (fault tolerance)
+			diag.Add(errors.DiagDecompressionFailed, bodyStart,
+				"%s decompression failed, body kept as is", compression.CompressionTypeToString(compressionType))
 			req.Body = bodyBytes
 			req.Compressed = false
 			req.DetectedCompression = compression.CompressionNone
@@ -250,8 +444,37 @@ func parse(data []byte) (*Request, error) {
 		req.Compressed = false
 	}
 
-	// Auto-parse Transfer-Encoding header
-	req.parseTransferEncoding()
+	if transferDecoded {
+		req.IsBodyChunked = false
+
+		// The message is no longer chunked, so a compressed RawBody must hold
+		// the compressed content rather than the chunked wire bytes
+		if req.Compressed {
+			req.RawBody = bodyBytes
+		}
+
+		// Preserve trailers as headers if requested
+		if opts.PreserveChunkedTrailers && len(trailers) > 0 {
+			for name, value := range trailers {
+				req.Headers.Set(name, value)
+			}
+		}
+
+		if len(keptCodings) > 0 {
+			// Keep Transfer-Encoding for the codings that could not be decoded
+			req.Headers.Set("Transfer-Encoding", strings.Join(keptCodings, ", "))
+			req.parseTransferEncoding()
+		} else {
+			// Remove Transfer-Encoding header
+			req.Headers.Del("Transfer-Encoding")
+			req.TransferEncoding = []string{}
+
+			// Add Content-Length header with the size of the transfer-decoded body
+			if len(bodyBytes) > 0 {
+				req.Headers.Set("Content-Length", strconv.Itoa(len(bodyBytes)))
+			}
+		}
+	}
 
 	// Auto-parse query parameters from URL
 	req.ParseQueryParams()
@@ -259,9 +482,44 @@ func parse(data []byte) (*Request, error) {
 	// Auto-parse cookies from Cookie header
 	req.ParseCookies()
 
+	// Auto-parse urlencoded form parameters from body
+	req.ParseFormParams()
+
+	req.ParseWarnings = *diag
+
+	if opts.StrictMode {
+		for _, first := range req.ParseWarnings {
+			if first.Code == errors.DiagBodyTruncated {
+				continue
+			}
+			return nil, errors.NewErrorAt(errors.ErrorTypeInvalidFormat,
+				"strict mode: "+string(first.Code)+": "+first.Message, "parse", data, first.Offset)
+		}
+	}
+
 	return req, nil
 }
 
+// excessHeaderLine returns the offset of the first header line beyond limit
+// in headerData, or -1 if there is none or limit is 0
+func excessHeaderLine(headerData []byte, limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	offset := 0
+	for n := 0; offset < len(headerData); n++ {
+		if n == limit {
+			return offset
+		}
+		end := bytes.IndexByte(headerData[offset:], '\n')
+		if end == -1 {
+			break
+		}
+		offset += end + 1
+	}
+	return -1
+}
+
 // parseTransferEncoding parses Transfer-Encoding header
 func (r *Request) parseTransferEncoding() {
 	teHeader := r.Headers.Get("Transfer-Encoding")
@@ -293,12 +551,13 @@ func (r *Request) parseTransferEncoding() {
 }
 
 // parseRequestLine parses the HTTP request line with fault tolerance
-func (r *Request) parseRequestLine(line string) error {
+// Tolerated anomalies are reported to diag (nil = not collected)
+func (r *Request) parseRequestLine(line string, diag *errors.Diagnostics) error {
 	parts := strings.Fields(line)
 
 	if len(parts) < 2 {
-		return errors.NewError(errors.ErrorTypeInvalidFormat,
-			"invalid request line format", "parseRequestLine", []byte(line))
+		return errors.NewErrorAt(errors.ErrorTypeInvalidFormat,
+			"invalid request line format", "parseRequestLine", []byte(line), len(strings.TrimRight(line, "\r\n")))
 	}
 
 	// Method
@@ -319,12 +578,19 @@ func (r *Request) parseRequestLine(line string) error {
 	if len(parts) >= 3 {
 		r.Version = parts[2]
 	} else {
+		diag.Add(errors.DiagMissingVersion, len(line), "request line without version, assuming HTTP/1.1")
 		r.Version = "HTTP/1.1" // Default version for fault tolerance
 	}
+	if len(parts) > 3 {
+		diag.Add(errors.DiagExtraStartLineTokens, strings.Index(line, parts[3]),
+			"request line has %d tokens, extra ones ignored", len(parts))
+	}
 
 	// Validate version format
 	if !strings.HasPrefix(strings.ToUpper(r.Version), "HTTP/") {
 		// Keep the invalid version but mark as fault tolerance
+		diag.Add(errors.DiagInvalidVersion, strings.LastIndex(line, r.Version),
+			"invalid version %q, assuming HTTP/1.1", r.Version)
 		r.Version = "HTTP/1.1"
 	}
 
@@ -332,24 +598,15 @@ func (r *Request) parseRequestLine(line string) error {
 }
 
 // findHeaderEndIndex finds the index of the header end marker (\r\n\r\n or \n\n)
-// Returns both the index and the length of the separator (4 for CRLF, 2 for LF)
+// CRLF is preferred; LF is the fallback for fault tolerance
 func findHeaderEndIndex(data []byte) int {
 	// First try to find CRLF separator (\r\n\r\n)
-	for i := 0; i < len(data)-3; i++ {
-		if data[i] == '\r' && data[i+1] == '\n' &&
-			data[i+2] == '\r' && data[i+3] == '\n' {
-			return i
-		}
+	if idx := bytes.Index(data, []byte("\r\n\r\n")); idx != -1 {
+		return idx
 	}
 
 	// Fallback: try to find LF separator (\n\n) for fault tolerance
-	for i := 0; i < len(data)-1; i++ {
-		if data[i] == '\n' && data[i+1] == '\n' {
-			return i
-		}
-	}
-
-	return -1
+	return bytes.Index(data, []byte("\n\n"))
 }
 
 // getHeaderSeparatorLength returns the length of the header separator at the given position
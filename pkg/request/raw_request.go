@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/WhileEndless/go-httptools/pkg/bufpool"
 	"github.com/WhileEndless/go-httptools/pkg/headers"
 )
 
@@ -129,7 +130,7 @@ func (r *RawRequest) parseRequestLineRaw(line string) error {
 
 // BuildRaw reconstructs the HTTP request with exact formatting preservation
 func (r *RawRequest) BuildRaw() []byte {
-	var buf bytes.Buffer
+	buf := bufpool.Get()
 
 	// Request line (exact format)
 	buf.WriteString(r.RequestLine)
@@ -149,7 +150,7 @@ func (r *RawRequest) BuildRaw() []byte {
 		buf.Write(r.BodySection)
 	}
 
-	return buf.Bytes()
+	return bufpool.Release(buf)
 }
 
 // BuildRawString reconstructs the HTTP request as a string
@@ -1,15 +1,22 @@
 package request
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/clock"
 	"github.com/WhileEndless/go-httptools/pkg/compression"
 	"github.com/WhileEndless/go-httptools/pkg/cookies"
+	"github.com/WhileEndless/go-httptools/pkg/errors"
 	"github.com/WhileEndless/go-httptools/pkg/headers"
+	"github.com/WhileEndless/go-httptools/pkg/search"
+	"github.com/WhileEndless/go-httptools/pkg/stream"
 )
 
 // Request represents a parsed HTTP request
@@ -27,6 +34,10 @@ type Request struct {
 	DetectedCompression compression.CompressionType // Detected compression type (via header or magic bytes)
 	IsBodyChunked       bool                        // Whether body is chunked encoded
 
+	// Trailer fields after the final chunk of a chunked body (nil if none)
+	// Build and WriteToWithBodyChunked emit them after the final chunk
+	Trailers *headers.OrderedHeaders
+
 	// Line ending preservation
 	LineSeparator string // Original line separator (\r\n or \n)
 
@@ -37,11 +48,24 @@ type Request struct {
 	Path        string     // URL path without query string
 	QueryParams url.Values // Parsed query parameters
 
+	// Form parameters of an application/x-www-form-urlencoded body
+	FormParams url.Values
+
 	// Cookies
 	Cookies []cookies.Cookie // Parsed from Cookie header
 
 	// HTTP/2 specific
 	PseudoHeaders map[string]string // :method, :path, :authority, :scheme
+
+	// Anomalies tolerated while parsing (nil for well-formed requests)
+	ParseWarnings []errors.Diagnostic
+
+	// Truncated is set when the body was cut at ParseOptions.MaxBodySize
+	// Body and Raw then hold only the first MaxBodySize body bytes
+	Truncated bool
+
+	// pristine is the output of build when StrictBuild was called
+	pristine []byte
 }
 
 // NewRequest creates a new Request instance
@@ -51,6 +75,7 @@ func NewRequest() *Request {
 		LineSeparator:    "\r\n", // Default to CRLF
 		TransferEncoding: []string{},
 		QueryParams:      url.Values{},
+		FormParams:       url.Values{},
 		Cookies:          []cookies.Cookie{},
 		PseudoHeaders:    make(map[string]string),
 	}
@@ -64,6 +89,7 @@ func (r *Request) Clone() *Request {
 	clone.Version = r.Version
 	clone.Path = r.Path
 	clone.Compressed = r.Compressed
+	clone.Truncated = r.Truncated
 	clone.DetectedCompression = r.DetectedCompression
 	clone.IsBodyChunked = r.IsBodyChunked
 	clone.LineSeparator = r.LineSeparator
@@ -93,6 +119,13 @@ func (r *Request) Clone() *Request {
 		copy(clone.QueryParams[key], values)
 	}
 
+	// Clone form params
+	clone.FormParams = url.Values{}
+	for key, values := range r.FormParams {
+		clone.FormParams[key] = make([]string, len(values))
+		copy(clone.FormParams[key], values)
+	}
+
 	// Clone cookies
 	clone.Cookies = make([]cookies.Cookie, len(r.Cookies))
 	copy(clone.Cookies, r.Cookies)
@@ -102,6 +135,14 @@ func (r *Request) Clone() *Request {
 		clone.PseudoHeaders[key] = value
 	}
 
+	// Clone trailers
+	if r.Trailers != nil {
+		clone.Trailers = r.Trailers.Clone()
+	}
+
+	clone.ParseWarnings = append([]errors.Diagnostic(nil), r.ParseWarnings...)
+	clone.pristine = r.pristine
+
 	return clone
 }
 
@@ -125,11 +166,32 @@ func (r *Request) GetUserAgent() string {
 	return strings.TrimSpace(r.Headers.Get("User-Agent"))
 }
 
+// DuplicateHeaders reports singleton headers (Host, Content-Length, ...) that
+// occur more than once in the raw request
+// Headers keeps one value per name, so the analysis runs on Raw; returns nil
+// when Raw is empty
+func (r *Request) DuplicateHeaders() []headers.DuplicateHeader {
+	if len(r.Raw) == 0 {
+		return nil
+	}
+	return headers.FindDuplicateHeaders(headers.HeaderSection(r.Raw))
+}
+
+// HeaderAnomalies reports header quirks of the raw request that parsers disagree
+// on (folded lines, space before colon, ambiguous Content-Length and
+// Transfer-Encoding); returns nil when Raw is empty
+func (r *Request) HeaderAnomalies() []headers.Anomaly {
+	if len(r.Raw) == 0 {
+		return nil
+	}
+	return headers.FindAnomalies(headers.HeaderSection(r.Raw))
+}
+
 // SetBody sets the request body and updates Content-Length
 func (r *Request) SetBody(body []byte) {
 	r.Body = body
 	if len(body) > 0 {
-		r.Headers.Set("Content-Length", fmt.Sprintf("%d", len(body)))
+		r.Headers.Set("Content-Length", strconv.Itoa(len(body)))
 	} else {
 		r.Headers.Del("Content-Length")
 	}
@@ -155,6 +217,7 @@ func (r *Request) DecodeChunkedBody() map[string]string {
 
 	// Decode using chunked package
 	decodedBody, trailers := chunked.Decode(r.Body)
+	r.Trailers = chunked.Trailers(r.Body)
 
 	// Store original chunked body in RawBody
 	if len(r.RawBody) == 0 {
@@ -390,32 +453,22 @@ func (r *Request) WriteTo(w io.Writer) (int64, error) {
 // This is useful for streaming large bodies separately
 // Returns the number of bytes written and any error encountered
 func (r *Request) WriteHeadersTo(w io.Writer) (int64, error) {
-	var total int64
-
-	// Build request line
-	requestLine := fmt.Sprintf("%s %s %s%s", r.Method, r.URL, r.Version, r.LineSeparator)
-	n, err := w.Write([]byte(requestLine))
-	total += int64(n)
-	if err != nil {
-		return total, err
-	}
-
-	// Write headers
-	headerBytes := r.Headers.Build()
-	n, err = w.Write(headerBytes)
-	total += int64(n)
-	if err != nil {
-		return total, err
-	}
-
-	// Write header-body separator
-	n, err = w.Write([]byte(r.LineSeparator))
-	total += int64(n)
-	if err != nil {
-		return total, err
-	}
-
-	return total, nil
+	// Build request line into a pre-sized buffer and write the head in one call
+	buf := make([]byte, 0, len(r.Method)+len(r.URL)+len(r.Version)+2+
+		2*len(r.LineSeparator)+r.Headers.BuildSize())
+	buf = append(buf, r.Method...)
+	buf = append(buf, ' ')
+	buf = append(buf, r.URL...)
+	buf = append(buf, ' ')
+	buf = append(buf, r.Version...)
+	buf = append(buf, r.LineSeparator...)
+
+	// Headers followed by the header-body separator
+	buf = r.Headers.AppendBuild(buf)
+	buf = append(buf, r.LineSeparator...)
+
+	n, err := w.Write(buf)
+	return int64(n), err
 }
 
 // WriteBodyTo writes only the body to the writer
@@ -483,6 +536,7 @@ func (r *Request) WriteToWithBodyChunked(dst io.Writer, bodyReader io.Reader, ch
 
 	// Write body with chunked encoding
 	chunkedWriter := chunked.NewEncodeWriter(dst, chunkSize)
+	chunkedWriter.SetTrailers(r.Trailers)
 
 	// Use a buffer to copy
 	buf := make([]byte, 32*1024) // 32KB buffer
@@ -525,6 +579,30 @@ type StreamingBody struct {
 	isCompressed bool
 	compType     compression.CompressionType
 	totalRead    int64
+	rawCapture   *bytes.Buffer
+	warnings     []errors.Diagnostic
+	clock        clock.Clock
+	startTime    time.Time
+	onProgress   func(bytesRead int64, elapsed time.Duration)
+	limit        int64
+	limitErr     error
+	lineReader   *stream.LineReader
+}
+
+// BodyReaderOptions configures WrapBodyReaderWithOptions
+type BodyReaderOptions struct {
+	// Tee receives a copy of the undecoded (wire) body bytes as they are pulled
+	// from the source reader, e.g. a *bytes.Buffer or *os.File
+	// Decoders read ahead, so bytes are teed as soon as they are consumed from
+	// the source, not when the decoded data is returned to the caller
+	Tee io.Writer
+
+	// CaptureRaw keeps an in-memory copy of the undecoded body bytes,
+	// available via StreamingBody.RawBody() after the body has been consumed
+	CaptureRaw bool
+
+	// Clock times Elapsed, Rate and progress callbacks (nil = clock.Real)
+	Clock clock.Clock
 }
 
 // WrapBodyReader wraps a body reader with automatic decompression and/or chunked decoding
@@ -532,19 +610,59 @@ type StreamingBody struct {
 //
 // If isChunked is true, wraps with chunked decoder
 // If contentEncoding is set, wraps with appropriate decompressor
-// Order: raw -> chunked decode -> decompress (matches HTTP specification)
+// Order: raw -> chunked decode -> other transfer codings -> decompress (matches HTTP specification)
 //
 // The returned StreamingBody must be closed when done
 func (r *Request) WrapBodyReader(bodyReader io.Reader) (*StreamingBody, error) {
+	return r.WrapBodyReaderWithOptions(bodyReader, BodyReaderOptions{})
+}
+
+// WrapBodyReaderWithOptions wraps a body reader like WrapBodyReader with additional options
+// Use Tee or CaptureRaw to keep the undecoded wire bytes so RawBody can be
+// reconstructed after the decoded stream has been consumed
+func (r *Request) WrapBodyReaderWithOptions(bodyReader io.Reader, opts BodyReaderOptions) (*StreamingBody, error) {
 	var reader io.Reader = bodyReader
 	var closers []func() error
 
+	// Tee the wire bytes before any decoding takes place
+	var rawCapture *bytes.Buffer
+	var teeWriters []io.Writer
+	if opts.Tee != nil {
+		teeWriters = append(teeWriters, opts.Tee)
+	}
+	if opts.CaptureRaw {
+		rawCapture = &bytes.Buffer{}
+		teeWriters = append(teeWriters, rawCapture)
+	}
+	if len(teeWriters) == 1 {
+		reader = io.TeeReader(reader, teeWriters[0])
+	} else if len(teeWriters) > 1 {
+		reader = io.TeeReader(reader, io.MultiWriter(teeWriters...))
+	}
+
 	// First: decode chunked if needed (chunked is outermost encoding)
 	if r.IsBodyChunked {
 		reader = chunked.NewDecodeReader(reader)
 	}
 
-	// Second: decompress if needed
+	// Then remove transfer codings applied before chunked (e.g. "gzip, chunked")
+	// Unknown codings, and any applied before them, are passed through
+	var warnings errors.Diagnostics
+	if codings := compression.TransferCodings(r.Headers.Get("Transfer-Encoding")); len(codings) > 0 {
+		undecodable, decodable := compression.SplitTransferCodings(codings)
+		if len(undecodable) > 0 {
+			warnings.Add(errors.DiagDecompressionFailed, 0,
+				"transfer coding %q not decoded", strings.Join(undecodable, ", "))
+		}
+		transferReader, err := compression.NewTransferDecodeReader(reader, decodable)
+		if err != nil {
+			return nil, err
+		}
+		reader = transferReader
+		closers = append(closers, transferReader.Close)
+	}
+
+	// Finally: decompress the content if needed
 	contentEncoding := r.GetContentEncoding()
 	compType := compression.DetectCompression(contentEncoding)
 	if compType != compression.CompressionNone {
@@ -560,6 +678,7 @@ func (r *Request) WrapBodyReader(bodyReader io.Reader) (*StreamingBody, error) {
 		closers = append(closers, decompReader.Close)
 	}
 
+	clk := clock.Or(opts.Clock)
 	closeFunc := func() error {
 		var lastErr error
 		for i := len(closers) - 1; i >= 0; i-- {
@@ -576,13 +695,30 @@ func (r *Request) WrapBodyReader(bodyReader io.Reader) (*StreamingBody, error) {
 		isChunked:    r.IsBodyChunked,
 		isCompressed: compType != compression.CompressionNone,
 		compType:     compType,
+		rawCapture:   rawCapture,
+		warnings:     warnings,
+		clock:        clk,
+		startTime:    clk.Now(),
 	}, nil
 }
 
 // Read implements io.Reader interface
 func (s *StreamingBody) Read(p []byte) (int, error) {
+	if s.limitErr != nil {
+		return 0, s.limitErr
+	}
+
 	n, err := s.reader.Read(p)
+	if s.limit > 0 && s.totalRead+int64(n) > s.limit {
+		n = int(s.limit - s.totalRead)
+		s.limitErr = errors.NewError(errors.ErrorTypeBodyTooLarge,
+			fmt.Sprintf("decoded body exceeds %d bytes", s.limit), "streaming body", nil)
+		err = s.limitErr
+	}
 	s.totalRead += int64(n)
+	if s.onProgress != nil && (n > 0 || err == io.EOF) {
+		s.onProgress(s.totalRead, clock.Since(s.clock, s.startTime))
+	}
 	return n, err
 }
 
@@ -609,21 +745,70 @@ func (s *StreamingBody) IsCompressed() bool {
 	return s.isCompressed
 }
 
+// LimitBytes caps the number of decoded bytes that can be read from the body
+// Once more than n bytes are produced, Read returns the bytes up to the limit
+// followed by an ErrorTypeBodyTooLarge error (see errors.IsBodyTooLarge)
+// This protects ReadAll and Search against decompression bombs
+// A value of 0 or less disables the limit
+func (s *StreamingBody) LimitBytes(n int64) {
+	s.limit = n
+}
+
+// OnProgress registers a callback invoked after every read that returns data
+// and once more at EOF, with the decoded bytes read so far and the time elapsed
+// since the body was wrapped
+// The callback runs on the reading goroutine and should return quickly
+func (s *StreamingBody) OnProgress(fn func(bytesRead int64, elapsed time.Duration)) {
+	s.onProgress = fn
+}
+
+// Elapsed returns the time since the body was wrapped
+func (s *StreamingBody) Elapsed() time.Duration {
+	return clock.Since(s.clock, s.startTime)
+}
+
+// Rate returns the average throughput in decoded bytes per second since the body was wrapped
+// A rate that keeps falling while the body is being read indicates a stalled transfer
+func (s *StreamingBody) Rate() float64 {
+	elapsed := clock.Since(s.clock, s.startTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.totalRead) / elapsed
+}
+
+// RawBody returns the undecoded body bytes consumed so far
+// Only available when the body was wrapped with BodyReaderOptions.CaptureRaw,
+// otherwise returns nil
+func (s *StreamingBody) RawBody() []byte {
+	if s.rawCapture == nil {
+		return nil
+	}
+	return s.rawCapture.Bytes()
+}
+
 // CompressionType returns the compression type used
 func (s *StreamingBody) CompressionType() compression.CompressionType {
 	return s.compType
 }
 
+// Warnings returns the anomalies found while setting up decoding, such as
+// transfer codings that were passed through undecoded
+func (s *StreamingBody) Warnings() []errors.Diagnostic {
+	return s.warnings
+}
+
 // WriteTo implements io.WriterTo interface
 // Writes all remaining body data to the writer
 func (s *StreamingBody) WriteTo(w io.Writer) (int64, error) {
-	return io.Copy(w, s.reader)
+	// Hide WriteTo from io.Copy so reads go through Read and are counted
+	return io.Copy(w, struct{ io.Reader }{s})
 }
 
 // ReadAll reads all remaining body data into memory
 // Use with caution for large bodies
 func (s *StreamingBody) ReadAll() ([]byte, error) {
-	return io.ReadAll(s.reader)
+	return io.ReadAll(s)
 }
 
 // Search searches for a pattern in the streaming body
@@ -648,7 +833,7 @@ func (s *StreamingBody) Search(pattern []byte) (int64, error) {
 
 	for {
 		// Read more data
-		n, err := s.reader.Read(buf[buffered:])
+		n, err := s.Read(buf[buffered:])
 		if n > 0 {
 			buffered += n
 
@@ -695,30 +880,75 @@ func (s *StreamingBody) ContainsString(pattern string) (bool, error) {
 	return s.Contains([]byte(pattern))
 }
 
+// SearchFold searches for a pattern ignoring ASCII case
+// Returns the offset of the first match, or -1 if not found
+// WARNING: This reads through the body and cannot be undone
+func (s *StreamingBody) SearchFold(pattern []byte) (int64, error) {
+	return s.SearchWithOptions(pattern, search.StreamOptions{CaseInsensitive: true})
+}
+
+// ContainsFold checks if the pattern exists in the streaming body ignoring ASCII case
+// WARNING: This reads through the body and cannot be undone
+func (s *StreamingBody) ContainsFold(pattern []byte) (bool, error) {
+	offset, err := s.SearchFold(pattern)
+	return offset >= 0, err
+}
+
+// SearchWithOptions searches for a pattern after normalizing both the pattern and
+// the body (case folding, whitespace collapsing) as configured by opts
+// Returns the offset in the decoded body of the first match, or -1 if not found
+// WARNING: This reads through the body and cannot be undone
+func (s *StreamingBody) SearchWithOptions(pattern []byte, opts search.StreamOptions) (int64, error) {
+	return search.SearchReader(s, pattern, opts)
+}
+
+// ContainsWithOptions checks if the pattern exists in the streaming body using normalization options
+// WARNING: This reads through the body and cannot be undone
+func (s *StreamingBody) ContainsWithOptions(pattern []byte, opts search.StreamOptions) (bool, error) {
+	offset, err := s.SearchWithOptions(pattern, opts)
+	return offset >= 0, err
+}
+
+// Lines returns a LineReader for consuming the body record-by-record
+// (NDJSON, logs, etc.), with lines limited to maxLineLength bytes
+// (0 = stream.DefaultMaxLineLength)
+// The LineReader buffers ahead, so do not mix it with direct Read calls
+func (s *StreamingBody) Lines(maxLineLength int) *stream.LineReader {
+	s.lineReader = stream.NewLineReader(s, maxLineLength)
+	return s.lineReader
+}
+
+// ReadLine returns the next line of the body without the trailing line ending
+// Uses the LineReader created by Lines(), or one with the default maximum length
+// Returns io.EOF when the body is exhausted and stream.ErrLineTooLong for oversized lines
+func (s *StreamingBody) ReadLine() ([]byte, error) {
+	if s.lineReader == nil {
+		s.lineReader = stream.NewLineReader(s, 0)
+	}
+	return s.lineReader.ReadLine()
+}
+
+// Rewindable wraps the body in a stream.RewindableReader so it can be read,
+// rewound and read again (e.g. search the body, then forward it)
+// Data is buffered in memory up to opts.MemoryLimit, then spilled to a temp file
+// Close the returned reader to remove the spill file
+func (s *StreamingBody) Rewindable(opts stream.RewindOptions) *stream.RewindableReader {
+	return stream.NewRewindableReader(s, opts)
+}
+
 // CopyTo copies all remaining body data to the writer
 // This is an alias for WriteTo for clearer semantics
 func (s *StreamingBody) CopyTo(w io.Writer) (int64, error) {
 	return s.WriteTo(w)
 }
 
-// searchBytes searches for pattern in data using a simple algorithm
+// searchBytes searches for pattern in data
+// Delegates to bytes.Index, which uses SIMD-accelerated and Rabin-Karp
+// based searching instead of a naive O(n*m) comparison loop
 // Returns the index of the first match, or -1 if not found
 func searchBytes(data, pattern []byte) int {
 	if len(pattern) == 0 || len(data) < len(pattern) {
 		return -1
 	}
-
-	for i := 0; i <= len(data)-len(pattern); i++ {
-		found := true
-		for j := 0; j < len(pattern); j++ {
-			if data[i+j] != pattern[j] {
-				found = false
-				break
-			}
-		}
-		if found {
-			return i
-		}
-	}
-	return -1
+	return bytes.Index(data, pattern)
 }
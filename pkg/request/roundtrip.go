@@ -0,0 +1,45 @@
+package request
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/WhileEndless/go-httptools/pkg/errors"
+)
+
+// RawEquals reports whether Build reproduces Raw byte for byte
+func (r *Request) RawEquals() bool {
+	return bytes.Equal(r.Build(), r.Raw)
+}
+
+// VerifyRoundTrip rebuilds the request and compares it with Raw
+// Returns nil when they are identical, or an error located at the first
+// differing byte of Raw (see errors.Error Offset, Line and Snippet)
+func (r *Request) VerifyRoundTrip() error {
+	return verifyRoundTrip(r.Raw, r.Build())
+}
+
+// StrictBuild enables strict mode: as long as the request is not modified
+// afterwards, Build returns Raw exactly, even where the parser normalizes
+// Call it right after parsing; modifications made later disable it implicitly
+func (r *Request) StrictBuild() {
+	r.pristine = r.build()
+}
+
+// verifyRoundTrip compares a rebuilt message with the original bytes
+func verifyRoundTrip(raw, built []byte) error {
+	n := min(len(raw), len(built))
+	offset := n
+	for i := 0; i < n; i++ {
+		if raw[i] != built[i] {
+			offset = i
+			break
+		}
+	}
+	if offset == n && len(raw) == len(built) {
+		return nil
+	}
+	return errors.NewErrorAt(errors.ErrorTypeInvalidFormat,
+		fmt.Sprintf("rebuilt message differs from Raw at byte %d (%d vs %d bytes)", offset, len(built), len(raw)),
+		"VerifyRoundTrip", raw, offset)
+}
@@ -0,0 +1,290 @@
+package request
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/WhileEndless/go-httptools/pkg/errors"
+	"github.com/WhileEndless/go-httptools/pkg/framing"
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+)
+
+// StreamHandler receives the events of a StreamParser
+// Nil callbacks are skipped; an error returned by a callback stops parsing
+// and is returned from Feed
+type StreamHandler struct {
+	// OnRequestLine is called with the parsed request line
+	OnRequestLine func(method, target, version string) error
+
+	// OnHeader is called for each header line, duplicates included
+	OnHeader func(name, value string) error
+
+	// OnHeadersComplete is called with the parsed head; Body is still empty
+	OnHeadersComplete func(req *Request) error
+
+	// OnBody is called with body data as it arrives, already dechunked
+	// The slice is only valid during the call
+	OnBody func(data []byte) error
+
+	// OnComplete is called with the fully parsed request once its body ends
+	// Setting it makes the parser keep the raw message until then
+	OnComplete func(req *Request) error
+}
+
+// streamState is the position of a StreamParser within a message
+type streamState int
+
+const (
+	streamHead streamState = iota
+	streamBody
+	streamChunkSize
+	streamChunkData
+	streamChunkEnd
+	streamTrailers
+)
+
+// Default StreamParser limits
+const (
+	DefaultMaxHeadSize = 64 * 1024 // 64KB
+	DefaultMaxLineSize = 8 * 1024  // 8KB
+)
+
+// StreamParser parses requests from bytes fed incrementally, so proxies can
+// act on a request as it arrives instead of buffering it for Parse
+// Pipelined requests are parsed one after another
+//
+//	p := request.NewStreamParser(request.StreamHandler{
+//		OnHeadersComplete: func(req *request.Request) error { ... },
+//		OnBody:            func(data []byte) error { ... },
+//	})
+//	for segment := range segments {
+//		if err := p.Feed(segment); err != nil { ... }
+//	}
+type StreamParser struct {
+	// MaxHeadSize is the largest head buffered while waiting for its end
+	// Feed returns an error once an incomplete head grows past it (0 = no limit)
+	MaxHeadSize int
+
+	// MaxLineSize is the same limit for chunk size and trailer lines
+	MaxLineSize int
+
+	handler StreamHandler
+	state   streamState
+	buf     []byte // Unconsumed input
+	raw     []byte // Current message, kept only for OnComplete
+	remain  int64  // Bytes left in the body or current chunk
+}
+
+// NewStreamParser creates a StreamParser delivering events to handler
+// with the default size limits
+func NewStreamParser(handler StreamHandler) *StreamParser {
+	return &StreamParser{
+		MaxHeadSize: DefaultMaxHeadSize,
+		MaxLineSize: DefaultMaxLineSize,
+		handler:     handler,
+	}
+}
+
+// Feed parses data, emitting events for everything it completes
+func (p *StreamParser) Feed(data []byte) error {
+	p.buf = append(p.buf, data...)
+	start := 0
+	defer func() {
+		// Compact the buffer, keeping only the unconsumed tail
+		n := copy(p.buf, p.buf[start:])
+		p.buf = p.buf[:n]
+	}()
+
+	for {
+		if p.state == streamHead {
+			// Blank lines between pipelined requests belong to no message
+			for start < len(p.buf) && (p.buf[start] == '\r' || p.buf[start] == '\n') {
+				start++
+			}
+		}
+		n, err := p.step(p.buf[start:])
+		if n > 0 && p.handler.OnComplete != nil {
+			p.raw = append(p.raw, p.buf[start:start+n]...)
+		}
+		start += n
+		if err != nil {
+			return err
+		}
+		if p.state == streamHead && len(p.raw) > 0 {
+			if err := p.complete(); err != nil {
+				return err
+			}
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
+
+// Buffered returns the number of bytes waiting for a line or head to complete
+func (p *StreamParser) Buffered() int {
+	return len(p.buf)
+}
+
+// InBody reports whether the parser is between a head and the end of its body
+func (p *StreamParser) InBody() bool {
+	return p.state != streamHead
+}
+
+// step consumes what it can from the front of data
+// Returns the number of bytes consumed; 0 means more input is needed
+func (p *StreamParser) step(data []byte) (int, error) {
+	switch p.state {
+	case streamHead:
+		end := framing.HeadEnd(data)
+		if end == -1 {
+			if p.MaxHeadSize > 0 && len(data) > p.MaxHeadSize {
+				return 0, errors.NewError(errors.ErrorTypeInvalidFormat,
+					"request head exceeds "+strconv.Itoa(p.MaxHeadSize)+" bytes", "streamParser", nil)
+			}
+			return 0, nil
+		}
+		return end, p.head(data[:end])
+
+	case streamBody:
+		n := int(min(p.remain, int64(len(data))))
+		if n == 0 {
+			return 0, nil
+		}
+		p.remain -= int64(n)
+		if p.remain == 0 {
+			p.state = streamHead
+		}
+		return n, p.body(data[:n])
+
+	case streamChunkSize:
+		line, n := nextLine(data)
+		if n == 0 {
+			return 0, p.checkLine(data)
+		}
+		if idx := bytes.IndexByte(line, ';'); idx != -1 {
+			line = line[:idx]
+		}
+		size, err := strconv.ParseInt(string(bytes.TrimSpace(line)), 16, 64)
+		switch {
+		case err != nil || size < 0:
+			// Malformed size line - the body ends here, matching chunked.ScanRaw
+			p.state = streamHead
+		case size == 0:
+			p.state = streamTrailers
+		default:
+			p.remain = size
+			p.state = streamChunkData
+		}
+		return n, nil
+
+	case streamChunkData:
+		n := int(min(p.remain, int64(len(data))))
+		if n == 0 {
+			return 0, nil
+		}
+		p.remain -= int64(n)
+		if p.remain == 0 {
+			p.state = streamChunkEnd
+		}
+		return n, p.body(data[:n])
+
+	case streamChunkEnd:
+		_, n := nextLine(data)
+		if n == 0 {
+			return 0, p.checkLine(data)
+		}
+		p.state = streamChunkSize
+		return n, nil
+
+	case streamTrailers:
+		line, n := nextLine(data)
+		if n == 0 {
+			return 0, p.checkLine(data)
+		}
+		if len(bytes.TrimRight(line, "\r")) == 0 {
+			p.state = streamHead
+		}
+		return n, nil
+	}
+	return 0, nil
+}
+
+// head parses a complete head, emits its events and selects the body state
+func (p *StreamParser) head(data []byte) error {
+	req, err := parse(data, ParseOptions{})
+	if err != nil {
+		return err
+	}
+
+	if p.handler.OnRequestLine != nil {
+		if err := p.handler.OnRequestLine(req.Method, req.URL, req.Version); err != nil {
+			return err
+		}
+	}
+	if p.handler.OnHeader != nil {
+		// Every header line, since Headers keeps one value per name
+		for _, line := range bytes.Split(headers.HeaderSection(data), []byte("\n")) {
+			name, value, ok := bytes.Cut(bytes.TrimRight(line, "\r"), []byte(":"))
+			if !ok {
+				continue
+			}
+			if err := p.handler.OnHeader(string(bytes.TrimSpace(name)), string(bytes.TrimSpace(value))); err != nil {
+				return err
+			}
+		}
+	}
+	if p.handler.OnHeadersComplete != nil {
+		if err := p.handler.OnHeadersComplete(req); err != nil {
+			return err
+		}
+	}
+
+	f := headers.GetFraming(req.Headers)
+	switch {
+	case f.Chunked:
+		p.state = streamChunkSize
+	case f.ContentLength > 0:
+		p.remain = f.ContentLength
+		p.state = streamBody
+	}
+	return nil
+}
+
+// body emits body data
+func (p *StreamParser) body(data []byte) error {
+	if p.handler.OnBody == nil {
+		return nil
+	}
+	return p.handler.OnBody(data)
+}
+
+// complete parses the buffered message and emits OnComplete
+func (p *StreamParser) complete() error {
+	raw := p.raw
+	p.raw = nil
+	req, err := Parse(raw)
+	if err != nil {
+		return err
+	}
+	return p.handler.OnComplete(req)
+}
+
+// checkLine returns an error when data, an incomplete line, exceeds MaxLineSize
+func (p *StreamParser) checkLine(data []byte) error {
+	if p.MaxLineSize > 0 && len(data) > p.MaxLineSize {
+		return errors.NewError(errors.ErrorTypeMalformedChunk,
+			"chunk line exceeds "+strconv.Itoa(p.MaxLineSize)+" bytes", "streamParser", nil)
+	}
+	return nil
+}
+
+// nextLine returns the line at the start of data and its length including
+// the line ending; n is 0 when data holds no complete line
+func nextLine(data []byte) (line []byte, n int) {
+	end := bytes.IndexByte(data, '\n')
+	if end == -1 {
+		return nil, 0
+	}
+	return data[:end], end + 1
+}
@@ -2,47 +2,66 @@ package response
 
 import (
 	"bytes"
-	"fmt"
+	"strconv"
+
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
 )
 
 // Build reconstructs the HTTP response from parsed components
 // Preserves original line endings when available
 // Uses RawBody (potentially compressed) for accurate reconstruction
+// In strict mode (see StrictBuild) an unmodified response returns a copy of Raw
 func (r *Response) Build() []byte {
-	var buf bytes.Buffer
+	out := r.build()
+	if r.pristine != nil && bytes.Equal(out, r.pristine) {
+		return append([]byte(nil), r.finalRaw()...)
+	}
+	return out
+}
 
+// build implements Build without strict mode
+func (r *Response) build() []byte {
 	// Use original line separator or default to CRLF
 	lineSep := r.LineSeparator
 	if lineSep == "" {
 		lineSep = "\r\n"
 	}
 
-	// Status line
-	buf.WriteString(r.Version)
-	buf.WriteString(" ")
-	buf.WriteString(fmt.Sprintf("%d", r.StatusCode))
-	buf.WriteString(" ")
-	buf.WriteString(r.StatusText)
-	buf.WriteString(lineSep)
-
-	// Headers (in preserved order with original formatting)
-	headerBytes := r.Headers.Build()
-	buf.Write(headerBytes)
-
 	// Empty line between headers and body (use same separator as headers)
-	allHeaders := r.Headers.All()
-	if len(allHeaders) > 0 && allHeaders[len(allHeaders)-1].LineEnding != "" {
-		buf.WriteString(allHeaders[len(allHeaders)-1].LineEnding)
-	} else {
-		buf.WriteString(lineSep)
+	headerEnd := r.Headers.LastLineEnding()
+	if headerEnd == "" {
+		headerEnd = lineSep
 	}
 
+	// Pre-size the output so the whole message is built with a single allocation
 	// Body (use RawBody to maintain compression if it was originally compressed)
-	if len(r.RawBody) > 0 {
-		buf.Write(r.RawBody)
+	body := r.RawBody
+	if !r.BodyAllowed() {
+		body = nil
+	} else if r.IsBodyChunked && r.Trailers != nil {
+		body = chunked.SetTrailers(body, r.Trailers)
 	}
 
-	return buf.Bytes()
+	statusText := r.buildStatusText()
+	size := len(r.Version) + 1 + 3 + 1 + len(statusText) + len(lineSep) +
+		r.Headers.BuildSize() + len(headerEnd) + len(body)
+	buf := make([]byte, 0, size)
+
+	// Status line
+	buf = append(buf, r.Version...)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, int64(r.StatusCode), 10)
+	buf = append(buf, ' ')
+	buf = append(buf, statusText...)
+	buf = append(buf, lineSep...)
+
+	// Headers (in preserved order with original formatting)
+	buf = r.Headers.AppendBuild(buf)
+	buf = append(buf, headerEnd...)
+
+	buf = append(buf, body...)
+
+	return buf
 }
 
 // BuildString reconstructs the HTTP response as a string
@@ -54,7 +73,7 @@ func (r *Response) BuildString() string {
 // Uses RawBody size to maintain accuracy with compressed content
 func (r *Response) UpdateContentLength() {
 	if len(r.RawBody) > 0 {
-		r.Headers.Set("Content-Length", fmt.Sprintf("%d", len(r.RawBody)))
+		r.Headers.Set("Content-Length", strconv.Itoa(len(r.RawBody)))
 	} else {
 		r.Headers.Del("Content-Length")
 	}
@@ -0,0 +1,93 @@
+package response
+
+import (
+	"strings"
+	"time"
+
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+)
+
+// heuristicStatusCodes are the status codes cacheable by default (RFC 9110 section 15.1)
+var heuristicStatusCodes = map[int]bool{
+	200: true, 203: true, 204: true, 206: true, 300: true, 301: true,
+	308: true, 404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+// GetDate returns the parsed Date header
+// Returns the zero time if the header is missing or invalid
+func (r *Response) GetDate() time.Time {
+	return r.headerTime("Date")
+}
+
+// GetExpires returns the parsed Expires header
+// Returns the zero time if the header is missing or invalid (e.g. "0")
+func (r *Response) GetExpires() time.Time {
+	return r.headerTime("Expires")
+}
+
+// GetLastModified returns the parsed Last-Modified header
+// Returns the zero time if the header is missing or invalid
+func (r *Response) GetLastModified() time.Time {
+	return r.headerTime("Last-Modified")
+}
+
+// GetAge returns the Age header as a duration
+// Returns 0 if the header is missing or invalid
+// Values above 2^31 seconds are capped (RFC 9111 section 1.2.2)
+func (r *Response) GetAge() time.Duration {
+	age, _ := headers.ParseDeltaSeconds(r.Headers.Get("Age"))
+	return age
+}
+
+// FreshnessLifetime returns how long the response stays fresh after it was
+// generated, following RFC 9111 section 4.2.1 from a private cache's view
+// (s-maxage is ignored)
+// Order: Cache-Control max-age, then Expires minus Date, then 10% of
+// Date minus Last-Modified as a heuristic
+// now is used in place of a missing Date header
+func (r *Response) FreshnessLifetime(now time.Time) time.Duration {
+	directives := headers.ParseCacheControl(r.Headers.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return 0
+	}
+	if maxAge, ok := directives["max-age"]; ok {
+		lifetime, _ := headers.ParseDeltaSeconds(maxAge)
+		return lifetime
+	}
+
+	date := r.GetDate()
+	if date.IsZero() {
+		date = now
+	}
+
+	if r.Headers.Has("Expires") {
+		// An invalid Expires value means the response is already expired
+		expires := r.GetExpires()
+		if expires.IsZero() || !expires.After(date) {
+			return 0
+		}
+		return expires.Sub(date)
+	}
+
+	// Heuristic freshness: allowed for cacheable-by-default codes or with "public"
+	_, public := directives["public"]
+	if lastModified := r.GetLastModified(); !lastModified.IsZero() && date.After(lastModified) &&
+		(public || heuristicStatusCodes[r.StatusCode]) {
+		return date.Sub(lastModified) / 10
+	}
+
+	return 0
+}
+
+// headerTime parses the named header as an HTTP-date
+func (r *Response) headerTime(name string) time.Time {
+	value := r.Headers.Get(name)
+	if strings.TrimSpace(value) == "" {
+		return time.Time{}
+	}
+	t, err := headers.ParseHTTPDate(value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,64 @@
+package response
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/headers"
+)
+
+// IsInterimStatus reports whether statusCode is an interim (1xx) response
+// that precedes the final response
+// 101 Switching Protocols is final for HTTP/1.1 and is not interim
+func IsInterimStatus(statusCode int) bool {
+	return statusCode >= 100 && statusCode < 200 && statusCode != 101
+}
+
+// statusCodeOf returns the status code on the first line of data, or 0
+func statusCodeOf(data []byte) int {
+	fields := strings.Fields(string(data[:headers.IndexLineEnd(data)]))
+	if len(fields) < 2 {
+		return 0
+	}
+	statusCode, _ := strconv.Atoi(fields[1])
+	return statusCode
+}
+
+// NewInterimResponse creates an HTTP/1.1 interim response with the standard
+// reason phrase, e.g. NewInterimResponse(100) for "100 Continue"
+// Headers can be added before building (103 Early Hints carries Link headers)
+func NewInterimResponse(statusCode int) *Response {
+	resp := NewResponse()
+	resp.Version = "HTTP/1.1"
+	resp.StatusCode = statusCode
+	return resp
+}
+
+// WriteContinue writes "HTTP/1.1 100 Continue" to w
+// Servers send it after accepting a request with "Expect: 100-continue",
+// before reading the request body
+func WriteContinue(w io.Writer) error {
+	_, err := NewInterimResponse(100).WriteTo(w)
+	return err
+}
+
+// Continue returns the 100 Continue response that preceded r, or nil
+func (r *Response) Continue() *Response {
+	for i := range r.InterimResponses {
+		if r.InterimResponses[i].StatusCode == 100 {
+			return &r.InterimResponses[i]
+		}
+	}
+	return nil
+}
+
+// BuildWithInterim builds the interim responses followed by the final response,
+// reproducing the complete exchange as seen on the wire
+func (r *Response) BuildWithInterim() []byte {
+	var buf []byte
+	for i := range r.InterimResponses {
+		buf = append(buf, r.InterimResponses[i].Build()...)
+	}
+	return append(buf, r.Build()...)
+}
@@ -7,11 +7,13 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/WhileEndless/go-httptools/pkg/bufpool"
 	"github.com/WhileEndless/go-httptools/pkg/chunked"
 	"github.com/WhileEndless/go-httptools/pkg/compression"
 	"github.com/WhileEndless/go-httptools/pkg/cookies"
 	"github.com/WhileEndless/go-httptools/pkg/errors"
 	"github.com/WhileEndless/go-httptools/pkg/headers"
+	"github.com/WhileEndless/go-httptools/pkg/status"
 )
 
 // ParseOptions contains options for parsing HTTP responses
@@ -24,6 +26,27 @@ type ParseOptions struct {
 	// PreserveChunkedTrailers stores trailers from chunked encoding as headers
 	// Only effective when AutoDecodeChunked is true
 	PreserveChunkedTrailers bool
+
+	// RequestMethod is the method of the request this response answers
+	// Responses to HEAD (like 1xx, 204 and 304 responses) have no body, so
+	// anything after the head is ignored despite Content-Length
+	RequestMethod string
+
+	// SetCookieMode controls splitting of Set-Cookie headers that hold several
+	// comma-separated cookies (default: cookies.SplitLenient)
+	SetCookieMode cookies.SplitMode
+
+	// ReadBufferSize is the bufio.Reader size used by the reader-based functions
+	// (0 = headers.DefaultReadBufferSize)
+	// Ignored when the reader passed in is already a *bufio.Reader
+	ReadBufferSize int
+
+	// MaxBodySize limits the body to this many bytes (0 = no limit)
+	// Longer bodies are cut and the response is marked Truncated, with the head
+	// fully usable; the reader-based functions read and discard the rest of a
+	// framed body, so the reader stays positioned at the next message
+	// The limit applies to the body as received (chunked or compressed)
+	MaxBodySize int64
 }
 
 // Parse parses raw HTTP response data with fault tolerance and automatic decompression
@@ -33,23 +56,123 @@ func Parse(data []byte) (*Response, error) {
 }
 
 // ParseReader parses an HTTP response from an io.Reader
-// Reads all data from the reader and parses it using default options
-// Suitable for integration with streaming sources like buffers or file readers
+// Reads the head incrementally, then exactly the framed body (Content-Length,
+// chunked, or until EOF when neither is present) and parses it using default options
+// Pass a *bufio.Reader to parse several pipelined responses from the same stream
+// Interim (1xx) responses are read through and kept in InterimResponses; to act
+// on a 100 Continue before the final response arrives, read its head with
+// ParseHeadersFromReader first
 func ParseReader(r io.Reader) (*Response, error) {
 	return ParseReaderWithOptions(r, ParseOptions{})
 }
 
 // ParseReaderWithOptions parses an HTTP response from an io.Reader with custom options
-// Reads all data from the reader and parses it with the specified options
+// Memory use is bounded by the header size plus the framed body
 func ParseReaderWithOptions(r io.Reader, opts ParseOptions) (*Response, error) {
-	data, err := io.ReadAll(r)
-	if err != nil {
-		return nil, errors.NewError(errors.ErrorTypeInvalidFormat,
-			"failed to read from reader: "+err.Error(), "parseReader", nil)
+	br := headers.NewReader(r, opts.ReadBufferSize)
+
+	// The message is read into a pooled buffer; parsing copies it into Raw
+	data, err := readMessage(bufpool.GetBytes(), br, opts.RequestMethod, opts.MaxBodySize)
+	defer bufpool.PutBytes(data)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, errors.WrapError(errors.ErrorTypeInvalidFormat,
+			"failed to read from reader: "+err.Error(), "parseReader", nil, err)
 	}
 	return ParseWithOptions(data, opts)
 }
 
+// readMessage reads one final response from br, including any interim (1xx)
+// responses preceding it, so they end up in InterimResponses
+// Truncated messages are returned with io.ErrUnexpectedEOF so they can still be parsed
+// With maxBody > 0, at most maxBody+1 body bytes are kept, so parse can tell
+// that the body exceeded the limit; the rest is read and discarded
+// The messages are appended to dst
+func readMessage(dst []byte, br *bufio.Reader, requestMethod string, maxBody int64) ([]byte, error) {
+	for {
+		start := len(dst)
+		data, err := readSingleMessage(dst, br, requestMethod, maxBody)
+		if err != nil || !IsInterimStatus(statusCodeOf(data[start:])) {
+			return data, err
+		}
+		dst = data
+	}
+}
+
+// readSingleMessage reads one response (head plus framed body) from br and
+// appends it to dst
+func readSingleMessage(dst []byte, br *bufio.Reader, requestMethod string, maxBody int64) ([]byte, error) {
+	data, err := headers.AppendHead(dst, br)
+	if err != nil {
+		return data, err
+	}
+
+	// Determine status code and body framing from the head
+	head := data[len(dst):]
+	lineEnd := bytes.IndexByte(head, '\n')
+	statusCode := statusCodeOf(head)
+
+	if !headers.ResponseHasBody(statusCode, requestMethod) {
+		return data, nil
+	}
+
+	parsedHeaders, _ := headers.ParseHeaders(head[lineEnd+1:])
+	if parsedHeaders == nil {
+		parsedHeaders = headers.NewOrderedHeaders()
+	}
+	framing := headers.GetFraming(parsedHeaders)
+
+	limit := int64(0)
+	if maxBody > 0 {
+		limit = maxBody + 1
+	}
+
+	switch {
+	case framing.Chunked:
+		body, err := chunked.ReadRawLimit(br, limit)
+		return append(data, body...), err
+	case framing.ContentLength >= 0:
+		return headers.ReadBodyLimit(data, br, framing.ContentLength, limit)
+	default:
+		// No framing: the body is delimited by connection close
+		r := io.Reader(br)
+		if limit > 0 {
+			r = io.LimitReader(br, limit)
+		}
+		body, err := io.ReadAll(r)
+		if err == nil && limit > 0 {
+			_, err = io.Copy(io.Discard, br)
+		}
+		return append(data, body...), err
+	}
+}
+
+// limitBody applies ParseOptions.MaxBodySize to the received body
+// Returns the body cut to maxBody bytes and whether the message body was longer:
+// cut here, or declared longer by Content-Length (ParseReader discards the rest)
+func limitBody(body []byte, h *headers.OrderedHeaders, maxBody int64) ([]byte, bool) {
+	if maxBody <= 0 {
+		return body, false
+	}
+	if int64(len(body)) > maxBody {
+		return body[:maxBody], true
+	}
+	f := headers.GetFraming(h)
+	return body, !f.Chunked && f.ContentLength > maxBody
+}
+
+// capBody cuts data to the head plus maxBody+1 body bytes, so that parse
+// copies no more of an oversized body than limitBody needs to detect it
+func capBody(data []byte, maxBody int64) []byte {
+	bodyStart := findHeaderEnd(data)
+	if maxBody <= 0 || bodyStart < 0 {
+		return data
+	}
+	if int64(len(data)-bodyStart) > maxBody {
+		return data[:bodyStart+int(maxBody)+1]
+	}
+	return data
+}
+
 // ParseHeadersFromReader parses only the HTTP response headers from an io.Reader
 // Returns the parsed Response (without body) and an io.Reader for the remaining body data
 // This is useful for streaming large responses where the body shouldn't be loaded into memory
@@ -71,7 +194,7 @@ func ParseHeadersFromReader(r io.Reader) (*Response, io.Reader, error) {
 // ParseHeadersFromReaderWithOptions parses only the HTTP response headers from an io.Reader with options
 // Returns the parsed Response (without body) and an io.Reader for the remaining body data
 func ParseHeadersFromReaderWithOptions(r io.Reader, opts ParseOptions) (*Response, io.Reader, error) {
-	br := bufio.NewReader(r)
+	br := headers.NewReader(r, opts.ReadBufferSize)
 
 	resp := NewResponse()
 
@@ -92,7 +215,7 @@ func ParseHeadersFromReaderWithOptions(r io.Reader, opts ParseOptions) (*Respons
 	}
 
 	// Parse status line
-	if err := resp.parseStatusLine(statusLine); err != nil {
+	if err := resp.parseStatusLine(statusLine, nil); err != nil {
 		return nil, nil, err
 	}
 
@@ -132,8 +255,7 @@ func ParseHeadersFromReaderWithOptions(r io.Reader, opts ParseOptions) (*Respons
 	// Extract Set-Cookie headers
 	for _, header := range resp.Headers.All() {
 		if strings.ToLower(header.Name) == "set-cookie" {
-			cookie := cookies.ParseSetCookie(header.Value)
-			resp.SetCookies = append(resp.SetCookies, cookie)
+			resp.SetCookies = append(resp.SetCookies, cookies.ParseSetCookies(header.Value, opts.SetCookieMode)...)
 		}
 	}
 
@@ -146,25 +268,62 @@ func ParseHeadersFromReaderWithOptions(r io.Reader, opts ParseOptions) (*Respons
 
 // ParseWithOptions parses raw HTTP response data with custom options
 // Preserves original header formatting and line endings
+// Tolerated anomalies are listed in ParseWarnings
 func ParseWithOptions(data []byte, opts ParseOptions) (*Response, error) {
+	return parse(data, opts)
+}
+
+// ParseWithDiagnostics parses raw HTTP response data and never fails
+// Every anomaly tolerated by the fault-tolerant parser is reported as a diagnostic
+// If the data cannot be parsed at all, an empty response holding Raw is returned
+// with a single errors.DiagUnparseable diagnostic
+func ParseWithDiagnostics(data []byte, opts ParseOptions) (*Response, []errors.Diagnostic) {
+	resp, err := parse(data, opts)
+	if err != nil {
+		var diag errors.Diagnostics
+		diag.AddError(err)
+		resp = NewResponse()
+		resp.RequestMethod = opts.RequestMethod
+		resp.Raw = append([]byte(nil), data...)
+		resp.ParseWarnings = diag
+	}
+	return resp, resp.ParseWarnings
+}
+
+// parse is the internal implementation of ParseWithOptions
+// Tolerated anomalies are collected in ParseWarnings
+// Offsets are relative to the final response, after any interim responses
+func parse(data []byte, opts ParseOptions) (*Response, error) {
 	if len(data) == 0 {
 		return nil, errors.NewError(errors.ErrorTypeInvalidFormat,
 			"empty response data", "parse", data)
 	}
+	diag := new(errors.Diagnostics)
 
 	resp := NewResponse()
+	resp.RequestMethod = opts.RequestMethod
+
+	// Split off interim (1xx) responses preceding the final response, then
+	// copy the input, with an oversized final body cut at MaxBodySize
+	interim, final := splitInterimResponses(data, opts)
+	resp.InterimResponses = interim
+	base := len(data) - len(final)
+	data = data[:base+len(capBody(final, opts.MaxBodySize))]
 	resp.Raw = make([]byte, len(data))
 	copy(resp.Raw, data)
 
+	// Everything below slices Raw, so nothing refers to the caller's data
+	data = resp.Raw[base:]
+
+	// Offsets below are relative to the final response; errors and
+	// diagnostics report them against the full input by adding base
+
 	// Find first line ending to extract status line and detect line separator
-	statusLineEnd := 0
-	for statusLineEnd < len(data) && data[statusLineEnd] != '\n' && data[statusLineEnd] != '\r' {
-		statusLineEnd++
-	}
+	statusLineEnd := headers.IndexLineEnd(data)
 
 	if statusLineEnd == 0 {
-		return nil, errors.NewError(errors.ErrorTypeInvalidFormat,
-			"no status line found", "parse", data)
+		return nil, errors.NewErrorAt(errors.ErrorTypeInvalidFormat,
+			"no status line found", "parse", resp.Raw, base)
 	}
 
 	// Detect line separator from first line
@@ -176,12 +335,15 @@ func ParseWithOptions(data []byte, opts ParseOptions) (*Response, error) {
 		} else if data[statusLineEnd] == '\r' {
 			resp.LineSeparator = "\r"
 		}
+		if resp.LineSeparator != "\r\n" {
+			diag.Add(errors.DiagBareLineEnding, statusLineEnd, "line separator %q instead of CRLF", resp.LineSeparator)
+		}
 	}
 
 	// Parse status line (Version StatusCode StatusText)
 	statusLine := string(data[:statusLineEnd])
-	if err := resp.parseStatusLine(statusLine); err != nil {
-		return nil, err
+	if err := resp.parseStatusLine(statusLine, diag); err != nil {
+		return nil, errors.Locate(err, resp.Raw, base)
 	}
 
 	// Skip past status line and its line ending
@@ -196,8 +358,8 @@ func ParseWithOptions(data []byte, opts ParseOptions) (*Response, error) {
 	// Find the end of headers (double line break)
 	headerEndIdx := findHeaderEndIndex(data)
 	if headerEndIdx == -1 {
-		return nil, errors.NewError(errors.ErrorTypeInvalidFormat,
-			"no header end found", "parse", data)
+		return nil, errors.NewErrorAt(errors.ErrorTypeInvalidFormat,
+			"no header end found", "parse", resp.Raw, base+len(data))
 	}
 
 	// Calculate header data end position (include last line ending)
@@ -223,7 +385,7 @@ func ParseWithOptions(data []byte, opts ParseOptions) (*Response, error) {
 	// Parse headers with original formatting preserved
 	if headerStart < headerDataEnd {
 		headerData := data[headerStart:headerDataEnd]
-		parsedHeaders, err := headers.ParseHeaders(headerData)
+		parsedHeaders, err := headers.ParseHeadersWithDiagnostics(headerData, headerStart, diag)
 		if err != nil {
 			resp.Headers = headers.NewOrderedHeaders()
 		} else {
@@ -233,8 +395,7 @@ func ParseWithOptions(data []byte, opts ParseOptions) (*Response, error) {
 
 	// Parse Set-Cookie headers collected separately
 	for _, setCookieValue := range setCookieHeaders {
-		cookie := cookies.ParseSetCookie(setCookieValue)
-		resp.SetCookies = append(resp.SetCookies, cookie)
+		resp.SetCookies = append(resp.SetCookies, cookies.ParseSetCookies(setCookieValue, opts.SetCookieMode)...)
 	}
 
 	// Get body bytes
@@ -243,10 +404,52 @@ func ParseWithOptions(data []byte, opts ParseOptions) (*Response, error) {
 		bodyStart = len(data)
 	}
 	bodyBytes := data[bodyStart:]
+	if !resp.BodyAllowed() {
+		// Bytes after the head belong to the next message, not to this response
+		bodyBytes = nil
+	}
 
-	// Store raw body and attempt decompression
+	if resp.BodyAllowed() {
+		bodyBytes, resp.Truncated = limitBody(bodyBytes, resp.Headers, opts.MaxBodySize)
+		if resp.Truncated {
+			diag.Add(errors.DiagBodyTruncated, bodyStart+len(bodyBytes), "body truncated at %d bytes", len(bodyBytes))
+			resp.Raw = resp.Raw[:len(resp.Raw)-len(data)+bodyStart+len(bodyBytes)]
+		} else {
+			headers.CheckFraming(resp.Headers, len(bodyBytes), bodyStart, diag)
+		}
+	}
+
+	// Store raw body
 	resp.RawBody = bodyBytes
 
+	// Auto-parse Transfer-Encoding header
+	resp.parseTransferEncoding()
+	if resp.IsBodyChunked {
+		resp.Trailers = chunked.Trailers(bodyBytes)
+	}
+
+	// Auto-decode transfer codings if requested: chunked first (it is always
+	// applied last), then any codings applied before it, e.g. "gzip, chunked"
+	var trailers map[string]string
+	var keptCodings []string // Codings still applied to the body
+	transferDecoded := resp.IsBodyChunked && opts.AutoDecodeChunked
+	if transferDecoded {
+		bodyBytes, trailers = chunked.Decode(bodyBytes)
+		codings := compression.TransferCodings(resp.Headers.Get("Transfer-Encoding"))
+		decoded, err := compression.DecodeTransferCodings(bodyBytes, codings)
+		if err != nil {
+			diag.Add(errors.DiagDecompressionFailed, bodyStart,
+				"transfer coding %q not decoded, body only dechunked", resp.Headers.Get("Transfer-Encoding"))
+			for _, c := range codings {
+				if c != "chunked" {
+					keptCodings = append(keptCodings, c)
+				}
+			}
+		} else {
+			bodyBytes = decoded
+		}
+	}
+
 	// Detect compression - first try header, then magic bytes
 	contentEncoding := resp.GetContentEncoding()
 	compressionType := compression.CompressionNone
@@ -269,6 +472,8 @@ func ParseWithOptions(data []byte, opts ParseOptions) (*Response, error) {
 		decompressed, err := compression.Decompress(bodyBytes, compressionType)
 		if err != nil {
 			// On decompression error, keep raw body (fault tolerance)
+			diag.Add(errors.DiagDecompressionFailed, bodyStart,
+				"%s decompression failed, body kept as is", compression.CompressionTypeToString(compressionType))
 			resp.Body = bodyBytes
 			resp.Compressed = false
 			resp.DetectedCompression = compression.CompressionNone
@@ -281,20 +486,15 @@ func ParseWithOptions(data []byte, opts ParseOptions) (*Response, error) {
 		resp.Compressed = false
 	}
 
-	// Auto-parse Transfer-Encoding header
-	resp.parseTransferEncoding()
-
-	// Auto-decode chunked transfer encoding if requested
-	if opts.AutoDecodeChunked && resp.IsBodyChunked {
-		decodedBody, trailers := chunked.Decode(resp.Body)
-
-		// Store original chunked body in RawBody
-		resp.RawBody = resp.Body
-
-		// Update body with decoded version
-		resp.Body = decodedBody
+	if transferDecoded {
 		resp.IsBodyChunked = false
 
+		// The message is no longer chunked, so a compressed RawBody must hold
+		// the compressed content rather than the chunked wire bytes
+		if resp.Compressed {
+			resp.RawBody = bodyBytes
+		}
+
 		// Preserve trailers as headers if requested
 		if opts.PreserveChunkedTrailers && len(trailers) > 0 {
 			for name, value := range trailers {
@@ -302,21 +502,58 @@ func ParseWithOptions(data []byte, opts ParseOptions) (*Response, error) {
 			}
 		}
 
-		// Remove Transfer-Encoding: chunked header
-		resp.Headers.Del("Transfer-Encoding")
-		resp.TransferEncoding = []string{}
+		if len(keptCodings) > 0 {
+			// Keep Transfer-Encoding for the codings that could not be decoded
+			resp.Headers.Set("Transfer-Encoding", strings.Join(keptCodings, ", "))
+			resp.parseTransferEncoding()
+		} else {
+			// Remove Transfer-Encoding header
+			resp.Headers.Del("Transfer-Encoding")
+			resp.TransferEncoding = []string{}
 
-		// Add Content-Length header with decoded body size
-		if len(decodedBody) > 0 {
-			resp.Headers.Set("Content-Length", strconv.Itoa(len(decodedBody)))
+			// Add Content-Length header with the size of the transfer-decoded body
+			if len(bodyBytes) > 0 {
+				resp.Headers.Set("Content-Length", strconv.Itoa(len(bodyBytes)))
+			}
 		}
 	}
 
 	// Set-Cookie headers already parsed above during header parsing
 
+	for i := range *diag {
+		(*diag)[i].Offset += base
+	}
+	resp.ParseWarnings = *diag
 	return resp, nil
 }
 
+// splitInterimResponses parses the 1xx responses at the start of data
+// Returns them and the remaining data holding the final response
+// 101 Switching Protocols is final for HTTP/1.1 and is not split off;
+// a lone 1xx response without anything after it is returned as the final response
+func splitInterimResponses(data []byte, opts ParseOptions) ([]Response, []byte) {
+	var interim []Response
+
+	for {
+		if !IsInterimStatus(statusCodeOf(data)) {
+			return interim, data
+		}
+
+		headEnd := findHeaderEnd(data)
+		if headEnd == -1 || headEnd == len(data) {
+			return interim, data
+		}
+
+		// Interim responses never have a body, so the head is the whole message
+		resp, err := ParseWithOptions(data[:headEnd], opts)
+		if err != nil {
+			return interim, data
+		}
+		interim = append(interim, *resp)
+		data = data[headEnd:]
+	}
+}
+
 // parseTransferEncoding parses Transfer-Encoding header
 func (r *Response) parseTransferEncoding() {
 	teHeader := r.Headers.Get("Transfer-Encoding")
@@ -348,18 +585,21 @@ func (r *Response) parseTransferEncoding() {
 }
 
 // parseStatusLine parses the HTTP status line with fault tolerance
-func (r *Response) parseStatusLine(line string) error {
+// Tolerated anomalies are reported to diag (nil = not collected)
+func (r *Response) parseStatusLine(line string, diag *errors.Diagnostics) error {
 	parts := strings.Fields(line)
 
 	if len(parts) < 2 {
-		return errors.NewError(errors.ErrorTypeInvalidFormat,
-			"invalid status line format", "parseStatusLine", []byte(line))
+		return errors.NewErrorAt(errors.ErrorTypeInvalidFormat,
+			"invalid status line format", "parseStatusLine", []byte(line), len(strings.TrimRight(line, "\r\n")))
 	}
 
 	// Version
 	r.Version = parts[0]
 	if !strings.HasPrefix(strings.ToUpper(r.Version), "HTTP/") {
 		// Keep the invalid version but set default for fault tolerance
+		diag.Add(errors.DiagInvalidVersion, strings.Index(line, r.Version),
+			"invalid version %q, assuming HTTP/1.1", r.Version)
 		r.Version = "HTTP/1.1"
 	}
 
@@ -367,8 +607,8 @@ func (r *Response) parseStatusLine(line string) error {
 	statusCodeStr := parts[1]
 	statusCode, err := strconv.Atoi(statusCodeStr)
 	if err != nil {
-		return errors.NewError(errors.ErrorTypeInvalidStatusCode,
-			"invalid status code: "+statusCodeStr, "parseStatusLine", []byte(line))
+		return errors.NewErrorAt(errors.ErrorTypeInvalidStatusCode,
+			"invalid status code: "+statusCodeStr, "parseStatusLine", []byte(line), strings.Index(line, statusCodeStr))
 	}
 	r.StatusCode = statusCode
 
@@ -377,50 +617,20 @@ func (r *Response) parseStatusLine(line string) error {
 		r.StatusText = strings.Join(parts[2:], " ")
 	} else {
 		// Provide default status text based on status code
+		diag.Add(errors.DiagMissingReason, len(line), "status line without reason phrase")
 		r.StatusText = getDefaultStatusText(statusCode)
 	}
 
 	return nil
 }
 
-// getDefaultStatusText provides default status text for common HTTP status codes
+// getDefaultStatusText provides the default status text for a status code
+// Uses the status registry, so custom codes registered via status.Register are included
 func getDefaultStatusText(statusCode int) string {
-	switch statusCode {
-	case 100:
-		return "Continue"
-	case 101:
-		return "Switching Protocols"
-	case 200:
-		return "OK"
-	case 201:
-		return "Created"
-	case 204:
-		return "No Content"
-	case 301:
-		return "Moved Permanently"
-	case 302:
-		return "Found"
-	case 304:
-		return "Not Modified"
-	case 400:
-		return "Bad Request"
-	case 401:
-		return "Unauthorized"
-	case 403:
-		return "Forbidden"
-	case 404:
-		return "Not Found"
-	case 405:
-		return "Method Not Allowed"
-	case 500:
-		return "Internal Server Error"
-	case 502:
-		return "Bad Gateway"
-	case 503:
-		return "Service Unavailable"
-	default:
-		return "Unknown"
+	if text := status.Text(statusCode); text != "" {
+		return text
 	}
+	return "Unknown"
 }
 
 // findHeaderEnd finds the position after the double line break that separates headers from body
@@ -462,28 +672,20 @@ func findHeaderEndIndex(data []byte) int {
 // extractSetCookieHeaders extracts Set-Cookie header values from header section
 func extractSetCookieHeaders(headerData []byte) []string {
 	var setCookies []string
+	prefix := []byte("set-cookie:")
 
 	i := 0
 	for i < len(headerData) {
 		// Find end of current line
 		lineStart := i
-		lineEnd := i
-		for lineEnd < len(headerData) && headerData[lineEnd] != '\n' && headerData[lineEnd] != '\r' {
-			lineEnd++
-		}
+		lineEnd := i + headers.IndexLineEnd(headerData[i:])
 
-		line := string(headerData[lineStart:lineEnd])
+		line := headerData[lineStart:lineEnd]
 
-		// Check if this is a Set-Cookie header (case-insensitive)
-		if len(line) > 11 {
-			lowerLine := strings.ToLower(line)
-			if strings.HasPrefix(lowerLine, "set-cookie:") {
-				colonPos := strings.Index(line, ":")
-				if colonPos != -1 {
-					value := strings.TrimSpace(line[colonPos+1:])
-					setCookies = append(setCookies, value)
-				}
-			}
+		// Check if this is a Set-Cookie header (case-insensitive) without allocating
+		if len(line) > len(prefix) && bytes.EqualFold(line[:len(prefix)], prefix) {
+			value := strings.TrimSpace(string(line[len(prefix):]))
+			setCookies = append(setCookies, value)
 		}
 
 		// Skip past line ending
@@ -1,15 +1,23 @@
 package response
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/clock"
 	"github.com/WhileEndless/go-httptools/pkg/compression"
 	"github.com/WhileEndless/go-httptools/pkg/cookies"
+	"github.com/WhileEndless/go-httptools/pkg/errors"
+	"github.com/WhileEndless/go-httptools/pkg/fingerprint"
 	"github.com/WhileEndless/go-httptools/pkg/headers"
+	"github.com/WhileEndless/go-httptools/pkg/search"
+	"github.com/WhileEndless/go-httptools/pkg/status"
+	"github.com/WhileEndless/go-httptools/pkg/stream"
 )
 
 // Response represents a parsed HTTP response
@@ -33,8 +41,30 @@ type Response struct {
 	TransferEncoding []string // Parsed from Transfer-Encoding header
 	IsBodyChunked    bool     // Whether body is chunked encoded
 
+	// Trailer fields after the final chunk of a chunked body (nil if none)
+	// Build and WriteToWithBodyChunked emit them after the final chunk
+	Trailers *headers.OrderedHeaders
+
 	// Set-Cookie headers
 	SetCookies []cookies.ResponseCookie // Parsed from Set-Cookie headers
+
+	// RequestMethod is the method of the request this response answers (if known)
+	RequestMethod string
+
+	// InterimResponses holds 1xx responses (100 Continue, 102 Processing,
+	// 103 Early Hints) that preceded the final response in the raw data
+	// Raw still contains the complete original data; Build emits only the final response
+	InterimResponses []Response
+
+	// Anomalies tolerated while parsing (nil for well-formed responses)
+	ParseWarnings []errors.Diagnostic
+
+	// Truncated is set when the body was cut at ParseOptions.MaxBodySize
+	// Body and Raw then hold only the first MaxBodySize body bytes
+	Truncated bool
+
+	// pristine is the output of build when StrictBuild was called
+	pristine []byte
 }
 
 // NewResponse creates a new Response instance
@@ -54,9 +84,11 @@ func (r *Response) Clone() *Response {
 	clone.StatusCode = r.StatusCode
 	clone.StatusText = r.StatusText
 	clone.Compressed = r.Compressed
+	clone.Truncated = r.Truncated
 	clone.DetectedCompression = r.DetectedCompression
 	clone.IsBodyChunked = r.IsBodyChunked
 	clone.LineSeparator = r.LineSeparator
+	clone.RequestMethod = r.RequestMethod
 
 	clone.Body = make([]byte, len(r.Body))
 	copy(clone.Body, r.Body)
@@ -80,6 +112,19 @@ func (r *Response) Clone() *Response {
 	clone.SetCookies = make([]cookies.ResponseCookie, len(r.SetCookies))
 	copy(clone.SetCookies, r.SetCookies)
 
+	// Clone interim responses
+	for i := range r.InterimResponses {
+		clone.InterimResponses = append(clone.InterimResponses, *r.InterimResponses[i].Clone())
+	}
+
+	// Clone trailers
+	if r.Trailers != nil {
+		clone.Trailers = r.Trailers.Clone()
+	}
+
+	clone.ParseWarnings = append([]errors.Diagnostic(nil), r.ParseWarnings...)
+	clone.pristine = r.pristine
+
 	return clone
 }
 
@@ -113,6 +158,36 @@ func (r *Response) GetServer() string {
 	return strings.TrimSpace(r.Headers.Get("Server"))
 }
 
+// DuplicateHeaders reports singleton headers (Host, Content-Length, ...) that
+// occur more than once in the raw response
+// Headers keeps one value per name, so the analysis runs on the final
+// response in Raw (interim responses are skipped); returns nil when Raw is empty
+func (r *Response) DuplicateHeaders() []headers.DuplicateHeader {
+	raw := r.finalRaw()
+	if len(raw) == 0 {
+		return nil
+	}
+	return headers.FindDuplicateHeaders(headers.HeaderSection(raw))
+}
+
+// HeaderAnomalies reports header quirks of the raw response that parsers disagree
+// on (folded lines, space before colon, ambiguous Content-Length and
+// Transfer-Encoding); interim responses are skipped
+// Returns nil when Raw is empty
+func (r *Response) HeaderAnomalies() []headers.Anomaly {
+	raw := r.finalRaw()
+	if len(raw) == 0 {
+		return nil
+	}
+	return headers.FindAnomalies(headers.HeaderSection(raw))
+}
+
+// BodySimHash returns a fuzzy SimHash fingerprint of the decoded body
+// Compare fingerprints with fingerprint.Distance to group near-duplicate responses
+func (r *Response) BodySimHash() uint64 {
+	return fingerprint.SimHash(r.Body)
+}
+
 // SetBody sets the response body and updates Content-Length
 // If compress is true, compresses the body based on Content-Encoding header
 func (r *Response) SetBody(body []byte, compress bool) error {
@@ -138,7 +213,7 @@ func (r *Response) SetBody(body []byte, compress bool) error {
 
 	// Update Content-Length based on raw body size
 	if len(r.RawBody) > 0 {
-		r.Headers.Set("Content-Length", fmt.Sprintf("%d", len(r.RawBody)))
+		r.Headers.Set("Content-Length", strconv.Itoa(len(r.RawBody)))
 	} else {
 		r.Headers.Del("Content-Length")
 	}
@@ -146,6 +221,16 @@ func (r *Response) SetBody(body []byte, compress bool) error {
 	return nil
 }
 
+// StatusClass returns the class of the status code (1xx-5xx)
+func (r *Response) StatusClass() status.Class {
+	return status.ClassOf(r.StatusCode)
+}
+
+// IsInformational returns true if the response has a 1xx status code
+func (r *Response) IsInformational() bool {
+	return r.StatusCode >= 100 && r.StatusCode < 200
+}
+
 // IsSuccessful returns true if the response has a 2xx status code
 func (r *Response) IsSuccessful() bool {
 	return r.StatusCode >= 200 && r.StatusCode < 300
@@ -166,6 +251,67 @@ func (r *Response) IsServerError() bool {
 	return r.StatusCode >= 500 && r.StatusCode < 600
 }
 
+// BodyAllowed reports whether the response may carry a body
+// Responses to HEAD and 1xx, 204 and 304 responses never have one,
+// so Parse ignores and Build omits any body for them
+func (r *Response) BodyAllowed() bool {
+	return headers.ResponseHasBody(r.StatusCode, r.RequestMethod)
+}
+
+// CanReuseConnection reports whether the connection can be reused for another
+// request after this response, following RFC 9112 section 9.3
+// requestVersion is the version of the request sent (e.g. "HTTP/1.1")
+//   - "Connection: close" always ends the connection
+//   - HTTP/1.1 connections persist by default
+//   - HTTP/1.0 (request or response) persists only with "Connection: keep-alive"
+//   - 101 Switching Protocols and bodies delimited by connection close end it
+func (r *Response) CanReuseConnection(requestVersion string) bool {
+	if r.StatusCode == 101 {
+		return false
+	}
+
+	var keepAlive bool
+	for _, token := range strings.Split(r.Headers.Get("Connection"), ",") {
+		switch strings.ToLower(strings.TrimSpace(token)) {
+		case "close":
+			return false
+		case "keep-alive":
+			keepAlive = true
+		}
+	}
+
+	if isHTTP10(r.Version) || isHTTP10(requestVersion) {
+		if !keepAlive {
+			return false
+		}
+	}
+
+	// Without framing the body ends when the server closes the connection
+	if r.BodyAllowed() {
+		framing := headers.GetFraming(r.Headers)
+		if !framing.Chunked && framing.ContentLength < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isHTTP10 reports whether version is HTTP/1.0 or older
+func isHTTP10(version string) bool {
+	version = strings.ToUpper(strings.TrimSpace(version))
+	return version == "HTTP/1.0" || version == "HTTP/0.9"
+}
+
+// buildStatusText returns the status text for building
+// Falls back to the registered reason phrase when StatusText is empty
+func (r *Response) buildStatusText() string {
+	if r.StatusText != "" {
+		return r.StatusText
+	}
+	return status.Text(r.StatusCode)
+}
+
 // GetRedirectLocation returns the Location header for redirects (trimmed)
 func (r *Response) GetRedirectLocation() string {
 	if r.IsRedirect() {
@@ -189,6 +335,7 @@ func (r *Response) DecodeChunkedBody() map[string]string {
 
 	// Decode using chunked package
 	decodedBody, trailers := chunked.Decode(r.Body)
+	r.Trailers = chunked.Trailers(r.Body)
 
 	// Store original chunked body in RawBody if not already stored
 	if len(r.RawBody) == 0 || r.IsBodyChunked {
@@ -234,16 +381,21 @@ func (r *Response) EncodeChunkedBody(chunkSize int) {
 // ============================================================================
 
 // ParseSetCookies extracts Set-Cookie headers
-// Updates SetCookies field
+// Updates SetCookies field; headers holding several comma-separated cookies are split leniently
 func (r *Response) ParseSetCookies() {
+	r.ParseSetCookiesWithMode(cookies.SplitLenient)
+}
+
+// ParseSetCookiesWithMode parses Set-Cookie headers into SetCookies
+// mode controls splitting of headers that hold several comma-separated cookies
+func (r *Response) ParseSetCookiesWithMode(mode cookies.SplitMode) {
 	// Get all Set-Cookie headers (there can be multiple)
 	allHeaders := r.Headers.All()
 	r.SetCookies = []cookies.ResponseCookie{}
 
 	for _, header := range allHeaders {
 		if header.Name == "Set-Cookie" {
-			cookie := cookies.ParseSetCookie(header.Value)
-			r.SetCookies = append(r.SetCookies, cookie)
+			r.SetCookies = append(r.SetCookies, cookies.ParseSetCookies(header.Value, mode)...)
 		}
 	}
 }
@@ -305,7 +457,7 @@ func (r *Response) WriteTo(w io.Writer) (int64, error) {
 	}
 
 	// Write body
-	if len(r.Body) > 0 {
+	if len(r.Body) > 0 && r.BodyAllowed() {
 		written, err := w.Write(r.Body)
 		total += int64(written)
 		if err != nil {
@@ -320,32 +472,23 @@ func (r *Response) WriteTo(w io.Writer) (int64, error) {
 // This is useful for streaming large bodies separately
 // Returns the number of bytes written and any error encountered
 func (r *Response) WriteHeadersTo(w io.Writer) (int64, error) {
-	var total int64
-
-	// Build status line
-	statusLine := fmt.Sprintf("%s %d %s%s", r.Version, r.StatusCode, r.StatusText, r.LineSeparator)
-	n, err := w.Write([]byte(statusLine))
-	total += int64(n)
-	if err != nil {
-		return total, err
-	}
-
-	// Write headers
-	headerBytes := r.Headers.Build()
-	n, err = w.Write(headerBytes)
-	total += int64(n)
-	if err != nil {
-		return total, err
-	}
-
-	// Write header-body separator
-	n, err = w.Write([]byte(r.LineSeparator))
-	total += int64(n)
-	if err != nil {
-		return total, err
-	}
-
-	return total, nil
+	// Build status line into a pre-sized buffer and write the head in one call
+	statusText := r.buildStatusText()
+	buf := make([]byte, 0, len(r.Version)+len(statusText)+5+
+		2*len(r.LineSeparator)+r.Headers.BuildSize())
+	buf = append(buf, r.Version...)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, int64(r.StatusCode), 10)
+	buf = append(buf, ' ')
+	buf = append(buf, statusText...)
+	buf = append(buf, r.LineSeparator...)
+
+	// Headers followed by the header-body separator
+	buf = r.Headers.AppendBuild(buf)
+	buf = append(buf, r.LineSeparator...)
+
+	n, err := w.Write(buf)
+	return int64(n), err
 }
 
 // WriteBodyTo writes only the body to the writer
@@ -413,6 +556,7 @@ func (r *Response) WriteToWithBodyChunked(dst io.Writer, bodyReader io.Reader, c
 
 	// Write body with chunked encoding
 	chunkedWriter := chunked.NewEncodeWriter(dst, chunkSize)
+	chunkedWriter.SetTrailers(r.Trailers)
 
 	// Use a countingWriter to track bytes
 	buf := make([]byte, 32*1024) // 32KB buffer
@@ -455,6 +599,30 @@ type StreamingBody struct {
 	isCompressed bool
 	compType     compression.CompressionType
 	totalRead    int64
+	rawCapture   *bytes.Buffer
+	warnings     []errors.Diagnostic
+	clock        clock.Clock
+	startTime    time.Time
+	onProgress   func(bytesRead int64, elapsed time.Duration)
+	limit        int64
+	limitErr     error
+	lineReader   *stream.LineReader
+}
+
+// BodyReaderOptions configures WrapBodyReaderWithOptions
+type BodyReaderOptions struct {
+	// Tee receives a copy of the undecoded (wire) body bytes as they are pulled
+	// from the source reader, e.g. a *bytes.Buffer or *os.File
+	// Decoders read ahead, so bytes are teed as soon as they are consumed from
+	// the source, not when the decoded data is returned to the caller
+	Tee io.Writer
+
+	// CaptureRaw keeps an in-memory copy of the undecoded body bytes,
+	// available via StreamingBody.RawBody() after the body has been consumed
+	CaptureRaw bool
+
+	// Clock times Elapsed, Rate and progress callbacks (nil = clock.Real)
+	Clock clock.Clock
 }
 
 // WrapBodyReader wraps a body reader with automatic decompression and/or chunked decoding
@@ -462,19 +630,59 @@ type StreamingBody struct {
 //
 // If isChunked is true, wraps with chunked decoder
 // If contentEncoding is set, wraps with appropriate decompressor
-// Order: raw -> chunked decode -> decompress (matches HTTP specification)
+// Order: raw -> chunked decode -> other transfer codings -> decompress (matches HTTP specification)
 //
 // The returned StreamingBody must be closed when done
 func (r *Response) WrapBodyReader(bodyReader io.Reader) (*StreamingBody, error) {
+	return r.WrapBodyReaderWithOptions(bodyReader, BodyReaderOptions{})
+}
+
+// WrapBodyReaderWithOptions wraps a body reader like WrapBodyReader with additional options
+// Use Tee or CaptureRaw to keep the undecoded wire bytes so RawBody can be
+// reconstructed after the decoded stream has been consumed
+func (r *Response) WrapBodyReaderWithOptions(bodyReader io.Reader, opts BodyReaderOptions) (*StreamingBody, error) {
 	var reader io.Reader = bodyReader
 	var closers []func() error
 
+	// Tee the wire bytes before any decoding takes place
+	var rawCapture *bytes.Buffer
+	var teeWriters []io.Writer
+	if opts.Tee != nil {
+		teeWriters = append(teeWriters, opts.Tee)
+	}
+	if opts.CaptureRaw {
+		rawCapture = &bytes.Buffer{}
+		teeWriters = append(teeWriters, rawCapture)
+	}
+	if len(teeWriters) == 1 {
+		reader = io.TeeReader(reader, teeWriters[0])
+	} else if len(teeWriters) > 1 {
+		reader = io.TeeReader(reader, io.MultiWriter(teeWriters...))
+	}
+
 	// First: decode chunked if needed (chunked is outermost encoding)
 	if r.IsBodyChunked {
 		reader = chunked.NewDecodeReader(reader)
 	}
 
-	// Second: decompress if needed
+	// Then remove transfer codings applied before chunked (e.g. "gzip, chunked")
+	// Unknown codings, and any applied before them, are passed through
+	var warnings errors.Diagnostics
+	if codings := compression.TransferCodings(r.Headers.Get("Transfer-Encoding")); len(codings) > 0 {
+		undecodable, decodable := compression.SplitTransferCodings(codings)
+		if len(undecodable) > 0 {
+			warnings.Add(errors.DiagDecompressionFailed, 0,
+				"transfer coding %q not decoded", strings.Join(undecodable, ", "))
+		}
+		transferReader, err := compression.NewTransferDecodeReader(reader, decodable)
+		if err != nil {
+			return nil, err
+		}
+		reader = transferReader
+		closers = append(closers, transferReader.Close)
+	}
+
+	// Finally: decompress the content if needed
 	contentEncoding := r.GetContentEncoding()
 	compType := compression.DetectCompression(contentEncoding)
 	if compType != compression.CompressionNone {
@@ -490,6 +698,7 @@ func (r *Response) WrapBodyReader(bodyReader io.Reader) (*StreamingBody, error)
 		closers = append(closers, decompReader.Close)
 	}
 
+	clk := clock.Or(opts.Clock)
 	closeFunc := func() error {
 		var lastErr error
 		for i := len(closers) - 1; i >= 0; i-- {
@@ -506,13 +715,30 @@ func (r *Response) WrapBodyReader(bodyReader io.Reader) (*StreamingBody, error)
 		isChunked:    r.IsBodyChunked,
 		isCompressed: compType != compression.CompressionNone,
 		compType:     compType,
+		rawCapture:   rawCapture,
+		warnings:     warnings,
+		clock:        clk,
+		startTime:    clk.Now(),
 	}, nil
 }
 
 // Read implements io.Reader interface
 func (s *StreamingBody) Read(p []byte) (int, error) {
+	if s.limitErr != nil {
+		return 0, s.limitErr
+	}
+
 	n, err := s.reader.Read(p)
+	if s.limit > 0 && s.totalRead+int64(n) > s.limit {
+		n = int(s.limit - s.totalRead)
+		s.limitErr = errors.NewError(errors.ErrorTypeBodyTooLarge,
+			fmt.Sprintf("decoded body exceeds %d bytes", s.limit), "streaming body", nil)
+		err = s.limitErr
+	}
 	s.totalRead += int64(n)
+	if s.onProgress != nil && (n > 0 || err == io.EOF) {
+		s.onProgress(s.totalRead, clock.Since(s.clock, s.startTime))
+	}
 	return n, err
 }
 
@@ -539,21 +765,70 @@ func (s *StreamingBody) IsCompressed() bool {
 	return s.isCompressed
 }
 
+// LimitBytes caps the number of decoded bytes that can be read from the body
+// Once more than n bytes are produced, Read returns the bytes up to the limit
+// followed by an ErrorTypeBodyTooLarge error (see errors.IsBodyTooLarge)
+// This protects ReadAll and Search against decompression bombs
+// A value of 0 or less disables the limit
+func (s *StreamingBody) LimitBytes(n int64) {
+	s.limit = n
+}
+
+// OnProgress registers a callback invoked after every read that returns data
+// and once more at EOF, with the decoded bytes read so far and the time elapsed
+// since the body was wrapped
+// The callback runs on the reading goroutine and should return quickly
+func (s *StreamingBody) OnProgress(fn func(bytesRead int64, elapsed time.Duration)) {
+	s.onProgress = fn
+}
+
+// Elapsed returns the time since the body was wrapped
+func (s *StreamingBody) Elapsed() time.Duration {
+	return clock.Since(s.clock, s.startTime)
+}
+
+// Rate returns the average throughput in decoded bytes per second since the body was wrapped
+// A rate that keeps falling while the body is being read indicates a stalled transfer
+func (s *StreamingBody) Rate() float64 {
+	elapsed := clock.Since(s.clock, s.startTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.totalRead) / elapsed
+}
+
+// RawBody returns the undecoded body bytes consumed so far
+// Only available when the body was wrapped with BodyReaderOptions.CaptureRaw,
+// otherwise returns nil
+func (s *StreamingBody) RawBody() []byte {
+	if s.rawCapture == nil {
+		return nil
+	}
+	return s.rawCapture.Bytes()
+}
+
 // CompressionType returns the compression type used
 func (s *StreamingBody) CompressionType() compression.CompressionType {
 	return s.compType
 }
 
+// Warnings returns the anomalies found while setting up decoding, such as
+// transfer codings that were passed through undecoded
+func (s *StreamingBody) Warnings() []errors.Diagnostic {
+	return s.warnings
+}
+
 // WriteTo implements io.WriterTo interface
 // Writes all remaining body data to the writer
 func (s *StreamingBody) WriteTo(w io.Writer) (int64, error) {
-	return io.Copy(w, s.reader)
+	// Hide WriteTo from io.Copy so reads go through Read and are counted
+	return io.Copy(w, struct{ io.Reader }{s})
 }
 
 // ReadAll reads all remaining body data into memory
 // Use with caution for large bodies
 func (s *StreamingBody) ReadAll() ([]byte, error) {
-	return io.ReadAll(s.reader)
+	return io.ReadAll(s)
 }
 
 // Search searches for a pattern in the streaming body
@@ -578,7 +853,7 @@ func (s *StreamingBody) Search(pattern []byte) (int64, error) {
 
 	for {
 		// Read more data
-		n, err := s.reader.Read(buf[buffered:])
+		n, err := s.Read(buf[buffered:])
 		if n > 0 {
 			buffered += n
 
@@ -625,30 +900,75 @@ func (s *StreamingBody) ContainsString(pattern string) (bool, error) {
 	return s.Contains([]byte(pattern))
 }
 
+// SearchFold searches for a pattern ignoring ASCII case
+// Returns the offset of the first match, or -1 if not found
+// WARNING: This reads through the body and cannot be undone
+func (s *StreamingBody) SearchFold(pattern []byte) (int64, error) {
+	return s.SearchWithOptions(pattern, search.StreamOptions{CaseInsensitive: true})
+}
+
+// ContainsFold checks if the pattern exists in the streaming body ignoring ASCII case
+// WARNING: This reads through the body and cannot be undone
+func (s *StreamingBody) ContainsFold(pattern []byte) (bool, error) {
+	offset, err := s.SearchFold(pattern)
+	return offset >= 0, err
+}
+
+// SearchWithOptions searches for a pattern after normalizing both the pattern and
+// the body (case folding, whitespace collapsing) as configured by opts
+// Returns the offset in the decoded body of the first match, or -1 if not found
+// WARNING: This reads through the body and cannot be undone
+func (s *StreamingBody) SearchWithOptions(pattern []byte, opts search.StreamOptions) (int64, error) {
+	return search.SearchReader(s, pattern, opts)
+}
+
+// ContainsWithOptions checks if the pattern exists in the streaming body using normalization options
+// WARNING: This reads through the body and cannot be undone
+func (s *StreamingBody) ContainsWithOptions(pattern []byte, opts search.StreamOptions) (bool, error) {
+	offset, err := s.SearchWithOptions(pattern, opts)
+	return offset >= 0, err
+}
+
+// Lines returns a LineReader for consuming the body record-by-record
+// (NDJSON, logs, etc.), with lines limited to maxLineLength bytes
+// (0 = stream.DefaultMaxLineLength)
+// The LineReader buffers ahead, so do not mix it with direct Read calls
+func (s *StreamingBody) Lines(maxLineLength int) *stream.LineReader {
+	s.lineReader = stream.NewLineReader(s, maxLineLength)
+	return s.lineReader
+}
+
+// ReadLine returns the next line of the body without the trailing line ending
+// Uses the LineReader created by Lines(), or one with the default maximum length
+// Returns io.EOF when the body is exhausted and stream.ErrLineTooLong for oversized lines
+func (s *StreamingBody) ReadLine() ([]byte, error) {
+	if s.lineReader == nil {
+		s.lineReader = stream.NewLineReader(s, 0)
+	}
+	return s.lineReader.ReadLine()
+}
+
+// Rewindable wraps the body in a stream.RewindableReader so it can be read,
+// rewound and read again (e.g. search the body, then forward it)
+// Data is buffered in memory up to opts.MemoryLimit, then spilled to a temp file
+// Close the returned reader to remove the spill file
+func (s *StreamingBody) Rewindable(opts stream.RewindOptions) *stream.RewindableReader {
+	return stream.NewRewindableReader(s, opts)
+}
+
 // CopyTo copies all remaining body data to the writer
 // This is an alias for WriteTo for clearer semantics
 func (s *StreamingBody) CopyTo(w io.Writer) (int64, error) {
 	return s.WriteTo(w)
 }
 
-// searchBytes searches for pattern in data using a simple algorithm
+// searchBytes searches for pattern in data
+// Delegates to bytes.Index, which uses SIMD-accelerated and Rabin-Karp
+// based searching instead of a naive O(n*m) comparison loop
 // Returns the index of the first match, or -1 if not found
 func searchBytes(data, pattern []byte) int {
 	if len(pattern) == 0 || len(data) < len(pattern) {
 		return -1
 	}
-
-	for i := 0; i <= len(data)-len(pattern); i++ {
-		found := true
-		for j := 0; j < len(pattern); j++ {
-			if data[i+j] != pattern[j] {
-				found = false
-				break
-			}
-		}
-		if found {
-			return i
-		}
-	}
-	return -1
+	return bytes.Index(data, pattern)
 }
@@ -0,0 +1,59 @@
+package response
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/WhileEndless/go-httptools/pkg/errors"
+)
+
+// finalRaw returns the part of Raw holding the final response
+// Interim responses are skipped, since Build emits only the final response
+func (r *Response) finalRaw() []byte {
+	skip := 0
+	for i := range r.InterimResponses {
+		skip += len(r.InterimResponses[i].Raw)
+	}
+	if skip > len(r.Raw) {
+		return nil
+	}
+	return r.Raw[skip:]
+}
+
+// RawEquals reports whether Build reproduces the final response in Raw byte for byte
+func (r *Response) RawEquals() bool {
+	return bytes.Equal(r.Build(), r.finalRaw())
+}
+
+// VerifyRoundTrip rebuilds the response and compares it with Raw
+// Returns nil when they are identical, or an error located at the first
+// differing byte of the final response (see errors.Error Offset, Line and Snippet)
+func (r *Response) VerifyRoundTrip() error {
+	return verifyRoundTrip(r.finalRaw(), r.Build())
+}
+
+// StrictBuild enables strict mode: as long as the response is not modified
+// afterwards, Build returns the final response in Raw exactly, even where
+// the parser normalizes
+// Call it right after parsing; modifications made later disable it implicitly
+func (r *Response) StrictBuild() {
+	r.pristine = r.build()
+}
+
+// verifyRoundTrip compares a rebuilt message with the original bytes
+func verifyRoundTrip(raw, built []byte) error {
+	n := min(len(raw), len(built))
+	offset := n
+	for i := 0; i < n; i++ {
+		if raw[i] != built[i] {
+			offset = i
+			break
+		}
+	}
+	if offset == n && len(raw) == len(built) {
+		return nil
+	}
+	return errors.NewErrorAt(errors.ErrorTypeInvalidFormat,
+		fmt.Sprintf("rebuilt message differs from Raw at byte %d (%d vs %d bytes)", offset, len(built), len(raw)),
+		"VerifyRoundTrip", raw, offset)
+}
@@ -0,0 +1,180 @@
+// Package schedule sends prepared requests with bounded concurrency
+//
+// The library does not open connections itself; the caller provides the
+// transport as a SendFunc and the scheduler takes care of the worker pool,
+// per-host limits and cancellation:
+//
+//	results := schedule.Run(ctx, requests, send, schedule.Options{Workers: 20, PerHost: 4})
+//	for r := range results {
+//		if r.Err != nil {
+//			log.Printf("request %d: %v", r.Index, r.Err)
+//			continue
+//		}
+//		analyze(r.Request, r.Response)
+//	}
+package schedule
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+// DefaultWorkers is the number of concurrent sends when Options.Workers is 0
+const DefaultWorkers = 10
+
+// SendFunc sends one request and returns its response
+// It should return promptly once ctx is cancelled
+type SendFunc func(ctx context.Context, req *request.Request) (*response.Response, error)
+
+// Options configures Run
+type Options struct {
+	// Workers is the maximum number of concurrent sends (0 = DefaultWorkers)
+	Workers int
+
+	// PerHost is the maximum number of concurrent sends per Host header
+	// (0 = no per-host limit)
+	// A worker waiting for a busy host does not pick up other requests
+	PerHost int
+}
+
+// Result is the outcome of sending one request
+type Result struct {
+	Index    int // Position of the request in the input
+	Request  *request.Request
+	Response *response.Response
+	Err      error
+}
+
+// Run sends every request received from in and streams the results as
+// they complete, in completion order
+// Once ctx is cancelled no further requests are taken from in; requests
+// already being sent report whatever send returns
+// The result channel is closed when in is closed or ctx is cancelled and
+// all sends have finished; it must be drained
+func Run(ctx context.Context, in <-chan *request.Request, send SendFunc, opts Options) <-chan Result {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	out := make(chan Result, workers)
+	jobs := make(chan Result)
+	limits := &hostLimits{max: opts.PerHost}
+
+	go func() {
+		defer close(jobs)
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case req, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case jobs <- Result{Index: i, Request: req}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				host := strings.ToLower(job.Request.GetHost())
+				if ctx.Err() != nil || !limits.acquire(ctx, host) {
+					job.Err = ctx.Err()
+					out <- job
+					continue
+				}
+				job.Response, job.Err = send(ctx, job.Request)
+				limits.release(host)
+				out <- job
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// SendAll sends all requests and returns the results in input order
+func SendAll(ctx context.Context, reqs []*request.Request, send SendFunc, opts Options) []Result {
+	in := make(chan *request.Request)
+	go func() {
+		defer close(in)
+		for _, req := range reqs {
+			select {
+			case in <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make([]Result, len(reqs))
+	sent := make([]bool, len(reqs))
+	for r := range Run(ctx, in, send, opts) {
+		results[r.Index] = r
+		sent[r.Index] = true
+	}
+	for i := range results {
+		if !sent[i] {
+			results[i] = Result{Index: i, Request: reqs[i], Err: ctx.Err()}
+		}
+	}
+	return results
+}
+
+// hostLimits holds one semaphore per host
+type hostLimits struct {
+	max  int
+	mu   sync.Mutex
+	sems map[string]chan struct{}
+}
+
+// acquire waits for a free slot for host; false if ctx was cancelled first
+func (l *hostLimits) acquire(ctx context.Context, host string) bool {
+	if l.max <= 0 {
+		return true
+	}
+	select {
+	case l.sem(host) <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees the slot taken by acquire
+func (l *hostLimits) release(host string) {
+	if l.max > 0 {
+		<-l.sem(host)
+	}
+}
+
+// sem returns the semaphore of host, creating it on first use
+func (l *hostLimits) sem(host string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sems == nil {
+		l.sems = make(map[string]chan struct{})
+	}
+	sem, ok := l.sems[host]
+	if !ok {
+		sem = make(chan struct{}, l.max)
+		l.sems[host] = sem
+	}
+	return sem
+}
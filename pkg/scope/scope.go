@@ -0,0 +1,183 @@
+// Package scope decides which requests and responses a tool should handle
+//
+// A Scope combines host globs, port ranges, path regexes and MIME type filters,
+// so proxies, stores and replayers can share one definition of "in scope":
+//
+//	s, err := scope.New(scope.Rules{
+//		IncludeHosts: []string{"example.com", "*.example.com"},
+//		ExcludePaths: []string{`\.(png|jpg|css)$`},
+//	})
+//	if s.Match(req) { ... }
+package scope
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+// PortRange is an inclusive range of ports
+type PortRange struct {
+	Low, High int
+}
+
+// Rules configures a Scope
+// Empty include lists match everything; exclude lists take precedence
+type Rules struct {
+	IncludeHosts []string // Host globs ("*.example.com"), matched case-insensitively without port
+	ExcludeHosts []string
+
+	Ports []PortRange // Allowed ports (empty = any)
+
+	IncludePaths []string // Regexes matched against the path (without query)
+	ExcludePaths []string
+
+	IncludeMIME []string // Response media types ("text/html", "application/*")
+	ExcludeMIME []string
+}
+
+// Scope is a compiled set of Rules
+type Scope struct {
+	rules        Rules
+	includePaths []*regexp.Regexp
+	excludePaths []*regexp.Regexp
+}
+
+// New compiles rules
+func New(rules Rules) (*Scope, error) {
+	s := &Scope{rules: rules}
+	var err error
+	if s.includePaths, err = compileAll(rules.IncludePaths); err != nil {
+		return nil, err
+	}
+	if s.excludePaths, err = compileAll(rules.ExcludePaths); err != nil {
+		return nil, err
+	}
+	for _, globs := range [][]string{rules.IncludeHosts, rules.ExcludeHosts} {
+		for _, glob := range globs {
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("scope: invalid host glob %q: %w", glob, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Match reports whether req is in scope by host, port and path
+// The host and port come from an absolute-form target or the Host header;
+// without an explicit port, 443 is assumed for https targets and 80 otherwise
+func (s *Scope) Match(req *request.Request) bool {
+	host, port, p := target(req)
+	if len(s.rules.IncludeHosts) > 0 && !matchGlob(s.rules.IncludeHosts, host) {
+		return false
+	}
+	if matchGlob(s.rules.ExcludeHosts, host) {
+		return false
+	}
+	if len(s.rules.Ports) > 0 && !inRanges(s.rules.Ports, port) {
+		return false
+	}
+	if len(s.includePaths) > 0 && !matchRegex(s.includePaths, p) {
+		return false
+	}
+	return !matchRegex(s.excludePaths, p)
+}
+
+// MatchResponse reports whether req is in scope and resp has an allowed media type
+// Responses without Content-Type only match when IncludeMIME is empty
+func (s *Scope) MatchResponse(req *request.Request, resp *response.Response) bool {
+	if !s.Match(req) {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Headers.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+	if len(s.rules.IncludeMIME) > 0 && !matchMIME(s.rules.IncludeMIME, mediaType) {
+		return false
+	}
+	return !matchMIME(s.rules.ExcludeMIME, mediaType)
+}
+
+// target returns the lowercase host, port and path of req
+func target(req *request.Request) (host string, port int, p string) {
+	authority, p, https := req.GetHost(), req.Path, false
+	if u, err := url.Parse(req.URL); err == nil && u.Scheme != "" && u.Host != "" {
+		authority, p, https = u.Host, u.Path, strings.EqualFold(u.Scheme, "https")
+	}
+
+	port = 80
+	if https {
+		port = 443
+	}
+	host = authority
+	if h, portStr, err := net.SplitHostPort(authority); err == nil {
+		host = h
+		if n, err := strconv.Atoi(portStr); err == nil {
+			port = n
+		}
+	}
+	return strings.ToLower(strings.Trim(host, "[]")), port, p
+}
+
+func compileAll(exprs []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("scope: invalid path regex %q: %w", expr, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchGlob(globs []string, host string) bool {
+	for _, glob := range globs {
+		if ok, _ := path.Match(strings.ToLower(glob), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRegex(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func inRanges(ranges []PortRange, port int) bool {
+	for _, r := range ranges {
+		if port >= r.Low && port <= r.High {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMIME matches a media type against patterns with optional "type/*" wildcards
+func matchMIME(patterns []string, mediaType string) bool {
+	if mediaType == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mediaType || pattern == "*/*" ||
+			(strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package search
+
+import (
+	"bytes"
+	"io"
+)
+
+// ============================================================================
+// Streaming Find-and-Replace
+// ============================================================================
+
+// streamReplacer holds the matching state shared by ReplaceReader and ReplaceWriter
+// Up to len(old)-1 trailing bytes are held back between calls so that matches
+// spanning buffer boundaries are still replaced
+type streamReplacer struct {
+	old     []byte
+	new     []byte
+	pending []byte
+	count   int
+}
+
+// process appends the replaced form of pending+data to dst
+// When final is true, no bytes are held back
+func (sr *streamReplacer) process(dst, data []byte, final bool) []byte {
+	if len(sr.old) == 0 {
+		return append(dst, data...)
+	}
+
+	buf := data
+	if len(sr.pending) > 0 {
+		buf = append(sr.pending, data...)
+	}
+
+	pos := 0
+	for {
+		idx := bytes.Index(buf[pos:], sr.old)
+		if idx == -1 {
+			break
+		}
+		dst = append(dst, buf[pos:pos+idx]...)
+		dst = append(dst, sr.new...)
+		pos += idx + len(sr.old)
+		sr.count++
+	}
+
+	if final {
+		dst = append(dst, buf[pos:]...)
+		sr.pending = sr.pending[:0]
+		return dst
+	}
+
+	// Hold back a possible partial match at the end of the buffer
+	tailStart := len(buf) - (len(sr.old) - 1)
+	if tailStart < pos {
+		tailStart = pos
+	}
+	dst = append(dst, buf[pos:tailStart]...)
+	sr.pending = append(sr.pending[:0], buf[tailStart:]...)
+	return dst
+}
+
+// ReplaceReader replaces every occurrence of a pattern while data is read through it
+// Memory use is bounded by the read size plus len(old)
+// Note: the output length differs from the input when len(old) != len(new),
+// so bodies rewritten this way should be sent chunked or with a recomputed Content-Length
+type ReplaceReader struct {
+	src      io.Reader
+	replacer streamReplacer
+	readBuf  []byte
+	out      []byte
+	err      error
+}
+
+// NewReplaceReader creates a reader that replaces old with new in the data read from r
+func NewReplaceReader(r io.Reader, old, new []byte) *ReplaceReader {
+	return &ReplaceReader{
+		src:      r,
+		replacer: streamReplacer{old: old, new: new},
+		readBuf:  make([]byte, 32*1024),
+	}
+}
+
+// Read implements io.Reader interface
+func (rr *ReplaceReader) Read(p []byte) (int, error) {
+	for len(rr.out) == 0 {
+		if rr.err != nil {
+			return 0, rr.err
+		}
+
+		n, err := rr.src.Read(rr.readBuf)
+		if err != nil {
+			rr.err = err
+		}
+		// Flush held-back bytes once the source is exhausted
+		rr.out = rr.replacer.process(rr.out[:0], rr.readBuf[:n], err != nil)
+	}
+
+	n := copy(p, rr.out)
+	rr.out = rr.out[n:]
+	return n, nil
+}
+
+// Count returns the number of replacements made so far
+func (rr *ReplaceReader) Count() int {
+	return rr.replacer.count
+}
+
+// ReplaceWriter replaces every occurrence of a pattern in data written through it
+// IMPORTANT: Always call Close() when done to flush held-back bytes
+// Close does not close the underlying writer
+type ReplaceWriter struct {
+	dst      io.Writer
+	replacer streamReplacer
+	out      []byte
+	closed   bool
+}
+
+// NewReplaceWriter creates a writer that replaces old with new before writing to w
+func NewReplaceWriter(w io.Writer, old, new []byte) *ReplaceWriter {
+	return &ReplaceWriter{
+		dst:      w,
+		replacer: streamReplacer{old: old, new: new},
+	}
+}
+
+// Write implements io.Writer interface
+// Returns len(p) on success, since replacement changes the number of bytes written downstream
+func (rw *ReplaceWriter) Write(p []byte) (int, error) {
+	if rw.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	rw.out = rw.replacer.process(rw.out[:0], p, false)
+	if len(rw.out) > 0 {
+		if _, err := rw.dst.Write(rw.out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close flushes any held-back bytes to the underlying writer
+func (rw *ReplaceWriter) Close() error {
+	if rw.closed {
+		return nil
+	}
+	rw.closed = true
+
+	rw.out = rw.replacer.process(rw.out[:0], nil, true)
+	if len(rw.out) > 0 {
+		_, err := rw.dst.Write(rw.out)
+		return err
+	}
+	return nil
+}
+
+// Count returns the number of replacements made so far
+func (rw *ReplaceWriter) Count() int {
+	return rw.replacer.count
+}
@@ -0,0 +1,132 @@
+package search
+
+import (
+	"bytes"
+	"io"
+)
+
+// StreamOptions configures normalization for streaming searches
+// Both the pattern and the stream are normalized the same way before matching,
+// so reflections that differ only in case or spacing are still found
+type StreamOptions struct {
+	// CaseInsensitive folds ASCII letters to lower case before matching
+	CaseInsensitive bool
+
+	// CollapseWhitespace treats any run of whitespace (space, tab, CR, LF, FF, VT)
+	// as a single space, so "a  b" matches "a\r\n\tb"
+	CollapseWhitespace bool
+
+	// BufferSize is the read window size in bytes (0 = default 64KB)
+	BufferSize int
+}
+
+// SearchReader searches for pattern in the data read from r
+// Returns the offset (in the original, un-normalized stream) of the first match,
+// or -1 if not found
+// The reader is consumed up to and including the chunk containing the match
+func SearchReader(r io.Reader, pattern []byte, opts StreamOptions) (int64, error) {
+	needle := newNormalizer(opts).normalize(pattern)
+	if len(needle) == 0 {
+		return -1, nil
+	}
+
+	bufSize := opts.BufferSize
+	if bufSize <= 0 {
+		bufSize = 64 * 1024
+	}
+	if len(needle) > bufSize/2 {
+		bufSize = len(needle) * 4
+	}
+
+	norm := newNormalizer(opts)
+	readBuf := make([]byte, bufSize)
+	window := make([]byte, 0, bufSize+len(needle))
+	offsets := make([]int64, 0, bufSize+len(needle))
+	var consumed int64
+
+	for {
+		n, err := r.Read(readBuf)
+		if n > 0 {
+			window, offsets = norm.appendNormalized(window, offsets, readBuf[:n], consumed)
+			consumed += int64(n)
+
+			if idx := bytes.Index(window, needle); idx >= 0 {
+				return offsets[idx], nil
+			}
+
+			// Keep overlap so matches spanning reads are found
+			if keep := len(needle) - 1; len(window) > keep {
+				copy(window, window[len(window)-keep:])
+				window = window[:keep]
+				copy(offsets, offsets[len(offsets)-keep:])
+				offsets = offsets[:keep]
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return -1, nil
+}
+
+// ContainsReader checks if pattern exists in the data read from r
+func ContainsReader(r io.Reader, pattern []byte, opts StreamOptions) (bool, error) {
+	offset, err := SearchReader(r, pattern, opts)
+	return offset >= 0, err
+}
+
+// normalizer applies StreamOptions normalization incrementally
+// It keeps whitespace state between calls so runs spanning reads collapse correctly
+type normalizer struct {
+	opts StreamOptions
+	inWS bool
+}
+
+func newNormalizer(opts StreamOptions) *normalizer {
+	return &normalizer{opts: opts}
+}
+
+// normalize returns the normalized form of data
+func (n *normalizer) normalize(data []byte) []byte {
+	out, _ := n.appendNormalized(nil, nil, data, 0)
+	return out
+}
+
+// appendNormalized appends the normalized form of data to dst
+// If offsets is non-nil, the original offset (base + index) of each emitted byte is appended too
+func (n *normalizer) appendNormalized(dst []byte, offsets []int64, data []byte, base int64) ([]byte, []int64) {
+	trackOffsets := offsets != nil
+	for i, c := range data {
+		if n.opts.CollapseWhitespace && isSpace(c) {
+			if n.inWS {
+				continue
+			}
+			n.inWS = true
+			c = ' '
+		} else {
+			n.inWS = false
+			if n.opts.CaseInsensitive && 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+		}
+		dst = append(dst, c)
+		if trackOffsets {
+			offsets = append(offsets, base+int64(i))
+		}
+	}
+	return dst, offsets
+}
+
+// isSpace reports whether c is ASCII whitespace
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', '\v':
+		return true
+	}
+	return false
+}
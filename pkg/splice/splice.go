@@ -0,0 +1,256 @@
+// Package splice edits raw HTTP messages in place
+//
+// Rebuilding a parsed message normalizes whatever the parser does not model.
+// An Editor instead locates the start line, header lines and body in the
+// original bytes and splices edits into them, so every byte that is not
+// edited (odd whitespace, line endings, duplicate headers) is kept exactly:
+//
+//	e := splice.New(req.Raw)
+//	e.SetHeader("Cookie", "session=other")
+//	e.SetBody([]byte(`{"role":"admin"}`), true)
+//	replay(e.Bytes())
+package splice
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/framing"
+)
+
+// ErrOverlap is returned when an edit overlaps an earlier one
+var ErrOverlap = errors.New("splice: edit overlaps a previous edit")
+
+// ErrOutOfRange is returned for spans outside the message
+var ErrOutOfRange = errors.New("splice: span out of range")
+
+// Span is the byte range [Start, End) of the original message
+type Span struct {
+	Start, End int
+}
+
+// Header locates one header line
+type Header struct {
+	Name  string
+	Line  Span // Whole line without its line ending
+	Value Span // Value without surrounding whitespace
+}
+
+// Editor applies edits to the original bytes of a message
+// Spans always refer to the original message; edits are applied by Bytes
+type Editor struct {
+	raw     []byte
+	start   [3]Span // Start line fields: method/version, target/status, version/reason
+	headers []Header
+	body    Span
+	edits   []edit
+}
+
+type edit struct {
+	span Span
+	data []byte
+}
+
+// New indexes raw, which holds one request or response
+// Without an empty line after the headers, the whole message is treated as head
+func New(raw []byte) *Editor {
+	e := &Editor{raw: raw}
+
+	headEnd := framing.HeadEnd(raw)
+	if headEnd == -1 {
+		headEnd = len(raw)
+	}
+	e.body = Span{headEnd, len(raw)}
+
+	pos := 0
+	for first := true; pos < headEnd; first = false {
+		end := bytes.IndexByte(raw[pos:headEnd], '\n')
+		next := headEnd
+		if end == -1 {
+			end = headEnd
+		} else {
+			end += pos
+			next = end + 1
+		}
+		if end > pos && raw[end-1] == '\r' {
+			end--
+		}
+
+		if first {
+			e.start = splitStartLine(raw, pos, end)
+		} else if colon := bytes.IndexByte(raw[pos:end], ':'); colon != -1 && end > pos {
+			colon += pos
+			e.headers = append(e.headers, Header{
+				Name:  string(bytes.TrimSpace(raw[pos:colon])),
+				Line:  Span{pos, end},
+				Value: trimSpan(raw, colon+1, end),
+			})
+		}
+		pos = next
+	}
+	return e
+}
+
+// splitStartLine locates the three space-separated fields of a start line
+// The last field extends to the end of the line, since reason phrases contain spaces
+func splitStartLine(raw []byte, start, end int) [3]Span {
+	var fields [3]Span
+	pos := start
+	for i := range fields {
+		for pos < end && raw[pos] == ' ' {
+			pos++
+		}
+		fieldEnd := pos
+		if i < 2 {
+			for fieldEnd < end && raw[fieldEnd] != ' ' {
+				fieldEnd++
+			}
+		} else {
+			fieldEnd = end
+		}
+		fields[i] = Span{pos, fieldEnd}
+		pos = fieldEnd
+	}
+	return fields
+}
+
+// trimSpan returns [start, end) without leading and trailing spaces and tabs
+func trimSpan(raw []byte, start, end int) Span {
+	for start < end && (raw[start] == ' ' || raw[start] == '\t') {
+		start++
+	}
+	for end > start && (raw[end-1] == ' ' || raw[end-1] == '\t') {
+		end--
+	}
+	return Span{start, end}
+}
+
+// Original returns the message being edited
+func (e *Editor) Original() []byte {
+	return e.raw
+}
+
+// StartLine returns the spans of the three start line fields:
+// method, target and version for requests; version, status code and
+// reason phrase for responses
+func (e *Editor) StartLine() [3]Span {
+	return e.start
+}
+
+// Headers returns the header lines in order, duplicates included
+func (e *Editor) Headers() []Header {
+	return e.headers
+}
+
+// Body returns the span of the body (everything after the head)
+func (e *Editor) Body() Span {
+	return e.body
+}
+
+// Replace replaces the original bytes in span with data
+// Insertions use an empty span; edits may not overlap, but several
+// insertions at the same position are applied in call order
+func (e *Editor) Replace(span Span, data []byte) error {
+	if span.Start < 0 || span.End < span.Start || span.End > len(e.raw) {
+		return ErrOutOfRange
+	}
+	for _, ed := range e.edits {
+		if span.Start < ed.span.End && ed.span.Start < span.End {
+			return ErrOverlap
+		}
+		if span.Start == span.End && span.Start > ed.span.Start && span.Start < ed.span.End {
+			return ErrOverlap
+		}
+	}
+	e.edits = append(e.edits, edit{span, append([]byte(nil), data...)})
+	return nil
+}
+
+// SetStartLine replaces start line field i (0-2) with value
+func (e *Editor) SetStartLine(i int, value string) error {
+	return e.Replace(e.start[i], []byte(value))
+}
+
+// SetHeader replaces the value of the first header named name (case-insensitive)
+// Whitespace around the original value is kept; returns false if there is no such header
+func (e *Editor) SetHeader(name, value string) (bool, error) {
+	for _, h := range e.headers {
+		if strings.EqualFold(h.Name, name) {
+			return true, e.Replace(h.Value, []byte(value))
+		}
+	}
+	return false, nil
+}
+
+// DelHeader removes every header line named name, including its line ending
+func (e *Editor) DelHeader(name string) error {
+	for _, h := range e.headers {
+		if !strings.EqualFold(h.Name, name) {
+			continue
+		}
+		end := h.Line.End
+		if end < len(e.raw) && e.raw[end] == '\r' {
+			end++
+		}
+		if end < len(e.raw) && e.raw[end] == '\n' {
+			end++
+		}
+		if err := e.Replace(Span{h.Line.Start, end}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBody replaces the body
+// With fixLength, an existing Content-Length header is set to the new length
+// unless the message uses Transfer-Encoding
+func (e *Editor) SetBody(body []byte, fixLength bool) error {
+	if err := e.Replace(e.body, body); err != nil {
+		return err
+	}
+	if !fixLength {
+		return nil
+	}
+	for _, h := range e.headers {
+		if strings.EqualFold(h.Name, "Transfer-Encoding") {
+			return nil
+		}
+	}
+	_, err := e.SetHeader("Content-Length", strconv.Itoa(len(body)))
+	return err
+}
+
+// Bytes returns the original message with all edits applied
+func (e *Editor) Bytes() []byte {
+	edits := slices.Clone(e.edits)
+
+	// Insertions go before a replacement starting at the same position
+	slices.SortStableFunc(edits, func(a, b edit) int {
+		if a.span.Start != b.span.Start {
+			return a.span.Start - b.span.Start
+		}
+		return min(a.span.End-a.span.Start, 1) - min(b.span.End-b.span.Start, 1)
+	})
+
+	size := len(e.raw)
+	for _, ed := range edits {
+		size += len(ed.data) - (ed.span.End - ed.span.Start)
+	}
+	out := make([]byte, 0, size)
+	prev := 0
+	for _, ed := range edits {
+		out = append(out, e.raw[prev:ed.span.Start]...)
+		out = append(out, ed.data...)
+		prev = ed.span.End
+	}
+	return append(out, e.raw[prev:]...)
+}
+
+// Reset discards all edits
+func (e *Editor) Reset() {
+	e.edits = nil
+}
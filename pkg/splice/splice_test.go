@@ -0,0 +1,67 @@
+package splice
+
+import "testing"
+
+const raw = "POST  /login?x=1 HTTP/1.1\r\nHost: example.com\r\nX-Odd:\t value \r\nContent-Length: 7\r\nx-odd: second\n\nuser=me"
+
+func TestEditorIndex(t *testing.T) {
+	e := New([]byte(raw))
+
+	start := e.StartLine()
+	if got := raw[start[0].Start:start[0].End] + "|" + raw[start[1].Start:start[1].End] + "|" + raw[start[2].Start:start[2].End]; got != "POST|/login?x=1|HTTP/1.1" {
+		t.Errorf("start line = %q", got)
+	}
+
+	hs := e.Headers()
+	if len(hs) != 4 || hs[1].Name != "X-Odd" || raw[hs[1].Value.Start:hs[1].Value.End] != "value" || hs[3].Name != "x-odd" {
+		t.Fatalf("headers = %+v", hs)
+	}
+	if body := e.Body(); raw[body.Start:body.End] != "user=me" {
+		t.Errorf("body = %q", raw[body.Start:body.End])
+	}
+	if string(e.Bytes()) != raw {
+		t.Error("Bytes without edits changed the message")
+	}
+
+	resp := New([]byte("HTTP/1.1 404 Not Found\r\n\r\n")).StartLine()
+	if resp[2] != (Span{13, 22}) {
+		t.Errorf("reason span = %+v", resp[2])
+	}
+}
+
+func TestEditorEdits(t *testing.T) {
+	e := New([]byte(raw))
+	if ok, err := e.SetHeader("x-odd", "changed"); !ok || err != nil {
+		t.Fatalf("SetHeader = %v, %v", ok, err)
+	}
+	if ok, _ := e.SetHeader("Missing", "v"); ok {
+		t.Error("SetHeader reported a missing header")
+	}
+	if err := e.SetBody([]byte("user=admin"), true); err != nil {
+		t.Fatalf("SetBody failed: %v", err)
+	}
+	if err := e.SetStartLine(1, "/admin"); err != nil {
+		t.Fatalf("SetStartLine failed: %v", err)
+	}
+
+	want := "POST  /admin HTTP/1.1\r\nHost: example.com\r\nX-Odd:\t changed \r\nContent-Length: 10\r\nx-odd: second\n\nuser=admin"
+	if got := string(e.Bytes()); got != want {
+		t.Errorf("Bytes = %q, want %q", got, want)
+	}
+
+	if err := e.Replace(Span{2, 8}, nil); err != ErrOverlap {
+		t.Errorf("overlapping edit: %v", err)
+	}
+	if err := e.Replace(Span{0, len(raw) + 1}, nil); err != ErrOutOfRange {
+		t.Errorf("out of range edit: %v", err)
+	}
+
+	e.Reset()
+	host := e.Headers()[0]
+	e.Replace(Span{host.Line.Start, host.Line.Start}, []byte("X-First: 1\r\n"))
+	e.DelHeader("X-ODD")
+	want = "POST  /login?x=1 HTTP/1.1\r\nX-First: 1\r\nHost: example.com\r\nContent-Length: 7\r\n\nuser=me"
+	if got := string(e.Bytes()); got != want {
+		t.Errorf("Bytes = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+// Package sse writes Server-Sent Events streams
+//
+// A Writer encodes events in the text/event-stream format and flushes after
+// each one, so mock servers and test fixtures can stream events to clients:
+//
+//	w := sse.NewWriter(rw) // rw is flushed if it implements Flush
+//	w.WriteEvent(sse.Event{ID: "1", Event: "update", Data: `{"n":1}`})
+//	go w.KeepAlive(ctx, 15*time.Second)
+package sse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the media type of an event stream
+const ContentType = "text/event-stream"
+
+// ErrInvalidField is returned for an ID or event name containing a line break
+// (or, for IDs, a NUL), which cannot be represented in the stream
+var ErrInvalidField = errors.New("sse: invalid field value")
+
+// Event is a single server-sent event
+type Event struct {
+	ID    string        // Sets the client's last event ID when non-empty
+	Event string        // Event type (empty = "message")
+	Data  string        // Payload; line breaks produce multiple data lines
+	Retry time.Duration // Reconnection delay sent when positive (millisecond precision)
+}
+
+// Writer encodes events to an underlying writer
+// It is safe for concurrent use
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriter creates a Writer for w
+// After each event, w is flushed if it has a Flush() or Flush() error method
+// (http.ResponseWriter, bufio.Writer)
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteEvent writes ev and flushes
+func (w *Writer) WriteEvent(ev Event) error {
+	if strings.ContainsAny(ev.ID, "\r\n\x00") || strings.ContainsAny(ev.Event, "\r\n") {
+		return ErrInvalidField
+	}
+	return w.write(Encode(ev))
+}
+
+// WriteComment writes a comment line, which clients ignore
+// Line breaks in text produce multiple comment lines
+func (w *Writer) WriteComment(text string) error {
+	var b strings.Builder
+	for _, line := range splitLines(text) {
+		b.WriteString(":")
+		if line != "" {
+			b.WriteString(" ")
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return w.write([]byte(b.String()))
+}
+
+// KeepAlive writes an empty comment every interval until ctx is done or a
+// write fails, keeping idle connections open through proxies
+// Returns ctx.Err() or the write error
+func (w *Writer) KeepAlive(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := w.WriteComment(""); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// write writes data and flushes under the lock
+func (w *Writer) write(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.w.Write(data); err != nil {
+		return err
+	}
+	switch f := w.w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	return nil
+}
+
+// Encode returns the wire form of ev, terminated by an empty line
+// Field values are not validated; use Writer.WriteEvent to reject IDs and
+// event names that would break the stream
+func Encode(ev Event) []byte {
+	var b strings.Builder
+	if ev.ID != "" {
+		b.WriteString("id: " + ev.ID + "\n")
+	}
+	if ev.Event != "" {
+		b.WriteString("event: " + ev.Event + "\n")
+	}
+	if ev.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range splitLines(ev.Data) {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+// splitLines splits s at CRLF, LF and CR, the line breaks of the format
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.Split(s, "\n")
+}
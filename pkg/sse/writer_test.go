@@ -0,0 +1,65 @@
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushRecorder) Flush() { f.flushes++ }
+
+func TestWriteEvent(t *testing.T) {
+	var out flushRecorder
+	w := NewWriter(&out)
+
+	if err := w.WriteEvent(Event{ID: "7", Event: "update", Data: "line1\r\nline2\rline3", Retry: 1500 * time.Millisecond}); err != nil {
+		t.Fatalf("WriteEvent failed: %v", err)
+	}
+	if err := w.WriteEvent(Event{}); err != nil {
+		t.Fatalf("WriteEvent failed: %v", err)
+	}
+	if err := w.WriteComment("ping"); err != nil {
+		t.Fatalf("WriteComment failed: %v", err)
+	}
+
+	want := "id: 7\nevent: update\nretry: 1500\ndata: line1\ndata: line2\ndata: line3\n\n" +
+		"data: \n\n" +
+		": ping\n\n"
+	if out.String() != want {
+		t.Errorf("stream = %q, want %q", out.String(), want)
+	}
+	if out.flushes != 3 {
+		t.Errorf("flushed %d times, want 3", out.flushes)
+	}
+
+	for _, ev := range []Event{{ID: "a\nb"}, {ID: "a\x00"}, {Event: "x\ry"}} {
+		if err := w.WriteEvent(ev); err != ErrInvalidField {
+			t.Errorf("WriteEvent(%+v) = %v, want ErrInvalidField", ev, err)
+		}
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	w := NewWriter(bw)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := w.KeepAlive(ctx, 5*time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("KeepAlive = %v", err)
+	}
+
+	// bufio.Writer is flushed after every comment, so nothing is left buffered
+	if bw.Buffered() != 0 || !strings.HasPrefix(buf.String(), ":\n\n") {
+		t.Errorf("stream = %q, buffered %d", buf.String(), bw.Buffered())
+	}
+}
@@ -0,0 +1,170 @@
+// Package status provides HTTP status reason phrases and status classes
+//
+// The reason phrase registry covers the IANA registry plus widely deployed
+// non-standard codes (nginx 499, Apache 509, Cloudflare 52x, ...). Vendor
+// codes can be added at startup:
+//
+//	status.Register(599, "Network Connect Timeout Error")
+package status
+
+import "sync"
+
+// Class is the class of a status code, given by its first digit
+type Class int
+
+const (
+	ClassUnknown       Class = iota // Outside 100-599
+	ClassInformational              // 1xx
+	ClassSuccess                    // 2xx
+	ClassRedirection                // 3xx
+	ClassClientError                // 4xx
+	ClassServerError                // 5xx
+)
+
+// String returns the class name (e.g. "Success")
+func (c Class) String() string {
+	switch c {
+	case ClassInformational:
+		return "Informational"
+	case ClassSuccess:
+		return "Success"
+	case ClassRedirection:
+		return "Redirection"
+	case ClassClientError:
+		return "Client Error"
+	case ClassServerError:
+		return "Server Error"
+	default:
+		return "Unknown"
+	}
+}
+
+// ClassOf returns the class of a status code
+func ClassOf(code int) Class {
+	if code < 100 || code > 599 {
+		return ClassUnknown
+	}
+	return Class(code / 100)
+}
+
+var (
+	mu    sync.RWMutex
+	texts = map[int]string{
+		// 1xx
+		100: "Continue",
+		101: "Switching Protocols",
+		102: "Processing",
+		103: "Early Hints",
+
+		// 2xx
+		200: "OK",
+		201: "Created",
+		202: "Accepted",
+		203: "Non-Authoritative Information",
+		204: "No Content",
+		205: "Reset Content",
+		206: "Partial Content",
+		207: "Multi-Status",
+		208: "Already Reported",
+		226: "IM Used",
+
+		// 3xx
+		300: "Multiple Choices",
+		301: "Moved Permanently",
+		302: "Found",
+		303: "See Other",
+		304: "Not Modified",
+		305: "Use Proxy",
+		307: "Temporary Redirect",
+		308: "Permanent Redirect",
+
+		// 4xx
+		400: "Bad Request",
+		401: "Unauthorized",
+		402: "Payment Required",
+		403: "Forbidden",
+		404: "Not Found",
+		405: "Method Not Allowed",
+		406: "Not Acceptable",
+		407: "Proxy Authentication Required",
+		408: "Request Timeout",
+		409: "Conflict",
+		410: "Gone",
+		411: "Length Required",
+		412: "Precondition Failed",
+		413: "Content Too Large",
+		414: "URI Too Long",
+		415: "Unsupported Media Type",
+		416: "Range Not Satisfiable",
+		417: "Expectation Failed",
+		418: "I'm a teapot",
+		421: "Misdirected Request",
+		422: "Unprocessable Content",
+		423: "Locked",
+		424: "Failed Dependency",
+		425: "Too Early",
+		426: "Upgrade Required",
+		428: "Precondition Required",
+		429: "Too Many Requests",
+		431: "Request Header Fields Too Large",
+		451: "Unavailable For Legal Reasons",
+
+		// 5xx
+		500: "Internal Server Error",
+		501: "Not Implemented",
+		502: "Bad Gateway",
+		503: "Service Unavailable",
+		504: "Gateway Timeout",
+		505: "HTTP Version Not Supported",
+		506: "Variant Also Negotiates",
+		507: "Insufficient Storage",
+		508: "Loop Detected",
+		510: "Not Extended",
+		511: "Network Authentication Required",
+
+		// Non-standard codes seen in the wild
+		419: "Page Expired",                         // Laravel
+		420: "Enhance Your Calm",                    // Twitter
+		440: "Login Time-out",                       // IIS
+		444: "No Response",                          // nginx
+		449: "Retry With",                           // IIS
+		450: "Blocked by Windows Parental Controls", // Microsoft
+		494: "Request Header Too Large",             // nginx
+		495: "SSL Certificate Error",                // nginx
+		496: "SSL Certificate Required",             // nginx
+		497: "HTTP Request Sent to HTTPS Port",      // nginx
+		498: "Invalid Token",                        // Esri
+		499: "Client Closed Request",                // nginx
+		509: "Bandwidth Limit Exceeded",             // Apache, cPanel
+		520: "Web Server Returned an Unknown Error", // Cloudflare
+		521: "Web Server Is Down",                   // Cloudflare
+		522: "Connection Timed Out",                 // Cloudflare
+		523: "Origin Is Unreachable",                // Cloudflare
+		524: "A Timeout Occurred",                   // Cloudflare
+		525: "SSL Handshake Failed",                 // Cloudflare
+		526: "Invalid SSL Certificate",              // Cloudflare
+		529: "Site is overloaded",                   // Qualys
+		530: "Site is frozen",                       // Pantheon
+		598: "Network Read Timeout Error",           // Proxies
+		599: "Network Connect Timeout Error",        // Proxies
+	}
+)
+
+// Text returns the reason phrase for a status code, or "" if none is registered
+func Text(code int) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return texts[code]
+}
+
+// Register sets the reason phrase for a status code, overriding any existing one
+// Safe for concurrent use; an empty text removes the entry
+func Register(code int, text string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if text == "" {
+		delete(texts, code)
+		return
+	}
+	texts[code] = text
+}
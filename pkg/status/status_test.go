@@ -0,0 +1,57 @@
+package status
+
+import "testing"
+
+func TestText(t *testing.T) {
+	tests := map[int]string{
+		200: "OK",
+		404: "Not Found",
+		499: "Client Closed Request",
+		509: "Bandwidth Limit Exceeded",
+		999: "",
+	}
+	for code, expected := range tests {
+		if got := Text(code); got != expected {
+			t.Errorf("Text(%d) = %q, expected %q", code, got, expected)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer Register(299, "")
+
+	Register(299, "Vendor Success")
+	if got := Text(299); got != "Vendor Success" {
+		t.Errorf("Expected registered text, got %q", got)
+	}
+
+	Register(299, "")
+	if got := Text(299); got != "" {
+		t.Errorf("Expected entry to be removed, got %q", got)
+	}
+}
+
+func TestClassOf(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected Class
+	}{
+		{99, ClassUnknown},
+		{100, ClassInformational},
+		{103, ClassInformational},
+		{204, ClassSuccess},
+		{308, ClassRedirection},
+		{499, ClassClientError},
+		{599, ClassServerError},
+		{600, ClassUnknown},
+	}
+	for _, tt := range tests {
+		if got := ClassOf(tt.code); got != tt.expected {
+			t.Errorf("ClassOf(%d) = %v, expected %v", tt.code, got, tt.expected)
+		}
+	}
+
+	if ClassClientError.String() != "Client Error" {
+		t.Errorf("Unexpected class name %q", ClassClientError.String())
+	}
+}
@@ -0,0 +1,123 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// DefaultMaxLineLength is the default maximum line length for LineReader
+const DefaultMaxLineLength = 1024 * 1024 // 1MB
+
+// ErrLineTooLong is returned when a line exceeds the maximum line length
+// The rest of the oversized line is discarded, so reading can continue with the next line
+var ErrLineTooLong = errors.New("stream: line too long")
+
+// LineReader reads newline-delimited records (NDJSON, logs, etc.) from a stream
+// Lines are returned without the trailing "\n" or "\r\n"
+//
+// Two styles are supported:
+//
+//	line, err := lr.ReadLine()
+//
+//	for lr.Next() {
+//		handle(lr.Line())
+//	}
+//	if err := lr.Err(); err != nil { ... }
+type LineReader struct {
+	br      *bufio.Reader
+	maxLen  int
+	line    []byte
+	current []byte
+	err     error
+}
+
+// NewLineReader creates a LineReader over r
+// maxLineLength limits the size of a single line (0 = DefaultMaxLineLength)
+func NewLineReader(r io.Reader, maxLineLength int) *LineReader {
+	if maxLineLength <= 0 {
+		maxLineLength = DefaultMaxLineLength
+	}
+	return &LineReader{
+		br:     bufio.NewReaderSize(r, min(maxLineLength+2, 64*1024)),
+		maxLen: maxLineLength,
+	}
+}
+
+// ReadLine returns the next line
+// A final line without a trailing newline is returned with a nil error,
+// followed by io.EOF on the next call
+// The returned slice is only valid until the next call to ReadLine or Next
+func (lr *LineReader) ReadLine() ([]byte, error) {
+	lr.line = lr.line[:0]
+	tooLong := false
+
+	for {
+		chunk, err := lr.br.ReadSlice('\n')
+		if !tooLong {
+			lr.line = append(lr.line, chunk...)
+			if len(bytes.TrimRight(lr.line, "\r\n")) > lr.maxLen {
+				tooLong = true
+				lr.line = lr.line[:0]
+			}
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if tooLong {
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			return nil, ErrLineTooLong
+		}
+		if err == io.EOF && len(lr.line) > 0 {
+			return trimEOL(lr.line), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return trimEOL(lr.line), nil
+	}
+}
+
+// Next advances to the next line, returning false at EOF or on error
+func (lr *LineReader) Next() bool {
+	if lr.err != nil {
+		return false
+	}
+	line, err := lr.ReadLine()
+	if err != nil {
+		lr.err = err
+		lr.current = nil
+		return false
+	}
+	lr.current = line
+	return true
+}
+
+// Line returns the line read by the last call to Next
+// The returned slice is only valid until the next call to Next
+func (lr *LineReader) Line() []byte {
+	return lr.current
+}
+
+// Err returns the first non-EOF error encountered by Next
+func (lr *LineReader) Err() error {
+	if lr.err == io.EOF {
+		return nil
+	}
+	return lr.err
+}
+
+// trimEOL removes a trailing "\n" or "\r\n"
+func trimEOL(line []byte) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+		if n := len(line); n > 0 && line[n-1] == '\r' {
+			line = line[:n-1]
+		}
+	}
+	return line
+}
@@ -0,0 +1,70 @@
+package stream
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLineReader_ReadLine(t *testing.T) {
+	lr := NewLineReader(strings.NewReader("first\r\nsecond\nthird"), 0)
+
+	expected := []string{"first", "second", "third"}
+	for _, want := range expected {
+		line, err := lr.ReadLine()
+		if err != nil {
+			t.Fatalf("ReadLine failed: %v", err)
+		}
+		if string(line) != want {
+			t.Errorf("Expected %q, got %q", want, line)
+		}
+	}
+
+	if _, err := lr.ReadLine(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestLineReader_Next(t *testing.T) {
+	input := `{"id":1}` + "\n" + `{"id":2}` + "\n\n" + `{"id":3}` + "\n"
+	lr := NewLineReader(iotest.OneByteReader(strings.NewReader(input)), 0)
+
+	var lines []string
+	for lr.Next() {
+		lines = append(lines, string(lr.Line()))
+	}
+	if err := lr.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{`{"id":1}`, `{"id":2}`, "", `{"id":3}`}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d: %q", len(expected), len(lines), lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Line %d: expected %q, got %q", i, expected[i], lines[i])
+		}
+	}
+}
+
+func TestLineReader_TooLong(t *testing.T) {
+	input := "short\n" + strings.Repeat("x", 100) + "\nafter\n"
+	lr := NewLineReader(strings.NewReader(input), 16)
+
+	line, err := lr.ReadLine()
+	if err != nil || string(line) != "short" {
+		t.Fatalf("Expected 'short', got %q (%v)", line, err)
+	}
+
+	if _, err := lr.ReadLine(); err != ErrLineTooLong {
+		t.Fatalf("Expected ErrLineTooLong, got %v", err)
+	}
+
+	// Reading continues after the oversized line
+	line, err = lr.ReadLine()
+	if err != nil || string(line) != "after" {
+		t.Errorf("Expected 'after', got %q (%v)", line, err)
+	}
+}
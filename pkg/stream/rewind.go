@@ -0,0 +1,168 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// DefaultMemoryLimit is the default number of bytes buffered in memory
+// before a RewindableReader spills to a temp file
+const DefaultMemoryLimit = 4 * 1024 * 1024 // 4MB
+
+// RewindOptions configures a RewindableReader
+type RewindOptions struct {
+	// MemoryLimit is the number of bytes kept in memory before spilling
+	// to a temp file (0 = DefaultMemoryLimit)
+	MemoryLimit int64
+
+	// TempDir is the directory for spill files ("" = os.TempDir())
+	TempDir string
+}
+
+// RewindableReader buffers everything read from the source so it can be
+// read again after Rewind()
+// Data is kept in memory up to MemoryLimit, then spilled to a temp file
+// Typical use: search a streaming body, then Rewind() and stream it to a client
+// IMPORTANT: Always call Close() when done to remove the spill file
+type RewindableReader struct {
+	src    io.Reader
+	opts   RewindOptions
+	mem    bytes.Buffer
+	file   *os.File
+	size   int64
+	pos    int64
+	srcErr error
+	closed bool
+}
+
+// NewRewindableReader creates a RewindableReader over r
+func NewRewindableReader(r io.Reader, opts RewindOptions) *RewindableReader {
+	if opts.MemoryLimit <= 0 {
+		opts.MemoryLimit = DefaultMemoryLimit
+	}
+	return &RewindableReader{
+		src:  r,
+		opts: opts,
+	}
+}
+
+// Read implements io.Reader interface
+// Buffered data is replayed first; once exhausted, reading continues from the source
+func (rr *RewindableReader) Read(p []byte) (int, error) {
+	if rr.closed {
+		return 0, os.ErrClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Replay buffered data
+	if rr.pos < rr.size {
+		n, err := rr.readBuffered(p)
+		rr.pos += int64(n)
+		return n, err
+	}
+
+	if rr.srcErr != nil {
+		return 0, rr.srcErr
+	}
+
+	n, err := rr.src.Read(p)
+	if n > 0 {
+		if werr := rr.store(p[:n]); werr != nil {
+			return 0, werr
+		}
+		rr.pos += int64(n)
+	}
+	if err != nil {
+		rr.srcErr = err
+	}
+	return n, err
+}
+
+// Rewind moves the read position back to the start of the data
+// Data not yet read from the source is still read from the source afterwards
+func (rr *RewindableReader) Rewind() error {
+	if rr.closed {
+		return os.ErrClosed
+	}
+	rr.pos = 0
+	return nil
+}
+
+// Buffered returns the number of bytes buffered so far
+func (rr *RewindableReader) Buffered() int64 {
+	return rr.size
+}
+
+// Spilled returns true if the buffered data was moved to a temp file
+func (rr *RewindableReader) Spilled() bool {
+	return rr.file != nil
+}
+
+// Close releases the buffer and removes the spill file, if any
+// Close does not close the source reader
+func (rr *RewindableReader) Close() error {
+	if rr.closed {
+		return nil
+	}
+	rr.closed = true
+	rr.mem = bytes.Buffer{}
+
+	if rr.file == nil {
+		return nil
+	}
+	name := rr.file.Name()
+	err := rr.file.Close()
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+	rr.file = nil
+	return err
+}
+
+// readBuffered copies buffered data at the current position into p
+func (rr *RewindableReader) readBuffered(p []byte) (int, error) {
+	remaining := rr.size - rr.pos
+	if int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	if rr.file == nil {
+		return copy(p, rr.mem.Bytes()[rr.pos:]), nil
+	}
+
+	n, err := rr.file.ReadAt(p, rr.pos)
+	if err == io.EOF && n == len(p) {
+		err = nil
+	}
+	return n, err
+}
+
+// store appends data to the buffer, spilling to disk when the memory limit is exceeded
+func (rr *RewindableReader) store(data []byte) error {
+	if rr.file == nil && rr.size+int64(len(data)) > rr.opts.MemoryLimit {
+		f, err := os.CreateTemp(rr.opts.TempDir, "httptools-body-*")
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(rr.mem.Bytes()); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return err
+		}
+		rr.file = f
+		rr.mem = bytes.Buffer{}
+	}
+
+	if rr.file != nil {
+		if _, err := rr.file.WriteAt(data, rr.size); err != nil {
+			return err
+		}
+	} else {
+		rr.mem.Write(data)
+	}
+	rr.size += int64(len(data))
+	return nil
+}
@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRewindableReader_InMemory(t *testing.T) {
+	input := "hello rewindable world"
+	rr := NewRewindableReader(strings.NewReader(input), RewindOptions{})
+	defer rr.Close()
+
+	first, err := io.ReadAll(rr)
+	if err != nil {
+		t.Fatalf("First read failed: %v", err)
+	}
+	if string(first) != input {
+		t.Errorf("Expected %q, got %q", input, first)
+	}
+
+	if err := rr.Rewind(); err != nil {
+		t.Fatalf("Rewind failed: %v", err)
+	}
+
+	second, err := io.ReadAll(rr)
+	if err != nil {
+		t.Fatalf("Second read failed: %v", err)
+	}
+	if string(second) != input {
+		t.Errorf("Expected %q after rewind, got %q", input, second)
+	}
+
+	if rr.Spilled() {
+		t.Error("Small body should not spill to disk")
+	}
+}
+
+func TestRewindableReader_PartialReadThenRewind(t *testing.T) {
+	input := "0123456789abcdef"
+	rr := NewRewindableReader(iotest.OneByteReader(strings.NewReader(input)), RewindOptions{})
+	defer rr.Close()
+
+	buf := make([]byte, 4)
+	io.ReadFull(rr, buf)
+	if string(buf) != "0123" {
+		t.Fatalf("Expected '0123', got %q", buf)
+	}
+
+	rr.Rewind()
+
+	// Replays buffered bytes, then continues from the source
+	all, err := io.ReadAll(rr)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(all) != input {
+		t.Errorf("Expected %q, got %q", input, all)
+	}
+}
+
+func TestRewindableReader_Spill(t *testing.T) {
+	input := bytes.Repeat([]byte("spill-data-"), 1000)
+	rr := NewRewindableReader(bytes.NewReader(input), RewindOptions{
+		MemoryLimit: 1024,
+		TempDir:     t.TempDir(),
+	})
+
+	first, _ := io.ReadAll(rr)
+	if !bytes.Equal(first, input) {
+		t.Fatal("First read mismatch")
+	}
+	if !rr.Spilled() {
+		t.Fatal("Expected body to spill to disk")
+	}
+	if rr.Buffered() != int64(len(input)) {
+		t.Errorf("Expected %d buffered bytes, got %d", len(input), rr.Buffered())
+	}
+
+	rr.Rewind()
+	second, _ := io.ReadAll(rr)
+	if !bytes.Equal(second, input) {
+		t.Error("Read after rewind mismatch")
+	}
+
+	name := rr.file.Name()
+	if err := rr.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Error("Spill file should be removed on Close")
+	}
+}
+
+func TestRewindableReader_Closed(t *testing.T) {
+	rr := NewRewindableReader(strings.NewReader("data"), RewindOptions{})
+	rr.Close()
+
+	if _, err := rr.Read(make([]byte, 4)); err != os.ErrClosed {
+		t.Errorf("Expected os.ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package template
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/WhileEndless/go-httptools/pkg/clock"
+)
+
+// randomAlphabet is the character set of randomString
+const randomAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// randomString(n) returns n random alphanumeric characters
+func randomString(env *Env, args []string) (string, error) {
+	n, err := intArgs("randomString", args, 1)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, max(n[0], 0))
+	for i := range b {
+		b[i] = randomAlphabet[env.intn(len(randomAlphabet))]
+	}
+	return string(b), nil
+}
+
+// randomInt(min, max) returns a random integer in [min, max]
+func randomInt(env *Env, args []string) (string, error) {
+	n, err := intArgs("randomInt", args, 2)
+	if err != nil {
+		return "", err
+	}
+	if n[1] < n[0] {
+		return "", fmt.Errorf("template: randomInt: max %d < min %d", n[1], n[0])
+	}
+	// The span overflows for ranges wider than the int type
+	if span := n[1] - n[0]; span < 0 || span == math.MaxInt {
+		return "", fmt.Errorf("template: randomInt: range %d to %d too large", n[0], n[1])
+	}
+	return strconv.Itoa(n[0] + env.intn(n[1]-n[0]+1)), nil
+}
+
+// timestamp() returns the current Unix time in seconds
+func timestamp(env *Env, args []string) (string, error) {
+	return strconv.FormatInt(env.now().Unix(), 10), nil
+}
+
+// timestampMs() returns the current Unix time in milliseconds
+func timestampMs(env *Env, args []string) (string, error) {
+	return strconv.FormatInt(env.now().UnixMilli(), 10), nil
+}
+
+// base64(s) returns the standard base64 encoding of s
+func base64Func(env *Env, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("template: base64 takes 1 argument, got %d", len(args))
+	}
+	return base64.StdEncoding.EncodeToString([]byte(args[0])), nil
+}
+
+// base64Decode(s) decodes standard base64
+func base64Decode(env *Env, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("template: base64Decode takes 1 argument, got %d", len(args))
+	}
+	b, err := base64.StdEncoding.DecodeString(args[0])
+	if err != nil {
+		return "", fmt.Errorf("template: base64Decode: %w", err)
+	}
+	return string(b), nil
+}
+
+// urlencode(s) percent-encodes s for use in a query component
+func urlencode(env *Env, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("template: urlencode takes 1 argument, got %d", len(args))
+	}
+	return url.QueryEscape(args[0]), nil
+}
+
+// unary adapts a string function taking exactly one argument
+func unary(fn func(string) string) Func {
+	return func(env *Env, args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("template: function takes 1 argument, got %d", len(args))
+		}
+		return fn(args[0]), nil
+	}
+}
+
+// intArgs parses exactly n integer arguments
+func intArgs(name string, args []string, n int) ([]int, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("template: %s takes %d arguments, got %d", name, n, len(args))
+	}
+	ints := make([]int, n)
+	for i, a := range args {
+		v, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, fmt.Errorf("template: %s: invalid integer %q", name, a)
+		}
+		ints[i] = v
+	}
+	return ints, nil
+}
+
+func (env *Env) intn(n int) int {
+	if env.Rand != nil {
+		return env.Rand.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+func (env *Env) now() time.Time {
+	return clock.Or(env.Clock).Now()
+}
@@ -0,0 +1,212 @@
+// Package template renders declarative request templates
+//
+// URL, header values and body may contain {{expressions}}: a variable name,
+// or a function call whose arguments are quoted strings, numbers, variables
+// or further calls:
+//
+//	t := &template.Template{
+//		Method:  "POST",
+//		URL:     "/api/users/{{id}}?ts={{timestamp()}}",
+//		Headers: []template.Field{{"Host", "{{host}}"}, {"Authorization", "Basic {{base64(creds)}}"}},
+//		Body:    `{"name":"{{randomString(8)}}"}`,
+//	}
+//	req, err := t.Render(template.Env{Vars: map[string]string{"id": "42", "host": "example.com", "creds": "a:b"}})
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/clock"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+)
+
+// ErrUndefined is returned for variables missing from the environment
+var ErrUndefined = errors.New("template: undefined variable")
+
+// Field is a template header
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Template is a request whose URL, header values and body contain expressions
+type Template struct {
+	Method  string // Defaults to GET
+	URL     string
+	Version string // Defaults to HTTP/1.1
+	Headers []Field
+	Body    string
+}
+
+// Env holds the variables and sources of randomness and time used for rendering
+type Env struct {
+	Vars  map[string]string
+	Rand  *rand.Rand  // nil = a randomly seeded source
+	Clock clock.Clock // nil = clock.Real
+}
+
+// Func is a template function; args are the evaluated arguments
+type Func func(env *Env, args []string) (string, error)
+
+// Funcs are the functions available to expressions
+// Add entries to make custom functions available
+var Funcs = map[string]Func{
+	"randomString": randomString,
+	"randomInt":    randomInt,
+	"timestamp":    timestamp,
+	"timestampMs":  timestampMs,
+	"base64":       base64Func,
+	"base64Decode": base64Decode,
+	"urlencode":    urlencode,
+	"lower":        unary(strings.ToLower),
+	"upper":        unary(strings.ToUpper),
+}
+
+// Render expands every expression and parses the result as a request
+// Content-Length is set to the body length unless the template sets
+// Content-Length or Transfer-Encoding itself
+func (t *Template) Render(env Env) (*request.Request, error) {
+	raw, err := t.RenderRaw(env)
+	if err != nil {
+		return nil, err
+	}
+	return request.Parse(raw)
+}
+
+// RenderRaw expands every expression and returns the raw request
+func (t *Template) RenderRaw(env Env) ([]byte, error) {
+	method := t.Method
+	if method == "" {
+		method = "GET"
+	}
+	version := t.Version
+	if version == "" {
+		version = "HTTP/1.1"
+	}
+	target, err := Expand(t.URL, env)
+	if err != nil {
+		return nil, err
+	}
+	body, err := Expand(t.Body, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(method + " " + target + " " + version + "\r\n")
+	framed := false
+	for _, f := range t.Headers {
+		value, err := Expand(f.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(f.Name, "Content-Length") || strings.EqualFold(f.Name, "Transfer-Encoding") {
+			framed = true
+		}
+		buf.WriteString(f.Name + ": " + value + "\r\n")
+	}
+	if !framed && body != "" {
+		buf.WriteString("Content-Length: " + strconv.Itoa(len(body)) + "\r\n")
+	}
+	buf.WriteString("\r\n")
+	buf.WriteString(body)
+	return buf.Bytes(), nil
+}
+
+// Expand replaces every {{expression}} in s
+func Expand(s string, env Env) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "{{")
+		if start == -1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		end := strings.Index(s[start:], "}}")
+		if end == -1 {
+			return "", fmt.Errorf("template: unclosed {{ in %q", s)
+		}
+		value, err := eval(strings.TrimSpace(s[start+2:start+end]), &env)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s[:start])
+		b.WriteString(value)
+		s = s[start+end+2:]
+	}
+}
+
+// eval evaluates a single expression
+func eval(expr string, env *Env) (string, error) {
+	if expr == "" {
+		return "", fmt.Errorf("template: empty expression")
+	}
+	if q := expr[0]; (q == '"' || q == '\'') && len(expr) >= 2 && expr[len(expr)-1] == q {
+		return expr[1 : len(expr)-1], nil
+	}
+	if _, err := strconv.ParseFloat(expr, 64); err == nil {
+		return expr, nil
+	}
+
+	if open := strings.IndexByte(expr, '('); open != -1 && strings.HasSuffix(expr, ")") {
+		name := strings.TrimSpace(expr[:open])
+		fn, ok := Funcs[name]
+		if !ok {
+			return "", fmt.Errorf("template: unknown function %q", name)
+		}
+		argExprs, err := splitArgs(expr[open+1 : len(expr)-1])
+		if err != nil {
+			return "", err
+		}
+		args := make([]string, len(argExprs))
+		for i, a := range argExprs {
+			if args[i], err = eval(a, env); err != nil {
+				return "", err
+			}
+		}
+		return fn(env, args)
+	}
+
+	value, ok := env.Vars[expr]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUndefined, expr)
+	}
+	return value, nil
+}
+
+// splitArgs splits a comma-separated argument list at the top level
+func splitArgs(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var args []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if quote != 0 || depth != 0 {
+		return nil, fmt.Errorf("template: unbalanced arguments %q", s)
+	}
+	return append(args, strings.TrimSpace(s[start:])), nil
+}
@@ -0,0 +1,210 @@
+// Package timing aggregates response times across repeated sends
+//
+// It is meant for blind time-based injection testing, where single
+// measurements are too noisy to tell an injected delay from network jitter:
+//
+//	baseline, delayed := timing.New(), timing.New()
+//	for i := 0; i < 10; i++ {
+//		baseline.Add(measure(normal))
+//		delayed.Add(measure(sleepPayload))
+//	}
+//	if timing.Slower(baseline, delayed, 5*time.Second) {
+//		report("time-based injection")
+//	}
+package timing
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// OutlierFactor is the IQR multiplier (Tukey's fences) used by WithoutOutliers
+const OutlierFactor = 1.5
+
+// Stats collects durations and computes summary statistics
+// It is safe for concurrent use
+type Stats struct {
+	mu        sync.Mutex
+	durations []time.Duration
+	sorted    bool
+}
+
+// Summary is a snapshot of the statistics of a Stats
+type Summary struct {
+	Count  int
+	Min    time.Duration
+	Max    time.Duration
+	Mean   time.Duration
+	Median time.Duration
+	StdDev time.Duration
+	P90    time.Duration
+	P95    time.Duration
+	P99    time.Duration
+}
+
+// New creates a Stats holding the given durations
+func New(durations ...time.Duration) *Stats {
+	return &Stats{durations: append([]time.Duration(nil), durations...)}
+}
+
+// Add records a duration
+func (s *Stats) Add(d time.Duration) {
+	s.mu.Lock()
+	s.durations = append(s.durations, d)
+	s.sorted = false
+	s.mu.Unlock()
+}
+
+// Len returns the number of recorded durations
+func (s *Stats) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.durations)
+}
+
+// Durations returns a sorted copy of the recorded durations
+func (s *Stats) Durations() []time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Duration(nil), s.sortedLocked()...)
+}
+
+// Min returns the smallest duration (0 if empty)
+func (s *Stats) Min() time.Duration {
+	return s.Percentile(0)
+}
+
+// Max returns the largest duration (0 if empty)
+func (s *Stats) Max() time.Duration {
+	return s.Percentile(100)
+}
+
+// Median returns the 50th percentile
+func (s *Stats) Median() time.Duration {
+	return s.Percentile(50)
+}
+
+// Mean returns the arithmetic mean (0 if empty)
+func (s *Stats) Mean() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(mean(s.durations))
+}
+
+// StdDev returns the population standard deviation (0 if empty)
+func (s *Stats) StdDev() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(stddev(s.durations))
+}
+
+// Percentile returns the p-th percentile (0-100) using linear interpolation
+// between the closest ranks (0 if empty)
+func (s *Stats) Percentile(p float64) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return percentile(s.sortedLocked(), p)
+}
+
+// Summary returns all statistics at once
+func (s *Stats) Summary() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sorted := s.sortedLocked()
+	return Summary{
+		Count:  len(sorted),
+		Min:    percentile(sorted, 0),
+		Max:    percentile(sorted, 100),
+		Mean:   time.Duration(mean(sorted)),
+		Median: percentile(sorted, 50),
+		StdDev: time.Duration(stddev(sorted)),
+		P90:    percentile(sorted, 90),
+		P95:    percentile(sorted, 95),
+		P99:    percentile(sorted, 99),
+	}
+}
+
+// WithoutOutliers returns a new Stats without durations outside Tukey's fences
+// (more than OutlierFactor interquartile ranges below Q1 or above Q3)
+// A single slow response caused by network jitter then no longer skews the mean
+func (s *Stats) WithoutOutliers() *Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sorted := s.sortedLocked()
+	q1, q3 := percentile(sorted, 25), percentile(sorted, 75)
+	fence := time.Duration(float64(q3-q1) * OutlierFactor)
+	low, high := q1-fence, q3+fence
+
+	kept := &Stats{sorted: true}
+	for _, d := range sorted {
+		if d >= low && d <= high {
+			kept.durations = append(kept.durations, d)
+		}
+	}
+	return kept
+}
+
+// Slower reports whether sample is consistently slower than baseline by at least delay
+// Outliers are rejected from both first; then every sample duration must exceed
+// the slowest baseline duration, and the medians must differ by at least delay
+func Slower(baseline, sample *Stats, delay time.Duration) bool {
+	b, s := baseline.WithoutOutliers(), sample.WithoutOutliers()
+	if b.Len() == 0 || s.Len() == 0 {
+		return false
+	}
+	return s.Min() > b.Max() && s.Median()-b.Median() >= delay
+}
+
+// sortedLocked returns the durations sorted in place; s.mu must be held
+func (s *Stats) sortedLocked() []time.Duration {
+	if !s.sorted {
+		slices.Sort(s.durations)
+		s.sorted = true
+	}
+	return s.durations
+}
+
+// percentile returns the p-th percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	p = math.Max(0, math.Min(100, p))
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(rank)
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := rank - float64(lo)
+	return sorted[lo] + time.Duration(frac*float64(sorted[lo+1]-sorted[lo]))
+}
+
+// mean returns the mean of durations in nanoseconds
+func mean(durations []time.Duration) float64 {
+	if len(durations) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, d := range durations {
+		sum += float64(d)
+	}
+	return sum / float64(len(durations))
+}
+
+// stddev returns the population standard deviation of durations in nanoseconds
+func stddev(durations []time.Duration) float64 {
+	if len(durations) == 0 {
+		return 0
+	}
+	m := mean(durations)
+	var sum float64
+	for _, d := range durations {
+		diff := float64(d) - m
+		sum += diff * diff
+	}
+	return math.Sqrt(sum / float64(len(durations)))
+}
@@ -0,0 +1,75 @@
+package timing
+
+import (
+	"testing"
+	"time"
+)
+
+func ms(values ...int) []time.Duration {
+	out := make([]time.Duration, len(values))
+	for i, v := range values {
+		out[i] = time.Duration(v) * time.Millisecond
+	}
+	return out
+}
+
+func TestStats(t *testing.T) {
+	s := New(ms(40, 10, 30, 20, 50)...)
+
+	sum := s.Summary()
+	if sum.Count != 5 || sum.Min != 10*time.Millisecond || sum.Max != 50*time.Millisecond {
+		t.Errorf("Summary = %+v", sum)
+	}
+	if sum.Mean != 30*time.Millisecond || sum.Median != 30*time.Millisecond {
+		t.Errorf("Mean = %v, Median = %v", sum.Mean, sum.Median)
+	}
+	// Population stddev of 10..50 step 10 is sqrt(200) ms
+	if got := s.StdDev(); got < 14142*time.Microsecond || got > 14143*time.Microsecond {
+		t.Errorf("StdDev = %v", got)
+	}
+	if got := s.Percentile(90); got != 46*time.Millisecond {
+		t.Errorf("P90 = %v", got)
+	}
+
+	s.Add(60 * time.Millisecond)
+	if got := s.Median(); got != 35*time.Millisecond {
+		t.Errorf("Median after Add = %v", got)
+	}
+
+	empty := New()
+	if empty.Mean() != 0 || empty.Median() != 0 || empty.StdDev() != 0 {
+		t.Error("empty stats should be zero")
+	}
+}
+
+func TestWithoutOutliers(t *testing.T) {
+	s := New(ms(100, 102, 98, 101, 99, 100, 2500)...)
+	clean := s.WithoutOutliers()
+	if clean.Len() != 6 {
+		t.Fatalf("kept %d durations: %v", clean.Len(), clean.Durations())
+	}
+	if clean.Max() != 102*time.Millisecond {
+		t.Errorf("Max = %v", clean.Max())
+	}
+	if s.Len() != 7 {
+		t.Error("WithoutOutliers modified the original")
+	}
+}
+
+func TestSlower(t *testing.T) {
+	baseline := New(ms(100, 120, 90, 110, 105, 3000)...)
+
+	delayed := New(ms(5100, 5120, 5090, 5110, 5105)...)
+	if !Slower(baseline, delayed, 5*time.Second) {
+		t.Error("consistent 5s delay not detected")
+	}
+
+	jitter := New(ms(100, 5200, 95, 110, 105)...)
+	if Slower(baseline, jitter, 5*time.Second) {
+		t.Error("single slow response should not count as a delay")
+	}
+
+	if Slower(New(), delayed, time.Second) {
+		t.Error("empty baseline should never be slower")
+	}
+}
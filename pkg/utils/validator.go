@@ -66,6 +66,14 @@ func ValidateRequest(req *request.Request) *ValidationResult {
 
 	// Validate headers
 	validateHeaders(req.Headers.All(), result)
+	validateDuplicateHeaders(req.DuplicateHeaders(), result)
+
+	// Absolute-form target and Host header must agree
+	if u, err := url.Parse(req.URL); err == nil && u.IsAbs() && u.Host != "" {
+		if host := req.GetHost(); host != "" && !strings.EqualFold(host, u.Host) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Host header %q differs from request target host %q", host, u.Host))
+		}
+	}
 
 	// Validate Content-Length vs body size
 	if contentLength := req.GetContentLength(); contentLength != "" {
@@ -107,6 +115,7 @@ func ValidateResponse(resp *response.Response) *ValidationResult {
 
 	// Validate headers
 	validateHeaders(resp.Headers.All(), result)
+	validateDuplicateHeaders(resp.DuplicateHeaders(), result)
 
 	// Validate Content-Length vs body size
 	contentLength := resp.GetContentLength()
@@ -128,6 +137,21 @@ func ValidateResponse(resp *response.Response) *ValidationResult {
 	return result
 }
 
+// validateDuplicateHeaders reports repeated singleton headers
+// Conflicting Content-Length or Host values are errors (RFC 9112 sections 3.2 and 6.3);
+// other repetitions are warnings
+func validateDuplicateHeaders(duplicates []headers.DuplicateHeader, result *ValidationResult) {
+	for _, d := range duplicates {
+		name := strings.ToLower(d.Name)
+		if d.Conflicting && (name == "content-length" || name == "host") {
+			result.Errors = append(result.Errors, fmt.Sprintf("Conflicting %s headers: %s", d.Name, strings.Join(d.Values, ", ")))
+			result.Valid = false
+			continue
+		}
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Repeated singleton header: %s (%d values)", d.Name, len(d.Values)))
+	}
+}
+
 // validateHeaders validates common header issues
 func validateHeaders(headerList []headers.Header, result *ValidationResult) {
 	headerNames := make(map[string]int)
@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"io"
+	"sync"
+)
+
+// Conn is one end of a WebSocket connection after the upgrade
+// Client ends mask every frame they send, as RFC 6455 requires
+// Reads and writes may run concurrently; writes are serialized
+//
+//	br := bufio.NewReader(conn)
+//	resp, _ := response.ParseReader(br) // 101 Switching Protocols
+//	ws := websocket.NewConn(br, conn, true)
+//	ws.WriteMessage(websocket.OpText, []byte("hello"))
+//	op, msg, err := ws.ReadMessage()
+type Conn struct {
+	*Reader
+
+	w      io.Writer
+	client bool
+	mu     sync.Mutex
+	closed bool // A close frame was sent
+}
+
+// NewConn creates a Conn reading frames from r and writing them to w
+// Pass the reader the handshake response was parsed from, so frames it
+// already buffered are not lost
+// Pings received by ReadMessage are answered with pongs; replace OnControl
+// to handle control frames differently
+func NewConn(r io.Reader, w io.Writer, client bool) *Conn {
+	c := &Conn{Reader: NewReader(r), w: w, client: client}
+	c.OnControl = func(f *Frame) {
+		if f.Opcode == OpPing {
+			c.WriteFrame(NewFrame(OpPong, f.Payload))
+		}
+	}
+	return c
+}
+
+// ReadMessage reads the next data message (see Reader.ReadMessage)
+// A close frame from the peer is answered with a close frame echoing its
+// code, then returned as an OpClose message
+func (c *Conn) ReadMessage() (Opcode, []byte, error) {
+	op, payload, err := c.Reader.ReadMessage()
+	if err == nil && op == OpClose {
+		code, _ := (&Frame{Opcode: OpClose, Payload: payload}).CloseCode()
+		if code == CloseNoStatus {
+			code = 0
+		}
+		c.Close(code, "")
+	}
+	return op, payload, err
+}
+
+// WriteFrame writes f, masking it with a random key on client connections
+// Frames that are already masked keep their key
+func (c *Conn) WriteFrame(f *Frame) error {
+	if c.client && !f.Masked {
+		masked := *f
+		masked.Masked = true
+		rand.Read(masked.MaskKey[:])
+		f = &masked
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.w.Write(f.Encode())
+	return err
+}
+
+// WriteMessage writes a data message as a single frame
+func (c *Conn) WriteMessage(op Opcode, payload []byte) error {
+	return c.WriteFrame(NewFrame(op, payload))
+}
+
+// Ping sends a ping frame
+func (c *Conn) Ping(payload []byte) error {
+	return c.WriteFrame(NewFrame(OpPing, payload))
+}
+
+// Close sends a close frame with code and reason (code 0 = no status)
+// Only the first call sends a frame; the underlying connection is not closed
+func (c *Conn) Close(code int, reason string) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	return c.WriteFrame(NewCloseFrame(code, reason))
+}
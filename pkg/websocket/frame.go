@@ -0,0 +1,410 @@
+// Package websocket encodes and decodes WebSocket frames (RFC 6455)
+//
+// Frames are handled at the byte level, independent of any connection, so
+// traffic captured elsewhere can be dissected and re-crafted, including
+// frames a conforming endpoint would reject (reserved bits, bad lengths):
+//
+//	f, n, err := websocket.Decode(data)
+//	f.Payload = []byte(`{"admin":true}`)
+//	out := f.Encode()
+//
+// IsUpgradeRequest, IsUpgradeResponse and ValidateHandshake inspect the HTTP
+// upgrade, after which a Conn exchanges messages over the connection
+package websocket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	httperrors "github.com/WhileEndless/go-httptools/pkg/errors"
+)
+
+// Opcode is the frame type
+type Opcode byte
+
+const (
+	OpContinuation Opcode = 0x0
+	OpText         Opcode = 0x1
+	OpBinary       Opcode = 0x2
+	OpClose        Opcode = 0x8
+	OpPing         Opcode = 0x9
+	OpPong         Opcode = 0xA
+)
+
+// IsControl reports whether op is a control opcode (close, ping, pong)
+func (op Opcode) IsControl() bool {
+	return op&0x8 != 0
+}
+
+// String returns the name of op
+func (op Opcode) String() string {
+	switch op {
+	case OpContinuation:
+		return "continuation"
+	case OpText:
+		return "text"
+	case OpBinary:
+		return "binary"
+	case OpClose:
+		return "close"
+	case OpPing:
+		return "ping"
+	case OpPong:
+		return "pong"
+	}
+	return fmt.Sprintf("opcode(%#x)", byte(op))
+}
+
+// MaxControlPayload is the largest payload allowed in a control frame
+const MaxControlPayload = 125
+
+// Close status codes (RFC 6455 section 7.4.1)
+const (
+	CloseNormal           = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatus         = 1005 // Never sent; reported for close frames without a code
+	CloseAbnormal         = 1006 // Never sent
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseMandatoryExt     = 1010
+	CloseInternalError    = 1011
+	CloseTLSHandshakeFail = 1015 // Never sent
+)
+
+var (
+	// ErrInvalidFrame is matched by errors for frames violating RFC 6455
+	ErrInvalidFrame = errors.New("websocket: invalid frame")
+
+	// ErrFrameTooLarge is returned when a payload exceeds the reader's limit
+	ErrFrameTooLarge = errors.New("websocket: frame too large")
+)
+
+// Frame is a single WebSocket frame
+// Payload always holds unmasked application data; masking is applied by
+// Encode and removed by Decode
+type Frame struct {
+	Fin    bool
+	RSV1   bool // Set by extensions such as permessage-deflate
+	RSV2   bool
+	RSV3   bool
+	Opcode Opcode
+
+	Masked  bool // Client-to-server frames must be masked
+	MaskKey [4]byte
+
+	Payload []byte
+}
+
+// NewFrame creates a final, unmasked frame
+func NewFrame(op Opcode, payload []byte) *Frame {
+	return &Frame{Fin: true, Opcode: op, Payload: payload}
+}
+
+// NewCloseFrame creates a close frame carrying code and reason
+// A code of 0 produces an empty close frame
+func NewCloseFrame(code int, reason string) *Frame {
+	if code == 0 {
+		return NewFrame(OpClose, nil)
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return NewFrame(OpClose, append(payload, reason...))
+}
+
+// CloseCode returns the status code and reason of a close frame
+// Frames without a code report CloseNoStatus
+func (f *Frame) CloseCode() (code int, reason string) {
+	if len(f.Payload) < 2 {
+		return CloseNoStatus, ""
+	}
+	return int(binary.BigEndian.Uint16(f.Payload)), string(f.Payload[2:])
+}
+
+// Validate checks f against the RFC 6455 framing rules
+// Extension bits are not checked, since their meaning depends on negotiation
+func (f *Frame) Validate() error {
+	switch f.Opcode {
+	case OpContinuation, OpText, OpBinary, OpClose, OpPing, OpPong:
+	default:
+		return fmt.Errorf("%w: reserved opcode %#x", ErrInvalidFrame, byte(f.Opcode))
+	}
+	if !f.Opcode.IsControl() {
+		return nil
+	}
+	if !f.Fin {
+		return fmt.Errorf("%w: fragmented %s frame", ErrInvalidFrame, f.Opcode)
+	}
+	if len(f.Payload) > MaxControlPayload {
+		return fmt.Errorf("%w: %s payload of %d bytes", ErrInvalidFrame, f.Opcode, len(f.Payload))
+	}
+	if f.Opcode == OpClose {
+		if len(f.Payload) == 1 {
+			return fmt.Errorf("%w: truncated close code", ErrInvalidFrame)
+		}
+		if code, reason := f.CloseCode(); len(f.Payload) >= 2 && (!validCloseCode(code) || !utf8.ValidString(reason)) {
+			return fmt.Errorf("%w: invalid close code %d or reason", ErrInvalidFrame, code)
+		}
+	}
+	return nil
+}
+
+// Encode returns the wire form of f
+// The payload length uses the shortest encoding; f is not validated
+func (f *Frame) Encode() []byte {
+	return f.AppendEncode(nil)
+}
+
+// AppendEncode appends the wire form of f to dst
+func (f *Frame) AppendEncode(dst []byte) []byte {
+	b0 := byte(f.Opcode) & 0x0F
+	for i, set := range []bool{f.Fin, f.RSV1, f.RSV2, f.RSV3} {
+		if set {
+			b0 |= 0x80 >> i
+		}
+	}
+	dst = append(dst, b0)
+
+	var mask byte
+	if f.Masked {
+		mask = 0x80
+	}
+	switch n := len(f.Payload); {
+	case n <= 125:
+		dst = append(dst, mask|byte(n))
+	case n <= 0xFFFF:
+		dst = binary.BigEndian.AppendUint16(append(dst, mask|126), uint16(n))
+	default:
+		dst = binary.BigEndian.AppendUint64(append(dst, mask|127), uint64(n))
+	}
+
+	if !f.Masked {
+		return append(dst, f.Payload...)
+	}
+	dst = append(dst, f.MaskKey[:]...)
+	start := len(dst)
+	dst = append(dst, f.Payload...)
+	maskBytes(f.MaskKey, dst[start:])
+	return dst
+}
+
+// Decode parses the frame at the start of data
+// Returns the frame and the number of bytes consumed, or io.ErrUnexpectedEOF
+// when data holds an incomplete frame; the payload is copied and unmasked
+// Frames are not validated, so malformed traffic can still be inspected
+func Decode(data []byte) (*Frame, int, error) {
+	f, headerLen, payloadLen, err := decodeHeader(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if uint64(len(data)-headerLen) < payloadLen {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	end := headerLen + int(payloadLen)
+	f.Payload = append([]byte(nil), data[headerLen:end]...)
+	if f.Masked {
+		maskBytes(f.MaskKey, f.Payload)
+	}
+	return f, end, nil
+}
+
+// decodeHeader parses the fixed and extended header at the start of data
+func decodeHeader(data []byte) (f *Frame, headerLen int, payloadLen uint64, err error) {
+	if len(data) < 2 {
+		return nil, 0, 0, io.ErrUnexpectedEOF
+	}
+	f = &Frame{
+		Fin:    data[0]&0x80 != 0,
+		RSV1:   data[0]&0x40 != 0,
+		RSV2:   data[0]&0x20 != 0,
+		RSV3:   data[0]&0x10 != 0,
+		Opcode: Opcode(data[0] & 0x0F),
+		Masked: data[1]&0x80 != 0,
+	}
+
+	headerLen = 2
+	payloadLen = uint64(data[1] & 0x7F)
+	switch payloadLen {
+	case 126:
+		headerLen += 2
+	case 127:
+		headerLen += 8
+	}
+	if f.Masked {
+		headerLen += 4
+	}
+	if len(data) < headerLen {
+		return nil, 0, 0, io.ErrUnexpectedEOF
+	}
+
+	switch payloadLen {
+	case 126:
+		payloadLen = uint64(binary.BigEndian.Uint16(data[2:]))
+	case 127:
+		payloadLen = binary.BigEndian.Uint64(data[2:])
+		if payloadLen>>63 != 0 {
+			return nil, 0, 0, fmt.Errorf("%w: payload length has the high bit set", ErrInvalidFrame)
+		}
+	}
+	if f.Masked {
+		copy(f.MaskKey[:], data[headerLen-4:headerLen])
+	}
+	return f, headerLen, payloadLen, nil
+}
+
+// Reader reads frames from a stream
+type Reader struct {
+	r io.Reader
+
+	// MaxPayload limits the payload of a single frame (0 = no limit)
+	MaxPayload int64
+
+	// MaxMessage limits the size of a reassembled message (0 = no limit)
+	MaxMessage int64
+
+	// OnControl is called by ReadMessage for ping and pong frames, which may
+	// arrive between the fragments of a message
+	OnControl func(*Frame)
+}
+
+// NewReader creates a Reader for r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// ReadFrame reads the next frame
+// A stream ending inside a frame returns io.ErrUnexpectedEOF
+func (r *Reader) ReadFrame() (*Frame, error) {
+	var head [14]byte
+	if _, err := io.ReadFull(r.r, head[:2]); err != nil {
+		return nil, err
+	}
+
+	n := 2
+	switch head[1] & 0x7F {
+	case 126:
+		n += 2
+	case 127:
+		n += 8
+	}
+	if head[1]&0x80 != 0 {
+		n += 4
+	}
+	if _, err := io.ReadFull(r.r, head[2:n]); err != nil {
+		return nil, httperrors.UnexpectedEOF(err)
+	}
+
+	f, _, payloadLen, err := decodeHeader(head[:n])
+	if err != nil {
+		return nil, err
+	}
+	if r.MaxPayload > 0 && payloadLen > uint64(r.MaxPayload) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, payloadLen)
+	}
+
+	// Grow with the data actually received rather than trusting the length
+	var payload bytes.Buffer
+	if _, err := io.CopyN(&payload, r.r, int64(payloadLen)); err != nil {
+		return nil, httperrors.UnexpectedEOF(err)
+	}
+	f.Payload = payload.Bytes()
+	if f.Masked {
+		maskBytes(f.MaskKey, f.Payload)
+	}
+	return f, nil
+}
+
+// ReadMessage reads frames until a complete data message and returns its
+// opcode (OpText or OpBinary) and reassembled payload
+// Control frames are passed to OnControl; a close frame is returned as an
+// OpClose message with its payload
+func (r *Reader) ReadMessage() (Opcode, []byte, error) {
+	var (
+		op      Opcode
+		message []byte
+		started bool
+	)
+	for {
+		f, err := r.ReadFrame()
+		if err != nil {
+			if started {
+				err = httperrors.UnexpectedEOF(err)
+			}
+			return 0, nil, err
+		}
+		if err := f.Validate(); err != nil {
+			return 0, nil, err
+		}
+
+		if f.Opcode.IsControl() {
+			if f.Opcode == OpClose {
+				return OpClose, f.Payload, nil
+			}
+			if r.OnControl != nil {
+				r.OnControl(f)
+			}
+			continue
+		}
+
+		switch {
+		case !started && f.Opcode == OpContinuation:
+			return 0, nil, fmt.Errorf("%w: continuation without a message", ErrInvalidFrame)
+		case started && f.Opcode != OpContinuation:
+			return 0, nil, fmt.Errorf("%w: %s frame inside a fragmented message", ErrInvalidFrame, f.Opcode)
+		case !started:
+			op, started = f.Opcode, true
+		}
+
+		if r.MaxMessage > 0 && int64(len(message)+len(f.Payload)) > r.MaxMessage {
+			return 0, nil, fmt.Errorf("%w: message exceeds %d bytes", ErrFrameTooLarge, r.MaxMessage)
+		}
+		message = append(message, f.Payload...)
+		if f.Fin {
+			return op, message, nil
+		}
+	}
+}
+
+// Fragment splits a message into frames carrying at most size payload bytes
+// The first frame has opcode op, the rest are continuations; size <= 0
+// yields a single frame
+func Fragment(op Opcode, payload []byte, size int) []*Frame {
+	if size <= 0 || len(payload) <= size {
+		return []*Frame{NewFrame(op, payload)}
+	}
+	var frames []*Frame
+	for len(payload) > 0 {
+		n := min(size, len(payload))
+		f := &Frame{Opcode: OpContinuation, Payload: payload[:n]}
+		if len(frames) == 0 {
+			f.Opcode = op
+		}
+		payload = payload[n:]
+		f.Fin = len(payload) == 0
+		frames = append(frames, f)
+	}
+	return frames
+}
+
+// maskBytes XORs data with key in place (masking is its own inverse)
+func maskBytes(key [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i&3]
+	}
+}
+
+// validCloseCode reports whether code may appear in a close frame
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package websocket
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	// Masked "Hello" from RFC 6455 section 5.7
+	wire := []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58}
+	f, n, err := Decode(append(wire, 0xFF))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if n != len(wire) || !f.Fin || f.Opcode != OpText || !f.Masked || string(f.Payload) != "Hello" {
+		t.Errorf("decoded %+v (%d bytes)", f, n)
+	}
+	if !bytes.Equal(f.Encode(), wire) {
+		t.Errorf("Encode = %x, want %x", f.Encode(), wire)
+	}
+
+	for _, size := range []int{0, 125, 126, 0xFFFF, 0x10000} {
+		f := &Frame{Opcode: OpBinary, RSV1: true, Payload: bytes.Repeat([]byte{'a'}, size)}
+		got, n, err := Decode(f.Encode())
+		if err != nil || n != len(f.Encode()) || len(got.Payload) != size || !got.RSV1 || got.Fin {
+			t.Errorf("size %d: %+v, %d, %v", size, got.Opcode, n, err)
+		}
+	}
+
+	if _, _, err := Decode(wire[:6]); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated frame: %v", err)
+	}
+}
+
+func TestFrameValidate(t *testing.T) {
+	tests := []struct {
+		frame *Frame
+		valid bool
+	}{
+		{NewFrame(OpText, []byte("x")), true},
+		{NewCloseFrame(CloseNormal, "bye"), true},
+		{NewCloseFrame(0, ""), true},
+		{&Frame{Opcode: OpPing}, false},
+		{NewFrame(OpPing, make([]byte, 126)), false},
+		{NewFrame(Opcode(3), nil), false},
+		{NewCloseFrame(CloseNoStatus, ""), false},
+		{NewFrame(OpClose, []byte{0x03}), false},
+	}
+	for _, tt := range tests {
+		err := tt.frame.Validate()
+		if (err == nil) != tt.valid || (err != nil && !errors.Is(err, ErrInvalidFrame)) {
+			t.Errorf("Validate(%s %x) = %v", tt.frame.Opcode, tt.frame.Payload, err)
+		}
+	}
+
+	code, reason := NewCloseFrame(CloseGoingAway, "restart").CloseCode()
+	if code != CloseGoingAway || reason != "restart" {
+		t.Errorf("CloseCode = %d %q", code, reason)
+	}
+}
+
+func TestReaderReadMessage(t *testing.T) {
+	var stream []byte
+	for i, f := range Fragment(OpText, []byte("hello world"), 4) {
+		f.Masked, f.MaskKey = true, [4]byte{1, 2, 3, byte(i)}
+		stream = f.AppendEncode(stream)
+		if i == 0 {
+			stream = NewFrame(OpPing, []byte("p")).AppendEncode(stream)
+		}
+	}
+	stream = NewCloseFrame(CloseNormal, "").AppendEncode(stream)
+
+	r := NewReader(bytes.NewReader(stream))
+	var pings []string
+	r.OnControl = func(f *Frame) { pings = append(pings, string(f.Payload)) }
+
+	op, msg, err := r.ReadMessage()
+	if err != nil || op != OpText || string(msg) != "hello world" {
+		t.Fatalf("ReadMessage = %s %q %v", op, msg, err)
+	}
+	if len(pings) != 1 || pings[0] != "p" {
+		t.Errorf("pings = %q", pings)
+	}
+	if op, _, err := r.ReadMessage(); op != OpClose || err != nil {
+		t.Errorf("expected close, got %s %v", op, err)
+	}
+	if _, _, err := r.ReadMessage(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+
+	r = NewReader(bytes.NewReader(Fragment(OpBinary, []byte("abcdef"), 2)[0].Encode()))
+	if _, _, err := r.ReadMessage(); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated message: %v", err)
+	}
+
+	r = NewReader(bytes.NewReader(NewFrame(OpBinary, make([]byte, 200)).Encode()))
+	r.MaxPayload = 100
+	if _, err := r.ReadFrame(); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected ErrFrameTooLarge, got %v", err)
+	}
+
+	r = NewReader(bytes.NewReader(NewFrame(OpContinuation, nil).Encode()))
+	if _, _, err := r.ReadMessage(); !errors.Is(err, ErrInvalidFrame) {
+		t.Errorf("expected ErrInvalidFrame, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+// ErrHandshake is matched by errors for upgrade handshakes violating RFC 6455
+var ErrHandshake = errors.New("websocket: invalid handshake")
+
+// acceptGUID is appended to Sec-WebSocket-Key to compute Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// NewKey returns a random Sec-WebSocket-Key value
+func NewKey() string {
+	var key [16]byte
+	rand.Read(key[:])
+	return base64.StdEncoding.EncodeToString(key[:])
+}
+
+// AcceptKey returns the Sec-WebSocket-Accept value for a Sec-WebSocket-Key
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(strings.TrimSpace(key) + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// IsUpgradeRequest reports whether req asks to upgrade to WebSocket:
+// Upgrade: websocket, Connection listing upgrade, and a Sec-WebSocket-Key
+// The method is not checked, so non-GET upgrade attempts are detected too
+func IsUpgradeRequest(req *request.Request) bool {
+	return hasToken(req.Headers.Get("Upgrade"), "websocket") &&
+		hasToken(req.Headers.Get("Connection"), "upgrade") &&
+		strings.TrimSpace(req.Headers.Get("Sec-WebSocket-Key")) != ""
+}
+
+// IsUpgradeResponse reports whether resp switches the connection to WebSocket
+func IsUpgradeResponse(resp *response.Response) bool {
+	return resp.StatusCode == 101 && hasToken(resp.Headers.Get("Upgrade"), "websocket")
+}
+
+// ValidateHandshake checks resp against the upgrade request req as a client
+// would (RFC 6455 section 4.1): status 101, Upgrade and Connection headers,
+// and a Sec-WebSocket-Accept matching the request key
+// Errors match ErrHandshake and name the first violation
+func ValidateHandshake(req *request.Request, resp *response.Response) error {
+	switch {
+	case !IsUpgradeRequest(req):
+		return fmt.Errorf("%w: request is not a WebSocket upgrade", ErrHandshake)
+	case resp.StatusCode != 101:
+		return fmt.Errorf("%w: status %d instead of 101", ErrHandshake, resp.StatusCode)
+	case !hasToken(resp.Headers.Get("Upgrade"), "websocket"):
+		return fmt.Errorf("%w: missing Upgrade: websocket", ErrHandshake)
+	case !hasToken(resp.Headers.Get("Connection"), "upgrade"):
+		return fmt.Errorf("%w: missing Connection: upgrade", ErrHandshake)
+	}
+	want := AcceptKey(req.Headers.Get("Sec-WebSocket-Key"))
+	if got := strings.TrimSpace(resp.Headers.Get("Sec-WebSocket-Accept")); got != want {
+		return fmt.Errorf("%w: Sec-WebSocket-Accept %q, want %q", ErrHandshake, got, want)
+	}
+	return nil
+}
+
+// NewUpgradeRequest creates a client upgrade request for host and target
+// with a fresh Sec-WebSocket-Key
+func NewUpgradeRequest(host, target string) *request.Request {
+	req := request.NewRequest()
+	req.Method = "GET"
+	req.URL = target
+	req.Version = "HTTP/1.1"
+	req.Headers.Set("Host", host)
+	req.Headers.Set("Upgrade", "websocket")
+	req.Headers.Set("Connection", "Upgrade")
+	req.Headers.Set("Sec-WebSocket-Key", NewKey())
+	req.Headers.Set("Sec-WebSocket-Version", "13")
+	req.ParseQueryParams()
+	return req
+}
+
+// NewUpgradeResponse creates the 101 response accepting the upgrade request req
+func NewUpgradeResponse(req *request.Request) *response.Response {
+	resp := response.NewResponse()
+	resp.Version = "HTTP/1.1"
+	resp.StatusCode = 101
+	resp.StatusText = "Switching Protocols"
+	resp.Headers.Set("Upgrade", "websocket")
+	resp.Headers.Set("Connection", "Upgrade")
+	resp.Headers.Set("Sec-WebSocket-Accept", AcceptKey(req.Headers.Get("Sec-WebSocket-Key")))
+	return resp
+}
+
+// hasToken reports whether a comma-separated header value contains token
+// (case-insensitive)
+func hasToken(value, token string) bool {
+	for _, part := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+	return false
+}
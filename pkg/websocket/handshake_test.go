@@ -0,0 +1,102 @@
+package websocket
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+func TestHandshake(t *testing.T) {
+	// Example from RFC 6455 section 1.3
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("AcceptKey = %q", got)
+	}
+
+	req, err := request.Parse([]byte("GET /chat HTTP/1.1\r\nHost: x\r\nUpgrade: WebSocket\r\nConnection: keep-alive, Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !IsUpgradeRequest(req) {
+		t.Fatal("IsUpgradeRequest = false")
+	}
+
+	resp, err := response.Parse(NewUpgradeResponse(req).Build())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !IsUpgradeResponse(resp) || ValidateHandshake(req, resp) != nil {
+		t.Errorf("ValidateHandshake = %v", ValidateHandshake(req, resp))
+	}
+
+	resp.Headers.Set("Sec-WebSocket-Accept", "bogus")
+	if err := ValidateHandshake(req, resp); !errors.Is(err, ErrHandshake) {
+		t.Errorf("ValidateHandshake = %v, want ErrHandshake", err)
+	}
+	if err := ValidateHandshake(NewUpgradeRequest("x", "/"), resp); !errors.Is(err, ErrHandshake) {
+		t.Errorf("ValidateHandshake with another key = %v", err)
+	}
+}
+
+func TestConn(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	defer clientSide.Close()
+	defer serverSide.Close()
+
+	client := NewConn(bufio.NewReader(clientSide), clientSide, true)
+	server := NewConn(serverSide, serverSide, false)
+
+	done := make(chan error, 1)
+	go func() {
+		// Frames from the client arrive masked
+		f, err := server.ReadFrame()
+		if err == nil && !f.Masked {
+			err = errors.New("client frame not masked")
+		}
+		var payload []byte
+		if err == nil {
+			payload = f.Payload
+		}
+		if err == nil {
+			err = server.Ping([]byte("p"))
+		}
+		if err == nil {
+			// The client answers the ping while waiting for a message
+			f, err = server.ReadFrame()
+			if err == nil && (f.Opcode != OpPong || string(f.Payload) != "p") {
+				err = errors.New("expected pong")
+			}
+		}
+		if err == nil {
+			err = server.WriteMessage(OpText, payload)
+		}
+		if err == nil {
+			err = server.Close(CloseNormal, "bye")
+		}
+		if err == nil {
+			// The client echoes the close code
+			f, err = server.ReadFrame()
+			if err == nil && f.Opcode != OpClose {
+				err = errors.New("expected close reply")
+			}
+		}
+		done <- err
+	}()
+
+	if err := client.WriteMessage(OpText, []byte("hello")); err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+	op, msg, err := client.ReadMessage()
+	if err != nil || op != OpText || string(msg) != "hello" {
+		t.Fatalf("ReadMessage = %v, %q, %v", op, msg, err)
+	}
+	if op, _, err := client.ReadMessage(); err != nil || op != OpClose {
+		t.Errorf("ReadMessage = %v, %v", op, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("server: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package wordlist expands a base request across a wordlist
+//
+// Each word is placed at one insertion point of the base request, producing
+// requests for content discovery or virtual host enumeration that can be fed
+// straight into schedule.Run:
+//
+//	f, _ := os.Open("common.txt")
+//	reqs := wordlist.Generate(ctx, base, f, wordlist.Options{Point: wordlist.PathSuffix})
+//	for r := range schedule.Run(ctx, reqs, send, schedule.Options{Workers: 20}) {
+//		...
+//	}
+package wordlist
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+)
+
+// Point selects where words are inserted
+type Point int
+
+const (
+	PathSuffix Point = iota // Appended to the path as a new segment (/base/word)
+	ParamValue              // Value of the query parameter Options.Param (added if missing)
+	Host                    // Host header (word + Options.HostSuffix)
+)
+
+// Options configures word insertion
+type Options struct {
+	Point Point
+
+	// Param is the query parameter for ParamValue
+	Param string
+
+	// HostSuffix is appended to each word for Host (e.g. ".example.com")
+	HostSuffix string
+
+	// Raw inserts words as is; by default they are percent-encoded for
+	// PathSuffix and ParamValue
+	Raw bool
+}
+
+// Apply returns a copy of base with word inserted
+func Apply(base *request.Request, word string, opts Options) *request.Request {
+	req := base.Clone()
+	path, query, hasQuery := strings.Cut(req.URL, "?")
+
+	switch opts.Point {
+	case PathSuffix:
+		if !opts.Raw {
+			word = url.PathEscape(word)
+		}
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		path += strings.TrimPrefix(word, "/")
+		req.URL = path
+		if hasQuery {
+			req.URL += "?" + query
+		}
+
+	case ParamValue:
+		if !opts.Raw {
+			word = url.QueryEscape(word)
+		}
+		req.URL = path + "?" + setParam(query, opts.Param, word)
+
+	case Host:
+		req.Headers.Set("Host", word+opts.HostSuffix)
+	}
+
+	req.ParseQueryParams()
+	return req
+}
+
+// Generate streams one request per word read from words (one word per line)
+// Empty lines and lines starting with # are skipped
+// The channel is closed at the end of words, on a read error, or when ctx is cancelled
+func Generate(ctx context.Context, base *request.Request, words io.Reader, opts Options) <-chan *request.Request {
+	out := make(chan *request.Request)
+	go func() {
+		defer close(out)
+		scanner := bufio.NewScanner(words)
+		for scanner.Scan() {
+			word := strings.TrimSpace(scanner.Text())
+			if word == "" || strings.HasPrefix(word, "#") {
+				continue
+			}
+			select {
+			case out <- Apply(base, word, opts):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// setParam sets every name=value pair in query to value, or appends one
+// Other pairs keep their original encoding and order
+func setParam(query, name, value string) string {
+	if query == "" {
+		return name + "=" + value
+	}
+	pairs := strings.Split(query, "&")
+	found := false
+	for i, pair := range pairs {
+		if k, _, _ := strings.Cut(pair, "="); k == name {
+			pairs[i] = name + "=" + value
+			found = true
+		}
+	}
+	if !found {
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, "&")
+}
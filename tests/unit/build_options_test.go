@@ -1,9 +1,12 @@
 package unit
 
 import (
+	"io"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/WhileEndless/go-httptools/pkg/compression"
 	"github.com/WhileEndless/go-httptools/pkg/request"
 	"github.com/WhileEndless/go-httptools/pkg/response"
 )
@@ -358,3 +361,171 @@ func TestRequest_BuildAsHTTP2(t *testing.T) {
 		t.Error("BuildAsHTTP2 should contain :method pseudo-header")
 	}
 }
+
+// ==================== APPEND / WRITER BUILD TESTS ====================
+
+func TestResponse_AppendBuild(t *testing.T) {
+	raw := []byte("HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: 5\r\n\r\nhello")
+
+	resp, err := response.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected, err := resp.BuildWithOptions(response.DefaultBuildOptions())
+	if err != nil {
+		t.Fatalf("BuildWithOptions failed: %v", err)
+	}
+
+	prefix := []byte("PREFIX|")
+	dst := make([]byte, len(prefix), 4096)
+	copy(dst, prefix)
+
+	out, err := resp.AppendBuild(dst)
+	if err != nil {
+		t.Fatalf("AppendBuild failed: %v", err)
+	}
+	if string(out) != string(prefix)+string(expected) {
+		t.Errorf("Unexpected AppendBuild output: %q", out)
+	}
+	if &out[0] != &dst[0] {
+		t.Error("AppendBuild should reuse dst when it has enough capacity")
+	}
+}
+
+func TestResponse_WriteToWithOptions(t *testing.T) {
+	raw := []byte("HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: 5\r\n\r\nhello")
+
+	resp, err := response.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	for _, opts := range []response.BuildOptions{
+		response.DefaultBuildOptions(),
+		response.HTTP2Options(),
+		{Chunked: response.ChunkedApply},
+	} {
+		expected, err := resp.BuildWithOptions(opts)
+		if err != nil {
+			t.Fatalf("BuildWithOptions failed: %v", err)
+		}
+
+		var sb strings.Builder
+		n, err := resp.WriteToWithOptions(&sb, opts)
+		if err != nil {
+			t.Fatalf("WriteToWithOptions failed: %v", err)
+		}
+		if sb.String() != string(expected) {
+			t.Errorf("WriteToWithOptions output mismatch:\ngot  %q\nwant %q", sb.String(), expected)
+		}
+		if n != int64(len(expected)) {
+			t.Errorf("Expected %d bytes written, got %d", len(expected), n)
+		}
+	}
+}
+
+func TestRequest_WriteToWithOptions(t *testing.T) {
+	raw := []byte("POST /api HTTP/1.1\r\nHost: example.com\r\nContent-Length: 4\r\n\r\ndata")
+
+	req, err := request.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected, err := req.BuildWithOptions(request.HTTP2Options())
+	if err != nil {
+		t.Fatalf("BuildWithOptions failed: %v", err)
+	}
+
+	var sb strings.Builder
+	if _, err := req.WriteToWithOptions(&sb, request.HTTP2Options()); err != nil {
+		t.Fatalf("WriteToWithOptions failed: %v", err)
+	}
+	if sb.String() != string(expected) {
+		t.Errorf("WriteToWithOptions output mismatch:\ngot  %q\nwant %q", sb.String(), expected)
+	}
+
+	out, err := req.AppendBuild(nil)
+	if err != nil {
+		t.Fatalf("AppendBuild failed: %v", err)
+	}
+	if !strings.HasSuffix(string(out), "\r\n\r\ndata") {
+		t.Errorf("Unexpected AppendBuild output: %q", out)
+	}
+}
+
+// reverseCodec is a toy content coding that reverses the body
+type reverseCodec struct{}
+
+func (reverseCodec) Compress(data []byte) ([]byte, error) {
+	out := slices.Clone(data)
+	slices.Reverse(out)
+	return out, nil
+}
+
+func (reverseCodec) Decompress(data []byte) ([]byte, error) {
+	return reverseCodec{}.Compress(data)
+}
+
+func TestBuildWithOptions_CustomEncoding(t *testing.T) {
+	compression.Register("x-reverse", reverseCodec{})
+
+	raw := []byte("HTTP/1.1 200 OK\r\nContent-Encoding: x-reverse\r\nContent-Length: 5\r\n\r\nolleh")
+	resp, err := response.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !resp.Compressed || string(resp.Body) != "hello" {
+		t.Fatalf("Expected decoded body, got %q", resp.Body)
+	}
+
+	// Keep leaves the registered coding alone
+	built, err := resp.BuildWithOptions(response.DefaultBuildOptions())
+	if err != nil || string(built) != string(raw) {
+		t.Errorf("Unexpected Keep build %q, %v", built, err)
+	}
+
+	opts := response.DefaultBuildOptions()
+	opts.Compression = response.CompressionCustom
+	opts.Encoding = "zstd"
+	built, err = resp.BuildWithOptions(opts)
+	if err != nil || !strings.Contains(string(built), "Content-Encoding: zstd") {
+		t.Errorf("Unexpected zstd build %q, %v", built, err)
+	}
+
+	req, err := request.Parse([]byte("POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 5\r\n\r\nhello"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	reqOpts := request.DefaultBuildOptions()
+	reqOpts.Compression = request.CompressionCustom
+	reqOpts.Encoding = "x-reverse"
+	built, err = req.BuildWithOptions(reqOpts)
+	if err != nil || !strings.HasSuffix(string(built), "Content-Encoding: x-reverse\r\n\r\nolleh") {
+		t.Errorf("Unexpected custom build %q, %v", built, err)
+	}
+
+	reqOpts.Encoding = "x-unknown"
+	if _, err := req.BuildWithOptions(reqOpts); err == nil {
+		t.Error("Expected error for an unregistered encoding")
+	}
+}
+
+func TestWrapBodyReader_CustomEncoding(t *testing.T) {
+	compression.Register("x-reverse", reverseCodec{})
+
+	resp, body, err := response.ParseHeadersFromReader(strings.NewReader(
+		"HTTP/1.1 200 OK\r\nContent-Encoding: x-reverse\r\nTransfer-Encoding: chunked\r\n\r\n3\r\noll\r\n2\r\neh\r\n0\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("ParseHeadersFromReader failed: %v", err)
+	}
+	stream, err := resp.WrapBodyReader(body)
+	if err != nil {
+		t.Fatalf("WrapBodyReader failed: %v", err)
+	}
+	defer stream.Close()
+	if out, err := io.ReadAll(stream); err != nil || string(out) != "hello" {
+		t.Errorf("ReadAll = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/bulk"
+)
+
+func bulkRawRequests(n int) [][]byte {
+	raws := make([][]byte, n)
+	for i := range raws {
+		raws[i] = []byte(fmt.Sprintf("GET /item/%d HTTP/1.1\r\nHost: example.com\r\n\r\n", i))
+	}
+	return raws
+}
+
+func TestBulkParseRequests_Ordered(t *testing.T) {
+	raws := bulkRawRequests(200)
+	raws[17] = []byte{} // Invalid message
+
+	results := bulk.ParseRequests(raws, bulk.Options{Workers: 8})
+	if len(results) != len(raws) {
+		t.Fatalf("Expected %d results, got %d", len(raws), len(results))
+	}
+
+	for i, r := range results {
+		if r.Index != i {
+			t.Errorf("Result %d has index %d", i, r.Index)
+		}
+		if i == 17 {
+			if r.Err == nil {
+				t.Error("Expected error for empty message")
+			}
+			continue
+		}
+		if r.Err != nil {
+			t.Errorf("Result %d: unexpected error %v", i, r.Err)
+			continue
+		}
+		if want := fmt.Sprintf("/item/%d", i); r.Request.URL != want {
+			t.Errorf("Result %d: expected URL %s, got %s", i, want, r.Request.URL)
+		}
+	}
+}
+
+func TestBulkParseResponses(t *testing.T) {
+	raws := [][]byte{
+		[]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"),
+		[]byte("HTTP/1.1 404 Not Found\r\n\r\n"),
+	}
+
+	results := bulk.ParseResponses(raws, bulk.Options{})
+	if results[0].Response.StatusCode != 200 || results[1].Response.StatusCode != 404 {
+		t.Errorf("Unexpected status codes: %d, %d",
+			results[0].Response.StatusCode, results[1].Response.StatusCode)
+	}
+}
+
+func TestBulkParseRequestsChan(t *testing.T) {
+	for _, ordered := range []bool{true, false} {
+		t.Run(fmt.Sprintf("ordered=%v", ordered), func(t *testing.T) {
+			raws := bulkRawRequests(500)
+			in := make(chan []byte)
+			go func() {
+				for _, raw := range raws {
+					in <- raw
+				}
+				close(in)
+			}()
+
+			seen := make(map[int]bool)
+			next := 0
+			for r := range bulk.ParseRequestsChan(in, bulk.Options{Workers: 4, Ordered: ordered}) {
+				if r.Err != nil {
+					t.Fatalf("Unexpected error: %v", r.Err)
+				}
+				if ordered && r.Index != next {
+					t.Fatalf("Expected index %d, got %d", next, r.Index)
+				}
+				next++
+				seen[r.Index] = true
+			}
+
+			if len(seen) != len(raws) {
+				t.Errorf("Expected %d results, got %d", len(raws), len(seen))
+			}
+		})
+	}
+}
+
+func BenchmarkBulkParseRequests(b *testing.B) {
+	raws := bulkRawRequests(1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bulk.ParseRequests(raws, bulk.Options{})
+	}
+}
@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WhileEndless/go-httptools/pkg/cache"
+	"github.com/WhileEndless/go-httptools/pkg/clock"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+func cacheRequest(t *testing.T, raw string) *request.Request {
+	t.Helper()
+	req, err := request.Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return req
+}
+
+func TestCacheFreshAndRevalidate(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := cache.New(nil)
+	c.Clock = fake
+
+	var sent []*request.Request
+	send := c.Wrap(func(ctx context.Context, req *request.Request) (*response.Response, error) {
+		sent = append(sent, req)
+		if req.Headers.Get("If-None-Match") == `"v1"` {
+			return response.Parse([]byte("HTTP/1.1 304 Not Modified\r\nCache-Control: max-age=60\r\nX-Revalidated: yes\r\n\r\n"))
+		}
+		return response.Parse([]byte("HTTP/1.1 200 OK\r\nCache-Control: max-age=60\r\nETag: \"v1\"\r\nContent-Length: 5\r\n\r\nhello"))
+	})
+
+	req := cacheRequest(t, "GET /page HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	for i := 0; i < 3; i++ {
+		resp, err := send(context.Background(), req)
+		if err != nil || string(resp.Body) != "hello" {
+			t.Fatalf("send %d = %v, %v", i, resp, err)
+		}
+	}
+	if len(sent) != 1 {
+		t.Fatalf("fresh entry: %d requests sent, want 1", len(sent))
+	}
+	if _, ok := c.Lookup(req); !ok {
+		t.Error("Lookup should find the fresh entry")
+	}
+
+	fake.Advance(2 * time.Minute)
+	if _, ok := c.Lookup(req); ok {
+		t.Error("Lookup should not return a stale entry")
+	}
+	resp, err := send(context.Background(), req)
+	if err != nil {
+		t.Fatalf("revalidation failed: %v", err)
+	}
+	if len(sent) != 2 || sent[1].Headers.Get("If-None-Match") != `"v1"` {
+		t.Fatalf("stale entry not revalidated: %d requests sent", len(sent))
+	}
+	if resp.StatusCode != 200 || string(resp.Body) != "hello" || strings.TrimSpace(resp.Headers.Get("X-Revalidated")) != "yes" {
+		t.Errorf("revalidated response = %d %q %q", resp.StatusCode, resp.Body, resp.Headers.Get("X-Revalidated"))
+	}
+	if req.Headers.Has("If-None-Match") {
+		t.Error("caller's request was modified")
+	}
+
+	// Revalidation refreshed the entry
+	send(context.Background(), req)
+	if len(sent) != 2 {
+		t.Errorf("revalidated entry not fresh: %d requests sent", len(sent))
+	}
+}
+
+func TestCacheKeys(t *testing.T) {
+	origin := cacheRequest(t, "GET /a?b=1#frag HTTP/1.1\r\nHost: Example.COM:443\r\nAccept-Language: en\r\nAccept-Encoding:  gzip,   br\r\n\r\n")
+	absolute := cacheRequest(t, "GET https://example.com/a?b=1 HTTP/1.1\r\nHost: other\r\nAccept-Encoding: gzip, br\r\nAccept-Language: en\r\n\r\n")
+
+	if got := cache.NormalizeURL(origin); got != "https://example.com/a?b=1" {
+		t.Errorf("NormalizeURL(origin-form) = %q", got)
+	}
+	if cache.Key(origin) != cache.Key(absolute) || cache.Key(origin) != "GET https://example.com/a?b=1" {
+		t.Errorf("Key mismatch: %q vs %q", cache.Key(origin), cache.Key(absolute))
+	}
+	if got := cache.NormalizeURL(cacheRequest(t, "GET //evil/x HTTP/1.1\r\nHost: [::1]:80\r\n\r\n")); got != "http://[::1]//evil/x" {
+		t.Errorf("NormalizeURL(//path) = %q", got)
+	}
+
+	k1, _ := cache.VaryKey(origin, "Accept-Language, accept-encoding")
+	k2, _ := cache.VaryKey(absolute, "Accept-Encoding,Accept-Language")
+	if k1 != k2 || k1 != "GET https://example.com/a?b=1\naccept-encoding: gzip, br\naccept-language: en" {
+		t.Errorf("VaryKey = %q / %q", k1, k2)
+	}
+	if _, ok := cache.VaryKey(origin, "*"); ok {
+		t.Error("Vary: * should not produce a key")
+	}
+
+	// Only the scheme's default port is stripped, and the scheme is part of the key
+	for _, raw := range []string{
+		"GET http://example.com:443/a?b=1 HTTP/1.1\r\n\r\n",
+		"GET http://example.com/a?b=1 HTTP/1.1\r\n\r\n",
+		"GET /a?b=1 HTTP/1.1\r\nHost: example.com\r\n\r\n",
+	} {
+		if other := cacheRequest(t, raw); cache.Key(other) == cache.Key(absolute) {
+			t.Errorf("Key(%q) collides with the https key %q", raw, cache.Key(absolute))
+		}
+	}
+	if got := cache.NormalizeURL(cacheRequest(t, "GET http://Example.com:80/ HTTP/1.1\r\n\r\n")); got != "http://example.com/" {
+		t.Errorf("NormalizeURL(http default port) = %q", got)
+	}
+}
+
+func TestCacheVaryAndNoStore(t *testing.T) {
+	c := cache.New(nil)
+	calls := 0
+	send := c.Wrap(func(ctx context.Context, req *request.Request) (*response.Response, error) {
+		calls++
+		if req.Path == "/private" {
+			return response.Parse([]byte("HTTP/1.1 200 OK\r\nCache-Control: no-store\r\nContent-Length: 0\r\n\r\n"))
+		}
+		return response.Parse([]byte("HTTP/1.1 200 OK\r\nCache-Control: max-age=60\r\nVary: Accept-Language\r\nContent-Length: 0\r\n\r\n"))
+	})
+
+	en := cacheRequest(t, "GET / HTTP/1.1\r\nHost: example.com\r\nAccept-Language: en\r\n\r\n")
+	de := cacheRequest(t, "GET / HTTP/1.1\r\nHost: example.com\r\nAccept-Language: de\r\n\r\n")
+	send(context.Background(), en)
+	send(context.Background(), en)
+	if calls != 1 {
+		t.Errorf("same Vary value: %d calls, want 1", calls)
+	}
+	send(context.Background(), de)
+	if calls != 2 {
+		t.Errorf("different Vary value: %d calls, want 2", calls)
+	}
+	// Each variant keeps its own entry
+	send(context.Background(), en)
+	send(context.Background(), de)
+	if calls != 2 {
+		t.Errorf("stored variants: %d calls, want 2", calls)
+	}
+
+	private := cacheRequest(t, "GET /private HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	send(context.Background(), private)
+	send(context.Background(), private)
+	if calls != 4 {
+		t.Errorf("no-store: %d calls, want 4", calls)
+	}
+
+	post := cacheRequest(t, "POST / HTTP/1.1\r\nHost: example.com\r\nAccept-Language: de\r\nContent-Length: 0\r\n\r\n")
+	send(context.Background(), post)
+	if calls != 5 {
+		t.Errorf("POST served from cache: %d calls, want 5", calls)
+	}
+}
@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/capture"
+)
+
+func TestCaptureFile_Responses(t *testing.T) {
+	content := "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nfirst" +
+		"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n6\r\nsecond\r\n0\r\n\r\n" +
+		"\r\n" +
+		"HTTP/1.1 204 No Content\r\n\r\n" +
+		"HTTP/1.1 200 OK\r\nServer: legacy\r\n\r\nunframed body\n" +
+		"HTTP/1.1 404 Not Found\r\nContent-Length: 9\r\n\r\nnot found"
+
+	path := filepath.Join(t.TempDir(), "responses.bin")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := capture.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	if f.Size() != int64(len(content)) {
+		t.Errorf("Expected size %d, got %d", len(content), f.Size())
+	}
+
+	expected := []int{200, 200, 204, 200, 404}
+	var statuses []int
+	it := f.Responses()
+	for it.Next() {
+		resp, err := it.Response()
+		if err != nil {
+			t.Fatalf("Response at offset %d failed: %v", it.Offset(), err)
+		}
+		statuses = append(statuses, resp.StatusCode)
+	}
+
+	if len(statuses) != len(expected) {
+		t.Fatalf("Expected %d responses, got %d: %v", len(expected), len(statuses), statuses)
+	}
+	for i := range expected {
+		if statuses[i] != expected[i] {
+			t.Errorf("Response %d: expected %d, got %d", i, expected[i], statuses[i])
+		}
+	}
+}
+
+func TestCaptureIterator_Requests(t *testing.T) {
+	data := []byte("GET /a HTTP/1.1\r\nHost: x\r\n\r\n" +
+		"POST /b HTTP/1.1\r\nHost: x\r\nContent-Length: 4\r\n\r\ndata" +
+		"GET /c HTTP/1.1\nHost: x\n\n")
+
+	it := capture.NewIterator(data, capture.KindRequest)
+	var urls []string
+	for it.Next() {
+		req, err := it.Request()
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		urls = append(urls, req.URL)
+	}
+
+	if len(urls) != 3 || urls[0] != "/a" || urls[1] != "/b" || urls[2] != "/c" {
+		t.Errorf("Unexpected requests: %v", urls)
+	}
+}
+
+func TestCaptureFile_ParsedAfterClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "response.bin")
+	content := "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nhello"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := capture.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	it := f.Responses()
+	if !it.Next() {
+		t.Fatal("Expected a response")
+	}
+	resp, err := it.Response()
+	if err != nil {
+		t.Fatalf("Response failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Body and RawBody must not point into the unmapped file
+	if string(resp.Body) != "hello" || string(resp.RawBody) != "hello" || len(resp.Raw) != 43 {
+		t.Errorf("Unexpected response after Close: %q, %q", resp.Body, resp.RawBody)
+	}
+}
+
+func TestCaptureFile_Empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.bin")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := capture.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	if f.Requests().Next() {
+		t.Error("Empty file should have no messages")
+	}
+}
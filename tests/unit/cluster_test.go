@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/cluster"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+func clusterResponse(status int, title, body string) *response.Response {
+	resp := response.NewResponse()
+	resp.StatusCode = status
+	resp.Body = []byte("<html><head><title>" + title + "</title></head><body>" + body + "</body></html>")
+	return resp
+}
+
+func TestClusterResponses(t *testing.T) {
+	notFound := func(path string) *response.Response {
+		return clusterResponse(404, "Not Found", "The requested page "+path+" could not be found on this server. "+strings.Repeat("Please check the address and try again later. ", 10))
+	}
+	page := func(token string) *response.Response {
+		return clusterResponse(200, "Admin", fmt.Sprintf("<input value=%q>", token)+strings.Repeat("Dashboard widgets listing recent orders and user accounts. ", 20))
+	}
+
+	resps := []*response.Response{
+		notFound("/a"), page("t1"), notFound("/backup"), notFound("/old"),
+		clusterResponse(500, "Error", "stack trace"), page("t2"), notFound("/x"),
+	}
+	groups := cluster.Responses(resps, cluster.Options{})
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups: %+v", len(groups), groups)
+	}
+
+	want := [][]int{{0, 2, 3, 6}, {1, 5}, {4}}
+	for i, g := range groups {
+		if fmt.Sprint(g.Members) != fmt.Sprint(want[i]) {
+			t.Errorf("group %d members = %v, want %v", i, g.Members, want[i])
+		}
+	}
+	if groups[0].Size() != 4 || groups[0].Exemplar() != 0 || groups[0].Signature.Title != "Not Found" {
+		t.Errorf("largest group = %+v", groups[0])
+	}
+
+	// Same body and title with a different status is a different cluster
+	resps = []*response.Response{notFound("/a"), notFound("/a")}
+	resps[1].StatusCode = 200
+	if n := len(cluster.Responses(resps, cluster.Options{})); n != 2 {
+		t.Errorf("status ignored: %d groups", n)
+	}
+}
@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/clock"
+	"github.com/WhileEndless/go-httptools/pkg/har"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+func TestHARRoundTrip(t *testing.T) {
+	req, err := request.Parse([]byte("POST /login?next=%2Fhome&x=1 HTTP/1.1\r\nHost: example.com:443\r\nCookie: sid=1\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 13\r\n\r\nuser=a+b&pw=1"))
+	if err != nil {
+		t.Fatalf("request.Parse failed: %v", err)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte{0xff, 0x00, 0x01})
+	zw.Close()
+	head := fmt.Sprintf("HTTP/1.1 302 Found\r\nLocation: /home\r\nSet-Cookie: sid=2; Path=/; HttpOnly\r\nContent-Encoding: gzip\r\nContent-Length: %d\r\n\r\n", gz.Len())
+	resp, err := response.Parse(append([]byte(head), gz.Bytes()...))
+	if err != nil {
+		t.Fatalf("response.Parse failed: %v", err)
+	}
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry := har.ToHAR(req, resp, har.Timing{Started: start, Connect: 10 * time.Millisecond, Wait: 25 * time.Millisecond})
+	if entry.Request.URL != "https://example.com/login?next=%2Fhome&x=1" || entry.Time != 35 || entry.Timings.DNS != -1 {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.Timings.Send != 0 || entry.Timings.Receive != 0 || entry.Timings.Wait != 25 {
+		t.Errorf("Timings = %+v", entry.Timings)
+	}
+	if len(entry.Request.QueryString) != 2 || entry.Request.QueryString[0].Value != "/home" {
+		t.Errorf("QueryString = %v", entry.Request.QueryString)
+	}
+	if p := entry.Request.PostData; p == nil || len(p.Params) != 2 || p.Params[0].Value != "a b" {
+		t.Errorf("PostData = %+v", p)
+	}
+	c := entry.Response.Content
+	if c.Encoding != "base64" || c.Size != 3 || entry.Response.RedirectURL != "/home" || !entry.Response.Cookies[0].HTTPOnly {
+		t.Errorf("Response = %+v", entry.Response)
+	}
+
+	archive := har.New()
+	archive.Add(entry)
+	data, err := json.Marshal(archive)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"version":"1.2"`) || !strings.Contains(string(data), `"startedDateTime":"2024-01-02T03:04:05Z"`) {
+		t.Errorf("HAR = %s", data)
+	}
+
+	parsed, err := har.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	req2, resp2, err := har.FromHAR(parsed.Log.Entries[0])
+	if err != nil {
+		t.Fatalf("FromHAR failed: %v", err)
+	}
+	if req2.URL != "/login?next=%2Fhome&x=1" || req2.GetHost() != "example.com:443" || string(req2.Body) != "user=a+b&pw=1" {
+		t.Errorf("request = %q", req2.Build())
+	}
+	if resp2.StatusCode != 302 || !bytes.Equal(resp2.Body, []byte{0xff, 0x00, 0x01}) || resp2.Headers.Has("Content-Encoding") {
+		t.Errorf("response = %q", resp2.Build())
+	}
+
+	// Started defaults to the current time on Clock
+	fake := clock.NewFake(start)
+	if e := har.ToHAR(req, nil, har.Timing{Clock: fake}); e.StartedDateTime != "2024-01-02T03:04:05Z" {
+		t.Errorf("StartedDateTime = %s", e.StartedDateTime)
+	}
+
+	// Compression counts the content coding only, not chunk framing
+	text := strings.Repeat("compressible ", 100)
+	gz.Reset()
+	zw = gzip.NewWriter(&gz)
+	zw.Write([]byte(text))
+	zw.Close()
+	opts := response.ParseOptions{AutoDecodeChunked: true}
+	resp, _ = response.ParseWithOptions(append([]byte("HTTP/1.1 200 OK\r\nContent-Encoding: gzip\r\nTransfer-Encoding: chunked\r\n\r\n"),
+		chunked.Encode(gz.Bytes(), 16)...), opts)
+	if c := har.ToHAR(req, resp, har.Timing{Started: start}).Response.Content; c.Compression != len(text)-gz.Len() {
+		t.Errorf("Compression = %d, want %d", c.Compression, len(text)-gz.Len())
+	}
+	resp, _ = response.ParseWithOptions([]byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n"), opts)
+	if c := har.ToHAR(req, resp, har.Timing{Started: start}).Response.Content; c.Compression != 0 {
+		t.Errorf("Compression = %d for an uncompressed chunked body", c.Compression)
+	}
+
+	// Chunked request bodies are exported without their framing
+	chunkedReq, _ := request.Parse([]byte("POST /f HTTP/1.1\r\nHost: x\r\nContent-Type: application/x-www-form-urlencoded\r\nTransfer-Encoding: chunked\r\n\r\n3\r\na=1\r\n0\r\n\r\n"))
+	p := har.ToHAR(chunkedReq, nil, har.Timing{Started: start}).Request.PostData
+	if p == nil || p.Text != "a=1" || len(p.Params) != 1 || p.Params[0].Name != "a" {
+		t.Errorf("chunked PostData = %+v", p)
+	}
+
+	// Binary request bodies survive the round trip
+	binaryReq, _ := request.Parse([]byte("POST /b HTTP/1.1\r\nHost: x\r\nContent-Length: 3\r\n\r\n\xff\x00\x01"))
+	e := har.ToHAR(binaryReq, nil, har.Timing{Started: start})
+	if e.Request.PostData == nil || e.Request.PostData.Encoding != "base64" {
+		t.Errorf("binary PostData = %+v", e.Request.PostData)
+	}
+	if req3, _, err := har.FromHAR(e); err != nil {
+		t.Errorf("FromHAR failed: %v", err)
+	} else if !bytes.Equal(req3.Body, []byte{0xff, 0x00, 0x01}) {
+		t.Errorf("FromHAR body = %q", req3.Body)
+	}
+
+	// Entries without a response
+	if _, resp3, err := har.FromHAR(har.Entry{Request: har.Request{Method: "GET", URL: "http://x/"}}); err != nil || resp3 != nil {
+		t.Errorf("FromHAR = %v, %v", resp3, err)
+	}
+}
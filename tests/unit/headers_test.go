@@ -1,9 +1,15 @@
 package unit
 
 import (
+	"io"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/WhileEndless/go-httptools/pkg/errors"
 	"github.com/WhileEndless/go-httptools/pkg/headers"
+	"github.com/WhileEndless/go-httptools/pkg/response"
 )
 
 func TestOrderedHeaders_Basic(t *testing.T) {
@@ -96,6 +102,24 @@ func TestOrderedHeaders_Update(t *testing.T) {
 	}
 }
 
+func TestOrderedHeaders_Clone(t *testing.T) {
+	h, err := headers.ParseHeaders([]byte("Host:  example.com\r\nSet-Cookie: a=1\n"))
+	if err != nil {
+		t.Fatalf("ParseHeaders failed: %v", err)
+	}
+	h.Add("Set-Cookie", "a=1")
+
+	clone := h.Clone()
+	if clone.Len() != 3 || string(clone.Build()) != string(h.Build()) {
+		t.Errorf("Clone = %q, want %q", clone.Build(), h.Build())
+	}
+
+	clone.Set("Host", "other")
+	if h.Get("Host") == "other" {
+		t.Error("Clone shares storage with the original")
+	}
+}
+
 func TestOrderedHeaders_Delete(t *testing.T) {
 	h := headers.NewOrderedHeaders()
 	h.Set("test", "deneme")
@@ -413,3 +437,303 @@ func TestOrderedHeaders_SpaceInValue(t *testing.T) {
 		t.Errorf("Internal spaces not preserved:\nExpected: %q\nGot: %q", headerData, built)
 	}
 }
+
+func TestReadBody(t *testing.T) {
+	body, err := headers.ReadBody([]byte("head|"), strings.NewReader("0123456789extra"), 10)
+	if err != nil {
+		t.Fatalf("ReadBody failed: %v", err)
+	}
+	if string(body) != "head|0123456789" {
+		t.Errorf("Expected 'head|0123456789', got %q", body)
+	}
+
+	// Truncated body returns what was read
+	body, err = headers.ReadBody(nil, strings.NewReader("short"), 10)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if string(body) != "short" {
+		t.Errorf("Expected 'short', got %q", body)
+	}
+
+	// A bogus Content-Length must not be preallocated
+	body, _ = headers.ReadBody(nil, strings.NewReader("x"), 1<<40)
+	if cap(body) > headers.MaxBodyPrealloc*2 {
+		t.Errorf("Expected capped preallocation, got cap %d", cap(body))
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	br := headers.NewReader(strings.NewReader("data"), 0)
+	if br.Size() != headers.DefaultReadBufferSize {
+		t.Errorf("Expected default size %d, got %d", headers.DefaultReadBufferSize, br.Size())
+	}
+
+	br = headers.NewReader(strings.NewReader("data"), 64*1024)
+	if br.Size() != 64*1024 {
+		t.Errorf("Expected size 65536, got %d", br.Size())
+	}
+
+	// Existing buffered readers are reused
+	if headers.NewReader(br, 16) != br {
+		t.Error("Expected existing *bufio.Reader to be reused")
+	}
+}
+
+func TestParseHTTPDate(t *testing.T) {
+	expected := time.Date(1994, 11, 6, 8, 49, 37, 0, time.UTC)
+
+	values := []string{
+		"Sun, 06 Nov 1994 08:49:37 GMT",
+		"Sunday, 06-Nov-94 08:49:37 GMT",
+		"Sun Nov  6 08:49:37 1994",
+		"  Sun, 6 Nov 1994 08:49:37 GMT  ",
+		"Sun, 06 Nov 1994 10:49:37 +0200",
+		"Sun, 06-Nov-1994 08:49:37 GMT",
+		"06 Nov 1994 08:49:37 GMT",
+	}
+	for _, value := range values {
+		got, err := headers.ParseHTTPDate(value)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", value, err)
+			continue
+		}
+		if !got.Equal(expected) || got.Location() != time.UTC {
+			t.Errorf("%q: expected %v, got %v", value, expected, got)
+		}
+	}
+
+	for _, value := range []string{"", "0", "-1", "not a date"} {
+		if _, err := headers.ParseHTTPDate(value); err == nil {
+			t.Errorf("%q: expected error", value)
+		}
+	}
+
+	if s := headers.FormatHTTPDate(expected); s != "Sun, 06 Nov 1994 08:49:37 GMT" {
+		t.Errorf("Unexpected formatted date %q", s)
+	}
+}
+
+func TestFindDuplicateHeaders(t *testing.T) {
+	data := []byte("Host: a.example\r\n" +
+		"Content-Length: 5\r\n" +
+		"Accept: */*\r\n" +
+		"Accept: text/html\r\n" +
+		"Host: b.example\r\n" +
+		"Content-Length: 5\r\n" +
+		"User-Agent: x\r\n" +
+		"\r\n")
+
+	findings := headers.FindDuplicateHeaders(data)
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings (list headers excluded), got %+v", findings)
+	}
+
+	host := findings[0]
+	if host.Name != "Host" || !host.Conflicting || len(host.Positions) != 2 || host.Positions[1] != 4 {
+		t.Errorf("Unexpected Host finding: %+v", host)
+	}
+	cl := findings[1]
+	if cl.Name != "Content-Length" || cl.Conflicting || cl.Positions[0] != 1 || cl.Positions[1] != 5 {
+		t.Errorf("Unexpected Content-Length finding: %+v", cl)
+	}
+
+	// A single line listing several lengths is reported too
+	findings = headers.FindDuplicateHeaders([]byte("Content-Length: 5, 6\r\n\r\n"))
+	if len(findings) != 1 || !findings[0].Conflicting || len(findings[0].Values) != 2 {
+		t.Errorf("Expected conflicting Content-Length list, got %+v", findings)
+	}
+
+	if !headers.IsSingletonHeader("content-type") || headers.IsSingletonHeader("Set-Cookie") {
+		t.Error("Unexpected IsSingletonHeader result")
+	}
+}
+
+func TestFindAnomalies(t *testing.T) {
+	data := []byte("Host: x\r\n" +
+		"Transfer-Encoding : chunked\r\n" +
+		"Content-Length: 5\r\n" +
+		"X-Note: a\r\n" +
+		" b\r\n" +
+		"Transfer-Encoding: xchunked\r\n" +
+		"Content-Length: 6\r\n" +
+		"\r\n")
+
+	var kinds []headers.AnomalyKind
+	for _, a := range headers.FindAnomalies(data) {
+		kinds = append(kinds, a.Kind)
+	}
+	want := []headers.AnomalyKind{
+		headers.AnomalySpaceBeforeColon,
+		headers.AnomalyObsFold,
+		headers.AnomalyDuplicateTransferEncoding,
+		headers.AnomalyObfuscatedTransferCoding,
+		headers.AnomalyConflictingContentLength,
+		headers.AnomalyContentLengthWithTE,
+	}
+	if !slices.Equal(kinds, want) {
+		t.Errorf("Anomalies = %v, want %v", kinds, want)
+	}
+
+	// A folded Transfer-Encoding continues its value
+	anomalies := headers.FindAnomalies([]byte("Transfer-Encoding: gzip,\r\n\tchunked\r\n\r\n"))
+	if len(anomalies) != 1 || anomalies[0].Kind != headers.AnomalyObsFold || anomalies[0].Line != 1 {
+		t.Errorf("Unexpected anomalies %+v", anomalies)
+	}
+
+	if anomalies := headers.FindAnomalies([]byte("Host: x\r\nContent-Length: 5\r\n\r\n")); len(anomalies) != 0 {
+		t.Errorf("Expected no anomalies, got %+v", anomalies)
+	}
+}
+
+func TestParseHeadersObsFold(t *testing.T) {
+	data := []byte("X-Long: first\r\n  second\r\nHost: x\r\n")
+	var diags errors.Diagnostics
+	h, err := headers.ParseHeadersWithDiagnostics(data, 0, &diags)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := strings.TrimSpace(h.Get("X-Long")); got != "first second" {
+		t.Errorf("Folded value = %q", got)
+	}
+	if h.Len() != 2 {
+		t.Errorf("Expected 2 headers, got %d", h.Len())
+	}
+	if string(h.Build()) != string(data) {
+		t.Errorf("Build = %q, want %q", h.Build(), data)
+	}
+	if len(diags) != 1 || diags[0].Code != errors.DiagObsFold || diags[0].Offset != 15 {
+		t.Errorf("Unexpected diagnostics %v", diags)
+	}
+}
+
+func TestHeaderSection(t *testing.T) {
+	raw := []byte("GET / HTTP/1.1\r\nHost: x\r\nA: b\r\n\r\nbody")
+	if got := string(headers.HeaderSection(raw)); got != "Host: x\r\nA: b\r\n" {
+		t.Errorf("Unexpected header section %q", got)
+	}
+	if got := string(headers.HeaderSection([]byte("GET / HTTP/1.1\nHost: x\n\nbody"))); got != "Host: x\n" {
+		t.Errorf("Unexpected header section %q", got)
+	}
+}
+
+func TestParseAccept(t *testing.T) {
+	items := headers.ParseAccept("text/*;q=0.5, text/html;level=1, */*;q=0.1, TEXT/HTML, bad;q=2, application/json;q=0.5;ext=1")
+	want := []string{"text/html", "text/html", "application/json", "text/*", "*/*"}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items: %+v", len(items), items)
+	}
+	for i, w := range want {
+		if items[i].Value != w {
+			t.Errorf("item %d = %q, want %q", i, items[i].Value, w)
+		}
+	}
+	if items[0].Params["level"] != "1" || items[2].Params != nil || items[3].Q != 0.5 {
+		t.Errorf("unexpected params or q: %+v", items)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header    string
+		available []string
+		want      string
+		ok        bool
+	}{
+		{"application/json, text/html;q=0.9", []string{"text/html", "application/json"}, "application/json", true},
+		{"text/*;q=0.8, */*;q=0.1", []string{"image/png", "text/plain"}, "text/plain", true},
+		{"text/html;level=1", []string{"text/html", "text/html;level=1"}, "text/html;level=1", true},
+		{"text/html, text/*;q=0", []string{"text/plain"}, "", false},
+		{"image/png", []string{"text/html"}, "", false},
+		{"", []string{"text/html", "application/json"}, "text/html", true},
+		{"de-CH, en;q=0.8", []string{"en-US", "fr"}, "en-US", true},
+		{"en;q=1, en-US;q=0.1", []string{"en-US", "en-GB"}, "en-GB", true},
+		{"fr;q=0.5, *;q=0.6", []string{"fr", "es"}, "es", true},
+		{"gzip;q=1.0, identity;q=0.5, *;q=0", []string{"br", "identity"}, "identity", true},
+	}
+	for _, tt := range tests {
+		got, ok := headers.Negotiate(tt.header, tt.available)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Negotiate(%q, %v) = %q, %v; want %q, %v", tt.header, tt.available, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func benchmarkHeaders() *headers.OrderedHeaders {
+	h := headers.NewOrderedHeaders()
+	h.Set("Host", "example.com")
+	h.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36")
+	h.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	h.Set("Accept-Language", "en-US,en;q=0.5")
+	h.Set("Accept-Encoding", "gzip, deflate, br")
+	h.Set("Cookie", "session=abc123; theme=dark; lang=en")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	return h
+}
+
+func BenchmarkOrderedHeadersBuild(b *testing.B) {
+	h := benchmarkHeaders()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Build()
+	}
+}
+
+func BenchmarkResponseBuild(b *testing.B) {
+	resp := response.NewResponse()
+	resp.Version = "HTTP/1.1"
+	resp.StatusCode = 200
+	resp.StatusText = "OK"
+	resp.Headers = benchmarkHeaders()
+	resp.SetBody([]byte(strings.Repeat("x", 1024)), false)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp.Build()
+	}
+}
+
+func BenchmarkResponseWriteHeadersTo(b *testing.B) {
+	resp := response.NewResponse()
+	resp.Version = "HTTP/1.1"
+	resp.StatusCode = 200
+	resp.StatusText = "OK"
+	resp.Headers = benchmarkHeaders()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp.WriteHeadersTo(io.Discard)
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	directives := headers.ParseCacheControl(` Max-Age=60, no-cache, private="Set-Cookie"`)
+	if directives["max-age"] != "60" || directives["private"] != "Set-Cookie" {
+		t.Errorf("Unexpected directives %v", directives)
+	}
+	if _, ok := directives["no-cache"]; !ok {
+		t.Error("Expected no-cache")
+	}
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"30", 30 * time.Second, true},
+		{"99999999999999999999", (1 << 31) * time.Second, true},
+		{"-1", 0, false},
+		{"-99999999999999999999", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := headers.ParseDeltaSeconds(tt.value); got != tt.want || ok != tt.ok {
+			t.Errorf("ParseDeltaSeconds(%q) = %v, %v", tt.value, got, ok)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package unit
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -140,6 +141,61 @@ func TestHeaderList_CaseInsensitive(t *testing.T) {
 	}
 }
 
+func TestHeaderList_IndexConsistency(t *testing.T) {
+	h := http2.NewHeaderList()
+	h.Add("accept", "a")
+	h.Add("X-Dup", "1")
+	h.Add("cookie", "c")
+	h.Add("x-dup", "2")
+
+	h.InsertAt(0, "first", "f")
+	if h.Get("X-DUP") != "1" {
+		t.Errorf("Expected first x-dup value after InsertAt, got %q", h.Get("X-DUP"))
+	}
+
+	h.MoveToBack("accept")
+	h.MoveToFront("cookie")
+	if all := h.All(); all[0].Name != "cookie" || all[len(all)-1].Name != "accept" {
+		t.Errorf("Unexpected order after moves: %+v", all)
+	}
+	if h.Get("Accept") != "a" || h.Get("COOKIE") != "c" {
+		t.Error("Lookups should follow moved headers")
+	}
+
+	h.Set("x-dup", "3")
+	if values := h.GetAll("X-Dup"); len(values) != 1 || values[0] != "3" {
+		t.Errorf("Expected single x-dup=3 after Set, got %v", values)
+	}
+
+	h.Del("first")
+	if h.Has("first") {
+		t.Error("Header should be deleted")
+	}
+	if h.Get("cookie") != "c" || h.Get("accept") != "a" {
+		t.Error("Lookups should survive deletion of another header")
+	}
+
+	clone := h.Clone()
+	clone.Set("cookie", "changed")
+	if h.Get("cookie") != "c" {
+		t.Error("Clone should not share index or fields")
+	}
+}
+
+func BenchmarkHeaderList_Get(b *testing.B) {
+	h := http2.NewHeaderList()
+	for i := 0; i < 100; i++ {
+		h.Add(fmt.Sprintf("x-header-%d", i), "value")
+	}
+	h.Add("content-length", "42")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Get("content-length")
+	}
+}
+
 // ==================== HTTP/2 REQUEST TESTS ====================
 
 func TestHTTP2Request_Basic(t *testing.T) {
@@ -543,4 +599,61 @@ func TestHeaderList_JSONSerialization(t *testing.T) {
 	if h2.Len() != 2 {
 		t.Errorf("Expected 2 headers after unmarshal, got %d", h2.Len())
 	}
+
+	if h2.Get("Content-Type") != "application/json" {
+		t.Errorf("Lookup after unmarshal failed, got %q", h2.Get("Content-Type"))
+	}
+}
+
+func BenchmarkHTTP2RequestBuild(b *testing.B) {
+	req := http2.NewRequest()
+	req.Method = "GET"
+	req.Scheme = "https"
+	req.Authority = "example.com"
+	req.Path = "/api/v1/users?page=2"
+	req.Headers.Add("user-agent", "Mozilla/5.0 (X11; Linux x86_64)")
+	req.Headers.Add("accept", "application/json")
+	req.Headers.Add("accept-encoding", "gzip, deflate, br")
+	req.Headers.Add("cookie", "session=abc123")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req.Build()
+	}
+}
+
+func TestHTTP2_DowngradeProbes(t *testing.T) {
+	base := http2.NewRequest()
+	base.Method = "POST"
+	base.Authority = "target.example"
+	base.Headers.Add("content-length", "4")
+	base.Body = []byte("data")
+
+	smuggled := []byte("GET /admin HTTP/1.1\r\nX: ")
+	probes := http2.DowngradeProbes(base, smuggled)
+
+	want := map[string]string{
+		"h2.cl":            "content-length: 0\r\n\r\nGET /admin HTTP/1.1\r\nX: ",
+		"h2.te":            "transfer-encoding: chunked\r\nContent-Length: 29\r\n\r\n0\r\n\r\nGET /admin",
+		"h2.te-crlf-value": "foo: bar\r\nTransfer-Encoding: chunked\r\n",
+		"h2.te-crlf-name":  "foo: bar\r\ntransfer-encoding: chunked\r\n",
+		"h2.path-space":    "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\nX-Ignore: x HTTP/1.1\r\n",
+	}
+	if len(probes) != len(want) {
+		t.Fatalf("Expected %d probes, got %d", len(want), len(probes))
+	}
+	for _, p := range probes {
+		if !strings.Contains(string(p.HTTP1), want[p.Name]) {
+			t.Errorf("%s: downgraded bytes missing %q:\n%s", p.Name, want[p.Name], p.HTTP1)
+		}
+		if len(p.HeaderBlock) == 0 || p.HeaderBlock[0].Name != ":method" {
+			t.Errorf("%s: header block should start with pseudo-headers", p.Name)
+		}
+	}
+
+	// Base must not be modified
+	if base.Headers.Get("content-length") != "4" || string(base.Body) != "data" || base.Path != "/" {
+		t.Error("DowngradeProbes modified the base request")
+	}
 }
@@ -1,11 +1,13 @@
 package unit
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
 )
 
 // TestBodyParsing_64KB_Boundary tests the exact 64KB boundary
@@ -303,3 +305,65 @@ func BenchmarkBodyParsing(b *testing.B) {
 		})
 	}
 }
+
+// naiveSearch is the previous O(n*m) searchBytes implementation, kept here
+// as a baseline for BenchmarkStreamingBodySearch
+func naiveSearch(data, pattern []byte) int {
+	if len(pattern) == 0 || len(data) < len(pattern) {
+		return -1
+	}
+	for i := 0; i <= len(data)-len(pattern); i++ {
+		found := true
+		for j := 0; j < len(pattern); j++ {
+			if data[i+j] != pattern[j] {
+				found = false
+				break
+			}
+		}
+		if found {
+			return i
+		}
+	}
+	return -1
+}
+
+// BenchmarkStreamingBodySearch compares the naive search loop with the
+// StreamingBody.Search implementation on large bodies
+// The body is made of near-matches so the naive loop hits its worst case
+func BenchmarkStreamingBodySearch(b *testing.B) {
+	sizes := []int{
+		64 * 1024,       // 64KB
+		1024 * 1024,     // 1MB
+		8 * 1024 * 1024, // 8MB
+	}
+	pattern := []byte(strings.Repeat("A", 31) + "B")
+
+	for _, size := range sizes {
+		body := []byte(strings.Repeat("A", size-len(pattern)) + string(pattern))
+
+		b.Run(fmt.Sprintf("Naive_%dKB", size/1024), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				if naiveSearch(body, pattern) < 0 {
+					b.Fatal("pattern not found")
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("StreamingBody_%dKB", size/1024), func(b *testing.B) {
+			resp := response.NewResponse()
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				sb, err := resp.WrapBodyReader(bytes.NewReader(body))
+				if err != nil {
+					b.Fatalf("WrapBodyReader failed: %v", err)
+				}
+				offset, err := sb.Search(pattern)
+				if err != nil || offset < 0 {
+					b.Fatalf("pattern not found: offset=%d err=%v", offset, err)
+				}
+				sb.Close()
+			}
+		})
+	}
+}
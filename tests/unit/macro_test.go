@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/macro"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+	"github.com/WhileEndless/go-httptools/pkg/template"
+)
+
+func TestMacroRun(t *testing.T) {
+	send := func(ctx context.Context, req *request.Request) (*response.Response, error) {
+		switch req.Path {
+		case "/login":
+			return response.Parse([]byte("HTTP/1.1 200 OK\r\nSet-Cookie: session=s3cr3t; HttpOnly\r\nX-Request-Id: 77\r\nContent-Length: 34\r\n\r\n<input name=\"csrf\" value=\"tok123\">"))
+		case "/me":
+			if !strings.Contains(req.Headers.Get("Cookie"), "session=s3cr3t") || req.QueryParams.Get("csrf") != "tok123" {
+				return response.Parse([]byte("HTTP/1.1 403 Forbidden\r\nContent-Length: 0\r\n\r\n"))
+			}
+			return response.Parse([]byte("HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: 52\r\n\r\n{\"user\":{\"email\":\"a@b.c\",\"roles\":[\"admin\",\"dev\"]}}"))
+		}
+		return nil, errors.New("unexpected request " + req.URL)
+	}
+
+	host := template.Field{Name: "Host", Value: "{{host}}"}
+	steps := []macro.Step{
+		{
+			Name:     "login",
+			Template: template.Template{Method: "POST", URL: "/login", Headers: []template.Field{host}, Body: "user={{user}}"},
+			Extract: []macro.Rule{
+				{Var: "session", Kind: macro.RuleCookie, Expr: "session", Required: true},
+				{Var: "csrf", Kind: macro.RuleRegex, Expr: `name="csrf" value="([^"]+)"`, Required: true},
+				{Var: "requestID", Kind: macro.RuleHeader, Expr: "X-Request-Id"},
+			},
+		},
+		{
+			Name:     "profile",
+			Template: template.Template{URL: "/me?csrf={{csrf}}", Headers: []template.Field{host, {Name: "Cookie", Value: "session={{session}}"}}},
+			Extract: []macro.Rule{
+				{Var: "email", Kind: macro.RuleJSON, Expr: "$.user.email", Required: true},
+				{Var: "role", Kind: macro.RuleJSON, Expr: "$.user.roles[0]"},
+				{Var: "missing", Kind: macro.RuleJSON, Expr: "$.user.phone"},
+			},
+		},
+	}
+
+	vars := map[string]string{"host": "example.com", "user": "alice"}
+	result, err := macro.Run(context.Background(), send, steps, template.Env{Vars: vars})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(result.Steps) != 2 || result.Steps[1].Response.StatusCode != 200 {
+		t.Fatalf("unexpected steps: %+v", result.Steps)
+	}
+	want := map[string]string{"session": "s3cr3t", "csrf": "tok123", "requestID": "77", "email": "a@b.c", "role": "admin"}
+	for k, v := range want {
+		if result.Vars[k] != v {
+			t.Errorf("var %s = %q, want %q", k, result.Vars[k], v)
+		}
+	}
+	if _, ok := result.Vars["missing"]; ok {
+		t.Error("optional rule without match should not set a variable")
+	}
+	if _, ok := vars["session"]; ok {
+		t.Error("Run modified the caller's variables")
+	}
+
+	// A required rule without match stops the chain
+	steps[0].Extract = append(steps[0].Extract, macro.Rule{Var: "token", Kind: macro.RuleHeader, Expr: "X-Token", Required: true})
+	result, err = macro.Run(context.Background(), send, steps, template.Env{Vars: vars})
+	if !errors.Is(err, macro.ErrNotCaptured) || len(result.Steps) != 1 {
+		t.Errorf("required rule: err = %v, %d steps", err, len(result.Steps))
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	doc := map[string]any{"a": map[string]any{"b c": []any{1.5, map[string]any{"d": true}}}}
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"$.a['b c'][0]", "1.5", true},
+		{`$.a["b c"][1].d`, "true", true},
+		{"$.a['b c'][2]", "", false},
+		{"$.x", "", false},
+		{"$.a", `{"b c":[1.5,{"d":true}]}`, true},
+	}
+	for _, tt := range tests {
+		got, ok, err := macro.JSONPath(doc, tt.path)
+		if err != nil || got != tt.want || ok != tt.ok {
+			t.Errorf("JSONPath(%q) = %q, %v, %v", tt.path, got, ok, err)
+		}
+	}
+	if _, _, err := macro.JSONPath(doc, "$.a[0"); err == nil {
+		t.Error("expected error for malformed path")
+	}
+
+	// Large integers keep every digit
+	resp, _ := response.Parse([]byte("HTTP/1.1 200 OK\r\nContent-Length: 49\r\n\r\n{\"id\":12345678901234567,\"ids\":[9007199254740993]}"))
+	for expr, want := range map[string]string{"$.id": "12345678901234567", "$.ids": "[9007199254740993]"} {
+		got, ok, err := macro.Rule{Kind: macro.RuleJSON, Expr: expr}.Apply(resp)
+		if err != nil || !ok || got != want {
+			t.Errorf("Apply(%q) = %q, %v, %v", expr, got, ok, err)
+		}
+	}
+}
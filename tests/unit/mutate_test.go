@@ -0,0 +1,45 @@
+package unit
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/mutate"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+// Malformed input must produce an error or a best-effort message, never a panic
+func TestParseMalformedCorpus(t *testing.T) {
+	reqSeed := []byte("POST /submit?a=1 HTTP/1.1\r\nHost: example.com\r\nCookie: a=b\r\nContent-Length: 11\r\n\r\nhello world")
+	respSeed := []byte("HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nSet-Cookie: a=b; Path=/\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n")
+
+	rng := rand.New(rand.NewSource(42))
+	reqCases := append(mutate.All(reqSeed), mutate.Random(reqSeed, rng, 200, 4)...)
+	respCases := append(mutate.All(respSeed), mutate.Random(respSeed, rng, 200, 4)...)
+
+	for _, c := range reqCases {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("request.Parse panicked on %s: %v", c.Name, r)
+				}
+			}()
+			if req, err := request.Parse(c.Raw); err == nil {
+				req.Build()
+			}
+		}()
+	}
+	for _, c := range respCases {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("response.Parse panicked on %s: %v", c.Name, r)
+				}
+			}()
+			if resp, err := response.Parse(c.Raw); err == nil {
+				resp.Build()
+			}
+		}()
+	}
+}
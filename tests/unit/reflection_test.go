@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/reflection"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+func TestReflectionDetect(t *testing.T) {
+	req, err := request.Parse([]byte("POST /users/alice42/profile?q=%3Cscript%3E&page=1&lang=en-US HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"User-Agent: scanner-probe\r\n" +
+		"Cookie: session=sess1234; theme=dark\r\n" +
+		"Content-Type: application/x-www-form-urlencoded\r\n" +
+		"Content-Length: 18\r\n" +
+		"\r\n" +
+		"comment=a%22b%22cd"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	resp, err := response.Parse([]byte("HTTP/1.1 200 OK\r\n" +
+		"Location: /profile?lang=en-US\r\n" +
+		"\r\n" +
+		"<p>Results for <script></p>" +
+		"<p>User alice42, theme dark</p>" +
+		"<input value=\"a&quot;b&quot;cd\">" +
+		"<!-- served to scanner-probe -->"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	found := make(map[string]reflection.Reflection)
+	for _, r := range reflection.Detect(req, resp, reflection.Options{}) {
+		found[r.Source.String()+":"+r.Name+":"+r.Location.String()] = r
+	}
+
+	tests := []struct {
+		key   string
+		match reflection.Match
+	}{
+		{"query:q:body", reflection.MatchURLDecoded},
+		{"query:lang:header", reflection.MatchExact},
+		{"path:2:body", reflection.MatchExact},
+		{"body:comment:body", reflection.MatchHTMLDecoded},
+		{"cookie:theme:body", reflection.MatchExact},
+		{"header:User-Agent:body", reflection.MatchExact},
+	}
+	for _, tt := range tests {
+		r, ok := found[tt.key]
+		if !ok {
+			t.Errorf("Expected reflection %s, got %v", tt.key, found)
+			continue
+		}
+		if r.Match != tt.match {
+			t.Errorf("%s: expected match %s, got %s", tt.key, tt.match, r.Match)
+		}
+	}
+
+	if r := found["query:lang:header"]; r.Header != "Location" {
+		t.Errorf("Expected reflection in Location header, got %q", r.Header)
+	}
+	// Too short to be meaningful
+	if _, ok := found["query:page:body"]; ok {
+		t.Error("Did not expect short value to be reported")
+	}
+	if _, ok := found["cookie:session:body"]; ok {
+		t.Error("Did not expect unreflected cookie to be reported")
+	}
+
+	for _, r := range reflection.Detect(req, resp, reflection.Options{SkipHeaders: true}) {
+		if r.Source == reflection.SourceHeader {
+			t.Errorf("Expected headers to be skipped, got %+v", r)
+		}
+	}
+}
+
+func TestReflectionDetect_ShortDecodedAndChunked(t *testing.T) {
+	req, err := request.Parse([]byte("POST /?c=%3C HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"Content-Type: application/x-www-form-urlencoded\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"e\r\nname=reflected\r\n0\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	resp, err := response.Parse([]byte("HTTP/1.1 200 OK\r\n\r\n<html><p>Hello reflected</p></html>"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var got []string
+	for _, r := range reflection.Detect(req, resp, reflection.Options{SkipHeaders: true}) {
+		got = append(got, r.Source.String()+":"+r.Name+"="+r.Value)
+	}
+	// "%3C" decodes to "<", which is below the minimum length
+	if len(got) != 1 || got[0] != "body:name=reflected" {
+		t.Errorf("Unexpected reflections %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/report"
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+)
+
+func TestReportSummary(t *testing.T) {
+	r := report.New()
+	for _, host := range []string{"b.example.com", "A.example.com", "a.example.com"} {
+		req, _ := request.Parse([]byte("GET / HTTP/1.1\r\nHost: " + host + "\r\n\r\n"))
+		resp := response.NewResponse()
+		resp.StatusCode = 200
+		r.AddExchange(req, resp)
+	}
+	failed, _ := request.Parse([]byte("GET / HTTP/1.1\r\nHost: b.example.com\r\n\r\n"))
+	r.AddExchange(failed, nil)
+
+	r.AddFinding(
+		report.Finding{Host: "a.example.com", Check: "cookie-no-secure", Severity: report.Low, URL: "/"},
+		report.Finding{Host: "b.example.com", Check: "cookie-no-secure", Severity: report.Medium, URL: "/login"},
+		report.Finding{Host: "B.example.com", Check: "hsts-missing"},
+	)
+
+	sum := r.Summary()
+	if sum.Requests != 4 || len(sum.Hosts) != 2 || sum.Hosts[0].Host != "a.example.com" {
+		t.Fatalf("summary = %+v", sum)
+	}
+	if b := sum.Hosts[1]; b.Requests != 2 || b.Statuses[200] != 1 || b.Findings["medium"] != 1 || b.Findings["info"] != 1 {
+		t.Errorf("b.example.com = %+v", b)
+	}
+	if len(sum.Checks) != 2 {
+		t.Fatalf("checks = %+v", sum.Checks)
+	}
+	if c := sum.Checks[0]; c.Check != "cookie-no-secure" || c.Count != 2 || c.Severity != report.Medium || len(c.Hosts) != 2 {
+		t.Errorf("cookie check = %+v", c)
+	}
+	if sum.Findings[0].Severity != report.Medium || sum.Findings[2].Severity != report.Info {
+		t.Errorf("findings not sorted by severity: %+v", sum.Findings)
+	}
+	if !sum.Exceeds(report.Medium) || sum.Exceeds(report.High) || sum.Exceeds("critical") {
+		t.Error("Exceeds mismatch")
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded report.Summary
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Requests != 4 || len(decoded.Findings) != 3 {
+		t.Errorf("round trip = %+v, %v", decoded, err)
+	}
+}
+
+func TestReportDiff(t *testing.T) {
+	old := report.New()
+	old.AddFinding(
+		report.Finding{Host: "example.com", Check: "hsts-missing"},
+		report.Finding{Host: "example.com", Check: "cl-te", Severity: report.High},
+	)
+	cur := report.New()
+	cur.AddFinding(
+		report.Finding{Host: "example.com", Check: "hsts-missing"},
+		report.Finding{Host: "example.com", Check: "cookie-no-httponly", Severity: report.Low},
+	)
+
+	added, removed := report.Diff(old.Summary(), cur.Summary())
+	if len(added) != 1 || added[0].Check != "cookie-no-httponly" {
+		t.Errorf("added = %+v", added)
+	}
+	if len(removed) != 1 || removed[0].Check != "cl-te" {
+		t.Errorf("removed = %+v", removed)
+	}
+}
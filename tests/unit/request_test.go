@@ -1,10 +1,16 @@
 package unit
 
 import (
+	"bufio"
 	"bytes"
+	"fmt"
 	"io"
+	"strings"
 	"testing"
 
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/errors"
+	"github.com/WhileEndless/go-httptools/pkg/multipart"
 	"github.com/WhileEndless/go-httptools/pkg/request"
 )
 
@@ -462,6 +468,7 @@ func TestRequestParseReader_WithBody(t *testing.T) {
 	raw := []byte(`POST /api/login HTTP/1.1
 Host: example.com
 Content-Type: application/json
+Content-Length: 38
 
 {"username":"admin","password":"pass"}`)
 
@@ -477,6 +484,25 @@ Content-Type: application/json
 	}
 }
 
+func TestRequestParseReader_PipelinedWithoutBody(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("GET /first HTTP/1.1\r\nHost: example.com\r\n\r\n" +
+		"GET /second HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+
+	for _, url := range []string{"/first", "/second"} {
+		req, err := request.ParseReader(br)
+		if err != nil {
+			t.Fatalf("ParseReader failed: %v", err)
+		}
+		if req.URL != url || len(req.Body) != 0 {
+			t.Errorf("Expected %s without body, got %s with body %q", url, req.URL, req.Body)
+		}
+	}
+
+	if _, err := request.ParseReader(br); err == nil {
+		t.Error("Expected an error once the stream is exhausted")
+	}
+}
+
 // ============================================================================
 // Streaming Support Tests (ParseHeadersFromReader, WriteTo)
 // ============================================================================
@@ -654,3 +680,487 @@ func TestRequestParseReader_ReaderError(t *testing.T) {
 		t.Error("Expected error when reader fails")
 	}
 }
+
+// benchmarkRawMessage builds a message with a realistic number of headers
+func TestRequestParseWithReadBufferSize(t *testing.T) {
+	// Header larger than the read buffer must still be read completely
+	longValue := strings.Repeat("a", 8192)
+	raw := "POST /upload HTTP/1.1\r\nHost: example.com\r\nX-Long: " + longValue +
+		"\r\nContent-Length: 4\r\n\r\nbody"
+
+	for _, size := range []int{16, 0, 1024 * 1024} {
+		opts := request.ParseOptions{ReadBufferSize: size}
+
+		req, err := request.ParseReaderWithOptions(strings.NewReader(raw), opts)
+		if err != nil {
+			t.Fatalf("size %d: ParseReaderWithOptions failed: %v", size, err)
+		}
+		if strings.TrimSpace(req.Headers.Get("X-Long")) != longValue || string(req.Body) != "body" {
+			t.Errorf("size %d: unexpected header or body %q", size, req.Body)
+		}
+
+		req, bodyReader, err := request.ParseHeadersFromReaderWithOptions(strings.NewReader(raw), opts)
+		if err != nil {
+			t.Fatalf("size %d: ParseHeadersFromReaderWithOptions failed: %v", size, err)
+		}
+		body, _ := io.ReadAll(bodyReader)
+		if strings.TrimSpace(req.Headers.Get("X-Long")) != longValue || string(body) != "body" {
+			t.Errorf("size %d: unexpected header or body %q", size, body)
+		}
+	}
+}
+
+func TestRequestDuplicateHeaders(t *testing.T) {
+	raw := []byte("POST / HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"Content-Length: 3\r\n" +
+		"Content-Length: 4\r\n" +
+		"\r\nabcd")
+
+	req, err := request.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Headers collapses duplicates, the raw analysis does not
+	duplicates := req.DuplicateHeaders()
+	if len(duplicates) != 1 || duplicates[0].Name != "Content-Length" || !duplicates[0].Conflicting {
+		t.Fatalf("Expected conflicting Content-Length, got %+v", duplicates)
+	}
+	if duplicates[0].Values[0] != "3" || duplicates[0].Values[1] != "4" {
+		t.Errorf("Unexpected values %q", duplicates[0].Values)
+	}
+
+	if request.NewRequest().DuplicateHeaders() != nil {
+		t.Error("Expected nil for request without Raw")
+	}
+}
+
+func TestRequestParseWithDiagnostics(t *testing.T) {
+	raw := "GET /index\nHost: example.com\nBroken header line\n: no name\nContent-Encoding: gzip\n\nnot gzip"
+	req, diags := request.ParseWithDiagnostics([]byte(raw), request.ParseOptions{})
+	if req.Method != "GET" || req.Headers.Get("Host") == "" {
+		t.Fatalf("request not parsed: %+v", req)
+	}
+
+	want := []struct {
+		code   errors.DiagnosticCode
+		offset int
+	}{
+		{errors.DiagBareLineEnding, 10},
+		{errors.DiagMissingVersion, 10},
+		{errors.DiagMalformedHeader, 29},
+		{errors.DiagEmptyHeaderName, 48},
+		{errors.DiagDecompressionFailed, 82},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics: %v", len(diags), diags)
+	}
+	for i, w := range want {
+		if diags[i].Code != w.code || diags[i].Offset != w.offset {
+			t.Errorf("diagnostic %d = %v, want %s at offset %d", i, diags[i], w.code, w.offset)
+		}
+	}
+
+	// Clean requests produce no diagnostics
+	if _, diags := request.ParseWithDiagnostics([]byte("GET / HTTP/1.1\r\nHost: a\r\n\r\n"), request.ParseOptions{}); len(diags) != 0 {
+		t.Errorf("clean request diagnostics: %v", diags)
+	}
+
+	// Unparseable data still returns a request
+	req, diags = request.ParseWithDiagnostics([]byte("\r\nGET"), request.ParseOptions{})
+	if req == nil || len(diags) != 1 || diags[0].Code != errors.DiagUnparseable {
+		t.Errorf("unparseable: %v %v", req, diags)
+	}
+
+	// Options are honored
+	req, diags = request.ParseWithDiagnostics([]byte("POST / HTTP/1.1\r\nContent-Length: 6\r\n\r\nabcdef"), request.ParseOptions{MaxBodySize: 3})
+	if string(req.Body) != "abc" || len(diags) != 1 || diags[0].Code != errors.DiagBodyTruncated {
+		t.Errorf("with MaxBodySize: %q %v", req.Body, diags)
+	}
+}
+
+func TestRequestParseWarnings(t *testing.T) {
+	raw := "POST /submit HTTP/1.1\r\nHost: example.com\r\nContent-Length: 120\r\n\r\n" + strings.Repeat("a", 98)
+	req, err := request.Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(req.ParseWarnings) != 1 {
+		t.Fatalf("got %d warnings: %v", len(req.ParseWarnings), req.ParseWarnings)
+	}
+	w := req.ParseWarnings[0]
+	if w.Code != errors.DiagLengthMismatch || w.Message != "Content-Length mismatch: header says 120, body is 98" {
+		t.Errorf("warning = %v", w)
+	}
+	if clone := req.Clone(); len(clone.ParseWarnings) != 1 {
+		t.Errorf("Clone dropped warnings: %v", clone.ParseWarnings)
+	}
+
+	conflicting := "POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 5\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n"
+	req, _ = request.Parse([]byte(conflicting))
+	if len(req.ParseWarnings) != 1 || req.ParseWarnings[0].Code != errors.DiagConflictingFraming {
+		t.Errorf("conflicting framing warnings: %v", req.ParseWarnings)
+	}
+
+	req, _ = request.Parse([]byte("POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 4\r\n\r\ntest"))
+	if req.ParseWarnings != nil {
+		t.Errorf("clean request warnings: %v", req.ParseWarnings)
+	}
+}
+
+func TestRequestTrailers(t *testing.T) {
+	raw := "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"4\r\ndata\r\n0\r\nX-Checksum: 1234\r\n\r\n"
+	req, err := request.Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if req.Trailers == nil || strings.TrimSpace(req.Trailers.Get("X-Checksum")) != "1234" {
+		t.Fatalf("Trailers = %v", req.Trailers)
+	}
+	if string(req.Build()) != raw {
+		t.Errorf("Build = %q", req.Build())
+	}
+
+	req.Trailers.Set("X-Checksum", "5678")
+	if !strings.HasSuffix(string(req.Build()), "0\r\nX-Checksum: 5678\r\n\r\n") {
+		t.Errorf("Build with modified trailers = %q", req.Build())
+	}
+	if clone := req.Clone(); clone.Trailers.Get("X-Checksum") != "5678" {
+		t.Error("Clone did not copy trailers")
+	}
+
+	var out bytes.Buffer
+	if _, err := req.WriteToWithBodyChunked(&out, strings.NewReader("abc"), 0); err != nil {
+		t.Fatalf("WriteToWithBodyChunked failed: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "3\r\nabc\r\n0\r\nX-Checksum: 5678\r\n\r\n") {
+		t.Errorf("streamed = %q", out.String())
+	}
+}
+
+func TestRequestRoundTrip(t *testing.T) {
+	clean := "POST /a HTTP/1.1\r\nHost: x\r\nContent-Length: 2\r\n\r\nhi"
+	req, err := request.Parse([]byte(clean))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !req.RawEquals() || req.VerifyRoundTrip() != nil {
+		t.Errorf("clean request does not round-trip: %v", req.VerifyRoundTrip())
+	}
+
+	// Duplicate headers collapse on rebuild
+	raw := "GET /a HTTP/1.1\r\nHost: x\r\nHost: y\r\n\r\n"
+	req, err = request.Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if req.RawEquals() {
+		t.Fatal("RawEquals = true for a normalized request")
+	}
+	if err, ok := req.VerifyRoundTrip().(*errors.Error); !ok || err.Offset != 23 || err.Line != 2 {
+		t.Errorf("VerifyRoundTrip = %v", err)
+	}
+
+	// Strict mode returns Raw until the request is modified
+	req.StrictBuild()
+	if string(req.Build()) != raw || req.VerifyRoundTrip() != nil {
+		t.Errorf("strict Build = %q", req.Build())
+	}
+	req.Headers.Set("X-Test", "1")
+	if strings.Contains(string(req.Build()), "Host: x") {
+		t.Errorf("modified request still built from Raw: %q", req.Build())
+	}
+}
+
+func TestRequestCanonicalize(t *testing.T) {
+	a, err := request.Parse([]byte("get https://Example.COM:443/a%7eb%2fc?b=2&a=%3d&a=1#frag HTTP/1.1\r\nX-Sig:  one   two\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	b, err := request.Parse([]byte("GET /a~b%2Fc?a=1&b=2&a=%3D HTTP/1.1\r\nHost: example.com:443\r\nx-sig: one two\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	opts := request.CanonicalOptions{Headers: []string{"X-Sig", "x-missing", "x-sig"}, SortQuery: true}
+	ca, cb := a.Canonicalize(opts), b.Canonicalize(opts)
+	want := "GET https://example.com/a~b%2Fc?a=%3D&a=1&b=2\nx-missing: \nx-sig: one two"
+	if ca.String() != want || cb.String() != want {
+		t.Errorf("Canonicalize =\n%q\n%q\nwant %q", ca, cb, want)
+	}
+
+	// Without SortQuery the parameter order is significant
+	if a.Canonicalize(request.CanonicalOptions{}).Query == b.Canonicalize(request.CanonicalOptions{}).Query {
+		t.Error("query order ignored without SortQuery")
+	}
+}
+
+func TestRequestMultipartForm(t *testing.T) {
+	body := "--x\r\nContent-Disposition: form-data; name=\"user\"\r\n\r\nbob\r\n--x\r\nContent-Disposition: form-data; name=\"up\"; filename=\"a.txt\"\r\n\r\nhi\r\n--x--\r\n"
+	raw := fmt.Sprintf("POST /u HTTP/1.1\r\nHost: x\r\nContent-Type: multipart/form-data;boundary=x\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+	req, err := request.Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	form, err := req.MultipartForm()
+	if err != nil {
+		t.Fatalf("MultipartForm failed: %v", err)
+	}
+	if form.Value("user") != "bob" || len(form.Files()) != 1 {
+		t.Errorf("form parts = %d", len(form.Parts))
+	}
+
+	// Unmodified forms leave the request untouched
+	req.SetMultipartForm(form)
+	if string(req.Build()) != raw {
+		t.Errorf("Build = %q", req.Build())
+	}
+
+	form.Get("user").Body = []byte("admin")
+	req.SetMultipartForm(form)
+	if req.GetContentLength() != fmt.Sprint(len(body)+2) || !strings.Contains(string(req.Body), "\r\n\r\nadmin\r\n--x") {
+		t.Errorf("body = %q", req.Body)
+	}
+
+	req.Headers.Set("Content-Type", "text/plain")
+	if _, err := req.MultipartForm(); err != multipart.ErrMissingBoundary {
+		t.Errorf("err = %v", err)
+	}
+
+	// Chunked bodies are re-chunked
+	req, _ = request.Parse([]byte("POST /u HTTP/1.1\r\nContent-Type: multipart/form-data; boundary=x\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		string(chunked.Encode([]byte(body), 16))))
+	form, _ = req.MultipartForm()
+	form.Get("user").Body = []byte("admin")
+	req.SetMultipartForm(form)
+	if decoded, _ := chunked.Decode(req.Body); !bytes.Equal(decoded, form.Build()) || req.Headers.Has("Content-Length") {
+		t.Errorf("body = %q", req.Body)
+	}
+}
+
+func TestRequestFormParams(t *testing.T) {
+	raw := "POST /login HTTP/1.1\r\nHost: x\r\nContent-Type: application/x-www-form-urlencoded; charset=utf-8\r\nContent-Length: 25\r\n\r\nuser=bob&pass=a%26b&r=1&r"
+	req, err := request.Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if req.GetFormParam("pass") != "a&b" || len(req.GetFormParams("r")) != 2 {
+		t.Errorf("FormParams = %v", req.FormParams)
+	}
+
+	req.SetFormParam("user", "admin")
+	req.DeleteFormParam("r")
+	req.AddFormParam("x", "1 2")
+	req.RebuildForm()
+	if string(req.Body) != "pass=a%26b&user=admin&x=1+2" || req.GetContentLength() != "27" {
+		t.Errorf("body = %q, Content-Length = %q", req.Body, req.GetContentLength())
+	}
+	if clone := req.Clone(); clone.GetFormParam("user") != "admin" {
+		t.Errorf("Clone lost form params: %v", clone.FormParams)
+	}
+
+	req, _ = request.Parse([]byte("POST / HTTP/1.1\r\nContent-Type: application/json\r\nContent-Length: 5\r\n\r\na=1&b"))
+	if len(req.FormParams) != 0 {
+		t.Errorf("FormParams for JSON body = %v", req.FormParams)
+	}
+}
+
+func TestStreamParser(t *testing.T) {
+	data := "POST /a HTTP/1.1\r\nHost: x\r\nX-Dup: 1\r\nX-Dup: 2\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"3\r\nabc\r\n2;ext=1\r\nde\r\n0\r\nX-Trailer: t\r\n\r\n" +
+		"\r\nGET /b HTTP/1.1\r\nHost: x\r\n\r\n" +
+		"PUT /c HTTP/1.1\r\nContent-Length: 4\r\n\r\nwxyz"
+
+	var events []string
+	var body bytes.Buffer
+	p := request.NewStreamParser(request.StreamHandler{
+		OnRequestLine: func(method, target, version string) error {
+			events = append(events, method+" "+target)
+			return nil
+		},
+		OnHeader: func(name, value string) error {
+			events = append(events, name+"="+value)
+			return nil
+		},
+		OnBody: func(data []byte) error {
+			body.Write(data)
+			return nil
+		},
+		OnComplete: func(req *request.Request) error {
+			events = append(events, fmt.Sprintf("done %s %d", req.Method, len(req.Raw)))
+			return nil
+		},
+	})
+
+	// One byte at a time exercises every partial state
+	for i := 0; i < len(data); i++ {
+		if err := p.Feed([]byte{data[i]}); err != nil {
+			t.Fatalf("Feed failed at %d: %v", i, err)
+		}
+	}
+
+	want := []string{
+		"POST /a", "Host=x", "X-Dup=1", "X-Dup=2", "Transfer-Encoding=chunked", "done POST 117",
+		"GET /b", "Host=x", "done GET 28",
+		"PUT /c", "Content-Length=4", "done PUT 42",
+	}
+	if strings.Join(events, "|") != strings.Join(want, "|") {
+		t.Errorf("events = %q", events)
+	}
+	if body.String() != "abcdewxyz" || p.Buffered() != 0 || p.InBody() {
+		t.Errorf("body = %q, buffered = %d", body.String(), p.Buffered())
+	}
+
+	stop := fmt.Errorf("stop")
+	p = request.NewStreamParser(request.StreamHandler{
+		OnHeadersComplete: func(req *request.Request) error { return stop },
+	})
+	if err := p.Feed([]byte("GET / HTTP/1.1\r\n\r\n")); err != stop {
+		t.Errorf("Feed = %v, want handler error", err)
+	}
+}
+
+func TestStreamParser_Limits(t *testing.T) {
+	p := request.NewStreamParser(request.StreamHandler{})
+	p.MaxHeadSize = 32
+	if err := p.Feed([]byte("GET / HTTP/1.1\r\nHost: x\r\n")); err != nil {
+		t.Fatalf("Feed failed under the limit: %v", err)
+	}
+	if err := p.Feed([]byte("X-Long: " + strings.Repeat("a", 32))); err == nil || err.(*errors.Error).Type != errors.ErrorTypeInvalidFormat {
+		t.Errorf("Feed with oversized head = %v", err)
+	}
+
+	p = request.NewStreamParser(request.StreamHandler{})
+	p.MaxLineSize = 16
+	if err := p.Feed([]byte("POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n")); err != nil {
+		t.Fatalf("Feed failed under the limit: %v", err)
+	}
+	if err := p.Feed([]byte(strings.Repeat("0", 17))); err == nil || err.(*errors.Error).Type != errors.ErrorTypeMalformedChunk {
+		t.Errorf("Feed with oversized chunk line = %v", err)
+	}
+}
+
+func TestRequestMaxBodySize(t *testing.T) {
+	opts := request.ParseOptions{MaxBodySize: 3}
+	// The rest of the body is discarded, so the next pipelined request parses
+	src := bufio.NewReader(strings.NewReader("POST /u HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n10000\r\n" +
+		strings.Repeat("z", 65536) + "\r\n0\r\n\r\n" +
+		"POST /a HTTP/1.1\r\nContent-Length: 40\r\n\r\nGET /smuggled HTTP/1.1\r\nHost: evil\r\n\r\n" +
+		"GET /next HTTP/1.1\r\nHost: x\r\n\r\n"))
+	req, err := request.ParseReaderWithOptions(src, opts)
+	if err != nil {
+		t.Fatalf("ParseReaderWithOptions failed: %v", err)
+	}
+	if !req.Truncated || string(req.Body) != "100" || req.GetHost() != "x" {
+		t.Errorf("Truncated = %v, Body = %q", req.Truncated, req.Body)
+	}
+	for _, want := range []string{"/a", "/next"} {
+		req, err = request.ParseReaderWithOptions(src, opts)
+		if err != nil || req.Path != want {
+			t.Errorf("Expected %s, got %+v, %v", want, req, err)
+		}
+	}
+
+	req, err = request.ParseWithOptions([]byte("POST /u HTTP/1.1\r\nContent-Length: 3\r\n\r\nabc"), opts)
+	if err != nil || req.Truncated {
+		t.Errorf("Truncated = %v, err = %v", req.Truncated, err)
+	}
+
+	// Only the kept part of an oversized body is copied, and the truncation
+	// the caller asked for does not fail strict parsing
+	strict := request.ParseOptions{MaxBodySize: 3, StrictMode: true}
+	req, err = request.ParseWithOptions([]byte("POST /u HTTP/1.1\r\nContent-Length: 100000\r\n\r\n"+strings.Repeat("z", 100000)), strict)
+	if err != nil {
+		t.Fatalf("ParseWithOptions failed: %v", err)
+	}
+	if !req.Truncated || string(req.Body) != "zzz" || cap(req.Raw) > 64 || cap(req.RawBody) > 8 {
+		t.Errorf("Truncated = %v, cap(Raw) = %d, cap(RawBody) = %d", req.Truncated, cap(req.Raw), cap(req.RawBody))
+	}
+}
+
+func TestRequestParseWithOptions(t *testing.T) {
+	raw := "POST /upload HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\nX-Sum: 1\r\n\r\n"
+	opts := request.ParseOptions{AutoDecodeChunked: true, PreserveChunkedTrailers: true}
+
+	req, err := request.ParseWithOptions([]byte(raw), opts)
+	if err != nil {
+		t.Fatalf("ParseWithOptions failed: %v", err)
+	}
+	if string(req.Body) != "hello" || req.IsBodyChunked || req.Headers.Has("Transfer-Encoding") {
+		t.Errorf("Expected decoded body, got %q (chunked=%v)", req.Body, req.IsBodyChunked)
+	}
+	if req.Headers.Get("Content-Length") != "5" || strings.TrimSpace(req.Headers.Get("X-Sum")) != "1" {
+		t.Errorf("Unexpected headers:\n%s", req.Headers.Build())
+	}
+
+	req, err = request.ParseReaderWithOptions(strings.NewReader(raw), opts)
+	if err != nil || string(req.Body) != "hello" {
+		t.Errorf("ParseReaderWithOptions = %q, %v", req.Body, err)
+	}
+
+	// Without the option the body stays chunked
+	req, _ = request.Parse([]byte(raw))
+	if !req.IsBodyChunked || string(req.Body) == "hello" {
+		t.Error("Expected chunked body by default")
+	}
+}
+
+func TestRequestParseMaxHeaderCount(t *testing.T) {
+	raw := "GET / HTTP/1.1\r\nA: 1\r\nB: 2\r\nC: 3\r\n\r\n"
+
+	_, err := request.ParseWithOptions([]byte(raw), request.ParseOptions{MaxHeaderCount: 2})
+	if e, ok := err.(*errors.Error); !ok || e.Type != errors.ErrorTypeMalformedHeader || e.Offset != 28 {
+		t.Errorf("Expected header count error at offset 28, got %v", err)
+	}
+	if _, err := request.ParseWithOptions([]byte(raw), request.ParseOptions{MaxHeaderCount: 3}); err != nil {
+		t.Errorf("Unexpected error at the limit: %v", err)
+	}
+
+	_, _, err = request.ParseHeadersFromReaderWithOptions(strings.NewReader(raw), request.ParseOptions{MaxHeaderCount: 2})
+	if e, ok := err.(*errors.Error); !ok || e.Type != errors.ErrorTypeMalformedHeader {
+		t.Errorf("Expected header count error from reader, got %v", err)
+	}
+}
+
+func TestRequestParseStrictMode(t *testing.T) {
+	strict := request.ParseOptions{StrictMode: true}
+
+	if _, err := request.ParseWithOptions([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"), strict); err != nil {
+		t.Errorf("Unexpected error for a clean request: %v", err)
+	}
+
+	// Tolerated by default, rejected in strict mode
+	raw := []byte("GET / HTTP/1.1\r\nHost: x\r\nContent-Length: 5\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n")
+	if _, err := request.Parse(raw); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	_, err := request.ParseWithOptions(raw, strict)
+	if e, ok := err.(*errors.Error); !ok || !strings.Contains(e.Message, string(errors.DiagConflictingFraming)) {
+		t.Errorf("Expected conflicting framing error, got %v", err)
+	}
+}
+
+func benchmarkRawMessage(startLine string) []byte {
+	var b strings.Builder
+	b.WriteString(startLine + "\r\n")
+	b.WriteString("Host: example.com\r\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&b, "X-Custom-Header-%d: some reasonably long header value number %d\r\n", i, i)
+	}
+	b.WriteString("Content-Length: 16\r\n\r\n")
+	b.WriteString(`{"key":"value!"}`)
+	return []byte(b.String())
+}
+
+func BenchmarkRequestParse(b *testing.B) {
+	raw := benchmarkRawMessage("POST /api/v1/items?page=2 HTTP/1.1")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(raw)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		request.Parse(raw)
+	}
+}
@@ -1,15 +1,29 @@
 package unit
 
 import (
+	"bufio"
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"strings"
 	"testing"
-
+	"testing/iotest"
+	"time"
+
+	"github.com/WhileEndless/go-httptools/pkg/bufpool"
+	"github.com/WhileEndless/go-httptools/pkg/chunked"
+	"github.com/WhileEndless/go-httptools/pkg/clock"
+	"github.com/WhileEndless/go-httptools/pkg/cookies"
+	"github.com/WhileEndless/go-httptools/pkg/fingerprint"
+	"github.com/WhileEndless/go-httptools/pkg/errors"
+	"github.com/WhileEndless/go-httptools/pkg/headers"
 	"github.com/WhileEndless/go-httptools/pkg/response"
+	"github.com/WhileEndless/go-httptools/pkg/status"
+	"github.com/WhileEndless/go-httptools/pkg/search"
+	"github.com/WhileEndless/go-httptools/pkg/stream"
 	"github.com/andybalholm/brotli"
 )
 
@@ -1062,6 +1076,50 @@ func TestResponseParseReader_GzipCompressed(t *testing.T) {
 	}
 }
 
+func TestResponseParseReader_Pipelined(t *testing.T) {
+	stream := "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nfirst" +
+		"HTTP/1.1 201 Created\r\nTransfer-Encoding: chunked\r\n\r\n6\r\nsecond\r\n0\r\n\r\n" +
+		"HTTP/1.1 304 Not Modified\r\nETag: \"x\"\r\n\r\n" +
+		"HTTP/1.1 200 OK\r\n\r\nrest until close"
+
+	br := bufio.NewReader(strings.NewReader(stream))
+
+	expected := []struct {
+		status int
+		body   string
+	}{
+		{200, "first"},
+		{201, "6\r\nsecond\r\n0\r\n\r\n"},
+		{304, ""},
+		{200, "rest until close"},
+	}
+
+	for i, want := range expected {
+		resp, err := response.ParseReader(br)
+		if err != nil {
+			t.Fatalf("Response %d: ParseReader failed: %v", i, err)
+		}
+		if resp.StatusCode != want.status {
+			t.Errorf("Response %d: expected status %d, got %d", i, want.status, resp.StatusCode)
+		}
+		if string(resp.Body) != want.body {
+			t.Errorf("Response %d: expected body %q, got %q", i, want.body, resp.Body)
+		}
+	}
+}
+
+func TestResponseParseReader_TruncatedContentLength(t *testing.T) {
+	raw := "HTTP/1.1 200 OK\r\nContent-Length: 1000000000000\r\n\r\npartial"
+
+	resp, err := response.ParseReader(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	if string(resp.Body) != "partial" {
+		t.Errorf("Expected truncated body 'partial', got %q", resp.Body)
+	}
+}
+
 // ============================================================================
 // Streaming Support Tests (ParseHeadersFromReader, WriteTo)
 // ============================================================================
@@ -1733,3 +1791,855 @@ func TestResponseStreamingBody_BrotliDecompression(t *testing.T) {
 	}
 }
 
+
+func TestResponseWrapBodyReaderWithOptions_TeeRawBytes(t *testing.T) {
+	originalBody := []byte(strings.Repeat("streamed body content ", 100))
+
+	var gzipBuf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipBuf)
+	gzipWriter.Write(originalBody)
+	gzipWriter.Close()
+
+	wire := chunked.Encode(gzipBuf.Bytes(), 256)
+
+	resp := response.NewResponse()
+	resp.Headers.Set("Content-Encoding", "gzip")
+	resp.Headers.Set("Transfer-Encoding", "chunked")
+	resp.IsBodyChunked = true
+
+	var tee bytes.Buffer
+	sb, err := resp.WrapBodyReaderWithOptions(bytes.NewReader(wire), response.BodyReaderOptions{
+		Tee:        &tee,
+		CaptureRaw: true,
+	})
+	if err != nil {
+		t.Fatalf("WrapBodyReaderWithOptions failed: %v", err)
+	}
+	defer sb.Close()
+
+	decoded, err := sb.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !bytes.Equal(decoded, originalBody) {
+		t.Error("Decoded body mismatch")
+	}
+
+	if !bytes.Equal(tee.Bytes(), wire) {
+		t.Errorf("Tee writer should receive the wire bytes: got %d bytes, want %d", tee.Len(), len(wire))
+	}
+	if !bytes.Equal(sb.RawBody(), wire) {
+		t.Errorf("RawBody should return the wire bytes: got %d bytes, want %d", len(sb.RawBody()), len(wire))
+	}
+}
+
+func TestResponseWrapBodyReader_NoRawCapture(t *testing.T) {
+	resp := response.NewResponse()
+	sb, err := resp.WrapBodyReader(strings.NewReader("plain"))
+	if err != nil {
+		t.Fatalf("WrapBodyReader failed: %v", err)
+	}
+	defer sb.Close()
+
+	sb.ReadAll()
+	if sb.RawBody() != nil {
+		t.Error("RawBody should be nil without CaptureRaw")
+	}
+}
+
+func TestStreamingBody_Clock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	resp := response.NewResponse()
+	sb, err := resp.WrapBodyReaderWithOptions(strings.NewReader("0123456789"), response.BodyReaderOptions{Clock: fake})
+	if err != nil {
+		t.Fatalf("WrapBodyReaderWithOptions failed: %v", err)
+	}
+	defer sb.Close()
+
+	var elapsed time.Duration
+	sb.OnProgress(func(bytesRead int64, e time.Duration) { elapsed = e })
+
+	fake.Advance(2 * time.Second)
+	sb.ReadAll()
+
+	if elapsed != 2*time.Second || sb.Elapsed() != 2*time.Second {
+		t.Errorf("elapsed = %v, Elapsed() = %v, want 2s", elapsed, sb.Elapsed())
+	}
+	if sb.Rate() != 5 {
+		t.Errorf("Rate() = %f, want 5", sb.Rate())
+	}
+}
+
+func TestStreamingBody_OnProgress(t *testing.T) {
+	body := strings.Repeat("x", 10000)
+
+	resp := response.NewResponse()
+	sb, err := resp.WrapBodyReader(iotest.OneByteReader(strings.NewReader(body)))
+	if err != nil {
+		t.Fatalf("WrapBodyReader failed: %v", err)
+	}
+	defer sb.Close()
+
+	calls := 0
+	var last int64
+	sb.OnProgress(func(bytesRead int64, elapsed time.Duration) {
+		calls++
+		if bytesRead < last {
+			t.Errorf("bytesRead went backwards: %d < %d", bytesRead, last)
+		}
+		if elapsed < 0 {
+			t.Errorf("Negative elapsed time: %v", elapsed)
+		}
+		last = bytesRead
+	})
+
+	var out bytes.Buffer
+	if _, err := sb.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+
+	if out.String() != body {
+		t.Error("Body mismatch")
+	}
+	if calls < len(body) {
+		t.Errorf("Expected at least %d progress calls, got %d", len(body), calls)
+	}
+	if last != int64(len(body)) {
+		t.Errorf("Expected final progress %d, got %d", len(body), last)
+	}
+	if sb.TotalRead() != int64(len(body)) {
+		t.Errorf("Expected TotalRead %d, got %d", len(body), sb.TotalRead())
+	}
+	if sb.Rate() <= 0 {
+		t.Errorf("Expected positive rate, got %f", sb.Rate())
+	}
+}
+
+func TestStreamingBody_LimitBytes(t *testing.T) {
+	var gzipBuf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipBuf)
+	gzipWriter.Write(make([]byte, 1<<20))
+	gzipWriter.Close()
+
+	resp := response.NewResponse()
+	resp.Headers.Set("Content-Encoding", "gzip")
+
+	sb, err := resp.WrapBodyReader(bytes.NewReader(gzipBuf.Bytes()))
+	if err != nil {
+		t.Fatalf("WrapBodyReader failed: %v", err)
+	}
+	defer sb.Close()
+	sb.LimitBytes(4096)
+
+	data, err := sb.ReadAll()
+	if !errors.IsBodyTooLarge(err) {
+		t.Fatalf("Expected body too large error, got %v", err)
+	}
+	if len(data) != 4096 {
+		t.Errorf("Expected 4096 bytes before limit, got %d", len(data))
+	}
+
+	// The error is sticky
+	if _, err := sb.Read(make([]byte, 10)); !errors.IsBodyTooLarge(err) {
+		t.Errorf("Expected sticky body too large error, got %v", err)
+	}
+}
+
+func TestStreamingBody_LimitBytesExact(t *testing.T) {
+	resp := response.NewResponse()
+	sb, err := resp.WrapBodyReader(strings.NewReader("exactly"))
+	if err != nil {
+		t.Fatalf("WrapBodyReader failed: %v", err)
+	}
+	defer sb.Close()
+	sb.LimitBytes(7)
+
+	data, err := sb.ReadAll()
+	if err != nil {
+		t.Fatalf("Body at the limit should not fail: %v", err)
+	}
+	if string(data) != "exactly" {
+		t.Errorf("Expected 'exactly', got %q", data)
+	}
+}
+
+func TestStreamingBody_RewindableSearchThenForward(t *testing.T) {
+	body := strings.Repeat("padding ", 500) + "needle" + strings.Repeat(" tail", 100)
+
+	resp := response.NewResponse()
+	sb, err := resp.WrapBodyReader(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("WrapBodyReader failed: %v", err)
+	}
+	defer sb.Close()
+
+	rr := sb.Rewindable(stream.RewindOptions{MemoryLimit: 512, TempDir: t.TempDir()})
+	defer rr.Close()
+
+	found, err := search.ContainsReader(rr, []byte("needle"), search.StreamOptions{})
+	if err != nil || !found {
+		t.Fatalf("Expected to find needle, found=%v err=%v", found, err)
+	}
+
+	rr.Rewind()
+	var out bytes.Buffer
+	if _, err := io.Copy(&out, rr); err != nil {
+		t.Fatalf("Copy after rewind failed: %v", err)
+	}
+	if out.String() != body {
+		t.Error("Forwarded body mismatch after rewind")
+	}
+}
+
+func TestStreamingBody_Lines(t *testing.T) {
+	body := "{\"event\":\"a\"}\n{\"event\":\"b\"}\n"
+
+	resp := response.NewResponse()
+	resp.Headers.Set("Transfer-Encoding", "chunked")
+	resp.IsBodyChunked = true
+
+	sb, err := resp.WrapBodyReader(bytes.NewReader(chunked.Encode([]byte(body), 5)))
+	if err != nil {
+		t.Fatalf("WrapBodyReader failed: %v", err)
+	}
+	defer sb.Close()
+
+	lines := sb.Lines(0)
+	count := 0
+	for lines.Next() {
+		count++
+		if !strings.HasPrefix(string(lines.Line()), "{\"event\":") {
+			t.Errorf("Unexpected line %q", lines.Line())
+		}
+	}
+	if lines.Err() != nil {
+		t.Fatalf("Unexpected error: %v", lines.Err())
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 lines, got %d", count)
+	}
+}
+
+func TestResponseBuild_WithBufferPool(t *testing.T) {
+	bufpool.Enable(true)
+	defer bufpool.Enable(false)
+
+	first, err := response.Parse([]byte("HTTP/1.1 200 OK\r\nX-First: 1\r\n\r\nfirst"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	second, err := response.Parse([]byte("HTTP/1.1 404 Not Found\r\nX-Second: 2\r\n\r\nsecond"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	built := first.Build()
+	expected := string(built)
+	for i := 0; i < 10; i++ {
+		second.Build()
+	}
+
+	if string(built) != expected {
+		t.Error("Built output changed after later builds reused pooled buffers")
+	}
+	if !strings.Contains(expected, "X-First: 1") {
+		t.Errorf("Unexpected build output: %q", expected)
+	}
+}
+
+func TestResponseParseReader_WithBufferPool(t *testing.T) {
+	bufpool.Enable(true)
+	defer bufpool.Enable(false)
+
+	br := bufio.NewReader(strings.NewReader("HTTP/1.1 200 OK\r\nX-First: 1\r\nContent-Length: 5\r\n\r\nfirst" +
+		"HTTP/1.1 404 Not Found\r\nX-Second: 2\r\nContent-Length: 6\r\n\r\nsecond"))
+
+	first, err := response.ParseReader(br)
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	raw, body, header := string(first.Raw), string(first.Body), first.Headers.Get("X-First")
+
+	second, err := response.ParseReader(br)
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	if string(second.Body) != "second" {
+		t.Errorf("Expected second body, got %q", second.Body)
+	}
+
+	if string(first.Raw) != raw || string(first.Body) != body || first.Headers.Get("X-First") != header {
+		t.Error("First response changed after the pooled read buffer was reused")
+	}
+	if body != "first" {
+		t.Errorf("Expected first body, got %q", body)
+	}
+}
+
+func TestResponseDateAccessors(t *testing.T) {
+	raw := []byte("HTTP/1.1 200 OK\r\n" +
+		"Date: Tue, 15 Nov 1994 08:12:31 GMT\r\n" +
+		"Expires: Tuesday, 15-Nov-94 09:12:31 GMT\r\n" +
+		"Last-Modified: Tue Nov  1 08:12:31 1994\r\n" +
+		"Age: 30\r\n" +
+		"\r\n")
+	resp, err := response.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	date := time.Date(1994, 11, 15, 8, 12, 31, 0, time.UTC)
+	if !resp.GetDate().Equal(date) {
+		t.Errorf("Unexpected Date: %v", resp.GetDate())
+	}
+	if !resp.GetExpires().Equal(date.Add(time.Hour)) {
+		t.Errorf("Unexpected Expires: %v", resp.GetExpires())
+	}
+	if !resp.GetLastModified().Equal(date.AddDate(0, 0, -14)) {
+		t.Errorf("Unexpected Last-Modified: %v", resp.GetLastModified())
+	}
+	if resp.GetAge() != 30*time.Second {
+		t.Errorf("Expected Age 30s, got %v", resp.GetAge())
+	}
+
+	empty := response.NewResponse()
+	if !empty.GetDate().IsZero() || empty.GetAge() != 0 {
+		t.Error("Expected zero values for missing headers")
+	}
+}
+
+func TestResponseFreshnessLifetime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	date := "Date: Mon, 01 Jan 2024 12:00:00 GMT\r\n"
+
+	tests := []struct {
+		name     string
+		status   string
+		headers  string
+		expected time.Duration
+	}{
+		{"max-age", "200 OK", date + "Cache-Control: public, max-age=600\r\nExpires: Mon, 01 Jan 2024 13:00:00 GMT\r\n", 10 * time.Minute},
+		{"no-store", "200 OK", "Cache-Control: no-store, max-age=600\r\n", 0},
+		{"huge max-age", "200 OK", "Cache-Control: max-age=99999999999\r\n", (1 << 31) * time.Second},
+		{"overlong max-age", "200 OK", "Cache-Control: max-age=99999999999999999999999\r\n", (1 << 31) * time.Second},
+		{"expires", "200 OK", date + "Expires: Mon, 01 Jan 2024 13:00:00 GMT\r\n", time.Hour},
+		{"expires without date", "200 OK", "Expires: Mon, 01 Jan 2024 12:30:00 GMT\r\n", 30 * time.Minute},
+		{"invalid expires", "200 OK", date + "Expires: 0\r\n", 0},
+		{"heuristic", "200 OK", date + "Last-Modified: Sun, 31 Dec 2023 14:00:00 GMT\r\n", 132 * time.Minute},
+		{"heuristic not cacheable", "302 Found", date + "Last-Modified: Sun, 31 Dec 2023 14:00:00 GMT\r\n", 0},
+		{"nothing", "200 OK", date, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := response.Parse([]byte("HTTP/1.1 " + tt.status + "\r\n" + tt.headers + "\r\n"))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := resp.FreshnessLifetime(now); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResponseStatusRegistry(t *testing.T) {
+	// Non-standard code without reason phrase gets the registered text
+	resp, err := response.Parse([]byte("HTTP/1.1 499\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if resp.StatusText != "Client Closed Request" {
+		t.Errorf("Expected 'Client Closed Request', got %q", resp.StatusText)
+	}
+
+	// Vendor codes can be registered
+	status.Register(299, "Vendor Success")
+	defer status.Register(299, "")
+
+	resp = response.NewResponse()
+	resp.Version = "HTTP/1.1"
+	resp.StatusCode = 299
+	if built := string(resp.Build()); !strings.HasPrefix(built, "HTTP/1.1 299 Vendor Success\r\n") {
+		t.Errorf("Expected registered reason phrase in built response, got %q", built)
+	}
+	if resp.StatusClass() != status.ClassSuccess || !resp.IsSuccessful() || resp.IsInformational() {
+		t.Errorf("Unexpected status class %v", resp.StatusClass())
+	}
+
+	resp.StatusCode = 103
+	if !resp.IsInformational() || resp.StatusClass() != status.ClassInformational {
+		t.Error("Expected 103 to be informational")
+	}
+}
+
+func TestResponseParse_NoBodySemantics(t *testing.T) {
+	// HEAD response advertises a length but carries no body
+	raw := "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\n"
+	resp, err := response.ParseWithOptions([]byte(raw+"NEXT!"), response.ParseOptions{RequestMethod: "HEAD"})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(resp.Body) != 0 || len(resp.RawBody) != 0 || resp.BodyAllowed() {
+		t.Errorf("Expected no body for HEAD response, got %q", resp.Body)
+	}
+
+	// Build keeps the advertised Content-Length but omits the body
+	if built := string(resp.Build()); built != raw {
+		t.Errorf("Unexpected Build output %q", built)
+	}
+	built, err := resp.BuildWithOptions(response.DefaultBuildOptions())
+	if err != nil {
+		t.Fatalf("BuildWithOptions failed: %v", err)
+	}
+	if !strings.Contains(string(built), "Content-Length: 5") || !strings.HasSuffix(string(built), "\r\n\r\n") {
+		t.Errorf("Unexpected BuildWithOptions output %q", built)
+	}
+
+	// ParseReader must not block waiting for the advertised body
+	br := bufio.NewReader(strings.NewReader(raw + "HTTP/1.1 204 No Content\r\nContent-Length: 3\r\n\r\n"))
+	for _, expected := range []int{200, 204} {
+		resp, err := response.ParseReaderWithOptions(br, response.ParseOptions{RequestMethod: "HEAD"})
+		if err != nil {
+			t.Fatalf("ParseReaderWithOptions failed: %v", err)
+		}
+		if resp.StatusCode != expected || len(resp.Body) != 0 {
+			t.Errorf("Expected bodiless %d, got %d with %q", expected, resp.StatusCode, resp.Body)
+		}
+	}
+
+	// 204 and 304 never have a body, even without RequestMethod
+	resp, err = response.Parse([]byte("HTTP/1.1 304 Not Modified\r\nContent-Length: 3\r\n\r\nabc"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(resp.Body) != 0 {
+		t.Errorf("Expected no body for 304, got %q", resp.Body)
+	}
+}
+
+func TestResponseCanReuseConnection(t *testing.T) {
+	tests := []struct {
+		name           string
+		raw            string
+		requestVersion string
+		expected       bool
+	}{
+		{"http/1.1 default", "HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n", "HTTP/1.1", true},
+		{"connection close", "HTTP/1.1 200 OK\r\nConnection: Close\r\nContent-Length: 0\r\n\r\n", "HTTP/1.1", false},
+		{"close among tokens", "HTTP/1.1 200 OK\r\nConnection: upgrade, close\r\nContent-Length: 0\r\n\r\n", "HTTP/1.1", false},
+		{"http/1.0 response", "HTTP/1.0 200 OK\r\nContent-Length: 0\r\n\r\n", "HTTP/1.1", false},
+		{"http/1.0 keep-alive", "HTTP/1.0 200 OK\r\nConnection: keep-alive\r\nContent-Length: 0\r\n\r\n", "HTTP/1.0", true},
+		{"http/1.0 request", "HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n", "HTTP/1.0", false},
+		{"chunked", "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n", "HTTP/1.1", true},
+		{"until close", "HTTP/1.1 200 OK\r\n\r\nbody", "HTTP/1.1", false},
+		{"no body status", "HTTP/1.1 304 Not Modified\r\n\r\n", "HTTP/1.1", true},
+		{"switching protocols", "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n\r\n", "HTTP/1.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := response.Parse([]byte(tt.raw))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := resp.CanReuseConnection(tt.requestVersion); got != tt.expected {
+				t.Errorf("Expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResponseParse_InterimResponses(t *testing.T) {
+	raw := "HTTP/1.1 100 Continue\r\n\r\n" +
+		"HTTP/1.1 103 Early Hints\r\nLink: </style.css>; rel=preload; as=style\r\n\r\n" +
+		"HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"
+
+	resp, err := response.Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if resp.StatusCode != 200 || string(resp.Body) != "ok" {
+		t.Errorf("Expected final 200 with body 'ok', got %d %q", resp.StatusCode, resp.Body)
+	}
+	if string(resp.Raw) != raw {
+		t.Error("Expected Raw to keep the complete original data")
+	}
+
+	if len(resp.InterimResponses) != 2 {
+		t.Fatalf("Expected 2 interim responses, got %d", len(resp.InterimResponses))
+	}
+	if resp.InterimResponses[0].StatusCode != 100 {
+		t.Errorf("Expected 100, got %d", resp.InterimResponses[0].StatusCode)
+	}
+	hints := resp.InterimResponses[1]
+	if hints.StatusCode != 103 || !strings.Contains(hints.Headers.Get("Link"), "rel=preload") {
+		t.Errorf("Unexpected Early Hints response: %d %q", hints.StatusCode, hints.Headers.Get("Link"))
+	}
+
+	if !strings.HasPrefix(string(resp.Build()), "HTTP/1.1 200 OK") {
+		t.Error("Expected Build to emit only the final response")
+	}
+	if clone := resp.Clone(); len(clone.InterimResponses) != 2 {
+		t.Error("Expected Clone to copy interim responses")
+	}
+
+	// A lone interim response is returned as the response itself
+	resp, err = response.Parse([]byte("HTTP/1.1 100 Continue\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if resp.StatusCode != 100 || len(resp.InterimResponses) != 0 {
+		t.Errorf("Expected lone 100 response, got %d with %d interim", resp.StatusCode, len(resp.InterimResponses))
+	}
+}
+
+func TestResponseParse_FoldedSetCookie(t *testing.T) {
+	raw := []byte("HTTP/1.1 200 OK\r\n" +
+		"Set-Cookie: a=1; Path=/, b=2; Expires=Wed, 09 Jun 2021 10:18:14 GMT\r\n" +
+		"\r\n")
+
+	resp, err := response.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(resp.SetCookies) != 2 || resp.SetCookies[1].Name != "b" {
+		t.Errorf("Expected folded header to be split into 2 cookies, got %+v", resp.SetCookies)
+	}
+
+	resp, err = response.ParseWithOptions(raw, response.ParseOptions{SetCookieMode: cookies.SplitStrict})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(resp.SetCookies) != 1 {
+		t.Errorf("Expected a single cookie in strict mode, got %d", len(resp.SetCookies))
+	}
+}
+
+func TestResponseBodySimHash(t *testing.T) {
+	page := "<html><body><h1>Welcome back</h1><p>Your session token is %s and your last login was from the usual device</p></body></html>"
+	a, _ := response.Parse([]byte("HTTP/1.1 200 OK\r\n\r\n" + fmt.Sprintf(page, "abc123")))
+	b, _ := response.Parse([]byte("HTTP/1.1 200 OK\r\n\r\n" + fmt.Sprintf(page, "zyx987654321")))
+
+	if d := fingerprint.Distance(a.BodySimHash(), b.BodySimHash()); d > 16 {
+		t.Errorf("Expected similar bodies to have close fingerprints, distance %d", d)
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	_, err := response.Parse([]byte("HTTP/1.1 2OO OK\r\nServer: x\r\n\r\n"))
+	var e *errors.Error
+	if !stderrors.As(err, &e) {
+		t.Fatalf("expected *errors.Error, got %v", err)
+	}
+	if e.Offset != 9 || e.Line != 1 || !strings.Contains(e.Snippet, "[32]") {
+		t.Errorf("Offset = %d, Line = %d, Snippet = %q", e.Offset, e.Line, e.Snippet)
+	}
+
+	_, err = response.Parse([]byte("HTTP/1.1 200 OK\r\nServer: x\r\n"))
+	if !stderrors.As(err, &e) || e.Offset != 28 || e.Line != 3 {
+		t.Errorf("missing header end: %v", err)
+	}
+
+	// Positions count the interim responses before the final one
+	_, err = response.Parse([]byte("HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 abc OK"))
+	if !stderrors.As(err, &e) || e.Offset != 34 || e.Line != 3 {
+		t.Errorf("after interim response: %v", err)
+	}
+	resp, _ := response.Parse([]byte("HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 200 OK\nServer: x\n\n"))
+	if len(resp.ParseWarnings) == 0 || resp.ParseWarnings[0].Offset != 40 {
+		t.Errorf("ParseWarnings = %v", resp.ParseWarnings)
+	}
+}
+
+func TestResponseParseWithDiagnostics(t *testing.T) {
+	raw := "HTTP/1.1 404\r\nServer: x\r\nbad line\r\n\r\n"
+	resp, diags := response.ParseWithDiagnostics([]byte(raw), response.ParseOptions{})
+	if resp.StatusCode != 404 || resp.StatusText != "Not Found" {
+		t.Fatalf("response not parsed: %d %q", resp.StatusCode, resp.StatusText)
+	}
+	if len(diags) != 2 ||
+		diags[0].Code != errors.DiagMissingReason || diags[0].Offset != 12 ||
+		diags[1].Code != errors.DiagMalformedHeader || diags[1].Offset != 25 {
+		t.Errorf("diagnostics = %v", diags)
+	}
+
+	resp, diags = response.ParseWithDiagnostics([]byte("HTTP/1.1 200 OK\r\nServer: x\r\n"), response.ParseOptions{})
+	if resp == nil || len(diags) != 1 || diags[0].Code != errors.DiagUnparseable || diags[0].Offset != 28 {
+		t.Errorf("unparseable: %v", diags)
+	}
+}
+
+func TestResponseParseWarnings(t *testing.T) {
+	resp, err := response.Parse([]byte("HTTP/1.1 200 OK\r\nContent-Length: abc\r\n\r\nbody"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(resp.ParseWarnings) != 1 || resp.ParseWarnings[0].Code != errors.DiagInvalidContentLength {
+		t.Errorf("invalid Content-Length warnings: %v", resp.ParseWarnings)
+	}
+
+	resp, _ = response.Parse([]byte("HTTP/1.1 200 OK\r\nContent-Length: 10\r\n\r\nshort"))
+	if len(resp.ParseWarnings) != 1 || resp.ParseWarnings[0].Message != "Content-Length mismatch: header says 10, body is 5" {
+		t.Errorf("mismatch warnings: %v", resp.ParseWarnings)
+	}
+
+	// Bodiless responses declare the length of the resource, not of the message
+	resp, _ = response.Parse([]byte("HTTP/1.1 304 Not Modified\r\nContent-Length: 10\r\n\r\n"))
+	if resp.ParseWarnings != nil {
+		t.Errorf("304 warnings: %v", resp.ParseWarnings)
+	}
+}
+
+func TestResponseTransferCodings(t *testing.T) {
+	original := []byte("gzip applied as a transfer coding")
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(original)
+	gw.Close()
+	raw := []byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: gzip, chunked\r\n\r\n" + string(chunked.Encode(gz.Bytes(), 8)))
+
+	resp, err := response.ParseWithOptions(raw, response.ParseOptions{AutoDecodeChunked: true})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !bytes.Equal(resp.Body, original) || resp.IsBodyChunked || resp.Headers.Has("Transfer-Encoding") {
+		t.Errorf("Body = %q, chunked %v, headers %v", resp.Body, resp.IsBodyChunked, resp.Headers)
+	}
+	if cl := strings.TrimSpace(resp.Headers.Get("Content-Length")); cl != fmt.Sprint(len(original)) {
+		t.Errorf("Content-Length = %s", cl)
+	}
+
+	// Streaming applies the same pipeline
+	head, _ := response.Parse(raw)
+	body, err := head.WrapBodyReader(bytes.NewReader(head.RawBody))
+	if err != nil {
+		t.Fatalf("WrapBodyReader failed: %v", err)
+	}
+	defer body.Close()
+	if data, err := body.ReadAll(); err != nil || !bytes.Equal(data, original) {
+		t.Errorf("streamed body = %q, %v", data, err)
+	}
+
+	// Unsupported codings are reported and the body is only dechunked
+	raw = []byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: compress, chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\n")
+	resp, _ = response.ParseWithOptions(raw, response.ParseOptions{AutoDecodeChunked: true})
+	if string(resp.Body) != "abc" || len(resp.ParseWarnings) != 1 || resp.ParseWarnings[0].Code != errors.DiagDecompressionFailed {
+		t.Errorf("Body = %q, warnings %v", resp.Body, resp.ParseWarnings)
+	}
+	if te := resp.Headers.Get("Transfer-Encoding"); te != "compress" || resp.IsBodyChunked || resp.Headers.Has("Content-Length") {
+		t.Errorf("Transfer-Encoding = %q, chunked %v, headers %v", te, resp.IsBodyChunked, resp.Headers)
+	}
+
+	// Streaming passes unknown codings through with a warning
+	for te, wire := range map[string]string{"xchunked": "abc", "cow": "abc", "chunked, compress": "3\r\nabc\r\n0\r\n\r\n"} {
+		head, _ := response.Parse([]byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: " + te + "\r\n\r\n"))
+		body, err := head.WrapBodyReader(strings.NewReader(wire))
+		if err != nil {
+			t.Fatalf("WrapBodyReader(%q) failed: %v", te, err)
+		}
+		if data, _ := body.ReadAll(); string(data) != "abc" || len(body.Warnings()) != 1 {
+			t.Errorf("For %q, body = %q, warnings %v", te, data, body.Warnings())
+		}
+		body.Close()
+	}
+}
+
+func TestResponseTrailers(t *testing.T) {
+	raw := "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\nTrailer: Server-Timing\r\n\r\n" +
+		"2\r\nok\r\n0\r\nServer-Timing: db;dur=53\r\n\r\n"
+
+	for _, opts := range []response.ParseOptions{{}, {AutoDecodeChunked: true}} {
+		resp, err := response.ParseWithOptions([]byte(raw), opts)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if resp.Trailers == nil || strings.TrimSpace(resp.Trailers.Get("Server-Timing")) != "db;dur=53" {
+			t.Errorf("AutoDecodeChunked=%v: Trailers = %v", opts.AutoDecodeChunked, resp.Trailers)
+		}
+		if resp.Headers.Has("Server-Timing") {
+			t.Error("trailers merged into headers without PreserveChunkedTrailers")
+		}
+	}
+
+	resp, _ := response.Parse([]byte(raw))
+	if string(resp.Build()) != raw {
+		t.Errorf("Build = %q", resp.Build())
+	}
+	resp.Trailers.Add("X-Extra", "1")
+	if !strings.HasSuffix(string(resp.Build()), "0\r\nServer-Timing: db;dur=53\r\nX-Extra: 1\r\n\r\n") {
+		t.Errorf("Build with added trailer = %q", resp.Build())
+	}
+
+	var out bytes.Buffer
+	if _, err := resp.WriteToWithBodyChunked(&out, strings.NewReader("hi"), 0); err != nil {
+		t.Fatalf("WriteToWithBodyChunked failed: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "2\r\nhi\r\n0\r\nServer-Timing: db;dur=53\r\nX-Extra: 1\r\n\r\n") {
+		t.Errorf("streamed = %q", out.String())
+	}
+
+	resp.Trailers.Add("X-Extra", "1")
+	if clone := resp.Clone(); clone.Trailers.Len() != 3 || string(clone.Trailers.Build()) != string(resp.Trailers.Build()) {
+		t.Errorf("Clone collapsed repeated trailers: %q", clone.Trailers.Build())
+	}
+
+	plain, _ := response.Parse([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+	if plain.Trailers != nil {
+		t.Errorf("unexpected trailers %v", plain.Trailers)
+	}
+}
+
+func TestResponseContinue(t *testing.T) {
+	raw := "HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 201 Created\r\nContent-Length: 2\r\n\r\nok"
+
+	resp, err := response.ParseReader(strings.NewReader(raw + "HTTP/1.1 204 No Content\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	if resp.StatusCode != 201 || string(resp.Body) != "ok" || resp.Continue() == nil {
+		t.Fatalf("got %d %q, continue %v", resp.StatusCode, resp.Body, resp.Continue())
+	}
+	if string(resp.BuildWithInterim()) != raw {
+		t.Errorf("BuildWithInterim = %q", resp.BuildWithInterim())
+	}
+
+	// The interim head can be consumed on its own before the final response
+	br := bufio.NewReader(strings.NewReader(raw))
+	interim, _, err := response.ParseHeadersFromReader(br)
+	if err != nil || interim.StatusCode != 100 {
+		t.Fatalf("interim = %v, %v", interim, err)
+	}
+	if final, err := response.ParseReader(br); err != nil || final.StatusCode != 201 || final.Continue() != nil {
+		t.Errorf("final = %v, %v", final, err)
+	}
+
+	var out bytes.Buffer
+	if err := response.WriteContinue(&out); err != nil || out.String() != "HTTP/1.1 100 Continue\r\n\r\n" {
+		t.Errorf("WriteContinue = %q, %v", out.String(), err)
+	}
+	if response.IsInterimStatus(101) || !response.IsInterimStatus(103) {
+		t.Error("IsInterimStatus mismatch")
+	}
+}
+
+func TestResponseRoundTrip(t *testing.T) {
+	// Interim responses are not part of the comparison
+	resp, err := response.Parse([]byte("HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nhi"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !resp.RawEquals() || resp.VerifyRoundTrip() != nil {
+		t.Errorf("clean response does not round-trip: %v", resp.VerifyRoundTrip())
+	}
+
+	raw := "HTTP/1.1  200 OK\r\nContent-Length: 2\r\n\r\nhi"
+	resp, err = response.Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	var herr *errors.Error
+	if err := resp.VerifyRoundTrip(); resp.RawEquals() || !stderrors.As(err, &herr) || herr.Offset != 9 {
+		t.Errorf("VerifyRoundTrip = %v", err)
+	}
+
+	resp.StrictBuild()
+	if string(resp.Build()) != raw {
+		t.Errorf("strict Build = %q", resp.Build())
+	}
+	resp.SetBody([]byte("bye"), false)
+	if string(resp.Build()) == raw {
+		t.Error("modified response still built from Raw")
+	}
+}
+
+func TestResponseMaxBodySize(t *testing.T) {
+	opts := response.ParseOptions{MaxBodySize: 4}
+
+	// The rest of the body is discarded, so the next pipelined response parses
+	src := bufio.NewReader(strings.NewReader("HTTP/1.1 200 OK\r\nContent-Length: 100000\r\nX-Id: 7\r\n\r\n" + strings.Repeat("a", 100000) +
+		"HTTP/1.1 200 OK\r\nContent-Length: 40\r\n\r\nHTTP/1.1 302 Found\r\nLocation: /x\r\n\r\n\r\n" +
+		"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nHTTP/\r\nd\r\n1.1 302 Found\r\n0\r\n\r\n" +
+		"HTTP/1.1 204 No Content\r\n\r\n"))
+	resp, err := response.ParseReaderWithOptions(src, opts)
+	if err != nil {
+		t.Fatalf("ParseReaderWithOptions failed: %v", err)
+	}
+	if !resp.Truncated || string(resp.Body) != "aaaa" || strings.TrimSpace(resp.Headers.Get("X-Id")) != "7" {
+		t.Errorf("Truncated = %v, Body = %q", resp.Truncated, resp.Body)
+	}
+	if len(resp.ParseWarnings) != 1 || resp.ParseWarnings[0].Code != errors.DiagBodyTruncated {
+		t.Errorf("ParseWarnings = %v", resp.ParseWarnings)
+	}
+	for _, want := range []int{200, 200, 204} {
+		resp, err = response.ParseReaderWithOptions(src, opts)
+		if err != nil || resp.StatusCode != want || (want == 200 && !resp.Truncated) {
+			t.Errorf("Expected truncated %d, got %+v, %v", want, resp, err)
+		}
+	}
+
+	resp, err = response.ParseWithOptions([]byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n"), opts)
+	if err != nil {
+		t.Fatalf("ParseWithOptions failed: %v", err)
+	}
+	if !resp.Truncated || string(resp.RawBody) != "5\r\nh" || !strings.HasSuffix(string(resp.Raw), "\r\n\r\n5\r\nh") {
+		t.Errorf("RawBody = %q, Raw = %q", resp.RawBody, resp.Raw)
+	}
+
+	// Only the kept part of an oversized body is copied
+	resp, _ = response.ParseWithOptions([]byte("HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 200 OK\r\n\r\n"+strings.Repeat("a", 100000)), opts)
+	if !resp.Truncated || len(resp.InterimResponses) != 1 || cap(resp.Raw) > 64 || cap(resp.RawBody) > 8 {
+		t.Errorf("Truncated = %v, cap(Raw) = %d, cap(RawBody) = %d", resp.Truncated, cap(resp.Raw), cap(resp.RawBody))
+	}
+
+	// Bodies within the limit are untouched
+	resp, _ = response.ParseWithOptions([]byte("HTTP/1.1 200 OK\r\nContent-Length: 4\r\n\r\nabcd"), opts)
+	if resp.Truncated || string(resp.Body) != "abcd" {
+		t.Errorf("Truncated = %v, Body = %q", resp.Truncated, resp.Body)
+	}
+}
+
+func TestResponseDuplicateHeadersAfterInterim(t *testing.T) {
+	raw := []byte("HTTP/1.1 103 Early Hints\r\nLink: </a.css>\r\nLink: </b.css>\r\n\r\n" +
+		"HTTP/1.1 200 OK\r\nContent-Length: 2\r\nContent-Length: 3\r\n\r\nabc")
+	resp, err := response.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	duplicates := resp.DuplicateHeaders()
+	if len(duplicates) != 1 || duplicates[0].Name != "Content-Length" || !duplicates[0].Conflicting {
+		t.Errorf("Expected conflicting Content-Length of the final response, got %+v", duplicates)
+	}
+}
+
+func TestResponseHeaderAnomaliesAfterInterim(t *testing.T) {
+	raw := []byte("HTTP/1.1 100 Continue\r\nX-A : 1\r\n\r\n" +
+		"HTTP/1.1 200 OK\r\nContent-Length: 3\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n")
+	resp, err := response.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	anomalies := resp.HeaderAnomalies()
+	if len(anomalies) != 1 || anomalies[0].Kind != headers.AnomalyContentLengthWithTE {
+		t.Errorf("Expected Content-Length with Transfer-Encoding only, got %+v", anomalies)
+	}
+}
+
+func TestResponseParseReaderMalformedChunk(t *testing.T) {
+	_, err := response.ParseReader(strings.NewReader("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nfoo\r\nzz\r\n"))
+	if !stderrors.Is(err, errors.ErrMalformedChunk) {
+		t.Errorf("Expected ErrMalformedChunk, got %v", err)
+	}
+}
+
+func BenchmarkResponseParse(b *testing.B) {
+	raw := benchmarkRawMessage("HTTP/1.1 200 OK")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(raw)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		response.Parse(raw)
+	}
+}
@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+	"github.com/WhileEndless/go-httptools/pkg/schedule"
+)
+
+func scheduleRequests(t *testing.T, n int, hosts ...string) []*request.Request {
+	t.Helper()
+	reqs := make([]*request.Request, n)
+	for i := range reqs {
+		raw := fmt.Sprintf("GET /item/%d HTTP/1.1\r\nHost: %s\r\n\r\n", i, hosts[i%len(hosts)])
+		req, err := request.Parse([]byte(raw))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		reqs[i] = req
+	}
+	return reqs
+}
+
+func TestScheduleSendAll(t *testing.T) {
+	reqs := scheduleRequests(t, 50, "a.example", "b.example")
+
+	var mu sync.Mutex
+	active := map[string]int{}
+	peak := map[string]int{}
+	var total, totalPeak int32
+
+	send := func(ctx context.Context, req *request.Request) (*response.Response, error) {
+		host := req.GetHost()
+		mu.Lock()
+		active[host]++
+		peak[host] = max(peak[host], active[host])
+		mu.Unlock()
+		if n := atomic.AddInt32(&total, 1); n > atomic.LoadInt32(&totalPeak) {
+			atomic.StoreInt32(&totalPeak, n)
+		}
+
+		time.Sleep(time.Millisecond)
+
+		atomic.AddInt32(&total, -1)
+		mu.Lock()
+		active[host]--
+		mu.Unlock()
+
+		if req.Path == "/item/7" {
+			return nil, fmt.Errorf("connection reset")
+		}
+		resp := response.NewResponse()
+		resp.StatusCode = 200
+		resp.Body = []byte(req.Path)
+		return resp, nil
+	}
+
+	results := schedule.SendAll(context.Background(), reqs, send, schedule.Options{Workers: 8, PerHost: 2})
+	for i, r := range results {
+		if r.Index != i || r.Request != reqs[i] {
+			t.Fatalf("result %d has index %d", i, r.Index)
+		}
+		if i == 7 {
+			if r.Err == nil {
+				t.Error("expected error for request 7")
+			}
+			continue
+		}
+		if r.Err != nil || string(r.Response.Body) != reqs[i].Path {
+			t.Errorf("result %d = %v, %v", i, r.Response, r.Err)
+		}
+	}
+
+	for host, p := range peak {
+		if p > 2 {
+			t.Errorf("%s peaked at %d concurrent sends, limit 2", host, p)
+		}
+	}
+	if totalPeak > 8 {
+		t.Errorf("peaked at %d concurrent sends, limit 8", totalPeak)
+	}
+}
+
+func TestScheduleCancel(t *testing.T) {
+	reqs := scheduleRequests(t, 20, "a.example")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var sent int32
+	send := func(ctx context.Context, req *request.Request) (*response.Response, error) {
+		if atomic.AddInt32(&sent, 1) == 3 {
+			cancel()
+			return nil, ctx.Err()
+		}
+		return response.NewResponse(), nil
+	}
+
+	results := schedule.SendAll(ctx, reqs, send, schedule.Options{Workers: 1})
+	if n := atomic.LoadInt32(&sent); n >= int32(len(reqs)) {
+		t.Errorf("all %d requests were sent despite cancellation", n)
+	}
+	for i, r := range results {
+		if i < 2 && r.Err != nil {
+			t.Errorf("result %d error = %v, want nil", i, r.Err)
+		}
+		if i >= 2 && r.Err != context.Canceled {
+			t.Errorf("result %d error = %v, want context.Canceled", i, r.Err)
+		}
+	}
+}
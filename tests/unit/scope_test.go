@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/response"
+	"github.com/WhileEndless/go-httptools/pkg/scope"
+)
+
+func TestScopeMatch(t *testing.T) {
+	s, err := scope.New(scope.Rules{
+		IncludeHosts: []string{"example.com", "*.example.com"},
+		ExcludeHosts: []string{"static.example.com"},
+		Ports:        []scope.PortRange{{Low: 80, High: 80}, {Low: 443, High: 443}, {Low: 8000, High: 8999}},
+		IncludePaths: []string{`^/api/`, `^/$`},
+		ExcludePaths: []string{`\.(png|css)$`},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"GET /api/users HTTP/1.1\r\nHost: example.com\r\n\r\n", true},
+		{"GET /api/users?x=1 HTTP/1.1\r\nHost: API.Example.com:8080\r\n\r\n", true},
+		{"GET https://example.com/ HTTP/1.1\r\nHost: other.net\r\n\r\n", true},
+		{"GET /api/users HTTP/1.1\r\nHost: example.com:9000\r\n\r\n", false},
+		{"GET /api/users HTTP/1.1\r\nHost: static.example.com\r\n\r\n", false},
+		{"GET /api/users HTTP/1.1\r\nHost: example.org\r\n\r\n", false},
+		{"GET /api/logo.png HTTP/1.1\r\nHost: example.com\r\n\r\n", false},
+		{"GET /admin HTTP/1.1\r\nHost: example.com\r\n\r\n", false},
+	}
+	for _, tt := range tests {
+		req, err := request.Parse([]byte(tt.raw))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if got := s.Match(req); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+
+	if _, err := scope.New(scope.Rules{IncludePaths: []string{"("}}); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if _, err := scope.New(scope.Rules{IncludeHosts: []string{"[a"}}); err == nil {
+		t.Error("expected error for invalid glob")
+	}
+}
+
+func TestScopeMatchResponse(t *testing.T) {
+	s, _ := scope.New(scope.Rules{IncludeMIME: []string{"text/*", "application/json"}, ExcludeMIME: []string{"text/css"}})
+	req, _ := request.Parse([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+
+	tests := map[string]bool{
+		"text/html; charset=utf-8": true,
+		"Application/JSON":         true,
+		"text/css":                 false,
+		"image/png":                false,
+		"":                         false,
+	}
+	for contentType, want := range tests {
+		resp := response.NewResponse()
+		if contentType != "" {
+			resp.Headers.Set("Content-Type", contentType)
+		}
+		if got := s.MatchResponse(req, resp); got != want {
+			t.Errorf("MatchResponse(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
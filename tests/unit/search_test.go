@@ -1,7 +1,11 @@
 package unit
 
 import (
+	"bytes"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/WhileEndless/go-httptools/pkg/request"
 	"github.com/WhileEndless/go-httptools/pkg/response"
@@ -468,3 +472,145 @@ func TestSearcher_SearchHeaders_RawFormat(t *testing.T) {
 		t.Errorf("Expected 1 match in raw format, got %d", len(results))
 	}
 }
+
+// ==================== STREAMING SEARCH TESTS ====================
+
+func TestSearchReader_CaseInsensitive(t *testing.T) {
+	data := []byte("<html><BODY>Reflected VALUE here</BODY></html>")
+
+	offset, err := search.SearchReader(bytes.NewReader(data), []byte("reflected value"),
+		search.StreamOptions{CaseInsensitive: true})
+	if err != nil {
+		t.Fatalf("SearchReader failed: %v", err)
+	}
+	if offset != 12 {
+		t.Errorf("Expected offset 12, got %d", offset)
+	}
+}
+
+func TestSearchReader_CollapseWhitespace(t *testing.T) {
+	data := []byte("prefix <input value=\"a\r\n\t  b\"> suffix")
+
+	offset, err := search.SearchReader(bytes.NewReader(data), []byte("value=\"a b\""),
+		search.StreamOptions{CollapseWhitespace: true})
+	if err != nil {
+		t.Fatalf("SearchReader failed: %v", err)
+	}
+	if offset != 14 {
+		t.Errorf("Expected offset 14, got %d", offset)
+	}
+
+	// Without collapsing, the pattern must not match
+	offset, _ = search.SearchReader(bytes.NewReader(data), []byte("value=\"a b\""), search.StreamOptions{})
+	if offset != -1 {
+		t.Errorf("Expected no match without normalization, got %d", offset)
+	}
+}
+
+func TestSearchReader_MatchAcrossReads(t *testing.T) {
+	data := []byte(strings.Repeat("x", 100) + "NeEdLe" + strings.Repeat("y", 100))
+
+	// A tiny buffer forces the match to span several reads
+	reader := iotest.OneByteReader(bytes.NewReader(data))
+	offset, err := search.SearchReader(reader, []byte("needle"),
+		search.StreamOptions{CaseInsensitive: true, BufferSize: 4})
+	if err != nil {
+		t.Fatalf("SearchReader failed: %v", err)
+	}
+	if offset != 100 {
+		t.Errorf("Expected offset 100, got %d", offset)
+	}
+}
+
+func TestResponseStreamingBody_SearchFold(t *testing.T) {
+	resp := response.NewResponse()
+	body := []byte("Hello <Script>alert(1)</Script> world")
+
+	sb, err := resp.WrapBodyReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("WrapBodyReader failed: %v", err)
+	}
+	defer sb.Close()
+
+	found, err := sb.ContainsFold([]byte("<script>"))
+	if err != nil {
+		t.Fatalf("ContainsFold failed: %v", err)
+	}
+	if !found {
+		t.Error("Expected case-insensitive match")
+	}
+}
+
+func TestRequestStreamingBody_SearchWithOptions(t *testing.T) {
+	req := request.NewRequest()
+	body := []byte("user=admin&comment=Hello\n   World")
+
+	sb, err := req.WrapBodyReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("WrapBodyReader failed: %v", err)
+	}
+	defer sb.Close()
+
+	offset, err := sb.SearchWithOptions([]byte("hello world"),
+		search.StreamOptions{CaseInsensitive: true, CollapseWhitespace: true})
+	if err != nil {
+		t.Fatalf("SearchWithOptions failed: %v", err)
+	}
+	if offset != 19 {
+		t.Errorf("Expected offset 19, got %d", offset)
+	}
+}
+
+// ==================== STREAMING REPLACE TESTS ====================
+
+func TestReplaceReader_SpanningBoundaries(t *testing.T) {
+	data := strings.Repeat("visit internal.example.com now. ", 50)
+	expected := strings.ReplaceAll(data, "internal.example.com", "proxy.test")
+
+	rr := search.NewReplaceReader(iotest.OneByteReader(strings.NewReader(data)),
+		[]byte("internal.example.com"), []byte("proxy.test"))
+
+	out, err := io.ReadAll(rr)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != expected {
+		t.Errorf("Replacement mismatch:\ngot  %q\nwant %q", string(out), expected)
+	}
+	if rr.Count() != 50 {
+		t.Errorf("Expected 50 replacements, got %d", rr.Count())
+	}
+}
+
+func TestReplaceReader_PartialMatchAtEnd(t *testing.T) {
+	rr := search.NewReplaceReader(strings.NewReader("token=abc tok"), []byte("token"), []byte("T"))
+
+	out, err := io.ReadAll(rr)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "T=abc tok" {
+		t.Errorf("Expected 'T=abc tok', got %q", string(out))
+	}
+}
+
+func TestReplaceWriter_Chunks(t *testing.T) {
+	var buf bytes.Buffer
+	rw := search.NewReplaceWriter(&buf, []byte("secret"), []byte("[redacted]"))
+
+	for _, chunk := range []string{"my sec", "ret is ", "se", "cret", "!"} {
+		if _, err := rw.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if buf.String() != "my [redacted] is [redacted]!" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+	if rw.Count() != 2 {
+		t.Errorf("Expected 2 replacements, got %d", rw.Count())
+	}
+}
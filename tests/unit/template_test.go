@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"errors"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WhileEndless/go-httptools/pkg/clock"
+	"github.com/WhileEndless/go-httptools/pkg/template"
+)
+
+func TestTemplateRender(t *testing.T) {
+	tmpl := &template.Template{
+		Method: "POST",
+		URL:    "/api/users/{{id}}?ts={{timestamp()}}&q={{urlencode('a b&c')}}",
+		Headers: []template.Field{
+			{Name: "Host", Value: "{{host}}"},
+			{Name: "Authorization", Value: "Basic {{base64(creds)}}"},
+		},
+		Body: `{"name":"{{upper(randomString(6))}}","n":{{randomInt(1, 3)}}}`,
+	}
+	env := template.Env{
+		Vars:  map[string]string{"id": "42", "host": "example.com", "creds": "user:pass"},
+		Rand:  rand.New(rand.NewSource(1)),
+		Clock: clock.NewFake(time.Unix(1700000000, 0)),
+	}
+
+	req, err := tmpl.Render(env)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if req.Method != "POST" || req.URL != "/api/users/42?ts=1700000000&q=a+b%26c" {
+		t.Errorf("request line = %s %s", req.Method, req.URL)
+	}
+	if req.GetHost() != "example.com" || strings.TrimSpace(req.Headers.Get("Authorization")) != "Basic dXNlcjpwYXNz" {
+		t.Errorf("headers = %q", req.Headers.Build())
+	}
+	body := string(req.Body)
+	if !regexp.MustCompile(`^\{"name":"[A-Z0-9]{6}","n":[123]\}$`).MatchString(body) {
+		t.Errorf("body = %q", body)
+	}
+	if strings.TrimSpace(req.Headers.Get("Content-Length")) != "23" {
+		t.Errorf("Content-Length = %q", req.Headers.Get("Content-Length"))
+	}
+
+	// The same seed renders the same request
+	env.Rand = rand.New(rand.NewSource(1))
+	again, _ := tmpl.Render(env)
+	if string(again.Body) != body {
+		t.Errorf("render not deterministic: %q vs %q", again.Body, body)
+	}
+}
+
+func TestTemplateExpandErrors(t *testing.T) {
+	env := template.Env{Vars: map[string]string{"a": "1"}}
+	if _, err := template.Expand("{{missing}}", env); !errors.Is(err, template.ErrUndefined) {
+		t.Errorf("undefined variable error = %v", err)
+	}
+	for _, s := range []string{"{{a", "{{nope(a)}}", "{{randomInt(1)}}", "{{randomInt(-9223372036854775808, 9223372036854775807)}}", "{{randomInt(0, 9223372036854775807)}}", "{{lower('x', 'y')}}", "{{base64(a}}"} {
+		if _, err := template.Expand(s, env); err == nil {
+			t.Errorf("Expand(%q) should fail", s)
+		}
+	}
+	if got, err := template.Expand("plain {{ a }} text", env); err != nil || got != "plain 1 text" {
+		t.Errorf("Expand = %q, %v", got, err)
+	}
+}
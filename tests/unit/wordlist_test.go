@@ -0,0 +1,68 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/WhileEndless/go-httptools/pkg/request"
+	"github.com/WhileEndless/go-httptools/pkg/wordlist"
+)
+
+func TestWordlistApply(t *testing.T) {
+	base, err := request.Parse([]byte("GET /app?x=1&id=5 HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		word string
+		opts wordlist.Options
+		url  string
+		host string
+	}{
+		{"admin", wordlist.Options{Point: wordlist.PathSuffix}, "/app/admin?x=1&id=5", "example.com"},
+		{"a b", wordlist.Options{Point: wordlist.PathSuffix}, "/app/a%20b?x=1&id=5", "example.com"},
+		{"../etc", wordlist.Options{Point: wordlist.PathSuffix, Raw: true}, "/app/../etc?x=1&id=5", "example.com"},
+		{"1 OR 1=1", wordlist.Options{Point: wordlist.ParamValue, Param: "id"}, "/app?x=1&id=1+OR+1%3D1", "example.com"},
+		{"v", wordlist.Options{Point: wordlist.ParamValue, Param: "new"}, "/app?x=1&id=5&new=v", "example.com"},
+		{"dev", wordlist.Options{Point: wordlist.Host, HostSuffix: ".example.com"}, "/app?x=1&id=5", "dev.example.com"},
+	}
+	for _, tt := range tests {
+		req := wordlist.Apply(base, tt.word, tt.opts)
+		if req.URL != tt.url || req.GetHost() != tt.host {
+			t.Errorf("Apply(%q) = %s (Host %s), want %s (Host %s)", tt.word, req.URL, req.GetHost(), tt.url, tt.host)
+		}
+	}
+	if base.URL != "/app?x=1&id=5" || base.GetHost() != "example.com" {
+		t.Error("Apply modified the base request")
+	}
+	if req := wordlist.Apply(base, "admin", wordlist.Options{}); !strings.Contains(string(req.Build()), "GET /app/admin?x=1&id=5 HTTP/1.1") || req.Path != "/app/admin" {
+		t.Errorf("built request = %q, path %q", req.Build(), req.Path)
+	}
+}
+
+func TestWordlistGenerate(t *testing.T) {
+	base, _ := request.Parse([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	words := "admin\n\n# comment\n  backup  \r\n.git\n"
+
+	var urls []string
+	for req := range wordlist.Generate(context.Background(), base, strings.NewReader(words), wordlist.Options{}) {
+		urls = append(urls, req.URL)
+	}
+	if strings.Join(urls, " ") != "/admin /backup /.git" {
+		t.Errorf("generated %v", urls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reqs := wordlist.Generate(ctx, base, strings.NewReader(strings.Repeat("w\n", 100)), wordlist.Options{})
+	<-reqs
+	cancel()
+	n := 0
+	for range reqs {
+		n++
+	}
+	if n > 1 {
+		t.Errorf("%d requests generated after cancellation", n)
+	}
+}